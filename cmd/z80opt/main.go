@@ -4,16 +4,20 @@ import (
 	"bufio"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"runtime"
 	"strconv"
 	"strings"
 
+	"github.com/oisee/z80-optimizer/pkg/asm"
+	"github.com/oisee/z80-optimizer/pkg/export"
 	"github.com/oisee/z80-optimizer/pkg/gpu"
 	"github.com/oisee/z80-optimizer/pkg/inst"
 	"github.com/oisee/z80-optimizer/pkg/result"
 	"github.com/oisee/z80-optimizer/pkg/search"
 	"github.com/oisee/z80-optimizer/pkg/stoke"
+	"github.com/oisee/z80-optimizer/pkg/timing"
 	"github.com/spf13/cobra"
 )
 
@@ -26,6 +30,7 @@ func main() {
 	// enumerate command
 	var maxTarget int
 	var output string
+	var outputFormat string
 	var checkpoint string
 	var verbose bool
 	var numWorkers int
@@ -40,6 +45,9 @@ func main() {
 			if err != nil {
 				return err
 			}
+			if outputFormat != "json" && outputFormat != "jsonl" {
+				return fmt.Errorf("unknown --output-format: %s (want json or jsonl)", outputFormat)
+			}
 
 			fmt.Printf("Z80 Superoptimizer\n")
 			fmt.Printf("  Max target length: %d\n", maxTarget)
@@ -55,6 +63,25 @@ func main() {
 			}
 			fmt.Println()
 
+			// jsonl streams each rule to outFile as it's found, so an
+			// interrupted run still leaves a usable partial file; json
+			// keeps the old write-everything-at-the-end behavior.
+			var outFile *os.File
+			var onRule func(result.Rule)
+			if output != "" && outputFormat == "jsonl" {
+				outFile, err = os.Create(output)
+				if err != nil {
+					return err
+				}
+				defer outFile.Close()
+				jw := result.NewJSONLWriter(outFile)
+				onRule = func(r result.Rule) {
+					if err := jw.WriteRule(r); err != nil {
+						fmt.Fprintf(os.Stderr, "jsonl write failed: %v\n", err)
+					}
+				}
+			}
+
 			var rules []result.Rule
 
 			if useGPU {
@@ -62,6 +89,7 @@ func main() {
 					MaxTargetLen: maxTarget,
 					Verbose:      verbose,
 					DeadFlags:    deadFlags,
+					OnRule:       onRule,
 				}
 				table, err := gpu.SearchGPU(gpuCfg)
 				if err != nil {
@@ -74,6 +102,10 @@ func main() {
 					NumWorkers:   numWorkers,
 					Verbose:      verbose,
 					DeadFlags:    deadFlags,
+					OnRule:       onRule,
+				}
+				if checkpoint != "" {
+					cfg.Checkpoint = search.FileCheckpointStore{Path: checkpoint}
 				}
 				table := search.Run(cfg)
 				rules = table.Rules()
@@ -82,23 +114,25 @@ func main() {
 			fmt.Printf("\nFound %d optimizations\n", len(rules))
 
 			if output != "" {
-				f, err := os.Create(output)
-				if err != nil {
-					return err
-				}
-				defer f.Close()
-				if err := result.WriteJSON(f, rules); err != nil {
-					return err
+				if outputFormat == "json" {
+					f, err := os.Create(output)
+					if err != nil {
+						return err
+					}
+					defer f.Close()
+					if err := result.WriteJSON(f, rules); err != nil {
+						return err
+					}
 				}
 				fmt.Printf("Written to %s\n", output)
 			}
 
-			_ = checkpoint // TODO: implement checkpoint resume
 			return nil
 		},
 	}
 	enumCmd.Flags().IntVar(&maxTarget, "max-target", 2, "Maximum target sequence length")
-	enumCmd.Flags().StringVar(&output, "output", "", "Output JSON file path")
+	enumCmd.Flags().StringVar(&output, "output", "", "Output file path")
+	enumCmd.Flags().StringVar(&outputFormat, "output-format", "json", "Output format: json (single array, written at the end) or jsonl (one rule per line, streamed as found)")
 	enumCmd.Flags().StringVar(&checkpoint, "checkpoint", "", "Checkpoint file for resume")
 	enumCmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Verbose output")
 	enumCmd.Flags().IntVar(&numWorkers, "workers", 0, "Number of workers (0 = NumCPU)")
@@ -107,23 +141,64 @@ func main() {
 
 	// target command
 	var maxCand int
+	var dialectStr string
+	var targetFile string
+	var targetSymbol string
+	var targetDeadFlagsStr string
 
 	targetCmd := &cobra.Command{
 		Use:   "target [instructions]",
 		Short: "Find optimal replacement for a specific instruction sequence",
-		Args:  cobra.MinimumNArgs(1),
+		Args: func(cmd *cobra.Command, args []string) error {
+			if targetFile != "" {
+				return nil
+			}
+			return cobra.MinimumNArgs(1)(cmd, args)
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			// Parse the target sequence from assembly
-			input := strings.Join(args, " ")
-			seq, err := parseAssembly(input)
+			flavor, err := parseDialect(dialectStr)
 			if err != nil {
-				return fmt.Errorf("failed to parse: %w", err)
+				return err
+			}
+
+			var input string
+			var seq []inst.Instruction
+			if targetFile != "" {
+				src, err := asm.NewFileSource(targetFile)
+				if err != nil {
+					return fmt.Errorf("failed to open %s: %w", targetFile, err)
+				}
+				prog, err := asm.Load(src, flavor)
+				if err != nil {
+					return fmt.Errorf("failed to parse %s: %w", targetFile, err)
+				}
+				if targetSymbol != "" {
+					seq, err = prog.Region(targetSymbol)
+					if err != nil {
+						return err
+					}
+					input = targetSymbol
+				} else {
+					seq = prog.Instructions
+					input = targetFile
+				}
+			} else {
+				input = strings.Join(args, " ")
+				seq, err = asm.ParseAssembly(input, flavor)
+				if err != nil {
+					return fmt.Errorf("failed to parse: %w", err)
+				}
 			}
 
 			fmt.Printf("Target: %s (%d bytes, %d T-states)\n",
 				input, inst.SeqByteSize(seq), inst.SeqTStates(seq))
 
-			rule := search.SearchSingle(seq, maxCand, verbose)
+			deadFlags, err := parseDeadFlags(targetDeadFlagsStr)
+			if err != nil {
+				return err
+			}
+
+			rule := search.SearchSingleLiveOut(seq, maxCand, deadFlags, search.DeadRegNone, verbose)
 			if rule == nil {
 				fmt.Println("No shorter replacement found.")
 				return nil
@@ -142,33 +217,81 @@ func main() {
 	}
 	targetCmd.Flags().IntVar(&maxCand, "max-candidate", 4, "Maximum candidate length")
 	targetCmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Verbose output")
+	targetCmd.Flags().StringVar(&dialectStr, "dialect", "default", "Assembly dialect: default, sjasmplus, pasmo, or z88dk")
+	targetCmd.Flags().StringVar(&targetFile, "file", "", "Load the target sequence from an assembly source file instead of the command line")
+	targetCmd.Flags().StringVar(&targetSymbol, "symbol", "", "With --file, use only the labeled region starting at this symbol")
+	targetCmd.Flags().StringVar(&targetDeadFlagsStr, "dead-flags", "none", "Dead flags mask: none, undoc, all, or hex (e.g. 0x13)")
 
 	// verify command
+	var verifyAsmFile string
 	verifyCmd := &cobra.Command{
 		Use:   "verify [rules.json]",
-		Short: "Re-verify all rules in a JSON file",
-		Args:  cobra.ExactArgs(1),
+		Short: "Re-verify all rules in a JSON file, or an --asm-file of <source> -> <replacement> pairs",
+		Args: func(cmd *cobra.Command, args []string) error {
+			if verifyAsmFile != "" {
+				return cobra.NoArgs(cmd, args)
+			}
+			return cobra.ExactArgs(1)(cmd, args)
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			f, err := os.Open(args[0])
+			flavor, err := parseDialect(dialectStr)
 			if err != nil {
 				return err
 			}
-			defer f.Close()
 
-			rules, err := result.ReadJSON(f)
-			if err != nil {
-				return err
+			var rules []asm.AsmRule
+			if verifyAsmFile != "" {
+				rules, err = asm.ParseRuleFile(verifyAsmFile)
+				if err != nil {
+					return err
+				}
+			} else {
+				f, err := os.Open(args[0])
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+
+				jsonRules, err := result.ReadJSON(f)
+				if err != nil {
+					return err
+				}
+				for _, r := range jsonRules {
+					rules = append(rules, asm.AsmRule{SourceASM: r.SourceASM, ReplacementASM: r.ReplacementASM})
+				}
 			}
 
 			fmt.Printf("Verifying %d rules...\n", len(rules))
+			failed := 0
 			for i, r := range rules {
 				fmt.Printf("  [%d] %s -> %s ... ", i+1, r.SourceASM, r.ReplacementASM)
-				// TODO: parse assembly back to instructions and ExhaustiveCheck
-				fmt.Println("(parse-back not yet implemented)")
+
+				source, err := asm.ParseAssembly(r.SourceASM, flavor)
+				if err != nil {
+					fmt.Printf("SKIP (source: %v)\n", err)
+					continue
+				}
+				replacement, err := asm.ParseAssembly(r.ReplacementASM, flavor)
+				if err != nil {
+					fmt.Printf("SKIP (replacement: %v)\n", err)
+					continue
+				}
+
+				if search.ExhaustiveCheck(source, replacement) {
+					fmt.Println("PASS")
+				} else {
+					fmt.Println("FAIL")
+					failed++
+				}
+			}
+			if failed > 0 {
+				return fmt.Errorf("%d rules failed verification", failed)
 			}
 			return nil
 		},
 	}
+	verifyCmd.Flags().StringVar(&dialectStr, "dialect", "default", "Assembly dialect: default, sjasmplus, pasmo, or z88dk")
+	verifyCmd.Flags().StringVar(&verifyAsmFile, "asm-file", "", "Verify rules from a text file of \"<source> -> <replacement>\" lines instead of rules.json")
 
 	// export command
 	var format string
@@ -178,27 +301,52 @@ func main() {
 		Short: "Export rules in various formats",
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
+			flavor, err := parseDialect(dialectStr)
+			if err != nil {
+				return err
+			}
+
+			emitter, ok := export.ByName(format)
+			if !ok {
+				return fmt.Errorf("unknown format: %s (want go, sdcc-peep, z88dk-peep, or sjasmplus)", format)
+			}
+
 			f, err := os.Open(args[0])
 			if err != nil {
 				return err
 			}
 			defer f.Close()
 
-			_, err = result.ReadJSON(f)
+			records, err := result.ReadJSON(f)
 			if err != nil {
 				return err
 			}
 
-			switch format {
-			case "go":
-				fmt.Println("// Go export not yet implemented — use verify + manual integration")
-			default:
-				return fmt.Errorf("unknown format: %s", format)
+			rules := make([]result.Rule, len(records))
+			for i, rec := range records {
+				source, err := asm.ParseAssembly(rec.SourceASM, flavor)
+				if err != nil {
+					return fmt.Errorf("rule %d source: %w", i, err)
+				}
+				replacement, err := asm.ParseAssembly(rec.ReplacementASM, flavor)
+				if err != nil {
+					return fmt.Errorf("rule %d replacement: %w", i, err)
+				}
+				rules[i] = result.Rule{
+					Source:      source,
+					Replacement: replacement,
+					BytesSaved:  rec.BytesSaved,
+					CyclesSaved: rec.CyclesSaved,
+					DeadFlags:   rec.DeadFlags,
+					DeadRegs:    rec.DeadRegs,
+				}
 			}
-			return nil
+
+			return emitter.Emit(os.Stdout, rules)
 		},
 	}
-	exportCmd.Flags().StringVarP(&format, "format", "f", "go", "Output format (go)")
+	exportCmd.Flags().StringVarP(&format, "format", "f", "go", "Output format: go, sdcc-peep, z88dk-peep, or sjasmplus")
+	exportCmd.Flags().StringVar(&dialectStr, "dialect", "default", "Assembly dialect rules.json was written in: default, sjasmplus, pasmo, or z88dk")
 
 	// stoke command
 	var stokeChains int
@@ -207,6 +355,8 @@ func main() {
 	var stokeOutput string
 	var stokeVerbose bool
 	var stokeDeadFlagsStr string
+	var stokeTimingStr string
+	var stokeVariantStr string
 
 	stokeCmd := &cobra.Command{
 		Use:   "stoke",
@@ -216,7 +366,7 @@ func main() {
 			if targetStr == "" {
 				return fmt.Errorf("--target is required")
 			}
-			seq, err := parseAssembly(targetStr)
+			seq, err := asm.ParseAssembly(targetStr, asm.Default{})
 			if err != nil {
 				return fmt.Errorf("failed to parse target: %w", err)
 			}
@@ -226,6 +376,16 @@ func main() {
 				return err
 			}
 
+			costModel, err := parseTimingModel(stokeTimingStr)
+			if err != nil {
+				return err
+			}
+
+			variant, ok := inst.ByName(stokeVariantStr)
+			if !ok {
+				return fmt.Errorf("invalid --variant value %q: use z80, z180, r800, ez80, or sm83", stokeVariantStr)
+			}
+
 			cfg := stoke.Config{
 				Target:     seq,
 				Chains:     stokeChains,
@@ -233,6 +393,8 @@ func main() {
 				Decay:      stokeDecay,
 				Verbose:    stokeVerbose,
 				DeadFlags:  deadFlags,
+				CostModel:  costModel,
+				Variant:    variant,
 			}
 
 			results := stoke.Run(cfg)
@@ -281,6 +443,10 @@ func main() {
 	stokeCmd.Flags().StringVar(&stokeOutput, "output", "", "Output JSON file path")
 	stokeCmd.Flags().BoolVarP(&stokeVerbose, "verbose", "v", false, "Verbose output")
 	stokeCmd.Flags().StringVar(&stokeDeadFlagsStr, "dead-flags", "none", "Dead flags mask: none, undoc, all, or hex (e.g. 0xFF)")
+	stokeCmd.Flags().StringVar(&stokeTimingStr, "timing", "plain", "Cycle timing model: plain, spectrum48, or spectrum128")
+	// Named --variant rather than --target (chunk5-5): --target above
+	// already means "the assembly sequence to optimize".
+	stokeCmd.Flags().StringVar(&stokeVariantStr, "variant", "z80", "Z80-family variant: z80, z180, r800, ez80, or sm83")
 
 	// verify-jsonl command: verify CUDA JSONL output against CPU ExhaustiveCheck
 	var verifyDeadFlagsStr string
@@ -289,13 +455,39 @@ func main() {
 		Short: "Verify JSONL rules from CUDA search using CPU ExhaustiveCheck",
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return verifyJSONL(args[0], verifyDeadFlagsStr, verbose)
+			flavor, err := parseDialect(dialectStr)
+			if err != nil {
+				return err
+			}
+			return verifyJSONL(args[0], verifyDeadFlagsStr, verbose, flavor)
 		},
 	}
 	verifyJSONLCmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Verbose output")
 	verifyJSONLCmd.Flags().StringVar(&verifyDeadFlagsStr, "dead-flags", "none", "Dead flags mask for verification")
+	verifyJSONLCmd.Flags().StringVar(&dialectStr, "dialect", "default", "Assembly dialect: default, sjasmplus, pasmo, or z88dk")
+
+	// dump-catalog command
+	var dumpCatalogOutput string
 
-	rootCmd.AddCommand(enumCmd, targetCmd, verifyCmd, exportCmd, stokeCmd, verifyJSONLCmd)
+	dumpCatalogCmd := &cobra.Command{
+		Use:   "dump-catalog",
+		Short: "Dump the opcode Catalog as JSON for external consumers (e.g. cuda/z80qc)",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			w := io.Writer(os.Stdout)
+			if dumpCatalogOutput != "" {
+				f, err := os.Create(dumpCatalogOutput)
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+				w = f
+			}
+			return inst.DumpCatalogJSON(w)
+		},
+	}
+	dumpCatalogCmd.Flags().StringVarP(&dumpCatalogOutput, "output", "o", "", "Output file path (default: stdout)")
+
+	rootCmd.AddCommand(enumCmd, targetCmd, verifyCmd, exportCmd, stokeCmd, verifyJSONLCmd, dumpCatalogCmd)
 	if err := rootCmd.Execute(); err != nil {
 		os.Exit(1)
 	}
@@ -321,82 +513,37 @@ func parseDeadFlags(s string) (search.FlagMask, error) {
 	}
 }
 
-// parseAssembly converts assembly text like "LD A, 0" into instructions.
-func parseAssembly(text string) ([]inst.Instruction, error) {
-	// Split on : for multi-instruction sequences
-	parts := strings.Split(text, ":")
-	var seq []inst.Instruction
-
-	for _, part := range parts {
-		part = strings.TrimSpace(part)
-		if part == "" {
-			continue
-		}
-		instr, err := parseSingleInstruction(part)
-		if err != nil {
-			return nil, fmt.Errorf("cannot parse %q: %w", part, err)
-		}
-		seq = append(seq, instr)
-	}
-
-	if len(seq) == 0 {
-		return nil, fmt.Errorf("no instructions parsed from %q", text)
+// parseTimingModel parses the --timing flag into a stoke.CostModel. "plain"
+// returns nil, which leaves Chain on its own PlainZ80 default; the
+// contended targets wrap pkg/timing's PC-aware models (chunk5-4) at PC
+// 0x8000 / frame offset 0 — a fixed, representative starting point, since
+// STOKE candidates aren't placed at a real address until assembled.
+func parseTimingModel(s string) (stoke.CostModel, error) {
+	switch strings.ToLower(s) {
+	case "", "plain":
+		return nil, nil
+	case "spectrum48":
+		return stoke.NewTimingCostModel(timing.Spectrum48, 0x8000, 0), nil
+	case "spectrum128":
+		return stoke.NewTimingCostModel(timing.Spectrum128, 0x8000, 0), nil
+	default:
+		return nil, fmt.Errorf("invalid --timing value %q: use plain, spectrum48, or spectrum128", s)
 	}
-	return seq, nil
 }
 
-func parseSingleInstruction(text string) (inst.Instruction, error) {
-	text = strings.TrimSpace(text)
-	upper := strings.ToUpper(text)
-
-	// Try to match against all catalog mnemonics
-	for op := inst.OpCode(0); op < inst.OpCodeCount; op++ {
-		info := &inst.Catalog[op]
-		if info.Mnemonic == "" {
-			continue
-		}
-
-		if !inst.HasImmediate(op) {
-			if strings.EqualFold(text, info.Mnemonic) {
-				return inst.Instruction{Op: op}, nil
-			}
-			continue
-		}
-
-		// For immediate instructions, the mnemonic has "n" as placeholder
-		// Match the pattern with any hex/decimal value
-		pattern := strings.ToUpper(info.Mnemonic)
-		nIdx := strings.LastIndex(pattern, "N")
-		if nIdx < 0 {
-			continue
-		}
-		prefix := pattern[:nIdx]
-		suffix := pattern[nIdx+1:]
-
-		if !strings.HasPrefix(upper, prefix) {
-			continue
-		}
-		if suffix != "" && !strings.HasSuffix(upper, suffix) {
-			continue
-		}
-
-		valStr := upper[len(prefix):]
-		if suffix != "" {
-			valStr = valStr[:len(valStr)-len(suffix)]
-		}
-		valStr = strings.TrimSpace(valStr)
-
-		val, err := parseImmediate(valStr)
-		if err != nil {
-			continue
-		}
-		return inst.Instruction{Op: op, Imm: uint16(val)}, nil
+// parseDialect parses the --dialect flag value into a pkg/asm Flavor.
+func parseDialect(s string) (asm.Flavor, error) {
+	if s == "" {
+		s = "default"
 	}
-
-	return inst.Instruction{}, fmt.Errorf("unknown instruction: %s", text)
+	flavor, ok := asm.ByName(strings.ToLower(s))
+	if !ok {
+		return nil, fmt.Errorf("invalid --dialect value %q: use default, sjasmplus, pasmo, or z88dk", s)
+	}
+	return flavor, nil
 }
 
-func verifyJSONL(path string, deadFlagsStr string, verbose bool) error {
+func verifyJSONL(path string, deadFlagsStr string, verbose bool, flavor asm.Flavor) error {
 	deadFlags, err := parseDeadFlags(deadFlagsStr)
 	if err != nil {
 		return err
@@ -431,13 +578,13 @@ func verifyJSONL(path string, deadFlagsStr string, verbose bool) error {
 			continue
 		}
 
-		source, err := parseAssembly(rule.SourceASM)
+		source, err := asm.ParseAssembly(rule.SourceASM, flavor)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "  [%d] Cannot parse source %q: %v\n", total, rule.SourceASM, err)
 			skipped++
 			continue
 		}
-		replacement, err := parseAssembly(rule.ReplacementASM)
+		replacement, err := asm.ParseAssembly(rule.ReplacementASM, flavor)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "  [%d] Cannot parse replacement %q: %v\n", total, rule.ReplacementASM, err)
 			skipped++
@@ -474,31 +621,3 @@ func verifyJSONL(path string, deadFlagsStr string, verbose bool) error {
 	}
 	return nil
 }
-
-func parseImmediate(s string) (int, error) {
-	s = strings.TrimSpace(s)
-	if s == "" {
-		return 0, fmt.Errorf("empty")
-	}
-
-	// Handle hex: 0xFF, FFh, 0x00, etc.
-	if strings.HasPrefix(s, "0X") || strings.HasPrefix(s, "0x") {
-		var v int
-		_, err := fmt.Sscanf(s, "0x%x", &v)
-		if err != nil {
-			_, err = fmt.Sscanf(s, "0X%x", &v)
-		}
-		return v, err
-	}
-	if strings.HasSuffix(strings.ToUpper(s), "H") {
-		s = s[:len(s)-1]
-		var v int
-		_, err := fmt.Sscanf(s, "%x", &v)
-		return v, err
-	}
-
-	// Decimal
-	var v int
-	_, err := fmt.Sscanf(s, "%d", &v)
-	return v, err
-}
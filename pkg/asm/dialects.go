@@ -0,0 +1,165 @@
+package asm
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Default is the dialect z80opt has always accepted: hex as "0xFF" or
+// "FFh", decimal otherwise, ";" comments. Kept as its own Flavor (rather
+// than special-cased in ParseInstr) so it's subject to the same interface
+// every other dialect is, and so --dialect default behaves identically to
+// omitting --dialect altogether.
+type Default struct{}
+
+func (Default) Name() string { return "default" }
+
+func (Default) StripComment(line string) string {
+	return stripAt(line, ";")
+}
+
+func (Default) ParseNumber(s string) (int, error) {
+	if v, ok, err := parseHexPrefixed(s); ok {
+		return v, err
+	}
+	if v, ok, err := parseHexSuffixed(s); ok {
+		return v, err
+	}
+	return parseDecimal(s)
+}
+
+// Sjasmplus matches sjasmplus's numeric literals: "$FF" or "#FF" hex,
+// "%1010" binary, a trailing "h" also accepted for pasted source that used
+// it out of habit, and ";" comments.
+type Sjasmplus struct{}
+
+func (Sjasmplus) Name() string { return "sjasmplus" }
+
+func (Sjasmplus) StripComment(line string) string {
+	return stripAt(line, ";")
+}
+
+func (Sjasmplus) ParseNumber(s string) (int, error) {
+	if v, ok, err := parseDollarOrHashHex(s); ok {
+		return v, err
+	}
+	if v, ok, err := parseBinary(s); ok {
+		return v, err
+	}
+	if v, ok, err := parseHexPrefixed(s); ok {
+		return v, err
+	}
+	if v, ok, err := parseHexSuffixed(s); ok {
+		return v, err
+	}
+	return parseDecimal(s)
+}
+
+// Pasmo matches pasmo's numeric literals: "0xFF" or "$FF" hex, a trailing
+// "h" also accepted, and ";" comments.
+type Pasmo struct{}
+
+func (Pasmo) Name() string { return "pasmo" }
+
+func (Pasmo) StripComment(line string) string {
+	return stripAt(line, ";")
+}
+
+func (Pasmo) ParseNumber(s string) (int, error) {
+	if v, ok, err := parseDollarOrHashHex(s); ok {
+		return v, err
+	}
+	if v, ok, err := parseHexPrefixed(s); ok {
+		return v, err
+	}
+	if v, ok, err := parseHexSuffixed(s); ok {
+		return v, err
+	}
+	return parseDecimal(s)
+}
+
+// Z88dk matches z88dk's z80asm literals: "0xFF" or "$FF" hex, "0b1010"
+// binary, and either ";" or "//" comments (z80asm accepts both).
+type Z88dk struct{}
+
+func (Z88dk) Name() string { return "z88dk" }
+
+func (Z88dk) StripComment(line string) string {
+	return stripAt(stripAt(line, ";"), "//")
+}
+
+func (Z88dk) ParseNumber(s string) (int, error) {
+	if v, ok, err := parseDollarOrHashHex(s); ok {
+		return v, err
+	}
+	if v, ok, err := parse0bBinary(s); ok {
+		return v, err
+	}
+	if v, ok, err := parseHexPrefixed(s); ok {
+		return v, err
+	}
+	return parseDecimal(s)
+}
+
+// stripAt cuts line at marker's first occurrence, if present.
+func stripAt(line, marker string) string {
+	if i := strings.Index(line, marker); i >= 0 {
+		return line[:i]
+	}
+	return line
+}
+
+// parseHexPrefixed recognizes "0xFF"/"0XFF". ok is false if s doesn't start
+// with the prefix at all — a signal to the caller to try another base.
+func parseHexPrefixed(s string) (int, bool, error) {
+	if !strings.HasPrefix(strings.ToLower(s), "0x") {
+		return 0, false, nil
+	}
+	v, err := strconv.ParseInt(s[2:], 16, 32)
+	return int(v), true, err
+}
+
+// parseHexSuffixed recognizes a trailing "h"/"H" (e.g. "FFh").
+func parseHexSuffixed(s string) (int, bool, error) {
+	if !strings.HasSuffix(strings.ToUpper(s), "H") {
+		return 0, false, nil
+	}
+	v, err := strconv.ParseInt(s[:len(s)-1], 16, 32)
+	return int(v), true, err
+}
+
+// parseDollarOrHashHex recognizes "$FF" or "#FF".
+func parseDollarOrHashHex(s string) (int, bool, error) {
+	if !strings.HasPrefix(s, "$") && !strings.HasPrefix(s, "#") {
+		return 0, false, nil
+	}
+	v, err := strconv.ParseInt(s[1:], 16, 32)
+	return int(v), true, err
+}
+
+// parseBinary recognizes "%1010".
+func parseBinary(s string) (int, bool, error) {
+	if !strings.HasPrefix(s, "%") {
+		return 0, false, nil
+	}
+	v, err := strconv.ParseInt(s[1:], 2, 32)
+	return int(v), true, err
+}
+
+// parse0bBinary recognizes "0b1010"/"0B1010".
+func parse0bBinary(s string) (int, bool, error) {
+	if !strings.HasPrefix(strings.ToLower(s), "0b") {
+		return 0, false, nil
+	}
+	v, err := strconv.ParseInt(s[2:], 2, 32)
+	return int(v), true, err
+}
+
+func parseDecimal(s string) (int, error) {
+	v, err := strconv.ParseInt(s, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("not a number: %q", s)
+	}
+	return int(v), nil
+}
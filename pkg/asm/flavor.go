@@ -0,0 +1,41 @@
+// Package asm parses the narrow slice of Z80 assembly syntax this project's
+// CLI actually needs — mnemonic plus operand, optionally several per line —
+// plus, through Load, labels, EQU/= constants, INCLUDE and IFDEF/ELSE/ENDIF,
+// without trying to be a full assembler front end: no macros, no other
+// directives, and no multi-pass layout. Real Z80 toolchains disagree on comment
+// markers and numeric literal bases (sjasmplus's "$FF", a trailing "h",
+// z88dk's "0x" and "$", ...), so those differences are isolated behind a
+// Flavor, modeled on go6502's Flavor, instead of baked into one parser.
+package asm
+
+// Flavor describes one assembler dialect's comment and numeric-literal
+// conventions. Mnemonic spelling itself doesn't vary across the dialects
+// this package ships (they're all the same Zilog mnemonics ParseInstr
+// matches against inst.Catalog) — only how a trailing comment is recognized
+// and how a numeric operand is written. Splitting a line into statements on
+// ":" is the same for every flavor.
+type Flavor interface {
+	// Name identifies the dialect, e.g. for --dialect and error messages.
+	Name() string
+	// StripComment removes a trailing comment from line, if this flavor
+	// recognizes one, and returns the remainder (still untrimmed).
+	StripComment(line string) string
+	// ParseNumber parses a numeric literal in this flavor's accepted bases
+	// (hex, binary, decimal) and returns an error if s isn't one.
+	ParseNumber(s string) (int, error)
+}
+
+// Flavors lists every built-in Flavor by name, for --dialect validation and
+// help text.
+var Flavors = map[string]Flavor{
+	"default":   Default{},
+	"sjasmplus": Sjasmplus{},
+	"pasmo":     Pasmo{},
+	"z88dk":     Z88dk{},
+}
+
+// ByName looks up a Flavor by its --dialect name.
+func ByName(name string) (Flavor, bool) {
+	f, ok := Flavors[name]
+	return f, ok
+}
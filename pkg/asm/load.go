@@ -0,0 +1,282 @@
+package asm
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/oisee/z80-optimizer/pkg/inst"
+)
+
+// Program is the result of Load: the flattened instruction stream plus a
+// symbol table mapping each label to the index it was defined at, so a
+// caller can pick out one labeled region (see Program.Region) instead of
+// assembling an entire file.
+type Program struct {
+	Instructions []inst.Instruction
+	Labels       map[string]int
+}
+
+// Region returns the instructions from symbol's label up to (but not
+// including) the next label defined after it, or the end of Instructions
+// if symbol's label is the last one — the slice "z80opt target --symbol"
+// hands to search.SearchSingleLiveOut.
+func (p *Program) Region(symbol string) ([]inst.Instruction, error) {
+	start, ok := p.Labels[symbol]
+	if !ok {
+		return nil, fmt.Errorf("no such label: %s", symbol)
+	}
+	end := len(p.Instructions)
+	for _, idx := range p.Labels {
+		if idx > start && idx < end {
+			end = idx
+		}
+	}
+	return p.Instructions[start:end], nil
+}
+
+// loader carries the state Load thread through nested INCLUDEs: the source
+// stack, accumulated instructions/labels, the EQU/= constant table (also
+// IFDEF's notion of "defined"), the label conditional-assembly stack, and
+// the last global label seen (for resolving ".local" labels).
+type loader struct {
+	flavor    Flavor
+	stack     []LineSource
+	prog      Program
+	consts    map[string]int
+	lastLabel string
+	condStack []bool // true = the innermost active block is currently emitting
+}
+
+// Load assembles root (following INCLUDE by pushing a nested LineSource),
+// expanding EQU/= constants into immediates and skipping inactive
+// IFDEF/ELSE branches, and returns the flattened Program. Conditional
+// nesting is still tracked correctly inside an inactive branch — only
+// instruction, label and EQU/= lines are skipped there, IFDEF/ELSE/ENDIF keep being
+// parsed — the same reason a real preprocessor can't just skip to the
+// matching ENDIF by counting lines.
+func Load(root LineSource, flavor Flavor) (*Program, error) {
+	ld := &loader{
+		flavor: flavor,
+		stack:  []LineSource{root},
+		consts: make(map[string]int),
+	}
+	if err := ld.run(); err != nil {
+		return nil, err
+	}
+	if len(ld.condStack) != 0 {
+		return nil, fmt.Errorf("%s: unterminated IFDEF (missing ENDIF)", root.Name())
+	}
+	return &ld.prog, nil
+}
+
+func (ld *loader) active() bool {
+	for _, a := range ld.condStack {
+		if !a {
+			return false
+		}
+	}
+	return true
+}
+
+func (ld *loader) run() error {
+	for len(ld.stack) > 0 {
+		top := ld.stack[len(ld.stack)-1]
+		line, ok := top.Next()
+		if !ok {
+			if closer, ok := top.(interface{ Close() error }); ok {
+				closer.Close()
+			}
+			ld.stack = ld.stack[:len(ld.stack)-1]
+			continue
+		}
+		if err := ld.line(top.Name(), top.Dir(), line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (ld *loader) line(sourceName, dir, raw string) error {
+	line := strings.TrimSpace(ld.flavor.StripComment(raw))
+	if line == "" {
+		return nil
+	}
+
+	directive, rest := splitWord(line)
+	switch strings.ToUpper(directive) {
+	case "INCLUDE":
+		path := strings.Trim(strings.TrimSpace(rest), `"`)
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+		src, err := NewFileSource(path)
+		if err != nil {
+			return fmt.Errorf("%s: INCLUDE %q: %w", sourceName, path, err)
+		}
+		ld.stack = append(ld.stack, src)
+		return nil
+
+	case "IFDEF":
+		sym := strings.TrimSpace(rest)
+		_, defined := ld.consts[sym]
+		ld.condStack = append(ld.condStack, defined)
+		return nil
+
+	case "ELSE":
+		if len(ld.condStack) == 0 {
+			return fmt.Errorf("%s: ELSE without IFDEF", sourceName)
+		}
+		ld.condStack[len(ld.condStack)-1] = !ld.condStack[len(ld.condStack)-1]
+		return nil
+
+	case "ENDIF":
+		if len(ld.condStack) == 0 {
+			return fmt.Errorf("%s: ENDIF without IFDEF", sourceName)
+		}
+		ld.condStack = ld.condStack[:len(ld.condStack)-1]
+		return nil
+	}
+
+	if !ld.active() {
+		return nil
+	}
+
+	if name, value, ok := parseEqu(directive, rest); ok {
+		v, err := ld.resolveNumber(value)
+		if err != nil {
+			return fmt.Errorf("%s: %s: %w", sourceName, line, err)
+		}
+		ld.consts[name] = v
+		return nil
+	}
+
+	if label, rest, ok := parseLabel(line); ok {
+		ld.defineLabel(label)
+		line = strings.TrimSpace(rest)
+		if line == "" {
+			return nil
+		}
+	}
+
+	instr, err := ParseInstr(ld.substConsts(line), ld.flavor)
+	if err != nil {
+		return fmt.Errorf("%s: %w", sourceName, err)
+	}
+	ld.prog.Instructions = append(ld.prog.Instructions, instr)
+	return nil
+}
+
+// defineLabel records label at the current instruction index. A label
+// starting with "." is local to the last global (non-local) label seen,
+// e.g. sjasmplus's ".loop" inside a "Decompress:" block is stored as
+// "Decompress.loop" so it can't collide with another routine's ".loop".
+func (ld *loader) defineLabel(label string) {
+	if strings.HasPrefix(label, ".") {
+		if ld.lastLabel != "" {
+			label = ld.lastLabel + label
+		}
+	} else {
+		ld.lastLabel = label
+	}
+	if ld.prog.Labels == nil {
+		ld.prog.Labels = make(map[string]int)
+	}
+	ld.prog.Labels[label] = len(ld.prog.Instructions)
+}
+
+// resolveNumber parses value either as a flavor literal or as a
+// previously-defined constant, so one EQU can reference another.
+func (ld *loader) resolveNumber(value string) (int, error) {
+	if v, ok := ld.consts[value]; ok {
+		return v, nil
+	}
+	return ld.flavor.ParseNumber(value)
+}
+
+// substConsts replaces whole-word constant names in line with their
+// numeric value, so ParseInstr only ever has to understand literals —
+// "LD A, SPRITE_COUNT" becomes "LD A, 16" before it reaches ParseInstr.
+func (ld *loader) substConsts(line string) string {
+	if len(ld.consts) == 0 {
+		return line
+	}
+	fields := strings.FieldsFunc(line, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t' || r == '(' || r == ')'
+	})
+	for _, f := range fields {
+		if v, ok := ld.consts[f]; ok {
+			line = replaceWord(line, f, strconv.Itoa(v))
+		}
+	}
+	return line
+}
+
+// replaceWord replaces whole-word occurrences of word in s with repl —
+// strings.ReplaceAll would also corrupt a longer identifier that merely
+// contains word as a substring (e.g. replacing "N" inside "COUNT").
+func replaceWord(s, word, repl string) string {
+	var b strings.Builder
+	for {
+		i := strings.Index(s, word)
+		if i < 0 {
+			b.WriteString(s)
+			return b.String()
+		}
+		before := i == 0 || !isWordByte(s[i-1])
+		after := i+len(word) == len(s) || !isWordByte(s[i+len(word)])
+		if before && after {
+			b.WriteString(s[:i])
+			b.WriteString(repl)
+			s = s[i+len(word):]
+		} else {
+			b.WriteString(s[:i+1])
+			s = s[i+1:]
+		}
+	}
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// splitWord splits line into its first whitespace-delimited word and the
+// remainder.
+func splitWord(line string) (word, rest string) {
+	i := strings.IndexAny(line, " \t")
+	if i < 0 {
+		return line, ""
+	}
+	return line[:i], strings.TrimSpace(line[i+1:])
+}
+
+// parseEqu recognizes "NAME EQU value" and "NAME = value".
+func parseEqu(directive, rest string) (name, value string, ok bool) {
+	if directive == "" {
+		return "", "", false
+	}
+	word, after := splitWord(rest)
+	if strings.EqualFold(word, "EQU") && after != "" {
+		return directive, after, true
+	}
+	if val := strings.TrimSpace(strings.TrimPrefix(rest, "=")); val != rest && val != "" {
+		return directive, val, true
+	}
+	return "", "", false
+}
+
+// parseLabel recognizes a leading "NAME:" label (global or ".local"),
+// returning the label and whatever followed it on the same line (e.g. an
+// instruction sharing the label's line).
+func parseLabel(line string) (label, rest string, ok bool) {
+	i := strings.IndexByte(line, ':')
+	if i < 0 {
+		return "", "", false
+	}
+	candidate := line[:i]
+	if candidate == "" || strings.ContainsAny(candidate, " \t") {
+		return "", "", false
+	}
+	return candidate, line[i+1:], true
+}
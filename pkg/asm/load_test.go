@@ -0,0 +1,144 @@
+package asm
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/oisee/z80-optimizer/pkg/inst"
+)
+
+func TestLoadLabelsAndConstants(t *testing.T) {
+	src := NewStringSource("test.asm", `
+SPRITE_COUNT EQU 16
+Start:
+    LD A, SPRITE_COUNT
+    INC A
+Loop:
+.again:
+    DEC A
+`)
+	prog, err := Load(src, Default{})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(prog.Instructions) != 3 {
+		t.Fatalf("got %d instructions, want 3: %+v", len(prog.Instructions), prog.Instructions)
+	}
+	if prog.Instructions[0].Op != inst.LD_A_N || prog.Instructions[0].Imm != 16 {
+		t.Fatalf("instruction 0 = %+v, want LD A,16", prog.Instructions[0])
+	}
+	if prog.Labels["Start"] != 0 {
+		t.Errorf("Start label at %d, want 0", prog.Labels["Start"])
+	}
+	if prog.Labels["Loop"] != 2 {
+		t.Errorf("Loop label at %d, want 2", prog.Labels["Loop"])
+	}
+	if idx, ok := prog.Labels["Loop.again"]; !ok || idx != 2 {
+		t.Errorf("Loop.again label = (%d, %v), want (2, true)", idx, ok)
+	}
+}
+
+func TestLoadIfdefSkipsInactiveBranch(t *testing.T) {
+	src := NewStringSource("test.asm", `
+DEBUG EQU 1
+IFDEF DEBUG
+    INC A
+ELSE
+    INC B
+ENDIF
+IFDEF RELEASE
+    INC C
+ENDIF
+    INC D
+`)
+	prog, err := Load(src, Default{})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	want := []inst.OpCode{inst.INC_A, inst.INC_D}
+	if len(prog.Instructions) != len(want) {
+		t.Fatalf("got %d instructions, want %d: %+v", len(prog.Instructions), len(want), prog.Instructions)
+	}
+	for i, op := range want {
+		if prog.Instructions[i].Op != op {
+			t.Errorf("instruction %d = %v, want %v", i, prog.Instructions[i].Op, op)
+		}
+	}
+}
+
+func TestLoadUnterminatedIfdefErrors(t *testing.T) {
+	src := NewStringSource("test.asm", "IFDEF DEBUG\nINC A\n")
+	if _, err := Load(src, Default{}); err == nil {
+		t.Fatal("expected an error for an IFDEF with no matching ENDIF")
+	}
+}
+
+func TestLoadInclude(t *testing.T) {
+	dir := t.TempDir()
+	includedPath := filepath.Join(dir, "inner.asm")
+	if err := os.WriteFile(includedPath, []byte("INC B\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	mainPath := filepath.Join(dir, "main.asm")
+	if err := os.WriteFile(mainPath, []byte("INC A\nINCLUDE \"inner.asm\"\nINC C\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	src, err := NewFileSource(mainPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prog, err := Load(src, Default{})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	want := []inst.OpCode{inst.INC_A, inst.INC_B, inst.INC_C}
+	if len(prog.Instructions) != len(want) {
+		t.Fatalf("got %d instructions, want %d: %+v", len(prog.Instructions), len(want), prog.Instructions)
+	}
+	for i, op := range want {
+		if prog.Instructions[i].Op != op {
+			t.Errorf("instruction %d = %v, want %v", i, prog.Instructions[i].Op, op)
+		}
+	}
+}
+
+func TestProgramRegion(t *testing.T) {
+	src := NewStringSource("test.asm", "Foo:\n  INC A\n  INC B\nBar:\n  INC C\n")
+	prog, err := Load(src, Default{})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	region, err := prog.Region("Foo")
+	if err != nil {
+		t.Fatalf("Region: %v", err)
+	}
+	if len(region) != 2 || region[0].Op != inst.INC_A || region[1].Op != inst.INC_B {
+		t.Fatalf("Region(Foo) = %+v, want [INC A, INC B]", region)
+	}
+
+	if _, err := prog.Region("NoSuchLabel"); err == nil {
+		t.Fatal("expected an error for an undefined label")
+	}
+}
+
+func TestParseRuleFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "rules.asm")
+	content := "; a comment\nINC A : INC A -> ADD A, 2\n\nDEC A : DEC A -> SUB 2\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	rules, err := ParseRuleFile(path)
+	if err != nil {
+		t.Fatalf("ParseRuleFile: %v", err)
+	}
+	if len(rules) != 2 {
+		t.Fatalf("got %d rules, want 2: %+v", len(rules), rules)
+	}
+	if rules[0].SourceASM != "INC A : INC A" || rules[0].ReplacementASM != "ADD A, 2" {
+		t.Errorf("rules[0] = %+v", rules[0])
+	}
+}
@@ -0,0 +1,87 @@
+package asm
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/oisee/z80-optimizer/pkg/inst"
+)
+
+// ParseAssembly converts assembly source (one or more instructions,
+// separated by ":" and/or newlines, comments stripped per flavor) into
+// instructions.
+func ParseAssembly(text string, flavor Flavor) ([]inst.Instruction, error) {
+	var seq []inst.Instruction
+	for _, line := range strings.Split(text, "\n") {
+		line = flavor.StripComment(line)
+		for _, part := range strings.Split(line, ":") {
+			part = strings.TrimSpace(part)
+			if part == "" {
+				continue
+			}
+			instr, err := ParseInstr(part, flavor)
+			if err != nil {
+				return nil, fmt.Errorf("cannot parse %q: %w", part, err)
+			}
+			seq = append(seq, instr)
+		}
+	}
+
+	if len(seq) == 0 {
+		return nil, fmt.Errorf("no instructions parsed from %q", text)
+	}
+	return seq, nil
+}
+
+// ParseInstr parses a single instruction, e.g. "LD A, 0xFF", against
+// inst.Catalog. Mnemonic spelling is the same across every Flavor; only the
+// immediate operand's numeric base is dialect-dependent.
+func ParseInstr(text string, flavor Flavor) (inst.Instruction, error) {
+	text = strings.TrimSpace(text)
+	upper := strings.ToUpper(text)
+
+	for op := inst.OpCode(0); op < inst.OpCodeCount; op++ {
+		info := &inst.Catalog[op]
+		if info.Mnemonic == "" {
+			continue
+		}
+
+		if !inst.HasImmediate(op) {
+			if strings.EqualFold(text, info.Mnemonic) {
+				return inst.Instruction{Op: op}, nil
+			}
+			continue
+		}
+
+		// For immediate instructions, the mnemonic has "N" as a placeholder;
+		// match the pattern with any numeric literal the flavor accepts.
+		pattern := strings.ToUpper(info.Mnemonic)
+		nIdx := strings.LastIndex(pattern, "N")
+		if nIdx < 0 {
+			continue
+		}
+		prefix := pattern[:nIdx]
+		suffix := pattern[nIdx+1:]
+
+		if !strings.HasPrefix(upper, prefix) {
+			continue
+		}
+		if suffix != "" && !strings.HasSuffix(upper, suffix) {
+			continue
+		}
+
+		valStr := upper[len(prefix):]
+		if suffix != "" {
+			valStr = valStr[:len(valStr)-len(suffix)]
+		}
+		valStr = strings.TrimSpace(valStr)
+
+		val, err := flavor.ParseNumber(valStr)
+		if err != nil {
+			continue
+		}
+		return inst.Instruction{Op: op, Imm: uint16(val)}, nil
+	}
+
+	return inst.Instruction{}, fmt.Errorf("unknown instruction: %s", text)
+}
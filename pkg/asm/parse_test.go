@@ -0,0 +1,118 @@
+package asm
+
+import (
+	"testing"
+
+	"github.com/oisee/z80-optimizer/pkg/inst"
+)
+
+func TestParseAssemblyDefaultDialect(t *testing.T) {
+	seq, err := ParseAssembly("LD A, 0xFF : INC A", Default{})
+	if err != nil {
+		t.Fatalf("ParseAssembly: %v", err)
+	}
+	if len(seq) != 2 || seq[0].Op != inst.LD_A_N || seq[0].Imm != 0xFF || seq[1].Op != inst.INC_A {
+		t.Fatalf("got %+v, want [LD A,0xFF INC A]", seq)
+	}
+}
+
+func TestParseAssemblySplitsOnNewlineAndColon(t *testing.T) {
+	seq, err := ParseAssembly("INC A\nINC B : INC C", Default{})
+	if err != nil {
+		t.Fatalf("ParseAssembly: %v", err)
+	}
+	if len(seq) != 3 || seq[0].Op != inst.INC_A || seq[1].Op != inst.INC_B || seq[2].Op != inst.INC_C {
+		t.Fatalf("got %+v, want [INC A, INC B, INC C]", seq)
+	}
+}
+
+func TestParseAssemblyStripsComments(t *testing.T) {
+	seq, err := ParseAssembly("INC A ; bump the counter\nINC B", Default{})
+	if err != nil {
+		t.Fatalf("ParseAssembly: %v", err)
+	}
+	if len(seq) != 2 || seq[0].Op != inst.INC_A || seq[1].Op != inst.INC_B {
+		t.Fatalf("got %+v, want [INC A, INC B]", seq)
+	}
+}
+
+func TestParseAssemblyEmptyInputErrors(t *testing.T) {
+	if _, err := ParseAssembly("   ", Default{}); err == nil {
+		t.Fatal("expected an error for input with no instructions")
+	}
+}
+
+func TestParseInstrSjasmplusNumericLiterals(t *testing.T) {
+	tests := []struct {
+		text string
+		want uint16
+	}{
+		{"LD A, $FF", 0xFF},
+		{"LD A, %1010", 0b1010},
+		{"LD A, #2A", 0x2A},
+		{"LD A, 10", 10},
+	}
+	for _, tc := range tests {
+		instr, err := ParseInstr(tc.text, Sjasmplus{})
+		if err != nil {
+			t.Errorf("ParseInstr(%q): %v", tc.text, err)
+			continue
+		}
+		if instr.Imm != tc.want {
+			t.Errorf("ParseInstr(%q).Imm = %#x, want %#x", tc.text, instr.Imm, tc.want)
+		}
+	}
+}
+
+func TestParseInstrZ88dkNumericLiterals(t *testing.T) {
+	tests := []struct {
+		text string
+		want uint16
+	}{
+		{"LD A, $FF", 0xFF},
+		{"LD A, 0b1010", 0b1010},
+		{"LD A, 0x2A", 0x2A},
+	}
+	for _, tc := range tests {
+		instr, err := ParseInstr(tc.text, Z88dk{})
+		if err != nil {
+			t.Errorf("ParseInstr(%q): %v", tc.text, err)
+			continue
+		}
+		if instr.Imm != tc.want {
+			t.Errorf("ParseInstr(%q).Imm = %#x, want %#x", tc.text, instr.Imm, tc.want)
+		}
+	}
+}
+
+func TestParseInstrPasmoDollarHex(t *testing.T) {
+	instr, err := ParseInstr("LD A, $2A", Pasmo{})
+	if err != nil {
+		t.Fatalf("ParseInstr: %v", err)
+	}
+	if instr.Imm != 0x2A {
+		t.Fatalf("Imm = %#x, want 0x2A", instr.Imm)
+	}
+}
+
+func TestParseInstrUnknownMnemonic(t *testing.T) {
+	if _, err := ParseInstr("FROBNICATE A", Default{}); err == nil {
+		t.Fatal("expected an error for an unknown mnemonic")
+	}
+}
+
+func TestZ88dkStripsSlashSlashComment(t *testing.T) {
+	got := Z88dk{}.StripComment("INC A // bump")
+	if got != "INC A " {
+		t.Fatalf("StripComment = %q, want %q", got, "INC A ")
+	}
+}
+
+func TestByName(t *testing.T) {
+	if _, ok := ByName("sjasmplus"); !ok {
+		t.Error("ByName(\"sjasmplus\") should be found")
+	}
+	if _, ok := ByName("not-a-dialect"); ok {
+		t.Error("ByName(\"not-a-dialect\") should not be found")
+	}
+}
@@ -0,0 +1,54 @@
+package asm
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// AsmRule is one source/replacement pair parsed from a rules.asm file — the
+// text-source counterpart to the JSON {source_asm, replacement_asm} records
+// result.ReadJSON consumes.
+type AsmRule struct {
+	SourceASM      string
+	ReplacementASM string
+}
+
+// ParseRuleFile reads a "z80opt verify --asm-file" rules file: one rule per
+// non-blank, non-comment line, written as "<source> -> <replacement>" with
+// each side in flavor's own colon-separated instruction syntax (the same
+// syntax ParseAssembly accepts) — e.g. "INC A : INC A -> ADD A, 2". This
+// intentionally does not reuse Load's label/EQU machinery: a rule file is a
+// flat list of independent before/after pairs, not a program with control
+// flow.
+func ParseRuleFile(path string) ([]AsmRule, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var rules []AsmRule
+	scanner := bufio.NewScanner(f)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(Default{}.StripComment(scanner.Text()))
+		if line == "" {
+			continue
+		}
+		i := strings.Index(line, "->")
+		if i < 0 {
+			return nil, fmt.Errorf("%s:%d: expected \"<source> -> <replacement>\", got %q", path, lineNo, line)
+		}
+		rules = append(rules, AsmRule{
+			SourceASM:      strings.TrimSpace(line[:i]),
+			ReplacementASM: strings.TrimSpace(line[i+2:]),
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
@@ -0,0 +1,82 @@
+package asm
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LineSource yields source lines one at a time — a file, an in-memory
+// string, or stdin — the abstraction Load stacks so an INCLUDE directive
+// can push a nested source onto the stack without the caller juggling file
+// handles itself. Modeled on go6502's Assembler.Load, which uses the same
+// stacked-source trick for its own include directive.
+type LineSource interface {
+	// Next returns the next line, without its trailing newline, and true;
+	// or ("", false) once the source is exhausted.
+	Next() (string, bool)
+	// Name identifies the source for error messages, e.g. a file path.
+	Name() string
+	// Dir is the directory an INCLUDE relative to this source resolves
+	// against — the source's own directory for a file, "." otherwise.
+	Dir() string
+}
+
+// fileSource reads lines from a file on disk.
+type fileSource struct {
+	path    string
+	f       *os.File
+	scanner *bufio.Scanner
+}
+
+// NewFileSource opens path and returns a LineSource over its contents. The
+// caller (or Load, once exhausted) is responsible for Close.
+func NewFileSource(path string) (*fileSource, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	return &fileSource{path: path, f: f, scanner: bufio.NewScanner(f)}, nil
+}
+
+func (s *fileSource) Next() (string, bool) {
+	if !s.scanner.Scan() {
+		return "", false
+	}
+	return s.scanner.Text(), true
+}
+
+func (s *fileSource) Name() string { return s.path }
+func (s *fileSource) Dir() string  { return filepath.Dir(s.path) }
+func (s *fileSource) Close() error { return s.f.Close() }
+
+// stringSource reads lines from an in-memory string — used for both
+// NewStringSource and stdin (the caller reads stdin into a string first).
+type stringSource struct {
+	name string
+	dir  string
+	rest string
+}
+
+// NewStringSource wraps text as a LineSource named name, for error messages.
+// Relative INCLUDEs resolve against the current working directory.
+func NewStringSource(name, text string) LineSource {
+	return &stringSource{name: name, dir: ".", rest: text}
+}
+
+func (s *stringSource) Next() (string, bool) {
+	if s.rest == "" {
+		return "", false
+	}
+	line := s.rest
+	if i := strings.IndexByte(s.rest, '\n'); i >= 0 {
+		line, s.rest = s.rest[:i], s.rest[i+1:]
+	} else {
+		s.rest = ""
+	}
+	return strings.TrimSuffix(line, "\r"), true
+}
+
+func (s *stringSource) Name() string { return s.name }
+func (s *stringSource) Dir() string  { return s.dir }
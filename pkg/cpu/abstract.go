@@ -0,0 +1,220 @@
+package cpu
+
+import "github.com/oisee/z80-optimizer/pkg/inst"
+
+// AbstractKind is the lattice value an AbstractCell holds: how precisely
+// ExecAbstract knows a register's or flag's contents at some program point
+// without actually running the sequence against concrete input.
+type AbstractKind uint8
+
+const (
+	AbsUnknown  AbstractKind = iota // could be anything; nothing may be folded from it
+	AbsZero                         // known to be exactly the all-zero byte, or a clear flag
+	AbsOne                          // known to be exactly a set flag (byte value 1 isn't distinct from AbsConcrete{1} and is never produced this way)
+	AbsConcrete                     // known to be exactly Byte
+	AbsSameAs                       // known to equal whatever Src held at this program point (copy propagation, registers only)
+)
+
+// AbstractCell is one lattice value.
+type AbstractCell struct {
+	Kind AbstractKind
+	Byte uint8       // valid when Kind == AbsConcrete
+	Src  AbstractReg // valid when Kind == AbsSameAs
+}
+
+// AbstractReg names the 7 general-purpose 8-bit registers ExecAbstract
+// tracks — the same family pkg/inst/family.go (chunk9-5) and
+// pkg/inst/pattern (chunk9-4) already enumerate for their own
+// register-class holes, not the full State (no SP/IX/IY/M lattice values
+// yet — widening this array is additive, see regOf).
+type AbstractReg uint8
+
+const (
+	AbsA AbstractReg = iota
+	AbsB
+	AbsC
+	AbsD
+	AbsE
+	AbsH
+	AbsL
+	abstractRegCount
+)
+
+// AbstractState is State's lattice counterpart: every tracked register and
+// flag holds an AbstractCell instead of a concrete byte, so a peephole pass
+// can ask "is this ADD's carry-out dead AND was its addend concretely 1" and
+// fold it to INC without running the program.
+//
+// Scope: ExecAbstract only has real transfer functions for the instruction
+// families a constant-propagation/copy-propagation pass over straight-line
+// peephole windows actually needs — register loads/copies, XOR A, INC/DEC,
+// and ADD A,n (concrete-operand folding only; ADD A,r is not modeled). Every other opcode
+// hits the conservative default: the whole state collapses to AbsUnknown,
+// since without a real transfer function the only sound thing to say about
+// an instruction's effect is "assume it could have changed anything" rather
+// than silently keeping stale cells that happen to still look precise.
+// Widening coverage is purely additive — see the cases in ExecAbstract.
+type AbstractState struct {
+	Reg               [abstractRegCount]AbstractCell
+	S, Z, H, PV, N, C AbstractCell
+}
+
+// regOf maps the OpCodes ExecAbstract models to the AbstractReg they read
+// or write, mirroring pkg/inst/pattern's literalByToken convention (no
+// name->value reflection over inst.OpCode, so small explicit tables are how
+// this module's other DSLs already do this).
+var regOf = map[inst.OpCode]AbstractReg{
+	inst.LD_A_N: AbsA, inst.LD_B_N: AbsB, inst.LD_C_N: AbsC, inst.LD_D_N: AbsD,
+	inst.LD_E_N: AbsE, inst.LD_H_N: AbsH, inst.LD_L_N: AbsL,
+	inst.INC_A: AbsA, inst.INC_B: AbsB, inst.INC_C: AbsC, inst.INC_D: AbsD,
+	inst.INC_E: AbsE, inst.INC_H: AbsH, inst.INC_L: AbsL,
+	inst.DEC_A: AbsA, inst.DEC_B: AbsB, inst.DEC_C: AbsC, inst.DEC_D: AbsD,
+	inst.DEC_E: AbsE, inst.DEC_H: AbsH, inst.DEC_L: AbsL,
+}
+
+// copyOf maps an LD r,r' OpCode to (dest, src) — the copies into and out of
+// A from pkg/inst/pattern's "LD_r_r" family table, duplicated here in
+// AbstractReg terms for the same no-reflection reason. Copies between two
+// non-A registers (e.g. LD B,C) are not listed and hit the default.
+var copyOf = map[inst.OpCode][2]AbstractReg{
+	inst.LD_A_B: {AbsA, AbsB}, inst.LD_A_C: {AbsA, AbsC}, inst.LD_A_D: {AbsA, AbsD},
+	inst.LD_A_E: {AbsA, AbsE}, inst.LD_A_H: {AbsA, AbsH}, inst.LD_A_L: {AbsA, AbsL},
+	inst.LD_B_A: {AbsB, AbsA}, inst.LD_C_A: {AbsC, AbsA}, inst.LD_D_A: {AbsD, AbsA},
+	inst.LD_E_A: {AbsE, AbsA}, inst.LD_H_A: {AbsH, AbsA}, inst.LD_L_A: {AbsL, AbsA},
+}
+
+// concreteByte returns cell's concrete byte value and true, or (0, false) if
+// cell isn't precisely known.
+func concreteByte(cell AbstractCell) (uint8, bool) {
+	switch cell.Kind {
+	case AbsZero:
+		return 0, true
+	case AbsConcrete:
+		return cell.Byte, true
+	default:
+		return 0, false
+	}
+}
+
+func concreteCell(v uint8) AbstractCell {
+	if v == 0 {
+		return AbstractCell{Kind: AbsZero}
+	}
+	return AbstractCell{Kind: AbsConcrete, Byte: v}
+}
+
+// invalidate collapses every cell that currently reads AbsSameAs{reg} back
+// to AbsUnknown — the copy-propagation equivalent of a use-def chain break:
+// once reg's own value changes, a cell that used to alias it no longer
+// safely does.
+func (s *AbstractState) invalidate(reg AbstractReg) {
+	for i := range s.Reg {
+		if s.Reg[i].Kind == AbsSameAs && s.Reg[i].Src == reg {
+			s.Reg[i] = AbstractCell{}
+		}
+	}
+}
+
+func (s *AbstractState) setReg(reg AbstractReg, cell AbstractCell) {
+	s.invalidate(reg)
+	s.Reg[reg] = cell
+}
+
+// flagsFromByte returns the S/Z cells a 1-byte ALU result determines
+// unconditionally — the part of flagMaskAll8BitResult ExecAbstract can
+// compute directly from the byte, without a lookup in Sz53pTable.
+func flagsFromByte(v uint8) (s, z AbstractCell) {
+	if v == 0 {
+		return AbstractCell{Kind: AbsZero}, AbstractCell{Kind: AbsOne}
+	}
+	if v&0x80 != 0 {
+		return AbstractCell{Kind: AbsOne}, AbstractCell{Kind: AbsZero}
+	}
+	return AbstractCell{Kind: AbsZero}, AbstractCell{Kind: AbsZero}
+}
+
+// ExecAbstract propagates op's effect through s in place. imm carries an
+// 8-bit immediate the same way Exec's does, for the LD r,n and ADD A,n
+// cases.
+//
+// Unhandled opcodes (everything outside the families this file's doc
+// comment names) fall through to the conservative default: *s is reset to
+// its zero value, meaning every cell reverts to AbsUnknown.
+func ExecAbstract(s *AbstractState, op inst.OpCode, imm uint16) {
+	if reg, ok := copyOf[op]; ok {
+		dest, src := reg[0], reg[1]
+		srcCell := s.Reg[src]
+		if srcCell.Kind == AbsUnknown {
+			s.setReg(dest, AbstractCell{Kind: AbsSameAs, Src: src})
+		} else {
+			s.setReg(dest, srcCell)
+		}
+		return
+	}
+
+	switch op {
+	case inst.NOP:
+		return
+
+	case inst.LD_A_N, inst.LD_B_N, inst.LD_C_N, inst.LD_D_N, inst.LD_E_N, inst.LD_H_N, inst.LD_L_N:
+		s.setReg(regOf[op], concreteCell(uint8(imm)))
+		return
+
+	case inst.XOR_A:
+		s.setReg(AbsA, AbstractCell{Kind: AbsZero})
+		s.S, s.Z, s.H, s.N, s.C = AbstractCell{Kind: AbsZero}, AbstractCell{Kind: AbsOne}, AbstractCell{Kind: AbsZero}, AbstractCell{Kind: AbsZero}, AbstractCell{Kind: AbsZero}
+		s.PV = AbstractCell{Kind: AbsOne} // parity of the zero byte is even
+		return
+
+	case inst.INC_A, inst.INC_B, inst.INC_C, inst.INC_D, inst.INC_E, inst.INC_H, inst.INC_L:
+		reg := regOf[op]
+		if v, ok := concreteByte(s.Reg[reg]); ok {
+			s.setReg(reg, concreteCell(v+1))
+			s.S, s.Z = flagsFromByte(v + 1)
+		} else {
+			s.setReg(reg, AbstractCell{})
+			s.S, s.Z = AbstractCell{}, AbstractCell{}
+		}
+		s.H, s.PV, s.N = AbstractCell{}, AbstractCell{}, AbstractCell{Kind: AbsZero}
+		return
+
+	case inst.DEC_A, inst.DEC_B, inst.DEC_C, inst.DEC_D, inst.DEC_E, inst.DEC_H, inst.DEC_L:
+		reg := regOf[op]
+		if v, ok := concreteByte(s.Reg[reg]); ok {
+			s.setReg(reg, concreteCell(v-1))
+			s.S, s.Z = flagsFromByte(v - 1)
+		} else {
+			s.setReg(reg, AbstractCell{})
+			s.S, s.Z = AbstractCell{}, AbstractCell{}
+		}
+		s.H, s.PV, s.N = AbstractCell{}, AbstractCell{}, AbstractCell{Kind: AbsOne}
+		return
+
+	case inst.ADD_A_N:
+		a, aOK := concreteByte(s.Reg[AbsA])
+		if aOK {
+			result := a + uint8(imm)
+			s.setReg(AbsA, concreteCell(result))
+			s.S, s.Z = flagsFromByte(result)
+			s.C = AbstractCell{Kind: boolKind(result < a)}
+		} else {
+			s.setReg(AbsA, AbstractCell{})
+			s.S, s.Z, s.C = AbstractCell{}, AbstractCell{}, AbstractCell{}
+		}
+		s.H, s.PV, s.N = AbstractCell{}, AbstractCell{}, AbstractCell{Kind: AbsZero}
+		return
+	}
+
+	// Conservative default: an opcode this file has no transfer function
+	// for might write any register or flag, so the only sound summary is
+	// "everything is unknown now" rather than leaving stale precise cells.
+	*s = AbstractState{}
+}
+
+// boolKind turns a computed carry-out bool into AbsOne/AbsZero.
+func boolKind(b bool) AbstractKind {
+	if b {
+		return AbsOne
+	}
+	return AbsZero
+}
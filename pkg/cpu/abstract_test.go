@@ -0,0 +1,66 @@
+package cpu
+
+import (
+	"testing"
+
+	"github.com/oisee/z80-optimizer/pkg/inst"
+)
+
+func TestExecAbstract_ConstantPropagation(t *testing.T) {
+	var s AbstractState
+	ExecAbstract(&s, inst.LD_A_N, 5)
+	if s.Reg[AbsA].Kind != AbsConcrete || s.Reg[AbsA].Byte != 5 {
+		t.Fatalf("LD A,5: Reg[A] = %+v, want AbsConcrete{5}", s.Reg[AbsA])
+	}
+
+	ExecAbstract(&s, inst.INC_A, 0)
+	if s.Reg[AbsA].Kind != AbsConcrete || s.Reg[AbsA].Byte != 6 {
+		t.Fatalf("INC A after LD A,5: Reg[A] = %+v, want AbsConcrete{6}", s.Reg[AbsA])
+	}
+	if s.Z.Kind != AbsZero {
+		t.Fatalf("INC A with nonzero result: Z = %+v, want AbsZero (flag clear)", s.Z)
+	}
+}
+
+func TestExecAbstract_XorASetsZero(t *testing.T) {
+	var s AbstractState
+	s.Reg[AbsA] = AbstractCell{Kind: AbsConcrete, Byte: 0x42}
+	ExecAbstract(&s, inst.XOR_A, 0)
+	if s.Reg[AbsA].Kind != AbsZero {
+		t.Fatalf("XOR A: Reg[A] = %+v, want AbsZero", s.Reg[AbsA])
+	}
+	if s.Z.Kind != AbsOne {
+		t.Fatalf("XOR A: Z = %+v, want AbsOne (flag set)", s.Z)
+	}
+}
+
+func TestExecAbstract_CopyPropagationAndInvalidation(t *testing.T) {
+	var s AbstractState
+	s.Reg[AbsB] = AbstractCell{Kind: AbsConcrete, Byte: 9}
+	ExecAbstract(&s, inst.LD_A_B, 0)
+	if s.Reg[AbsA].Kind != AbsConcrete || s.Reg[AbsA].Byte != 9 {
+		t.Fatalf("LD A,B with concrete B=9: Reg[A] = %+v, want AbsConcrete{9}", s.Reg[AbsA])
+	}
+
+	// Now alias by copy-propagation when the source isn't concretely known.
+	s.Reg[AbsB] = AbstractCell{}
+	ExecAbstract(&s, inst.LD_A_B, 0)
+	if s.Reg[AbsA].Kind != AbsSameAs || s.Reg[AbsA].Src != AbsB {
+		t.Fatalf("LD A,B with unknown B: Reg[A] = %+v, want AbsSameAs{B}", s.Reg[AbsA])
+	}
+
+	// Overwriting B must break the alias rather than leave a stale cell.
+	ExecAbstract(&s, inst.LD_B_N, 3)
+	if s.Reg[AbsA].Kind != AbsUnknown {
+		t.Fatalf("Reg[A] after B changed underneath its alias: %+v, want AbsUnknown", s.Reg[AbsA])
+	}
+}
+
+func TestExecAbstract_UnhandledOpcodeInvalidatesEverything(t *testing.T) {
+	var s AbstractState
+	s.Reg[AbsA] = AbstractCell{Kind: AbsConcrete, Byte: 1}
+	ExecAbstract(&s, inst.ADD_A_B, 0) // not in ExecAbstract's scoped family list
+	if s.Reg[AbsA].Kind != AbsUnknown {
+		t.Fatalf("unhandled opcode ADD A,B: Reg[A] = %+v, want AbsUnknown (conservative default)", s.Reg[AbsA])
+	}
+}
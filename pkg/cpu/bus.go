@@ -0,0 +1,104 @@
+package cpu
+
+// Bus is a full 64KB address space plus Z80 I/O ports. It exists alongside
+// State.M (the single virtual memory byte used by the default fast path)
+// for instructions that don't fit the "every access in a sequence targets
+// the same address" model. Step resolves real addressing for the Wave 5
+// HL/BC/DE-indirect forms and, as of chunk10-6, the Wave 6 (IX+d)/(IY+d)
+// forms (see step.go's memAccess). Block ops (LDI/LDIR/LDD/LDDR/CPI/CPIR/
+// CPD/CPDR), stack ops (PUSH/POP rr, EX (SP),HL), and absolute memory forms
+// (LD (nn),A / LD A,(nn) / LD (nn),rr / LD rr,(nn)) still aren't — this
+// remains the prerequisite harness for the waves that add them. The
+// superoptimizer's default equivalence checker keeps using State.M;
+// callers that need a real address space construct an AllRAM and pass it
+// explicitly.
+type Bus interface {
+	Read(addr uint16) uint8
+	Write(addr uint16, v uint8)
+	In(port uint16) uint8
+	Out(port uint16, v uint8)
+
+	// Tick advances the bus clock by n T-states, called by Step once for the
+	// M1 opcode fetch and once for the rest of the instruction, so a
+	// contention-aware Bus (e.g. a ULA that stalls on shared
+	// memory) can observe timing the lump-sum Exec never surfaces. AllRAM's
+	// implementation is a no-op.
+	Tick(n int)
+}
+
+// AllRAM is a Bus backed by a flat 64KB array, with no I/O devices attached:
+// ports are a plain 256-entry array indexed by the port's low byte, so In
+// reads back whatever Out last stored there (0 initially). Named after Clock Signal's
+// Z80AllRAM test harness: the simplest possible Bus, useful for running
+// instructions whose semantics need real addresses without modeling any
+// peripheral.
+type AllRAM struct {
+	mem   [65536]byte
+	ports [256]byte
+	dirty []uint16 // addresses written since the last Reset, in write order
+}
+
+// NewAllRAM returns an AllRAM with all memory and ports zeroed.
+func NewAllRAM() *AllRAM {
+	return &AllRAM{}
+}
+
+// Read returns the byte at addr.
+func (b *AllRAM) Read(addr uint16) uint8 {
+	return b.mem[addr]
+}
+
+// Write stores v at addr and records addr as touched.
+func (b *AllRAM) Write(addr uint16, v uint8) {
+	b.mem[addr] = v
+	b.dirty = append(b.dirty, addr)
+}
+
+// In returns the value stored for port's low byte (no device attached reads
+// back as 0xFF on real hardware's floating bus; AllRAM models a trivial port
+// array instead so tests can set expectations).
+func (b *AllRAM) In(port uint16) uint8 {
+	return b.ports[uint8(port)]
+}
+
+// Out stores v in the port array.
+func (b *AllRAM) Out(port uint16, v uint8) {
+	b.ports[uint8(port)] = v
+}
+
+// Touched returns the distinct addresses written since the last Reset, in
+// first-write order. The equivalence oracle uses this to hash a sequence's
+// memory footprint instead of a single M byte when running in bus mode.
+func (b *AllRAM) Touched() []uint16 {
+	seen := make(map[uint16]bool, len(b.dirty))
+	out := make([]uint16, 0, len(b.dirty))
+	for _, addr := range b.dirty {
+		if !seen[addr] {
+			seen[addr] = true
+			out = append(out, addr)
+		}
+	}
+	return out
+}
+
+// Footprint returns a snapshot of every touched address and its current
+// value, for use as part of an equivalence fingerprint. Unlike Touched, the
+// result is a map with no write order, so two buses that wrote the same set
+// of cells in a different order still compare equal.
+func (b *AllRAM) Footprint() map[uint16]uint8 {
+	out := make(map[uint16]uint8, len(b.dirty))
+	for _, addr := range b.dirty {
+		out[addr] = b.mem[addr]
+	}
+	return out
+}
+
+// Tick does nothing: AllRAM models no contention, so every T-state is free.
+func (b *AllRAM) Tick(n int) {}
+
+// Reset zeroes memory, ports, and the touched-address log.
+func (b *AllRAM) Reset() {
+	b.mem = [65536]byte{}
+	b.ports = [256]byte{}
+	b.dirty = nil
+}
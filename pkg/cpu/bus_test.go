@@ -0,0 +1,58 @@
+package cpu
+
+import "testing"
+
+func TestAllRAMReadWrite(t *testing.T) {
+	b := NewAllRAM()
+	b.Write(0x8000, 0x42)
+	if got := b.Read(0x8000); got != 0x42 {
+		t.Errorf("Read(0x8000) = %#x, want 0x42", got)
+	}
+	if got := b.Read(0x0000); got != 0 {
+		t.Errorf("Read of untouched address = %#x, want 0", got)
+	}
+}
+
+func TestAllRAMPorts(t *testing.T) {
+	b := NewAllRAM()
+	b.Out(0x00FE, 0x07)
+	if got := b.In(0x00FE); got != 0x07 {
+		t.Errorf("In(0xFE) = %#x, want 0x07", got)
+	}
+}
+
+func TestAllRAMTouched(t *testing.T) {
+	b := NewAllRAM()
+	b.Write(0x4000, 1)
+	b.Write(0x4001, 2)
+	b.Write(0x4000, 3) // rewritten, should not duplicate in Touched
+	touched := b.Touched()
+	if len(touched) != 2 {
+		t.Fatalf("Touched() = %v, want 2 distinct addresses", touched)
+	}
+	if touched[0] != 0x4000 || touched[1] != 0x4001 {
+		t.Errorf("Touched() = %v, want [0x4000 0x4001] in write order", touched)
+	}
+}
+
+func TestAllRAMFootprint(t *testing.T) {
+	b := NewAllRAM()
+	b.Write(0x4001, 2)
+	b.Write(0x4000, 1)
+	fp := b.Footprint()
+	if fp[0x4000] != 1 || fp[0x4001] != 2 {
+		t.Errorf("Footprint() = %v, want {0x4000:1, 0x4001:2}", fp)
+	}
+}
+
+func TestAllRAMReset(t *testing.T) {
+	b := NewAllRAM()
+	b.Write(0x1000, 0xFF)
+	b.Reset()
+	if got := b.Read(0x1000); got != 0 {
+		t.Errorf("Read after Reset = %#x, want 0", got)
+	}
+	if len(b.Touched()) != 0 {
+		t.Errorf("Touched after Reset = %v, want empty", b.Touched())
+	}
+}
@@ -0,0 +1,97 @@
+package cpu
+
+import "github.com/oisee/z80-optimizer/pkg/inst"
+
+// BusEventKind says which Bus method a BusEvent recorded.
+type BusEventKind uint8
+
+const (
+	BusRead BusEventKind = iota
+	BusWrite
+	BusIn
+	BusOut
+)
+
+func (k BusEventKind) String() string {
+	switch k {
+	case BusRead:
+		return "READ"
+	case BusWrite:
+		return "WRITE"
+	case BusIn:
+		return "IN"
+	case BusOut:
+		return "OUT"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// BusEvent records one access to a TracingBus: what kind, which address (or
+// port), and the value read or written.
+type BusEvent struct {
+	Kind  BusEventKind
+	Addr  uint16
+	Value uint8
+}
+
+// TracingBus wraps an inner Bus and records every Read/Write/In/Out in order
+// — the memory/IO equivalent of RecordingPorts, for asserting two candidate
+// sequences that touch real addresses (not just State.M) produce identical
+// ordered bus traces, not just identical final register state (chunk6-4).
+type TracingBus struct {
+	Inner Bus
+	Trace []BusEvent
+}
+
+// NewTracingBus wraps inner, recording every access made through it.
+func NewTracingBus(inner Bus) *TracingBus {
+	return &TracingBus{Inner: inner}
+}
+
+// Read reads through to Inner and records the access.
+func (b *TracingBus) Read(addr uint16) uint8 {
+	v := b.Inner.Read(addr)
+	b.Trace = append(b.Trace, BusEvent{Kind: BusRead, Addr: addr, Value: v})
+	return v
+}
+
+// Write writes through to Inner and records the access.
+func (b *TracingBus) Write(addr uint16, v uint8) {
+	b.Inner.Write(addr, v)
+	b.Trace = append(b.Trace, BusEvent{Kind: BusWrite, Addr: addr, Value: v})
+}
+
+// In reads through to Inner and records the access.
+func (b *TracingBus) In(port uint16) uint8 {
+	v := b.Inner.In(port)
+	b.Trace = append(b.Trace, BusEvent{Kind: BusIn, Addr: port, Value: v})
+	return v
+}
+
+// Out writes through to Inner and records the access.
+func (b *TracingBus) Out(port uint16, v uint8) {
+	b.Inner.Out(port, v)
+	b.Trace = append(b.Trace, BusEvent{Kind: BusOut, Addr: port, Value: v})
+}
+
+// Tick passes through to Inner untouched; contention timing isn't part of a
+// bus trace.
+func (b *TracingBus) Tick(n int) { b.Inner.Tick(n) }
+
+// Trace runs seq from initial over inner via Step, returning the resulting
+// state and the ordered BusEvent trace. Only the opcodes Step's memAccess
+// classifies — the HL/BC/DE-indirect forms and, as of chunk10-6, the
+// (IX+d)/(IY+d)-indexed forms — actually touch inner; everything else still
+// runs against State.M exactly as Step already documents, so a sequence with
+// no real-address ops simply traces empty. Block I/O and absolute-memory/
+// stack ops are still future work; widening memAccess to cover them widens
+// what Trace records for free.
+func Trace(initial State, inner Bus, seq []inst.Instruction) (State, []BusEvent) {
+	tb := NewTracingBus(inner)
+	s := initial
+	for i := range seq {
+		Step(&s, tb, seq[i].Op, seq[i].Imm, seq[i].Disp)
+	}
+	return s, tb.Trace
+}
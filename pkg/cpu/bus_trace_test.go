@@ -0,0 +1,89 @@
+package cpu
+
+import (
+	"testing"
+
+	"github.com/oisee/z80-optimizer/pkg/inst"
+)
+
+func TestTracingBusRecordsReadsAndWrites(t *testing.T) {
+	tb := NewTracingBus(NewAllRAM())
+	tb.Write(0x8000, 0x42)
+	if got := tb.Read(0x8000); got != 0x42 {
+		t.Fatalf("Read(0x8000) = %#x, want 0x42", got)
+	}
+	tb.Out(0x00FE, 0x07)
+	if got := tb.In(0x00FE); got != 0x07 {
+		t.Fatalf("In(0xFE) = %#x, want 0x07", got)
+	}
+
+	want := []BusEvent{
+		{Kind: BusWrite, Addr: 0x8000, Value: 0x42},
+		{Kind: BusRead, Addr: 0x8000, Value: 0x42},
+		{Kind: BusOut, Addr: 0x00FE, Value: 0x07},
+		{Kind: BusIn, Addr: 0x00FE, Value: 0x07},
+	}
+	if len(tb.Trace) != len(want) {
+		t.Fatalf("got %d events, want %d", len(tb.Trace), len(want))
+	}
+	for i, ev := range want {
+		if tb.Trace[i] != ev {
+			t.Errorf("event %d = %+v, want %+v", i, tb.Trace[i], ev)
+		}
+	}
+}
+
+func TestTraceLDHLIAWritesThroughBus(t *testing.T) {
+	s := State{A: 0x99, H: 0x80, L: 0x00}
+	seq := []inst.Instruction{{Op: inst.LD_HLI_A}}
+
+	_, events := Trace(s, NewAllRAM(), seq)
+	if len(events) != 1 {
+		t.Fatalf("got %d bus events, want 1", len(events))
+	}
+	want := BusEvent{Kind: BusWrite, Addr: 0x8000, Value: 0x99}
+	if events[0] != want {
+		t.Errorf("event = %+v, want %+v", events[0], want)
+	}
+}
+
+func TestTraceLDIXDReadsThroughBus(t *testing.T) {
+	bus := NewAllRAM()
+	bus.Write(0x9005, 0x77)
+	s := State{IX: 0x9000}
+	seq := []inst.Instruction{{Op: inst.LD_A_IXD, Disp: 5}}
+
+	got, events := Trace(s, bus, seq)
+	if got.A != 0x77 {
+		t.Fatalf("LD A,(IX+5): A = %#x, want 0x77", got.A)
+	}
+	want := BusEvent{Kind: BusRead, Addr: 0x9005, Value: 0x77}
+	if len(events) != 1 || events[0] != want {
+		t.Errorf("events = %+v, want [%+v]", events, want)
+	}
+}
+
+func TestTraceLDIYDNegativeDispWritesThroughBus(t *testing.T) {
+	bus := NewAllRAM()
+	s := State{A: 0x42, IY: 0x9000}
+	seq := []inst.Instruction{{Op: inst.LD_IYD_A, Disp: -1}}
+
+	_, events := Trace(s, bus, seq)
+	want := BusEvent{Kind: BusWrite, Addr: 0x8FFF, Value: 0x42}
+	if len(events) != 1 || events[0] != want {
+		t.Errorf("events = %+v, want [%+v]", events, want)
+	}
+	if got := bus.Read(0x8FFF); got != 0x42 {
+		t.Errorf("bus.Read(0x8FFF) = %#x, want 0x42", got)
+	}
+}
+
+func TestTraceNonMemoryOpcodeProducesNoEvents(t *testing.T) {
+	s := State{A: 1, B: 2}
+	seq := []inst.Instruction{{Op: inst.ADD_A_B}}
+
+	_, events := Trace(s, NewAllRAM(), seq)
+	if len(events) != 0 {
+		t.Errorf("got %d bus events for ADD A,B, want 0", len(events))
+	}
+}
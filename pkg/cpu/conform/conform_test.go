@@ -0,0 +1,107 @@
+package conform
+
+import (
+	"strings"
+	"testing"
+)
+
+// These corpora are a small hand-written smoke sample in the FUSE
+// tests.in/tests.expected text format, not the real FUSE corpus — that
+// file isn't vendored anywhere in this tree and fetching it isn't
+// something this package can do from inside a test. TestFuseVectors
+// exercises the parser and the Decode+Run path end to end against it; a
+// future wave that vendors the real corpus under testdata/ just needs to
+// point ParseVectors/ParseExpected at those files instead.
+const smokeTestsIn = `
+00
+0000 0000 0000 0000 0000 0000 0000 0000 0000 0000 ffff 0000
+00 00 0 0 1 0 4
+0000 00 -1
+
+3e42
+0000 0000 0000 0000 0000 0000 0000 0000 0000 0000 ffff 0000
+00 00 0 0 1 0 7
+0000 3e 42 -1
+`
+
+const smokeTestsExpected = `
+00
+ 0000 MR 0000 00
+
+0000 0000 0000 0000 0000 0000 0000 0000 0000 0000 ffff 0001
+00 01 0 0 1 0 4
+0000 00 -1
+
+3e42
+ 0000 MR 0000 3e
+ 0001 MR 0001 42
+
+4200 0000 0000 0000 0000 0000 0000 0000 0000 0000 ffff 0002
+00 02 0 0 1 0 7
+0000 3e 42 -1
+`
+
+func TestFuseVectors(t *testing.T) {
+	vectors, err := ParseVectors(strings.NewReader(smokeTestsIn))
+	if err != nil {
+		t.Fatalf("ParseVectors: %v", err)
+	}
+	expected, err := ParseExpected(strings.NewReader(smokeTestsExpected))
+	if err != nil {
+		t.Fatalf("ParseExpected: %v", err)
+	}
+	if len(vectors) != 2 {
+		t.Fatalf("ParseVectors: got %d vectors, want 2", len(vectors))
+	}
+
+	for _, v := range vectors {
+		exp, ok := expected[v.Name]
+		if !ok {
+			t.Errorf("%s: no expected entry", v.Name)
+			continue
+		}
+		diverged, recognized := Run(v, exp)
+		if !recognized {
+			t.Errorf("%s: Decode did not recognize the opcode at PC=%#04x", v.Name, v.Regs.PC)
+			continue
+		}
+		if diverged != "" {
+			t.Errorf("%s: diverged on %s", v.Name, diverged)
+		}
+	}
+}
+
+func TestParseVectors_RegisterLineShape(t *testing.T) {
+	vectors, err := ParseVectors(strings.NewReader(smokeTestsIn))
+	if err != nil {
+		t.Fatalf("ParseVectors: %v", err)
+	}
+	got := vectors[1]
+	if got.Regs.PC != 0 || got.Regs.SP != 0xffff || got.Regs.IM != 1 {
+		t.Errorf("parsed Regs = %+v, want PC=0 SP=0xffff IM=1", got.Regs)
+	}
+	if len(got.Mem) != 1 || got.Mem[0].Addr != 0 || len(got.Mem[0].Bytes) != 2 {
+		t.Errorf("parsed Mem = %+v, want one chunk of 2 bytes at 0", got.Mem)
+	}
+}
+
+func TestDecode_UnmodeledOpcodeNotRecognized(t *testing.T) {
+	// 0xED 0x47 is "LD I,A" on real Z80. This tree's Catalog does model
+	// other ED-prefixed opcodes (NEG, ADC/SBC HL,rr, IN/OUT, block I/O),
+	// but not this one, so Decode must report it as unrecognized rather
+	// than guessing.
+	if _, _, ok := Decode([]byte{0xED, 0x47}, 0); ok {
+		t.Error("Decode recognized an ED-prefixed opcode this tree doesn't model")
+	}
+}
+
+func TestDecode_IndexedBitOpSplicesDisplacement(t *testing.T) {
+	// BIT 0,(IX+5): DD CB 05 46
+	instr, n, ok := Decode([]byte{0xDD, 0xCB, 0x05, 0x46}, 0)
+	if !ok || n != 4 {
+		t.Fatalf("Decode(DD CB 05 46) = (_, %d, %v), want (_, 4, true)", n, ok)
+	}
+	if instr.Disp != 5 {
+		t.Errorf("Decode(DD CB 05 46).Disp = %d, want 5", instr.Disp)
+	}
+}
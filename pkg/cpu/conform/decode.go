@@ -0,0 +1,118 @@
+package conform
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/oisee/z80-optimizer/pkg/inst"
+)
+
+// pattern is one catalog OpCode's byte-matching template: fixed is the
+// full raw encoding length with -1 at every wildcard position (the
+// displacement byte and/or immediate bytes, whose value doesn't affect
+// which OpCode this is).
+type pattern struct {
+	op     inst.OpCode
+	fixed  []int
+	dispAt int // index into fixed, -1 if op has no displacement byte
+	immAt  int // index into fixed of the first immediate byte, -1 if none
+	immLen int
+}
+
+var (
+	patternsOnce sync.Once
+	patterns     []pattern
+)
+
+// buildPatterns derives every OpCode's byte template from inst.Table,
+// which already carries the Encoding/HasDisp/ImmSize shape Table's own
+// buildTable computes — this just has to know where in the raw byte stream
+// the displacement falls for the one encoding shape where it isn't simply
+// "after the fixed bytes": the DD/FD CB d xx indexed bit-ops, whose
+// Catalog.Bytes is {prefix, 0xCB, finalOp} with the displacement omitted
+// from the middle (see catalog.go's bitIxd/resIxd/setIxd construction).
+func buildPatterns() {
+	for _, op := range inst.AllOps() {
+		d := inst.Table[op]
+		enc := d.Encoding
+		if len(enc) == 0 {
+			// No real Z80 opcode has a zero-byte encoding — an empty
+			// Encoding here means this Catalog entry's metadata was never
+			// populated (as the LD_A_HLI..SET_7_HLI family's was before
+			// inst's initMemCatalog filled it in). Treating
+			// it as "no fixed bytes, immediate follows" produced an
+			// all-wildcard pattern that matched any byte sequence of that
+			// length, so Decode misrecognized arbitrary bytes as this op.
+			// Skip it instead of emitting a phantom pattern.
+			continue
+		}
+		dispAt, immAt := -1, -1
+
+		indexedBitOp := len(enc) == 3 && (enc[0] == 0xDD || enc[0] == 0xFD) && enc[1] == 0xCB
+		var fixed []int
+		if indexedBitOp && d.HasDisp {
+			fixed = []int{int(enc[0]), int(enc[1]), -1, int(enc[2])}
+			dispAt = 2
+		} else {
+			fixed = make([]int, len(enc))
+			for i, b := range enc {
+				fixed[i] = int(b)
+			}
+			if d.HasDisp {
+				dispAt = len(fixed)
+				fixed = append(fixed, -1)
+			}
+		}
+		if d.ImmSize > 0 {
+			immAt = len(fixed)
+			for i := 0; i < int(d.ImmSize); i++ {
+				fixed = append(fixed, -1)
+			}
+		}
+		patterns = append(patterns, pattern{op: op, fixed: fixed, dispAt: dispAt, immAt: immAt, immLen: int(d.ImmSize)})
+	}
+	// Longest pattern first: every real Z80 encoding is self-delimiting
+	// (a multi-byte prefix like CB/ED/DD/FD never collides with a
+	// single-byte opcode's value), so ties shouldn't occur in practice,
+	// but preferring the longest fixed-byte match first is the safe
+	// order if a future wave's Catalog entries ever did overlap.
+	sort.SliceStable(patterns, func(i, j int) bool { return len(patterns[i].fixed) > len(patterns[j].fixed) })
+}
+
+// Decode reads the instruction encoded at mem[pc:], returning the decoded
+// Instruction (with Disp/Imm populated straight from the raw bytes — see
+// conform.go's resolveTarget for the branch-target fixup Exec needs on
+// top) and how many bytes it consumed. ok is false if no catalog OpCode's
+// template matches, which includes every instruction this tree's Catalog
+// doesn't model (ED-prefixed ops outside its NEG, ADC/SBC HL,rr and I/O
+// entries, such as LD I,A; PUSH/POP, block LDI/LDIR/CPIR, EX
+// (SP),HL — see chunk9-6's fuse.go doc comment for the same gap).
+func Decode(mem []byte, pc int) (inst.Instruction, int, bool) {
+	patternsOnce.Do(buildPatterns)
+	for _, p := range patterns {
+		if pc+len(p.fixed) > len(mem) || !p.matches(mem[pc:]) {
+			continue
+		}
+		instr := inst.Instruction{Op: p.op}
+		if p.dispAt >= 0 {
+			instr.Disp = int8(mem[pc+p.dispAt])
+		}
+		switch p.immLen {
+		case 1:
+			instr.Imm = uint16(mem[pc+p.immAt])
+		case 2:
+			instr.Imm = uint16(mem[pc+p.immAt]) | uint16(mem[pc+p.immAt+1])<<8
+		}
+		return instr, len(p.fixed), true
+	}
+	return inst.Instruction{}, 0, false
+}
+
+func (p pattern) matches(mem []byte) bool {
+	for i, want := range p.fixed {
+		if want >= 0 && int(mem[i]) != want {
+			return false
+		}
+	}
+	return true
+}
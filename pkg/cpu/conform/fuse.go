@@ -0,0 +1,243 @@
+package conform
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Registers is the Z80 register snapshot a FUSE vector specifies or
+// expects back. AFp/BCp/DEp/HLp/I/R are parsed (tests.in and
+// tests.expected both carry them) but never compared by Run — see
+// conform.go's package doc comment for why this tree's cpu.State has
+// nowhere to put an alternate register set or I, and why R is not seeded.
+type Registers struct {
+	AF, BC, DE, HL     uint16
+	AFp, BCp, DEp, HLp uint16
+	IX, IY, SP, PC     uint16
+	I, R               uint8
+	IFF1, IFF2         bool
+	IM                 uint8
+	Halted             bool
+	TStates            int
+}
+
+// MemChunk is one contiguous run of bytes loaded at Addr — a FUSE vector's
+// memory section lists one or more of these, each terminated by a lone
+// "-1" token.
+type MemChunk struct {
+	Addr  uint16
+	Bytes []byte
+}
+
+// Vector is one parsed tests.in entry.
+type Vector struct {
+	Name string
+	Regs Registers
+	Mem  []MemChunk
+}
+
+// Expected is one parsed tests.expected entry — the final state Run should
+// see after executing the Vector with the same Name.
+type Expected struct {
+	Name string
+	Regs Registers
+	Mem  []MemChunk
+}
+
+// ParseVectors parses a tests.in-format stream into its named entries: a
+// name line, a register line (AF BC DE HL AF' BC' DE' HL' IX IY SP PC,
+// hex, no "0x"), a state line (I R IFF1 IFF2 IM <halted> tstates), then
+// zero or more memory lines ("addr byte byte ... -1"), with entries
+// separated by a blank line.
+func ParseVectors(r io.Reader) ([]Vector, error) {
+	sc := bufio.NewScanner(r)
+	var vectors []Vector
+	for {
+		name, ok, err := nextName(sc)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return vectors, nil
+		}
+		regs, err := parseEntryRegisters(sc, name)
+		if err != nil {
+			return nil, err
+		}
+		mem, err := parseMemChunks(sc)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		vectors = append(vectors, Vector{Name: name, Regs: regs, Mem: mem})
+	}
+}
+
+// ParseExpected parses a tests.expected-format stream. FUSE's
+// tests.expected additionally lists T-state-by-T-state bus activity
+// before the register line (e.g. "PC MR addr value"); those lines are
+// skipped rather than modeled, since Exec (unlike Step) doesn't surface
+// per-cycle bus traffic for every opcode (see step.go).
+func ParseExpected(r io.Reader) (map[string]Expected, error) {
+	sc := bufio.NewScanner(r)
+	out := make(map[string]Expected)
+	for {
+		name, ok, err := nextName(sc)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return out, nil
+		}
+		if err := skipBusActivity(sc); err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		regs, err := parseEntryRegisters(sc, name)
+		if err != nil {
+			return nil, err
+		}
+		mem, err := parseMemChunks(sc)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		out[name] = Expected{Name: name, Regs: regs, Mem: mem}
+	}
+}
+
+// nextName skips blank lines and returns the next non-blank line as an
+// entry's name, or ok=false at EOF.
+func nextName(sc *bufio.Scanner) (string, bool, error) {
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" {
+			continue
+		}
+		return line, true, nil
+	}
+	return "", false, sc.Err()
+}
+
+// skipBusActivity consumes tests.expected's per-cycle event lines, which
+// run until the blank line separating them from the final register line.
+func skipBusActivity(sc *bufio.Scanner) error {
+	for sc.Scan() {
+		if strings.TrimSpace(sc.Text()) == "" {
+			return nil
+		}
+	}
+	return sc.Err()
+}
+
+func parseEntryRegisters(sc *bufio.Scanner, name string) (Registers, error) {
+	if !sc.Scan() {
+		return Registers{}, fmt.Errorf("%s: missing register line", name)
+	}
+	regs, err := parseRegisterLine(sc.Text())
+	if err != nil {
+		return Registers{}, fmt.Errorf("%s: %w", name, err)
+	}
+	if !sc.Scan() {
+		return Registers{}, fmt.Errorf("%s: missing state line", name)
+	}
+	if err := parseStateLine(sc.Text(), &regs); err != nil {
+		return Registers{}, fmt.Errorf("%s: %w", name, err)
+	}
+	return regs, nil
+}
+
+// parseRegisterLine parses "af bc de hl af' bc' de' hl' ix iy sp pc".
+func parseRegisterLine(line string) (Registers, error) {
+	fields := strings.Fields(line)
+	if len(fields) < 12 {
+		return Registers{}, fmt.Errorf("register line %q: want 12 fields, got %d", line, len(fields))
+	}
+	vals := make([]uint16, 12)
+	for i, f := range fields[:12] {
+		v, err := strconv.ParseUint(f, 16, 16)
+		if err != nil {
+			return Registers{}, fmt.Errorf("register line %q: field %d: %w", line, i, err)
+		}
+		vals[i] = uint16(v)
+	}
+	return Registers{
+		AF: vals[0], BC: vals[1], DE: vals[2], HL: vals[3],
+		AFp: vals[4], BCp: vals[5], DEp: vals[6], HLp: vals[7],
+		IX: vals[8], IY: vals[9], SP: vals[10], PC: vals[11],
+	}, nil
+}
+
+// parseStateLine parses "i r iff1 iff2 im <halted> tstates" into the
+// fields parseRegisterLine's call left zero, in place.
+func parseStateLine(line string, regs *Registers) error {
+	fields := strings.Fields(line)
+	if len(fields) < 7 {
+		return fmt.Errorf("state line %q: want 7 fields, got %d", line, len(fields))
+	}
+	i, err := strconv.ParseUint(fields[0], 16, 8)
+	if err != nil {
+		return fmt.Errorf("state line %q: I: %w", line, err)
+	}
+	r, err := strconv.ParseUint(fields[1], 16, 8)
+	if err != nil {
+		return fmt.Errorf("state line %q: R: %w", line, err)
+	}
+	im, err := strconv.ParseUint(fields[4], 10, 8)
+	if err != nil {
+		return fmt.Errorf("state line %q: IM: %w", line, err)
+	}
+	tstates, err := strconv.Atoi(fields[6])
+	if err != nil {
+		return fmt.Errorf("state line %q: tstates: %w", line, err)
+	}
+	regs.I = uint8(i)
+	regs.R = uint8(r)
+	regs.IFF1 = fields[2] == "1"
+	regs.IFF2 = fields[3] == "1"
+	regs.IM = uint8(im)
+	regs.Halted = fields[5] == "1"
+	regs.TStates = tstates
+	return nil
+}
+
+// parseMemChunks reads "addr byte byte ... -1" lines until the blank line
+// (or EOF) that ends the entry.
+func parseMemChunks(sc *bufio.Scanner) ([]MemChunk, error) {
+	var chunks []MemChunk
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" {
+			return chunks, nil
+		}
+		chunk, err := parseMemLine(line)
+		if err != nil {
+			return nil, err
+		}
+		chunks = append(chunks, chunk)
+	}
+	return chunks, sc.Err()
+}
+
+func parseMemLine(line string) (MemChunk, error) {
+	fields := strings.Fields(line)
+	if len(fields) < 2 {
+		return MemChunk{}, fmt.Errorf("memory line %q: want address plus bytes", line)
+	}
+	addr, err := strconv.ParseUint(fields[0], 16, 16)
+	if err != nil {
+		return MemChunk{}, fmt.Errorf("memory line %q: address: %w", line, err)
+	}
+	var bytes []byte
+	for _, f := range fields[1:] {
+		if f == "-1" {
+			break
+		}
+		b, err := strconv.ParseUint(f, 16, 8)
+		if err != nil {
+			return MemChunk{}, fmt.Errorf("memory line %q: byte %q: %w", line, f, err)
+		}
+		bytes = append(bytes, byte(b))
+	}
+	return MemChunk{Addr: uint16(addr), Bytes: bytes}, nil
+}
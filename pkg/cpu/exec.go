@@ -5,7 +5,29 @@ import "github.com/oisee/z80-optimizer/pkg/inst"
 // Exec executes a single instruction on the given state.
 // Returns the T-state cost. The state is modified in place.
 // imm is uint16 to support 16-bit immediates (LD rr,nn); 8-bit ops use low byte.
+// Wave 7: imm also carries the resolved branch target for opcodes where
+// inst.HasBranchTarget(op) is true — PC defaults to advancing by the
+// opcode's ByteSize and a taken branch overrides it.
+//
+// chunk10-4: inst.TStates(op) is a single static cost per opcode —
+// inst.Catalog deliberately picks the "taken"/loop-continues figure for
+// the conditional-branch and block-I/O-repeat opcodes (see the Wave 8 doc
+// comment above INIR's entry in catalog.go), since the static analyzer
+// that's its main caller has no runtime state to know which way a branch
+// actually goes. Exec does have that state, so it starts from
+// inst.TStates(op) and corrects tStates down to the cheaper figure on the
+// not-taken/loop-exits path for JR cc, DJNZ, CALL cc, RET cc, and
+// INIR/INDR/OTIR/OTDR — the only opcodes in this catalog whose real T-state
+// cost depends on a runtime condition.
+//
+// chunk11-4: Exec also adds its return value to s.TStates, so a caller
+// stepping a sequence one instruction at a time can read the running total
+// off State directly. See inst.Cost for the static (op, operand) estimate a
+// search pass uses before anything has actually run.
 func Exec(s *State, op inst.OpCode, imm uint16) int {
+	nextPC := s.PC + uint16(inst.ByteSize(op))
+	tStates := inst.TStates(op)
+	s.R = s.R&0x80 | (s.R+uint8(inst.RefreshCycles(op)))&0x7F
 	switch op {
 	// === 8-bit register loads ===
 	case inst.LD_A_B:
@@ -470,117 +492,117 @@ func Exec(s *State, op inst.OpCode, imm uint16) int {
 
 	// === Wave 1: BIT n, r — test bit, set flags only ===
 	case inst.BIT_0_A:
-		execBit(s, s.A, 0)
+		execBit(s, s.A, 0, false)
 	case inst.BIT_0_B:
-		execBit(s, s.B, 0)
+		execBit(s, s.B, 0, false)
 	case inst.BIT_0_C:
-		execBit(s, s.C, 0)
+		execBit(s, s.C, 0, false)
 	case inst.BIT_0_D:
-		execBit(s, s.D, 0)
+		execBit(s, s.D, 0, false)
 	case inst.BIT_0_E:
-		execBit(s, s.E, 0)
+		execBit(s, s.E, 0, false)
 	case inst.BIT_0_H:
-		execBit(s, s.H, 0)
+		execBit(s, s.H, 0, false)
 	case inst.BIT_0_L:
-		execBit(s, s.L, 0)
+		execBit(s, s.L, 0, false)
 	case inst.BIT_1_A:
-		execBit(s, s.A, 1)
+		execBit(s, s.A, 1, false)
 	case inst.BIT_1_B:
-		execBit(s, s.B, 1)
+		execBit(s, s.B, 1, false)
 	case inst.BIT_1_C:
-		execBit(s, s.C, 1)
+		execBit(s, s.C, 1, false)
 	case inst.BIT_1_D:
-		execBit(s, s.D, 1)
+		execBit(s, s.D, 1, false)
 	case inst.BIT_1_E:
-		execBit(s, s.E, 1)
+		execBit(s, s.E, 1, false)
 	case inst.BIT_1_H:
-		execBit(s, s.H, 1)
+		execBit(s, s.H, 1, false)
 	case inst.BIT_1_L:
-		execBit(s, s.L, 1)
+		execBit(s, s.L, 1, false)
 	case inst.BIT_2_A:
-		execBit(s, s.A, 2)
+		execBit(s, s.A, 2, false)
 	case inst.BIT_2_B:
-		execBit(s, s.B, 2)
+		execBit(s, s.B, 2, false)
 	case inst.BIT_2_C:
-		execBit(s, s.C, 2)
+		execBit(s, s.C, 2, false)
 	case inst.BIT_2_D:
-		execBit(s, s.D, 2)
+		execBit(s, s.D, 2, false)
 	case inst.BIT_2_E:
-		execBit(s, s.E, 2)
+		execBit(s, s.E, 2, false)
 	case inst.BIT_2_H:
-		execBit(s, s.H, 2)
+		execBit(s, s.H, 2, false)
 	case inst.BIT_2_L:
-		execBit(s, s.L, 2)
+		execBit(s, s.L, 2, false)
 	case inst.BIT_3_A:
-		execBit(s, s.A, 3)
+		execBit(s, s.A, 3, false)
 	case inst.BIT_3_B:
-		execBit(s, s.B, 3)
+		execBit(s, s.B, 3, false)
 	case inst.BIT_3_C:
-		execBit(s, s.C, 3)
+		execBit(s, s.C, 3, false)
 	case inst.BIT_3_D:
-		execBit(s, s.D, 3)
+		execBit(s, s.D, 3, false)
 	case inst.BIT_3_E:
-		execBit(s, s.E, 3)
+		execBit(s, s.E, 3, false)
 	case inst.BIT_3_H:
-		execBit(s, s.H, 3)
+		execBit(s, s.H, 3, false)
 	case inst.BIT_3_L:
-		execBit(s, s.L, 3)
+		execBit(s, s.L, 3, false)
 	case inst.BIT_4_A:
-		execBit(s, s.A, 4)
+		execBit(s, s.A, 4, false)
 	case inst.BIT_4_B:
-		execBit(s, s.B, 4)
+		execBit(s, s.B, 4, false)
 	case inst.BIT_4_C:
-		execBit(s, s.C, 4)
+		execBit(s, s.C, 4, false)
 	case inst.BIT_4_D:
-		execBit(s, s.D, 4)
+		execBit(s, s.D, 4, false)
 	case inst.BIT_4_E:
-		execBit(s, s.E, 4)
+		execBit(s, s.E, 4, false)
 	case inst.BIT_4_H:
-		execBit(s, s.H, 4)
+		execBit(s, s.H, 4, false)
 	case inst.BIT_4_L:
-		execBit(s, s.L, 4)
+		execBit(s, s.L, 4, false)
 	case inst.BIT_5_A:
-		execBit(s, s.A, 5)
+		execBit(s, s.A, 5, false)
 	case inst.BIT_5_B:
-		execBit(s, s.B, 5)
+		execBit(s, s.B, 5, false)
 	case inst.BIT_5_C:
-		execBit(s, s.C, 5)
+		execBit(s, s.C, 5, false)
 	case inst.BIT_5_D:
-		execBit(s, s.D, 5)
+		execBit(s, s.D, 5, false)
 	case inst.BIT_5_E:
-		execBit(s, s.E, 5)
+		execBit(s, s.E, 5, false)
 	case inst.BIT_5_H:
-		execBit(s, s.H, 5)
+		execBit(s, s.H, 5, false)
 	case inst.BIT_5_L:
-		execBit(s, s.L, 5)
+		execBit(s, s.L, 5, false)
 	case inst.BIT_6_A:
-		execBit(s, s.A, 6)
+		execBit(s, s.A, 6, false)
 	case inst.BIT_6_B:
-		execBit(s, s.B, 6)
+		execBit(s, s.B, 6, false)
 	case inst.BIT_6_C:
-		execBit(s, s.C, 6)
+		execBit(s, s.C, 6, false)
 	case inst.BIT_6_D:
-		execBit(s, s.D, 6)
+		execBit(s, s.D, 6, false)
 	case inst.BIT_6_E:
-		execBit(s, s.E, 6)
+		execBit(s, s.E, 6, false)
 	case inst.BIT_6_H:
-		execBit(s, s.H, 6)
+		execBit(s, s.H, 6, false)
 	case inst.BIT_6_L:
-		execBit(s, s.L, 6)
+		execBit(s, s.L, 6, false)
 	case inst.BIT_7_A:
-		execBit(s, s.A, 7)
+		execBit(s, s.A, 7, false)
 	case inst.BIT_7_B:
-		execBit(s, s.B, 7)
+		execBit(s, s.B, 7, false)
 	case inst.BIT_7_C:
-		execBit(s, s.C, 7)
+		execBit(s, s.C, 7, false)
 	case inst.BIT_7_D:
-		execBit(s, s.D, 7)
+		execBit(s, s.D, 7, false)
 	case inst.BIT_7_E:
-		execBit(s, s.E, 7)
+		execBit(s, s.E, 7, false)
 	case inst.BIT_7_H:
-		execBit(s, s.H, 7)
+		execBit(s, s.H, 7, false)
 	case inst.BIT_7_L:
-		execBit(s, s.L, 7)
+		execBit(s, s.L, 7, false)
 
 	// === Wave 1: RES n, r — clear bit, no flag changes ===
 	case inst.RES_0_A:
@@ -921,14 +943,20 @@ func Exec(s *State, op inst.OpCode, imm uint16) int {
 
 	// LD A, (BC)/(DE): A = M (same address assumption)
 	case inst.LD_A_BCI:
+		s.WZ = (uint16(s.B)<<8 | uint16(s.C)) + 1 // chunk3-3: MEMPTR = BC+1
 		s.A = s.M
 	case inst.LD_A_DEI:
+		s.WZ = (uint16(s.D)<<8 | uint16(s.E)) + 1 // chunk3-3: MEMPTR = DE+1
 		s.A = s.M
 
 	// LD (BC), A / LD (DE), A: M = A
 	case inst.LD_BCI_A:
+		// chunk3-3: MEMPTR's low byte is BC+1, high byte is A (a real hardware
+		// quirk — the chip reuses A for the high half instead of carrying it).
+		s.WZ = uint16(s.A)<<8 | (uint16(s.C)+1)&0xFF
 		s.M = s.A
 	case inst.LD_DEI_A:
+		s.WZ = uint16(s.A)<<8 | (uint16(s.E)+1)&0xFF
 		s.M = s.A
 
 	// ALU A, (HL)
@@ -973,23 +1001,33 @@ func Exec(s *State, op inst.OpCode, imm uint16) int {
 	case inst.SLL_HLI:
 		s.M = execSll(s, s.M)
 
-	// BIT n, (HL)
+	// BIT n, (HL): reading (HL) sets WZ=HL on real hardware, and the
+	// undocumented flag 3/5 bits come from WZ's high byte (chunk3-3) rather
+	// than the fetched byte itself.
 	case inst.BIT_0_HLI:
-		execBit(s, s.M, 0)
+		s.WZ = uint16(s.H)<<8 | uint16(s.L)
+		execBit(s, s.M, 0, true)
 	case inst.BIT_1_HLI:
-		execBit(s, s.M, 1)
+		s.WZ = uint16(s.H)<<8 | uint16(s.L)
+		execBit(s, s.M, 1, true)
 	case inst.BIT_2_HLI:
-		execBit(s, s.M, 2)
+		s.WZ = uint16(s.H)<<8 | uint16(s.L)
+		execBit(s, s.M, 2, true)
 	case inst.BIT_3_HLI:
-		execBit(s, s.M, 3)
+		s.WZ = uint16(s.H)<<8 | uint16(s.L)
+		execBit(s, s.M, 3, true)
 	case inst.BIT_4_HLI:
-		execBit(s, s.M, 4)
+		s.WZ = uint16(s.H)<<8 | uint16(s.L)
+		execBit(s, s.M, 4, true)
 	case inst.BIT_5_HLI:
-		execBit(s, s.M, 5)
+		s.WZ = uint16(s.H)<<8 | uint16(s.L)
+		execBit(s, s.M, 5, true)
 	case inst.BIT_6_HLI:
-		execBit(s, s.M, 6)
+		s.WZ = uint16(s.H)<<8 | uint16(s.L)
+		execBit(s, s.M, 6, true)
 	case inst.BIT_7_HLI:
-		execBit(s, s.M, 7)
+		s.WZ = uint16(s.H)<<8 | uint16(s.L)
+		execBit(s, s.M, 7, true)
 
 	// RES n, (HL)
 	case inst.RES_0_HLI:
@@ -1027,10 +1065,553 @@ func Exec(s *State, op inst.OpCode, imm uint16) int {
 	case inst.SET_7_HLI:
 		s.M |= 1 << 7
 
+	// === Wave 6: IX/IY indexed addressing ===
+	// (IX+d)/(IY+d) memory ops share State.M, same model as Wave 5's (HL) ops.
+	case inst.LD_IX_NN:
+		s.IX = imm
+	case inst.LD_IY_NN:
+		s.IY = imm
+	case inst.ADD_IX_BC:
+		s.IX = execAddIdx(s, s.IX, uint16(s.B)<<8|uint16(s.C))
+	case inst.ADD_IX_DE:
+		s.IX = execAddIdx(s, s.IX, uint16(s.D)<<8|uint16(s.E))
+	case inst.ADD_IX_IX:
+		s.IX = execAddIdx(s, s.IX, s.IX)
+	case inst.ADD_IX_SP:
+		s.IX = execAddIdx(s, s.IX, s.SP)
+	case inst.ADD_IY_BC:
+		s.IY = execAddIdx(s, s.IY, uint16(s.B)<<8|uint16(s.C))
+	case inst.ADD_IY_DE:
+		s.IY = execAddIdx(s, s.IY, uint16(s.D)<<8|uint16(s.E))
+	case inst.ADD_IY_IY:
+		s.IY = execAddIdx(s, s.IY, s.IY)
+	case inst.ADD_IY_SP:
+		s.IY = execAddIdx(s, s.IY, s.SP)
+
+	case inst.LD_A_IXD, inst.LD_A_IYD:
+		s.A = s.M
+	case inst.LD_B_IXD, inst.LD_B_IYD:
+		s.B = s.M
+	case inst.LD_C_IXD, inst.LD_C_IYD:
+		s.C = s.M
+	case inst.LD_D_IXD, inst.LD_D_IYD:
+		s.D = s.M
+	case inst.LD_E_IXD, inst.LD_E_IYD:
+		s.E = s.M
+	case inst.LD_H_IXD, inst.LD_H_IYD:
+		s.H = s.M
+	case inst.LD_L_IXD, inst.LD_L_IYD:
+		s.L = s.M
+
+	case inst.LD_IXD_A, inst.LD_IYD_A:
+		s.M = s.A
+	case inst.LD_IXD_B, inst.LD_IYD_B:
+		s.M = s.B
+	case inst.LD_IXD_C, inst.LD_IYD_C:
+		s.M = s.C
+	case inst.LD_IXD_D, inst.LD_IYD_D:
+		s.M = s.D
+	case inst.LD_IXD_E, inst.LD_IYD_E:
+		s.M = s.E
+	case inst.LD_IXD_H, inst.LD_IYD_H:
+		s.M = s.H
+	case inst.LD_IXD_L, inst.LD_IYD_L:
+		s.M = s.L
+	case inst.LD_IXD_N, inst.LD_IYD_N:
+		s.M = uint8(imm)
+
+	case inst.ADD_A_IXD, inst.ADD_A_IYD:
+		execAdd(s, s.M)
+	case inst.ADC_A_IXD, inst.ADC_A_IYD:
+		execAdc(s, s.M)
+	case inst.SUB_IXD, inst.SUB_IYD:
+		execSub(s, s.M)
+	case inst.SBC_A_IXD, inst.SBC_A_IYD:
+		execSbc(s, s.M)
+	case inst.AND_IXD, inst.AND_IYD:
+		execAnd(s, s.M)
+	case inst.XOR_IXD, inst.XOR_IYD:
+		execXor(s, s.M)
+	case inst.OR_IXD, inst.OR_IYD:
+		execOr(s, s.M)
+	case inst.CP_IXD, inst.CP_IYD:
+		execCp(s, s.M)
+
+	case inst.INC_IXD, inst.INC_IYD:
+		execInc(s, &s.M)
+	case inst.DEC_IXD, inst.DEC_IYD:
+		execDec(s, &s.M)
+
+	case inst.RLC_IXD, inst.RLC_IYD:
+		s.M = execRlc(s, s.M)
+	case inst.RRC_IXD, inst.RRC_IYD:
+		s.M = execRrc(s, s.M)
+	case inst.RL_IXD, inst.RL_IYD:
+		s.M = execRl(s, s.M)
+	case inst.RR_IXD, inst.RR_IYD:
+		s.M = execRr(s, s.M)
+	case inst.SLA_IXD, inst.SLA_IYD:
+		s.M = execSla(s, s.M)
+	case inst.SRA_IXD, inst.SRA_IYD:
+		s.M = execSra(s, s.M)
+	case inst.SRL_IXD, inst.SRL_IYD:
+		s.M = execSrl(s, s.M)
+	case inst.SLL_IXD, inst.SLL_IYD:
+		s.M = execSll(s, s.M)
+
+	// chunk3-3: BIT n,(IX+d)/(IY+d) source their undocumented flag 3/5 bits
+	// from WZ's high byte like BIT n,(HL) does, approximated here as the
+	// index register's own high byte (s.M is the only displaced value Exec
+	// has; it doesn't carry Instruction.Disp to recompute IX+d exactly, so
+	// this misses the rare case where d carries the low byte into a
+	// different page).
+	case inst.BIT_0_IXD:
+		s.WZ = s.IX
+		execBit(s, s.M, 0, true)
+	case inst.BIT_0_IYD:
+		s.WZ = s.IY
+		execBit(s, s.M, 0, true)
+	case inst.BIT_1_IXD:
+		s.WZ = s.IX
+		execBit(s, s.M, 1, true)
+	case inst.BIT_1_IYD:
+		s.WZ = s.IY
+		execBit(s, s.M, 1, true)
+	case inst.BIT_2_IXD:
+		s.WZ = s.IX
+		execBit(s, s.M, 2, true)
+	case inst.BIT_2_IYD:
+		s.WZ = s.IY
+		execBit(s, s.M, 2, true)
+	case inst.BIT_3_IXD:
+		s.WZ = s.IX
+		execBit(s, s.M, 3, true)
+	case inst.BIT_3_IYD:
+		s.WZ = s.IY
+		execBit(s, s.M, 3, true)
+	case inst.BIT_4_IXD:
+		s.WZ = s.IX
+		execBit(s, s.M, 4, true)
+	case inst.BIT_4_IYD:
+		s.WZ = s.IY
+		execBit(s, s.M, 4, true)
+	case inst.BIT_5_IXD:
+		s.WZ = s.IX
+		execBit(s, s.M, 5, true)
+	case inst.BIT_5_IYD:
+		s.WZ = s.IY
+		execBit(s, s.M, 5, true)
+	case inst.BIT_6_IXD:
+		s.WZ = s.IX
+		execBit(s, s.M, 6, true)
+	case inst.BIT_6_IYD:
+		s.WZ = s.IY
+		execBit(s, s.M, 6, true)
+	case inst.BIT_7_IXD:
+		s.WZ = s.IX
+		execBit(s, s.M, 7, true)
+	case inst.BIT_7_IYD:
+		s.WZ = s.IY
+		execBit(s, s.M, 7, true)
+
+	case inst.RES_0_IXD, inst.RES_0_IYD:
+		s.M &^= 1 << 0
+	case inst.RES_1_IXD, inst.RES_1_IYD:
+		s.M &^= 1 << 1
+	case inst.RES_2_IXD, inst.RES_2_IYD:
+		s.M &^= 1 << 2
+	case inst.RES_3_IXD, inst.RES_3_IYD:
+		s.M &^= 1 << 3
+	case inst.RES_4_IXD, inst.RES_4_IYD:
+		s.M &^= 1 << 4
+	case inst.RES_5_IXD, inst.RES_5_IYD:
+		s.M &^= 1 << 5
+	case inst.RES_6_IXD, inst.RES_6_IYD:
+		s.M &^= 1 << 6
+	case inst.RES_7_IXD, inst.RES_7_IYD:
+		s.M &^= 1 << 7
+
+	case inst.SET_0_IXD, inst.SET_0_IYD:
+		s.M |= 1 << 0
+	case inst.SET_1_IXD, inst.SET_1_IYD:
+		s.M |= 1 << 1
+	case inst.SET_2_IXD, inst.SET_2_IYD:
+		s.M |= 1 << 2
+	case inst.SET_3_IXD, inst.SET_3_IYD:
+		s.M |= 1 << 3
+	case inst.SET_4_IXD, inst.SET_4_IYD:
+		s.M |= 1 << 4
+	case inst.SET_5_IXD, inst.SET_5_IYD:
+		s.M |= 1 << 5
+	case inst.SET_6_IXD, inst.SET_6_IYD:
+		s.M |= 1 << 6
+	case inst.SET_7_IXD, inst.SET_7_IYD:
+		s.M |= 1 << 7
+
+	// Undocumented IXH/IXL/IYH/IYL half-register ops
+	case inst.LD_A_IXH:
+		s.A = s.IXH()
+	case inst.LD_A_IXL:
+		s.A = s.IXL()
+	case inst.LD_IXH_A:
+		s.IX = uint16(s.A)<<8 | uint16(s.IXL())
+	case inst.LD_IXL_A:
+		s.IX = uint16(s.IXH())<<8 | uint16(s.A)
+	case inst.INC_IXH:
+		s.IX = execIncHalf(s, s.IX, true)
+	case inst.INC_IXL:
+		s.IX = execIncHalf(s, s.IX, false)
+	case inst.DEC_IXH:
+		s.IX = execDecHalf(s, s.IX, true)
+	case inst.DEC_IXL:
+		s.IX = execDecHalf(s, s.IX, false)
+	case inst.ADD_A_IXH:
+		execAdd(s, s.IXH())
+	case inst.ADD_A_IXL:
+		execAdd(s, s.IXL())
+	case inst.ADC_A_IXH:
+		execAdc(s, s.IXH())
+	case inst.ADC_A_IXL:
+		execAdc(s, s.IXL())
+	case inst.SUB_IXH:
+		execSub(s, s.IXH())
+	case inst.SUB_IXL:
+		execSub(s, s.IXL())
+	case inst.SBC_A_IXH:
+		execSbc(s, s.IXH())
+	case inst.SBC_A_IXL:
+		execSbc(s, s.IXL())
+	case inst.AND_IXH:
+		execAnd(s, s.IXH())
+	case inst.AND_IXL:
+		execAnd(s, s.IXL())
+	case inst.XOR_IXH:
+		execXor(s, s.IXH())
+	case inst.XOR_IXL:
+		execXor(s, s.IXL())
+	case inst.OR_IXH:
+		execOr(s, s.IXH())
+	case inst.OR_IXL:
+		execOr(s, s.IXL())
+	case inst.CP_IXH:
+		execCp(s, s.IXH())
+	case inst.CP_IXL:
+		execCp(s, s.IXL())
+	case inst.LD_A_IYH:
+		s.A = s.IYH()
+	case inst.LD_A_IYL:
+		s.A = s.IYL()
+	case inst.LD_IYH_A:
+		s.IY = uint16(s.A)<<8 | uint16(s.IYL())
+	case inst.LD_IYL_A:
+		s.IY = uint16(s.IYH())<<8 | uint16(s.A)
+	case inst.INC_IYH:
+		s.IY = execIncHalf(s, s.IY, true)
+	case inst.INC_IYL:
+		s.IY = execIncHalf(s, s.IY, false)
+	case inst.DEC_IYH:
+		s.IY = execDecHalf(s, s.IY, true)
+	case inst.DEC_IYL:
+		s.IY = execDecHalf(s, s.IY, false)
+	case inst.ADD_A_IYH:
+		execAdd(s, s.IYH())
+	case inst.ADD_A_IYL:
+		execAdd(s, s.IYL())
+	case inst.ADC_A_IYH:
+		execAdc(s, s.IYH())
+	case inst.ADC_A_IYL:
+		execAdc(s, s.IYL())
+	case inst.SUB_IYH:
+		execSub(s, s.IYH())
+	case inst.SUB_IYL:
+		execSub(s, s.IYL())
+	case inst.SBC_A_IYH:
+		execSbc(s, s.IYH())
+	case inst.SBC_A_IYL:
+		execSbc(s, s.IYL())
+	case inst.AND_IYH:
+		execAnd(s, s.IYH())
+	case inst.AND_IYL:
+		execAnd(s, s.IYL())
+	case inst.XOR_IYH:
+		execXor(s, s.IYH())
+	case inst.XOR_IYL:
+		execXor(s, s.IYL())
+	case inst.OR_IYH:
+		execOr(s, s.IYH())
+	case inst.OR_IYL:
+		execOr(s, s.IYL())
+	case inst.CP_IYH:
+		execCp(s, s.IYH())
+	case inst.CP_IYL:
+		execCp(s, s.IYL())
+
+	// === Wave 7: control flow ===
+	// chunk3-3: JR leaves MEMPTR = the branch target, but only when the
+	// branch is actually taken — a not-taken conditional jump never
+	// touches it.
+	case inst.JR:
+		s.WZ = imm
+		nextPC = imm
+	case inst.JR_NZ:
+		if s.F&FlagZ == 0 {
+			s.WZ = imm
+			nextPC = imm
+		} else {
+			tStates = 7
+		}
+	case inst.JR_Z:
+		if s.F&FlagZ != 0 {
+			s.WZ = imm
+			nextPC = imm
+		} else {
+			tStates = 7
+		}
+	case inst.JR_NC:
+		if s.F&FlagC == 0 {
+			s.WZ = imm
+			nextPC = imm
+		} else {
+			tStates = 7
+		}
+	case inst.JR_C:
+		if s.F&FlagC != 0 {
+			s.WZ = imm
+			nextPC = imm
+		} else {
+			tStates = 7
+		}
+	case inst.DJNZ:
+		s.B--
+		if s.B != 0 {
+			s.WZ = imm
+			nextPC = imm
+		} else {
+			tStates = 8
+		}
+
+	// chunk3-3: JP (and JP cc) sets MEMPTR = target unconditionally, even
+	// when the condition is false — the operand is always fetched and
+	// latched, unlike JR's relative displacement.
+	case inst.JP:
+		s.WZ = imm
+		nextPC = imm
+	case inst.JP_NZ:
+		s.WZ = imm
+		if s.F&FlagZ == 0 {
+			nextPC = imm
+		}
+	case inst.JP_Z:
+		s.WZ = imm
+		if s.F&FlagZ != 0 {
+			nextPC = imm
+		}
+	case inst.JP_NC:
+		s.WZ = imm
+		if s.F&FlagC == 0 {
+			nextPC = imm
+		}
+	case inst.JP_C:
+		s.WZ = imm
+		if s.F&FlagC != 0 {
+			nextPC = imm
+		}
+
+	// CALL has no return-address stack yet (see Wave 7 doc comment in
+	// instruction.go), so it behaves exactly like JP for now. MEMPTR still
+	// follows the same "always latched" rule as JP cc (chunk3-3).
+	case inst.CALL:
+		s.WZ = imm
+		nextPC = imm
+	case inst.CALL_NZ:
+		s.WZ = imm
+		if s.F&FlagZ == 0 {
+			nextPC = imm
+		} else {
+			tStates = 10
+		}
+	case inst.CALL_Z:
+		s.WZ = imm
+		if s.F&FlagZ != 0 {
+			nextPC = imm
+		} else {
+			tStates = 10
+		}
+	case inst.CALL_NC:
+		s.WZ = imm
+		if s.F&FlagC == 0 {
+			nextPC = imm
+		} else {
+			tStates = 10
+		}
+	case inst.CALL_C:
+		s.WZ = imm
+		if s.F&FlagC != 0 {
+			nextPC = imm
+		} else {
+			tStates = 10
+		}
+
+	// RET/RET cc end the modeled sequence rather than popping a return
+	// address; PC is left at its post-RET advance (unused once Halted).
+	case inst.RET:
+		s.Halted = true
+	case inst.RET_NZ:
+		if s.F&FlagZ == 0 {
+			s.Halted = true
+		} else {
+			tStates = 5
+		}
+	case inst.RET_Z:
+		if s.F&FlagZ != 0 {
+			s.Halted = true
+		} else {
+			tStates = 5
+		}
+	case inst.RET_NC:
+		if s.F&FlagC == 0 {
+			s.Halted = true
+		} else {
+			tStates = 5
+		}
+	case inst.RET_C:
+		if s.F&FlagC != 0 {
+			s.Halted = true
+		} else {
+			tStates = 5
+		}
+
+	// RST n: fixed-target CALL to a caller-supplied ROM entry point.
+	// chunk3-3: follows the same MEMPTR rule as CALL.
+	case inst.RST_00, inst.RST_08, inst.RST_10, inst.RST_18,
+		inst.RST_20, inst.RST_28, inst.RST_30, inst.RST_38:
+		s.WZ = imm
+		nextPC = imm
+
+	// === Wave 8: I/O ports ===
+	// chunk3-3: IN A,(n)/OUT (n),A latch MEMPTR = (A<<8|n)+1 — the chip
+	// drives the top half of the port address from A while the operation
+	// runs, then increments the whole thing for the next memory cycle.
+	case inst.IN_A_N:
+		s.WZ = (uint16(s.A)<<8 | imm) + 1
+		s.A = s.portIn(imm)
+	case inst.OUT_N_A:
+		s.WZ = (uint16(s.A)<<8 | imm) + 1
+		s.portOut(imm, s.A)
+
+	// chunk3-3: IN r,(C)/OUT (C),r latch MEMPTR = BC+1.
+	case inst.IN_A_C:
+		s.WZ = (uint16(s.B)<<8 | uint16(s.C)) + 1
+		s.A = s.portIn(uint16(s.C))
+	case inst.IN_B_C:
+		s.WZ = (uint16(s.B)<<8 | uint16(s.C)) + 1
+		s.B = s.portIn(uint16(s.C))
+	case inst.IN_C_C:
+		s.WZ = (uint16(s.B)<<8 | uint16(s.C)) + 1
+		s.C = s.portIn(uint16(s.C))
+	case inst.IN_D_C:
+		s.WZ = (uint16(s.B)<<8 | uint16(s.C)) + 1
+		s.D = s.portIn(uint16(s.C))
+	case inst.IN_E_C:
+		s.WZ = (uint16(s.B)<<8 | uint16(s.C)) + 1
+		s.E = s.portIn(uint16(s.C))
+	case inst.IN_H_C:
+		s.WZ = (uint16(s.B)<<8 | uint16(s.C)) + 1
+		s.H = s.portIn(uint16(s.C))
+	case inst.IN_L_C:
+		s.WZ = (uint16(s.B)<<8 | uint16(s.C)) + 1
+		s.L = s.portIn(uint16(s.C))
+
+	case inst.OUT_C_A:
+		s.WZ = (uint16(s.B)<<8 | uint16(s.C)) + 1
+		s.portOut(uint16(s.C), s.A)
+	case inst.OUT_C_B:
+		s.WZ = (uint16(s.B)<<8 | uint16(s.C)) + 1
+		s.portOut(uint16(s.C), s.B)
+	case inst.OUT_C_C:
+		s.WZ = (uint16(s.B)<<8 | uint16(s.C)) + 1
+		s.portOut(uint16(s.C), s.C)
+	case inst.OUT_C_D:
+		s.WZ = (uint16(s.B)<<8 | uint16(s.C)) + 1
+		s.portOut(uint16(s.C), s.D)
+	case inst.OUT_C_E:
+		s.WZ = (uint16(s.B)<<8 | uint16(s.C)) + 1
+		s.portOut(uint16(s.C), s.E)
+	case inst.OUT_C_H:
+		s.WZ = (uint16(s.B)<<8 | uint16(s.C)) + 1
+		s.portOut(uint16(s.C), s.H)
+	case inst.OUT_C_L:
+		s.WZ = (uint16(s.B)<<8 | uint16(s.C)) + 1
+		s.portOut(uint16(s.C), s.L)
+
+	// Block I/O: memory side routes through M (same one-address-per-sequence
+	// model as Wave 5), port side through C; B is the repeat counter.
+	//
+	// chunk11-2: MEMPTR after INI/IND is BC+-1 computed from BC *before* B
+	// decrements (C doesn't change, so this only matters for B's old vs new
+	// value); after OUTI/OUTD it's BC+-1 computed *after* B has decremented
+	// instead, per Sean Young's "The Undocumented Z80 Documented". Each
+	// repeating form (INIR/INDR/OTIR/OTDR) re-latches WZ the same way every
+	// iteration, since it's just the plain form run in a loop.
+	case inst.INI:
+		s.WZ = (uint16(s.B)<<8 | uint16(s.C)) + 1
+		s.M = s.portIn(uint16(s.C))
+		s.B--
+	case inst.INIR:
+		s.WZ = (uint16(s.B)<<8 | uint16(s.C)) + 1
+		s.M = s.portIn(uint16(s.C))
+		s.B--
+		if s.B != 0 {
+			nextPC = s.PC
+		} else {
+			tStates = 16
+		}
+	case inst.IND:
+		s.WZ = (uint16(s.B)<<8 | uint16(s.C)) - 1
+		s.M = s.portIn(uint16(s.C))
+		s.B--
+	case inst.INDR:
+		s.WZ = (uint16(s.B)<<8 | uint16(s.C)) - 1
+		s.M = s.portIn(uint16(s.C))
+		s.B--
+		if s.B != 0 {
+			nextPC = s.PC
+		} else {
+			tStates = 16
+		}
+	case inst.OUTI:
+		s.portOut(uint16(s.C), s.M)
+		s.B--
+		s.WZ = (uint16(s.B)<<8 | uint16(s.C)) + 1
+	case inst.OTIR:
+		s.portOut(uint16(s.C), s.M)
+		s.B--
+		s.WZ = (uint16(s.B)<<8 | uint16(s.C)) + 1
+		if s.B != 0 {
+			nextPC = s.PC
+		} else {
+			tStates = 16
+		}
+	case inst.OUTD:
+		s.portOut(uint16(s.C), s.M)
+		s.B--
+		s.WZ = (uint16(s.B)<<8 | uint16(s.C)) - 1
+	case inst.OTDR:
+		s.portOut(uint16(s.C), s.M)
+		s.B--
+		s.WZ = (uint16(s.B)<<8 | uint16(s.C)) - 1
+		if s.B != 0 {
+			nextPC = s.PC
+		} else {
+			tStates = 16
+		}
+
 	default:
 		panic("unhandled opcode in Exec")
 	}
-	return inst.TStates(op)
+	s.PC = nextPC
+	s.TStates += uint64(tStates)
+	return tStates
 }
 
 // --- ALU helpers, ported from remogatto/z80 ---
@@ -1115,6 +1696,11 @@ func execDec(s *State, reg *uint8) {
 	s.F |= bsel(*reg == 0x7F, FlagV, 0) | Sz53Table[*reg]
 }
 
+// execDaa applies the standard Z80 BCD correction: it derives the same
+// 0x00/0x06/0x60/0x66 diff the real chip's lookup PLA does from A's two
+// nibbles plus the incoming H/C flags, adds it (or, when N is set for a
+// preceding subtract, subtracts it), and refreshes C/H/P from the correction
+// itself while N is left untouched and S/Z/5/3 come from the add/sub helper.
 func execDaa(s *State) {
 	var add, carry uint8
 	carry = s.F & FlagC
@@ -1188,12 +1774,13 @@ func execSrl(s *State, v uint8) uint8 {
 // Preserves S, Z, P/V flags.
 func execAddHL(s *State, value uint16) {
 	hl := uint16(s.H)<<8 | uint16(s.L)
+	s.WZ = hl + 1 // chunk3-3: ADD HL,rr leaves MEMPTR = HL(before the add) + 1
 	result := uint32(hl) + uint32(value)
 	// Half-carry from bit 11
 	hc := (hl & 0x0FFF) + (value & 0x0FFF)
 	s.F = (s.F & (FlagS | FlagZ | FlagP)) | // preserve S, Z, P/V
-		bsel(hc&0x1000 != 0, FlagH, 0) | // half-carry from bit 11
-		bsel(result&0x10000 != 0, FlagC, 0) | // carry from bit 15
+		halfCarryFlag(hc) | // half-carry from bit 11
+		carryFlag(result) | // carry from bit 15
 		(uint8(result>>8) & (Flag3 | Flag5)) // undocumented bits from high byte
 	s.H = uint8(result >> 8)
 	s.L = uint8(result)
@@ -1204,16 +1791,19 @@ func execAddHL(s *State, value uint16) {
 // From remogatto/z80: uses lookup tables for half-carry and overflow.
 func execAdcHL(s *State, value uint16) {
 	hl := uint16(s.H)<<8 | uint16(s.L)
+	s.WZ = hl + 1 // chunk3-3: same MEMPTR rule as ADD HL,rr
 	carry := uint(s.F & FlagC)
 	result := uint(hl) + uint(value) + carry
 	// Lookup: bits 11 and 15 of hl, value, result → 3-bit index for half-carry, 3-bit for overflow
 	lookup := byte(((uint(hl) & 0x8800) >> 11) | ((uint(value) & 0x8800) >> 10) | ((result & 0x8800) >> 9))
 	s.H = uint8(result >> 8)
 	s.L = uint8(result)
-	s.F = bsel(result&0x10000 != 0, FlagC, 0) |
+	s.F = carryFlag(uint32(result)) |
 		OverflowAddTable[lookup>>4] |
 		(s.H & (Flag3 | Flag5 | FlagS)) |
 		HalfcarryAddTable[lookup&0x07] |
+		// No literal bit to extract Z from (it's "both halves zero", not one
+		// bit of a wider result), so this one stays a branch (chunk3-4).
 		bsel(s.H|s.L != 0, 0, FlagZ)
 }
 
@@ -1222,23 +1812,72 @@ func execAdcHL(s *State, value uint16) {
 // From remogatto/z80: uses lookup tables for half-carry and overflow.
 func execSbcHL(s *State, value uint16) {
 	hl := uint16(s.H)<<8 | uint16(s.L)
+	s.WZ = hl + 1 // chunk3-3: same MEMPTR rule as ADD HL,rr
 	carry := uint(s.F & FlagC)
 	result := uint(hl) - uint(value) - carry
 	lookup := byte(((uint(hl) & 0x8800) >> 11) | ((uint(value) & 0x8800) >> 10) | (((result) & 0x8800) >> 9))
 	s.H = uint8(result >> 8)
 	s.L = uint8(result)
-	s.F = bsel(result&0x10000 != 0, FlagC, 0) |
+	s.F = carryFlag(uint32(result)) |
 		FlagN |
 		OverflowSubTable[lookup>>4] |
 		(s.H & (Flag3 | Flag5 | FlagS)) |
 		HalfcarrySubTable[lookup&0x07] |
-		bsel(s.H|s.L != 0, 0, FlagZ)
+		bsel(s.H|s.L != 0, 0, FlagZ) // no literal bit to extract Z from (chunk3-4)
+}
+
+// execAddIdx implements ADD IX,rr / ADD IY,rr: same flag behavior as
+// execAddHL (sets H from bit 11, N=0, C from bit 15; preserves S,Z,P/V) but
+// operates on an index register instead of HL.
+func execAddIdx(s *State, idx, value uint16) uint16 {
+	s.WZ = idx + 1 // chunk3-3: ADD IX/IY,rr follows the same MEMPTR rule as ADD HL,rr
+	result := uint32(idx) + uint32(value)
+	hc := (idx & 0x0FFF) + (value & 0x0FFF)
+	s.F = (s.F & (FlagS | FlagZ | FlagP)) |
+		halfCarryFlag(hc) |
+		carryFlag(result) |
+		(uint8(result>>8) & (Flag3 | Flag5))
+	return uint16(result)
+}
+
+// execIncHalf/execDecHalf implement INC/DEC IXH|IXL|IYH|IYL: 8-bit INC/DEC
+// flag semantics (see execInc/execDec) applied to one half of an index register.
+func execIncHalf(s *State, idx uint16, high bool) uint16 {
+	v := uint8(idx)
+	if high {
+		v = uint8(idx >> 8)
+	}
+	execInc(s, &v)
+	if high {
+		return uint16(v)<<8 | (idx & 0x00FF)
+	}
+	return (idx & 0xFF00) | uint16(v)
+}
+
+func execDecHalf(s *State, idx uint16, high bool) uint16 {
+	v := uint8(idx)
+	if high {
+		v = uint8(idx >> 8)
+	}
+	execDec(s, &v)
+	if high {
+		return uint16(v)<<8 | (idx & 0x00FF)
+	}
+	return (idx & 0xFF00) | uint16(v)
 }
 
 // execBit implements BIT n, r: test bit n of register, set flags accordingly.
 // From remogatto/z80: F = (F & C) | H | (r & (flag3|flag5)); if bit is zero → F |= P|Z; if n==7 && bit set → F |= S.
-func execBit(s *State, r uint8, bit uint8) {
-	s.F = (s.F & FlagC) | FlagH | (r & (Flag3 | Flag5))
+// chunk3-3: for the (HL)/(IX+d)/(IY+d) forms (fromMem), real hardware draws
+// the undocumented flag 3/5 bits from WZ's high byte — the internal MEMPTR
+// latched by the memory read — rather than from r itself; see the BIT_*_HLI
+// and BIT_*_IXD/IYD cases, which set s.WZ before calling in.
+func execBit(s *State, r uint8, bit uint8, fromMem bool) {
+	bits35 := r
+	if fromMem {
+		bits35 = uint8(s.WZ >> 8)
+	}
+	s.F = (s.F & FlagC) | FlagH | (bits35 & (Flag3 | Flag5))
 	if r&(1<<bit) == 0 {
 		s.F |= FlagP | FlagZ
 	}
@@ -1255,10 +1894,28 @@ func execSll(s *State, v uint8) uint8 {
 	return v
 }
 
-// bsel returns a if cond is true, else b. Branchless flag selection.
+// bsel returns a if cond is true, else b. Used for flag bits that don't
+// come from a single bit of a wider arithmetic result — see carryFlag and
+// halfCarryFlag (chunk3-4) for the branchless form used in the hotter
+// 16-bit add/adc/sbc paths, where the flag bit already sits at a fixed
+// offset in the result and can be masked out directly.
 func bsel(cond bool, a, b uint8) uint8 {
 	if cond {
 		return a
 	}
 	return b
 }
+
+// carryFlag extracts FlagC from bit 16 of a 16-bit add/sub result (the
+// carry/borrow out of bit 15) without branching: FlagC is bit 0, so
+// shifting bit 16 down to bit 0 lines it up exactly (chunk3-4).
+func carryFlag(result uint32) uint8 {
+	return uint8(result>>16) & FlagC
+}
+
+// halfCarryFlag extracts FlagH from bit 12 of a 12-bit nibble-sum (the
+// carry out of bit 11) without branching: FlagH is bit 4, so shifting bit
+// 12 down by 8 lines it up exactly (chunk3-4).
+func halfCarryFlag(hc uint16) uint8 {
+	return uint8(hc>>8) & FlagH
+}
@@ -380,6 +380,12 @@ func TestDAA(t *testing.T) {
 		{0x1A, 0, 0x20, "BCD adjust low nibble"},
 		{0xA0, 0, 0x00, "BCD adjust high nibble"}, // carry set
 		{0x9A, 0, 0x00, "BCD 9A -> 00"},
+
+		// N set (after a subtract): the diff is subtracted from A instead
+		// of added (see execDaa).
+		{0x55, FlagN, 0x55, "BCD subtract, no adjust"},
+		{0x06, FlagN | FlagH, 0x00, "BCD subtract, H set, low nibble"},
+		{0x9A, FlagN | FlagH | FlagC, 0x34, "BCD subtract, H and C set"},
 	}
 
 	for _, tc := range tests {
@@ -393,6 +399,50 @@ func TestDAA(t *testing.T) {
 	}
 }
 
+// negSilicon computes NEG's result and flags via a carry-in bitfield
+// derivation (cpl = A^0xFF, result = cpl+1, with H/P-V read off
+// (0 ^ A ^ result) the way a subtractor's internal carry chain would surface
+// them) rather than HalfcarrySubTable/OverflowSubTable. Exists only so
+// TestNEG below can cross-check cpu.Exec's lookup-table-based NEG against an
+// independent derivation of the same hardware-documented flags.
+func negSilicon(a uint8) (result, flags uint8) {
+	cpl := a ^ 0xFF
+	result = uint8(uint16(cpl) + 1)
+
+	ci := 0 ^ a ^ result // carry-in-per-bit bitfield for the 0-A subtraction
+
+	flags = FlagN
+	if ci&0x10 != 0 {
+		flags |= FlagH
+	}
+	if a&result&0x80 != 0 {
+		flags |= FlagV
+	}
+	if a != 0 {
+		flags |= FlagC
+	}
+	flags |= result & (FlagS | Flag5 | Flag3)
+	if result == 0 {
+		flags |= FlagZ
+	}
+	return result, flags
+}
+
+// TestNEG verifies cpu.Exec's NEG (0 - A via execSub) against the
+// silicon-faithful carry-in formulation for every possible A, the same
+// parity ZEXALL/FUSE check this instruction needs to pass.
+func TestNEG(t *testing.T) {
+	for a := 0; a < 256; a++ {
+		s := State{A: uint8(a)}
+		Exec(&s, inst.NEG, 0)
+
+		wantA, wantF := negSilicon(uint8(a))
+		if s.A != wantA || s.F != wantF {
+			t.Fatalf("NEG A=%02X: got A=%02X F=%02X, want A=%02X F=%02X (silicon cross-check)", a, s.A, s.F, wantA, wantF)
+		}
+	}
+}
+
 // TestCBRotatesOnOtherRegs verifies CB-prefix operations on B-L.
 func TestCBRotatesOnOtherRegs(t *testing.T) {
 	// RLC B
@@ -440,9 +490,24 @@ func BenchmarkExecSequence(b *testing.B) {
 	}
 }
 
+// BenchmarkExecConditionalBranch benchmarks the not-taken path of a
+// conditional branch (chunk10-4), one of the cases (listed in Exec's doc
+// comment) where Exec's returned T-state count diverges from the static
+// inst.TStates(op) figure.
+func BenchmarkExecConditionalBranch(b *testing.B) {
+	s := State{F: FlagZ}
+	for i := 0; i < b.N; i++ {
+		s2 := s
+		Exec(&s2, inst.JR_NZ, 0x9000)
+	}
+}
+
 func TestAllOpcodes(t *testing.T) {
-	// Verify every opcode in the catalog can be executed without panic
-	for op := inst.OpCode(0); op < inst.OpCodeCount; op++ {
+	// Verify every opcode Exec (the Z80 executor) is responsible for can be
+	// executed without panic. Bounded by Z80OpCodeCount, not OpCodeCount:
+	// the opcodes above it are SM83-only (see the chunk2-5 block in
+	// instruction.go) and Exec never handles them — pkg/cpu/sm83.Exec does.
+	for op := inst.OpCode(0); op < inst.Z80OpCodeCount; op++ {
 		info := &inst.Catalog[op]
 		if info.Mnemonic == "" {
 			t.Errorf("OpCode %d has no mnemonic in catalog", op)
@@ -517,7 +582,8 @@ func TestRRAPreservesFlags(t *testing.T) {
 // TestExecDeterministic verifies same input → same output for all opcodes.
 func TestExecDeterministic(t *testing.T) {
 	initial := State{A: 0x42, F: 0x01, B: 0x13, C: 0x24, D: 0x35, E: 0x46, H: 0x57, L: 0x68}
-	for op := inst.OpCode(0); op < inst.OpCodeCount; op++ {
+	// Bounded by Z80OpCodeCount — see TestAllOpcodes.
+	for op := inst.OpCode(0); op < inst.Z80OpCodeCount; op++ {
 		s1 := initial
 		s2 := initial
 		Exec(&s1, op, 0x55)
@@ -620,6 +686,105 @@ func TestBIT(t *testing.T) {
 	}
 }
 
+// TestBitMemptrFlags verifies the chunk3-3 MEMPTR (WZ) quirk: for the
+// (HL)/(IX+d)/(IY+d) forms, BIT's undocumented flag 3/5 bits come from
+// WZ's high byte, not from the tested byte itself.
+func TestBitMemptrFlags(t *testing.T) {
+	// BIT n, (HL): WZ = HL, so flag 3/5 should reflect H, not M.
+	s := State{H: 0x28, L: 0x00, M: 0x00} // M has no flag3/5 bits set
+	Exec(&s, inst.BIT_0_HLI, 0)
+	if s.F&(Flag3|Flag5) != 0x28&(Flag3|Flag5) {
+		t.Errorf("BIT 0, (HL): flag 3/5 = %02X, want %02X (from H)", s.F&(Flag3|Flag5), 0x28&(Flag3|Flag5))
+	}
+
+	// BIT n, (IX+d): approximated as IX's own high byte (see execBit).
+	s = State{IX: 0x1300, M: 0x00}
+	Exec(&s, inst.BIT_0_IXD, 0)
+	if s.F&(Flag3|Flag5) != 0x13&(Flag3|Flag5) {
+		t.Errorf("BIT 0, (IX+d): flag 3/5 = %02X, want %02X (from IXH)", s.F&(Flag3|Flag5), 0x13&(Flag3|Flag5))
+	}
+
+	// Register forms are unaffected: flag 3/5 still come from the register.
+	s = State{A: 0x28}
+	Exec(&s, inst.BIT_0_A, 0)
+	if s.F&(Flag3|Flag5) != 0x28&(Flag3|Flag5) {
+		t.Errorf("BIT 0, A: flag 3/5 = %02X, want %02X (from A)", s.F&(Flag3|Flag5), 0x28&(Flag3|Flag5))
+	}
+}
+
+// TestWZPropagation spot-checks the MEMPTR (WZ) update rules on a handful
+// of representative instructions from each family (chunk3-3).
+func TestWZPropagation(t *testing.T) {
+	s := State{PC: 0x0000}
+	Exec(&s, inst.JP, 0x4000)
+	if s.WZ != 0x4000 {
+		t.Errorf("JP 0x4000: WZ=%04X, want 4000", s.WZ)
+	}
+
+	// JP cc latches WZ even when the condition is false.
+	s = State{F: FlagZ}
+	Exec(&s, inst.JP_NZ, 0x5000)
+	if s.WZ != 0x5000 {
+		t.Errorf("JP NZ (not taken): WZ=%04X, want 5000", s.WZ)
+	}
+
+	// JR cc only latches WZ when the branch is taken.
+	s = State{F: 0}
+	Exec(&s, inst.JR_NZ, 0x0100)
+	if s.WZ != 0x0100 {
+		t.Errorf("JR NZ (taken): WZ=%04X, want 0100", s.WZ)
+	}
+	s = State{F: FlagZ, WZ: 0xAAAA}
+	Exec(&s, inst.JR_NZ, 0x0100)
+	if s.WZ != 0xAAAA {
+		t.Errorf("JR NZ (not taken): WZ=%04X, want unchanged AAAA", s.WZ)
+	}
+
+	s = State{H: 0x12, L: 0x34, B: 0x00, C: 0x01}
+	Exec(&s, inst.ADD_HL_BC, 0)
+	if s.WZ != 0x1235 {
+		t.Errorf("ADD HL, BC: WZ=%04X, want 1235 (old HL + 1)", s.WZ)
+	}
+
+	s = State{B: 0x20, C: 0x00}
+	Exec(&s, inst.LD_A_BCI, 0)
+	if s.WZ != 0x2001 {
+		t.Errorf("LD A, (BC): WZ=%04X, want 2001 (BC + 1)", s.WZ)
+	}
+
+	s = State{A: 0x42, C: 0x10}
+	Exec(&s, inst.IN_A_N, 0x34)
+	if s.WZ != 0x4235 {
+		t.Errorf("IN A, (n): WZ=%04X, want 4235 ((A<<8|n) + 1)", s.WZ)
+	}
+
+	// chunk11-2: block I/O latches WZ too. INI/IND use BC from before B
+	// decrements; OUTI/OUTD use BC from after.
+	s = State{B: 0x10, C: 0x20}
+	Exec(&s, inst.INI, 0)
+	if s.WZ != 0x1021 {
+		t.Errorf("INI: WZ=%04X, want 1021 (BC-before-decrement + 1)", s.WZ)
+	}
+
+	s = State{B: 0x10, C: 0x20}
+	Exec(&s, inst.IND, 0)
+	if s.WZ != 0x101F {
+		t.Errorf("IND: WZ=%04X, want 101F (BC-before-decrement - 1)", s.WZ)
+	}
+
+	s = State{B: 0x10, C: 0x20}
+	Exec(&s, inst.OUTI, 0)
+	if s.WZ != 0x0F21 {
+		t.Errorf("OUTI: WZ=%04X, want 0F21 (BC-after-decrement + 1)", s.WZ)
+	}
+
+	s = State{B: 0x10, C: 0x20}
+	Exec(&s, inst.OUTD, 0)
+	if s.WZ != 0x0F1F {
+		t.Errorf("OUTD: WZ=%04X, want 0F1F (BC-after-decrement - 1)", s.WZ)
+	}
+}
+
 // TestRES verifies RES n, r instructions.
 func TestRES(t *testing.T) {
 	// RES 0, A: clears bit 0
@@ -1198,6 +1363,426 @@ func TestADCSBCHLCrossCheck(t *testing.T) {
 	}
 }
 
+// TestADCSBCHLFlagsCrossCheck is chunk11-1's reference cross-check: it
+// recomputes S, Z, H, P/V, C, and the undocumented bit3/bit5 flags from
+// scratch (plain arithmetic on the 32-bit intermediate, not
+// HalfcarryAddTable/OverflowAddTable/etc.) and compares against what
+// execAdcHL/execSbcHL actually set, over the same sampled HL x operand x
+// carry space TestADCSBCHLCrossCheck already walks. TestADCSBCHLCrossCheck
+// above only ever checked N and the 16-bit result — this is the "does H/
+// P/V/X/Y actually match an independent computation" check the request
+// asked for; the lookup-table-driven flag semantics in execAdcHL/execSbcHL
+// were already complete before this commit (H, P/V, and Flag3/Flag5 were
+// all already set from OverflowAddTable/OverflowSubTable/
+// HalfcarryAddTable/HalfcarrySubTable and s.H's own bits), so no exec.go
+// change was needed to make this pass.
+func TestADCSBCHLFlagsCrossCheck(t *testing.T) {
+	for hl := uint32(0); hl < 0x10000; hl += 0x1111 {
+		for operand := uint32(0); operand < 0x10000; operand += 0x1111 {
+			for carry := uint32(0); carry <= 1; carry++ {
+				adc := State{H: uint8(hl >> 8), L: uint8(hl), B: uint8(operand >> 8), C: uint8(operand), F: uint8(carry)}
+				Exec(&adc, inst.ADC_HL_BC, 0)
+				sum := hl + operand + carry
+				adcResult := uint16(sum)
+				wantC := sum > 0xFFFF
+				wantH := (hl&0xFFF)+(operand&0xFFF)+carry > 0xFFF
+				wantPV := int16(hl) >= 0 == (int16(operand) >= 0) && int16(hl) >= 0 != (int16(adcResult) >= 0)
+				checkHLFlags(t, adc.F, adcResult, wantC, wantH, wantPV, hl, operand, carry)
+
+				sbc := State{H: uint8(hl >> 8), L: uint8(hl), D: uint8(operand >> 8), E: uint8(operand), F: uint8(carry)}
+				Exec(&sbc, inst.SBC_HL_DE, 0)
+				diff := int64(hl) - int64(operand) - int64(carry)
+				sbcResult := uint16(uint32(diff))
+				wantC = diff < 0
+				wantH = int64(hl&0xFFF)-int64(operand&0xFFF)-int64(carry) < 0
+				wantPV = int16(hl) >= 0 != (int16(operand) >= 0) && int16(hl) >= 0 != (int16(sbcResult) >= 0)
+				checkHLFlags(t, sbc.F, sbcResult, wantC, wantH, wantPV, hl, operand, carry)
+			}
+		}
+	}
+}
+
+// checkHLFlags compares F against the S/Z/C/H/P-V/Flag3/Flag5 bits an
+// independent reference would set for a 16-bit ADC/SBC HL result.
+func checkHLFlags(t *testing.T, f uint8, result16 uint16, wantC, wantH, wantPV bool, hl, operand, carry uint32) {
+	t.Helper()
+	wantS := result16&0x8000 != 0
+	wantZ := result16 == 0
+	wantFlag3 := uint8(result16>>8)&Flag3 != 0
+	wantFlag5 := uint8(result16>>8)&Flag5 != 0
+
+	if got := f&FlagC != 0; got != wantC {
+		t.Errorf("C: got %v want %v (hl=%04X op=%04X c=%d)", got, wantC, hl, operand, carry)
+	}
+	if got := f&FlagH != 0; got != wantH {
+		t.Errorf("H: got %v want %v (hl=%04X op=%04X c=%d)", got, wantH, hl, operand, carry)
+	}
+	if got := f&FlagP != 0; got != wantPV {
+		t.Errorf("P/V: got %v want %v (hl=%04X op=%04X c=%d)", got, wantPV, hl, operand, carry)
+	}
+	if got := f&FlagS != 0; got != wantS {
+		t.Errorf("S: got %v want %v (hl=%04X op=%04X c=%d)", got, wantS, hl, operand, carry)
+	}
+	if got := f&FlagZ != 0; got != wantZ {
+		t.Errorf("Z: got %v want %v (hl=%04X op=%04X c=%d)", got, wantZ, hl, operand, carry)
+	}
+	if got := f&Flag3 != 0; got != wantFlag3 {
+		t.Errorf("bit3: got %v want %v (hl=%04X op=%04X c=%d)", got, wantFlag3, hl, operand, carry)
+	}
+	if got := f&Flag5 != 0; got != wantFlag5 {
+		t.Errorf("bit5: got %v want %v (hl=%04X op=%04X c=%d)", got, wantFlag5, hl, operand, carry)
+	}
+}
+
+// === Wave 6 Tests: IX/IY indexed addressing ===
+
+func TestLDIXNN(t *testing.T) {
+	s := State{}
+	Exec(&s, inst.LD_IX_NN, 0x1234)
+	if s.IX != 0x1234 {
+		t.Fatalf("LD IX, nn: got %04X, want 1234", s.IX)
+	}
+	Exec(&s, inst.LD_IY_NN, 0xABCD)
+	if s.IY != 0xABCD {
+		t.Fatalf("LD IY, nn: got %04X, want ABCD", s.IY)
+	}
+}
+
+func TestADDIX(t *testing.T) {
+	s := State{IX: 0x1000, B: 0x20, C: 0x00}
+	Exec(&s, inst.ADD_IX_BC, 0)
+	if s.IX != 0x3000 {
+		t.Fatalf("ADD IX, BC: got %04X, want 3000", s.IX)
+	}
+	if s.F&FlagN != 0 {
+		t.Fatal("ADD IX, BC: N flag should be clear")
+	}
+}
+
+func TestIndexedMemoryOps(t *testing.T) {
+	// LD A, (IX+d) / LD (IX+d), A share State.M, same model as (HL) ops.
+	s := State{M: 0x77}
+	Exec(&s, inst.LD_A_IXD, 0)
+	if s.A != 0x77 {
+		t.Fatalf("LD A, (IX+d): got %02X, want 77", s.A)
+	}
+	s.A = 0x42
+	Exec(&s, inst.LD_IYD_A, 0)
+	if s.M != 0x42 {
+		t.Fatalf("LD (IY+d), A: got %02X, want 42", s.M)
+	}
+}
+
+func TestIndexedALU(t *testing.T) {
+	s := State{A: 0x10, M: 0x05}
+	Exec(&s, inst.ADD_A_IXD, 0)
+	if s.A != 0x15 {
+		t.Fatalf("ADD A, (IX+d): got %02X, want 15", s.A)
+	}
+}
+
+func TestIndexedIncDec(t *testing.T) {
+	s := State{M: 0x0F}
+	Exec(&s, inst.INC_IXD, 0)
+	if s.M != 0x10 || s.F&FlagH == 0 {
+		t.Fatalf("INC (IX+d): got M=%02X F=%02X, want 10 with half-carry", s.M, s.F)
+	}
+}
+
+func TestIndexedBitResSet(t *testing.T) {
+	s := State{M: 0x00}
+	Exec(&s, inst.BIT_3_IXD, 0)
+	if s.F&FlagZ == 0 {
+		t.Fatal("BIT 3, (IX+d): expected Z flag set for zero bit")
+	}
+	Exec(&s, inst.SET_3_IYD, 0)
+	if s.M != 0x08 {
+		t.Fatalf("SET 3, (IY+d): got %02X, want 08", s.M)
+	}
+	Exec(&s, inst.RES_3_IXD, 0)
+	if s.M != 0x00 {
+		t.Fatalf("RES 3, (IX+d): got %02X, want 00", s.M)
+	}
+}
+
+func TestHalfRegisterOps(t *testing.T) {
+	s := State{IX: 0x1234}
+	Exec(&s, inst.LD_A_IXH, 0)
+	if s.A != 0x12 {
+		t.Fatalf("LD A, IXH: got %02X, want 12", s.A)
+	}
+	Exec(&s, inst.LD_A_IXL, 0)
+	if s.A != 0x34 {
+		t.Fatalf("LD A, IXL: got %02X, want 34", s.A)
+	}
+
+	s = State{IX: 0x00FF}
+	Exec(&s, inst.INC_IXL, 0)
+	if s.IX != 0x0000 || s.F&FlagZ == 0 {
+		t.Fatalf("INC IXL: got IX=%04X F=%02X, want 0000 with Z set", s.IX, s.F)
+	}
+
+	s = State{A: 0x01, IX: 0x0005}
+	Exec(&s, inst.ADD_A_IXL, 0)
+	if s.A != 0x06 {
+		t.Fatalf("ADD A, IXL: got %02X, want 06", s.A)
+	}
+}
+
+// TestHalfRegisterALUOps covers chunk2-6: the ALU ops against IXH/IXL/IYH/IYL
+// that TestHalfRegisterOps' original subset (LD/INC/DEC/ADD A,) didn't reach.
+func TestHalfRegisterALUOps(t *testing.T) {
+	s := State{A: 0x10, F: FlagC, IX: 0x0205}
+	Exec(&s, inst.ADC_A_IXH, 0)
+	if s.A != 0x13 {
+		t.Fatalf("ADC A, IXH: got %02X, want 13", s.A)
+	}
+
+	s = State{A: 0x10, IX: 0x0003}
+	Exec(&s, inst.SUB_IXL, 0)
+	if s.A != 0x0D {
+		t.Fatalf("SUB IXL: got %02X, want 0D", s.A)
+	}
+
+	s = State{A: 0xFF, IY: 0x0F00}
+	Exec(&s, inst.AND_IYH, 0)
+	if s.A != 0x0F {
+		t.Fatalf("AND IYH: got %02X, want 0F", s.A)
+	}
+
+	s = State{A: 0x05, IY: 0x0005}
+	Exec(&s, inst.CP_IYL, 0)
+	if s.F&FlagZ == 0 {
+		t.Fatalf("CP IYL: F=%02X, want Z set (A == IYL)", s.F)
+	}
+}
+
+func TestDisassembleIndexDisp(t *testing.T) {
+	got := inst.Disassemble(inst.Instruction{Op: inst.LD_A_IXD, Disp: -3})
+	want := "LD A, (IX-3)"
+	if got != want {
+		t.Fatalf("Disassemble LD A,(IX+d) with Disp=-3: got %q, want %q", got, want)
+	}
+}
+
+// === Wave 7 Tests: control flow ===
+
+func TestJRUnconditional(t *testing.T) {
+	s := State{PC: 0x8000}
+	Exec(&s, inst.JR, 0x8010)
+	if s.PC != 0x8010 {
+		t.Fatalf("JR: got PC=%04X, want 8010", s.PC)
+	}
+}
+
+func TestJRConditionalTakenAndNotTaken(t *testing.T) {
+	s := State{PC: 0x8000, F: FlagZ}
+	Exec(&s, inst.JR_NZ, 0x9000)
+	if s.PC != 0x8002 {
+		t.Fatalf("JR NZ not taken (Z set): got PC=%04X, want 8002 (fallthrough)", s.PC)
+	}
+
+	s = State{PC: 0x8000, F: 0}
+	Exec(&s, inst.JR_NZ, 0x9000)
+	if s.PC != 0x9000 {
+		t.Fatalf("JR NZ taken (Z clear): got PC=%04X, want 9000", s.PC)
+	}
+}
+
+func TestDJNZLoop(t *testing.T) {
+	// B=3: DJNZ should branch on the first two decrements and fall through
+	// once B reaches 0, mirroring "LD B,n: loop: DEC B: JR NZ,loop".
+	s := State{PC: 0x8000, B: 3}
+	Exec(&s, inst.DJNZ, 0x8000)
+	if s.B != 2 || s.PC != 0x8000 {
+		t.Fatalf("DJNZ #1: got B=%d PC=%04X, want B=2 PC=8000", s.B, s.PC)
+	}
+	Exec(&s, inst.DJNZ, 0x8000)
+	if s.B != 1 || s.PC != 0x8000 {
+		t.Fatalf("DJNZ #2: got B=%d PC=%04X, want B=1 PC=8000", s.B, s.PC)
+	}
+	Exec(&s, inst.DJNZ, 0x8000)
+	if s.B != 0 || s.PC != 0x8002 {
+		t.Fatalf("DJNZ #3 (falls through): got B=%d PC=%04X, want B=0 PC=8002", s.B, s.PC)
+	}
+}
+
+func TestJPAndCall(t *testing.T) {
+	s := State{PC: 0x1000}
+	Exec(&s, inst.JP, 0xC000)
+	if s.PC != 0xC000 {
+		t.Fatalf("JP: got PC=%04X, want C000", s.PC)
+	}
+
+	s = State{PC: 0x1000}
+	Exec(&s, inst.CALL, 0xC000)
+	if s.PC != 0xC000 {
+		t.Fatalf("CALL: got PC=%04X, want C000 (no stack modeled yet)", s.PC)
+	}
+}
+
+func TestRetSetsHalted(t *testing.T) {
+	s := State{PC: 0x1000}
+	Exec(&s, inst.RET, 0)
+	if !s.Halted {
+		t.Fatal("RET: want Halted=true")
+	}
+
+	s = State{PC: 0x1000, F: FlagZ}
+	Exec(&s, inst.RET_NZ, 0)
+	if s.Halted {
+		t.Fatal("RET NZ with Z set: want Halted=false")
+	}
+}
+
+func TestRSTIsFixedTargetCall(t *testing.T) {
+	s := State{PC: 0x1000}
+	Exec(&s, inst.RST_38, 0x0038)
+	if s.PC != 0x0038 {
+		t.Fatalf("RST 38H: got PC=%04X, want 0038", s.PC)
+	}
+}
+
+func TestDisassembleBranch(t *testing.T) {
+	got := inst.Disassemble(inst.Instruction{Op: inst.JP, Imm: 0xC000})
+	want := "JP 0C000h"
+	if got != want {
+		t.Fatalf("Disassemble JP: got %q, want %q", got, want)
+	}
+}
+
+// === Wave 8 Tests: I/O ports ===
+
+func TestINOUTVirtualPort(t *testing.T) {
+	s := State{}
+	s.IOPort = 0x55
+	Exec(&s, inst.IN_A_N, 0x10)
+	if s.A != 0x55 {
+		t.Fatalf("IN A,(n) with no IO device: got A=%02X, want 55 (from IOPort)", s.A)
+	}
+
+	s = State{A: 0xAA}
+	Exec(&s, inst.OUT_N_A, 0x10)
+	if s.IOPort != 0xAA {
+		t.Fatalf("OUT (n),A with no IO device: got IOPort=%02X, want AA", s.IOPort)
+	}
+}
+
+func TestINOUTRecordingPorts(t *testing.T) {
+	ports := NewRecordingPorts()
+	ports.Inputs[0x10] = 0x33
+	s := State{IO: ports}
+	Exec(&s, inst.IN_A_N, 0x10)
+	if s.A != 0x33 {
+		t.Fatalf("IN A,(n) via RecordingPorts: got A=%02X, want 33", s.A)
+	}
+	Exec(&s, inst.OUT_N_A, 0x20)
+	if len(ports.Trace) != 2 {
+		t.Fatalf("Trace = %v, want 2 entries", ports.Trace)
+	}
+	if ports.Trace[1] != (PortAccess{Port: 0x20, Value: 0x33, Out: true}) {
+		t.Errorf("Trace[1] = %+v, want OUT (0x20, 0x33)", ports.Trace[1])
+	}
+}
+
+func TestINRCAndOUTCR(t *testing.T) {
+	ports := NewRecordingPorts()
+	ports.Inputs[0xFE] = 0x07
+	s := State{C: 0xFE, IO: ports}
+	Exec(&s, inst.IN_B_C, 0)
+	if s.B != 0x07 {
+		t.Fatalf("IN B,(C): got B=%02X, want 07", s.B)
+	}
+	Exec(&s, inst.OUT_C_B, 0)
+	if ports.Trace[1] != (PortAccess{Port: 0xFE, Value: 0x07, Out: true}) {
+		t.Errorf("OUT (C),B trace = %+v, want port 0xFE value 0x07", ports.Trace[1])
+	}
+}
+
+func TestOTIRRepeatsUntilBZero(t *testing.T) {
+	ports := NewRecordingPorts()
+	s := State{PC: 0x8000, B: 2, C: 0x10, M: 0x42, IO: ports}
+	Exec(&s, inst.OTIR, 0)
+	if s.B != 1 || s.PC != 0x8000 {
+		t.Fatalf("OTIR #1: got B=%d PC=%04X, want B=1 PC=8000 (repeats in place)", s.B, s.PC)
+	}
+	Exec(&s, inst.OTIR, 0)
+	if s.B != 0 || s.PC != 0x8002 {
+		t.Fatalf("OTIR #2: got B=%d PC=%04X, want B=0 PC=8002 (falls through)", s.B, s.PC)
+	}
+	if len(ports.Trace) != 2 {
+		t.Fatalf("OTIR trace = %v, want 2 OUTs", ports.Trace)
+	}
+}
+
+// TestTStates covers the canonical Z80 user manual timings, including the
+// conditional-branch and block-I/O-repeat opcodes whose actual cost (chunk10-4)
+// depends on runtime state rather than the static inst.TStates figure alone.
+func TestTStates(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup State
+		op    inst.OpCode
+		imm   uint16
+		want  int
+	}{
+		{"LD A,n", State{}, inst.LD_A_N, 0x00, 7},
+		{"ADD HL,rr", State{}, inst.ADD_HL_BC, 0, 11},
+		{"CB-prefixed reg op", State{}, inst.RLC_B, 0, 8},
+		{"DDCB (IX+d)", State{IX: 0x1000}, inst.RLC_IXD, 0, 23},
+		{"JR cc taken", State{F: 0}, inst.JR_NZ, 0x9000, 12},
+		{"JR cc not taken", State{F: FlagZ}, inst.JR_NZ, 0x9000, 7},
+		{"DJNZ taken", State{B: 2}, inst.DJNZ, 0x9000, 13},
+		{"DJNZ not taken", State{B: 1}, inst.DJNZ, 0x9000, 8},
+		{"CALL cc taken", State{F: 0}, inst.CALL_NZ, 0x9000, 17},
+		{"CALL cc not taken", State{F: FlagZ}, inst.CALL_NZ, 0x9000, 10},
+		{"RET cc taken", State{F: 0}, inst.RET_NZ, 0, 11},
+		{"RET cc not taken", State{F: FlagZ}, inst.RET_NZ, 0, 5},
+		{"OTIR repeats", State{B: 2}, inst.OTIR, 0, 21},
+		{"OTIR exits", State{B: 1}, inst.OTIR, 0, 16},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := tt.setup
+			if got := Exec(&s, tt.op, tt.imm); got != tt.want {
+				t.Errorf("Exec(%s) T-states = %d, want %d", tt.name, got, tt.want)
+			}
+			if s.TStates != uint64(tt.want) {
+				t.Errorf("Exec(%s) s.TStates = %d, want %d", tt.name, s.TStates, tt.want)
+			}
+		})
+	}
+}
+
+// TestStateTStatesAccumulates checks Exec adds to s.TStates across calls
+// rather than overwriting it, including on the dynamic not-taken/taken
+// correction (chunk10-4) Exec's return value itself already covers.
+func TestStateTStatesAccumulates(t *testing.T) {
+	s := State{F: FlagZ} // JR NZ not taken
+	Exec(&s, inst.LD_A_N, 0x00)  // 7
+	Exec(&s, inst.JR_NZ, 0x9000) // 7, not taken
+	if s.TStates != 14 {
+		t.Errorf("s.TStates after LD A,n + JR NZ (not taken) = %d, want 14", s.TStates)
+	}
+}
+
+// TestExecAdvancesRefreshRegister checks R increments by inst.RefreshCycles
+// (1 for a plain opcode, 2 across a CB/ED/DD/FD prefix byte), wraps at 7
+// bits, and leaves bit 7 untouched.
+func TestExecAdvancesRefreshRegister(t *testing.T) {
+	s := State{R: 0x7F}
+	Exec(&s, inst.NOP, 0)
+	if s.R != 0x00 {
+		t.Errorf("R after NOP from 0x7F = %#02x, want 0x00 (wraps within 7 bits)", s.R)
+	}
+
+	s = State{R: 0x80}
+	Exec(&s, inst.RLC_B, 0) // CB-prefixed: 2 refresh cycles
+	if s.R != 0x82 {
+		t.Errorf("R after RLC B from 0x80 = %#02x, want 0x82 (bit 7 preserved, +2)", s.R)
+	}
+}
+
 func init() {
 	// Force unused import
 	_ = fmt.Sprintf
@@ -0,0 +1,101 @@
+package cpu
+
+import "github.com/oisee/z80-optimizer/pkg/inst"
+
+// ExecBlock runs seq against s, returning the total T-states consumed.
+// When s.Fuse is true, it first tries to recognize a handful of common
+// 2-instruction idioms (see tryFuse) and executes each as a single fused
+// step instead of two separate Exec calls; anything it doesn't recognize
+// falls back to the normal one-instruction-at-a-time path. With s.Fuse
+// false, ExecBlock behaves exactly like calling Exec in a loop — this is
+// the FUSE/ZEXALL escape hatch (chunk3-5).
+func ExecBlock(s *State, seq []inst.Instruction) int {
+	total := 0
+	for i := 0; i < len(seq); {
+		if s.Fuse {
+			if n, tstates, ok := tryFuse(s, seq[i:]); ok {
+				total += tstates
+				i += n
+				continue
+			}
+		}
+		total += Exec(s, seq[i].Op, seq[i].Imm)
+		i++
+	}
+	return total
+}
+
+// hlPairOperand returns the 16-bit register-pair value a 16-bit HL
+// arithmetic opcode (ADD/ADC/SBC HL,rr) reads, and whether op is one of
+// those opcodes at all.
+func hlPairOperand(s *State, op inst.OpCode) (uint16, bool) {
+	switch op {
+	case inst.ADD_HL_BC, inst.ADC_HL_BC, inst.SBC_HL_BC:
+		return uint16(s.B)<<8 | uint16(s.C), true
+	case inst.ADD_HL_DE, inst.ADC_HL_DE, inst.SBC_HL_DE:
+		return uint16(s.D)<<8 | uint16(s.E), true
+	case inst.ADD_HL_HL, inst.ADC_HL_HL, inst.SBC_HL_HL:
+		return uint16(s.H)<<8 | uint16(s.L), true
+	case inst.ADD_HL_SP, inst.ADC_HL_SP, inst.SBC_HL_SP:
+		return s.SP, true
+	}
+	return 0, false
+}
+
+// advance applies the same PC/R/TStates bookkeeping Exec's dispatch wrapper
+// does for op (see exec.go), without re-running op's case in the switch —
+// tryFuse calls this once per fused instruction, after computing its
+// register/flag effects with the shared exec* helpers, so a fused pair
+// leaves s exactly as two ordinary Exec calls would. None of tryFuse's
+// idioms involve a branch opcode, so nextPC is always just PC+ByteSize(op).
+func advance(s *State, op inst.OpCode) int {
+	tstates := inst.TStates(op)
+	s.PC += uint16(inst.ByteSize(op))
+	s.R = s.R&0x80 | (s.R+uint8(inst.RefreshCycles(op)))&0x7F
+	s.TStates += uint64(tstates)
+	return tstates
+}
+
+// tryFuse recognizes a fusable idiom at the start of seq and executes it,
+// returning how many instructions it consumed and the total T-states. ok
+// is false if seq doesn't start with a recognized idiom, in which case
+// ExecBlock falls back to executing seq[0] on its own.
+func tryFuse(s *State, seq []inst.Instruction) (consumed int, tstates int, ok bool) {
+	if len(seq) < 2 {
+		return 0, 0, false
+	}
+	first, second := seq[0], seq[1]
+
+	// ADD HL,rr : ADC HL,rr' — the canonical 32-bit add idiom.
+	if first.Op >= inst.ADD_HL_BC && first.Op <= inst.ADD_HL_SP &&
+		second.Op >= inst.ADC_HL_BC && second.Op <= inst.ADC_HL_SP {
+		addend, _ := hlPairOperand(s, first.Op)
+		adcValue, _ := hlPairOperand(s, second.Op)
+		execAddHL(s, addend)
+		t := advance(s, first.Op)
+		execAdcHL(s, adcValue)
+		t += advance(s, second.Op)
+		return 2, t, true
+	}
+
+	// OR A : SBC HL,rr — the canonical "clear carry then subtract" idiom.
+	if first.Op == inst.OR_A && second.Op >= inst.SBC_HL_BC && second.Op <= inst.SBC_HL_SP {
+		value, _ := hlPairOperand(s, second.Op)
+		execOr(s, s.A)
+		t := advance(s, first.Op)
+		execSbcHL(s, value)
+		t += advance(s, second.Op)
+		return 2, t, true
+	}
+
+	// SRL H : RR L — 16-bit HL right shift, carry out of H feeding into L.
+	if first.Op == inst.SRL_H && second.Op == inst.RR_L {
+		s.H = execSrl(s, s.H)
+		t := advance(s, first.Op)
+		s.L = execRr(s, s.L)
+		t += advance(s, second.Op)
+		return 2, t, true
+	}
+
+	return 0, 0, false
+}
@@ -0,0 +1,90 @@
+package cpu
+
+import (
+	"testing"
+
+	"github.com/oisee/z80-optimizer/pkg/inst"
+)
+
+func TestExecBlockUnfusedMatchesExecLoop(t *testing.T) {
+	seq := []inst.Instruction{{Op: inst.ADD_HL_BC}, {Op: inst.ADC_HL_DE}}
+	s := State{B: 1, D: 2, H: 0, L: 0}
+	want := s
+	wantT := Exec(&want, seq[0].Op, seq[0].Imm)
+	wantT += Exec(&want, seq[1].Op, seq[1].Imm)
+
+	got := s
+	gotT := ExecBlock(&got, seq)
+
+	if got != want || gotT != wantT {
+		t.Fatalf("ExecBlock(Fuse=false) = %+v (%d T), want %+v (%d T)", got, gotT, want, wantT)
+	}
+}
+
+func TestExecBlockFusesAddAdcHL(t *testing.T) {
+	seq := []inst.Instruction{{Op: inst.ADD_HL_BC}, {Op: inst.ADC_HL_DE}}
+	s := State{B: 1, D: 2, H: 0, L: 0}
+	want := s
+	wantT := Exec(&want, seq[0].Op, seq[0].Imm)
+	wantT += Exec(&want, seq[1].Op, seq[1].Imm)
+
+	got := s
+	got.Fuse = true
+	want.Fuse = true // Exec never reads or writes Fuse
+	gotT := ExecBlock(&got, seq)
+
+	if got != want || gotT != wantT {
+		t.Fatalf("ExecBlock(Fuse=true) = %+v (%d T), want %+v (%d T)", got, gotT, want, wantT)
+	}
+}
+
+func TestExecBlockFusesOrSbcHL(t *testing.T) {
+	seq := []inst.Instruction{{Op: inst.OR_A}, {Op: inst.SBC_HL_BC}}
+	s := State{A: 0, B: 1, H: 0, L: 0, F: FlagC}
+	want := s
+	Exec(&want, seq[0].Op, seq[0].Imm)
+	Exec(&want, seq[1].Op, seq[1].Imm)
+
+	got := s
+	got.Fuse = true
+	want.Fuse = true // Exec never reads or writes Fuse
+	ExecBlock(&got, seq)
+
+	if got != want {
+		t.Fatalf("ExecBlock(Fuse=true) OR/SBC = %+v, want %+v", got, want)
+	}
+}
+
+func TestExecBlockFusesSrlHRrL(t *testing.T) {
+	seq := []inst.Instruction{{Op: inst.SRL_H}, {Op: inst.RR_L}}
+	s := State{H: 0x03, L: 0x01}
+	want := s
+	Exec(&want, seq[0].Op, seq[0].Imm)
+	Exec(&want, seq[1].Op, seq[1].Imm)
+
+	got := s
+	got.Fuse = true
+	want.Fuse = true // Exec never reads or writes Fuse
+	ExecBlock(&got, seq)
+
+	if got != want {
+		t.Fatalf("ExecBlock(Fuse=true) SRL/RR = %+v, want %+v", got, want)
+	}
+}
+
+func TestExecBlockFallsBackOnUnrecognizedPair(t *testing.T) {
+	seq := []inst.Instruction{{Op: inst.NOP}, {Op: inst.ADD_A_B}}
+	s := State{A: 1, B: 2}
+	want := s
+	Exec(&want, seq[0].Op, seq[0].Imm)
+	Exec(&want, seq[1].Op, seq[1].Imm)
+
+	got := s
+	got.Fuse = true
+	want.Fuse = true // Exec never reads or writes Fuse
+	ExecBlock(&got, seq)
+
+	if got != want {
+		t.Fatalf("ExecBlock(Fuse=true) fallback = %+v, want %+v", got, want)
+	}
+}
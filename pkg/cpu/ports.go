@@ -0,0 +1,44 @@
+package cpu
+
+// Ports is a Z80 I/O device. Unlike memory, ports are not assumed
+// idempotent — reading a status register can have side effects, and two
+// instruction sequences are only equivalent if they produce the same
+// ordered trace of accesses (see inst.HasSideEffects).
+type Ports interface {
+	In(port uint16) uint8
+	Out(port uint16, v uint8)
+}
+
+// PortAccess records one IN or OUT as part of a RecordingPorts trace.
+type PortAccess struct {
+	Port  uint16
+	Value uint8
+	Out   bool // true for OUT, false for IN
+}
+
+// RecordingPorts is a Ports test double that captures every access in
+// order, for asserting that two candidate sequences produce identical I/O
+// traces (not just identical final register state).
+type RecordingPorts struct {
+	Trace []PortAccess
+	// Inputs, if set, supplies canned values for IN by port number;
+	// ports not present read back as 0.
+	Inputs map[uint16]uint8
+}
+
+// NewRecordingPorts returns a RecordingPorts with no canned inputs.
+func NewRecordingPorts() *RecordingPorts {
+	return &RecordingPorts{Inputs: make(map[uint16]uint8)}
+}
+
+// In returns the canned value for port (0 if none was set) and records the access.
+func (p *RecordingPorts) In(port uint16) uint8 {
+	v := p.Inputs[port]
+	p.Trace = append(p.Trace, PortAccess{Port: port, Value: v, Out: false})
+	return v
+}
+
+// Out records the access.
+func (p *RecordingPorts) Out(port uint16, v uint8) {
+	p.Trace = append(p.Trace, PortAccess{Port: port, Value: v, Out: true})
+}
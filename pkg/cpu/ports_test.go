@@ -0,0 +1,33 @@
+package cpu
+
+import "testing"
+
+func TestRecordingPortsTrace(t *testing.T) {
+	p := NewRecordingPorts()
+	p.Inputs[0x10] = 0x42
+	got := p.In(0x10)
+	if got != 0x42 {
+		t.Fatalf("In(0x10) = %#x, want 0x42", got)
+	}
+	p.Out(0x20, 0x07)
+
+	want := []PortAccess{
+		{Port: 0x10, Value: 0x42, Out: false},
+		{Port: 0x20, Value: 0x07, Out: true},
+	}
+	if len(p.Trace) != len(want) {
+		t.Fatalf("Trace = %v, want %v", p.Trace, want)
+	}
+	for i := range want {
+		if p.Trace[i] != want[i] {
+			t.Errorf("Trace[%d] = %+v, want %+v", i, p.Trace[i], want[i])
+		}
+	}
+}
+
+func TestRecordingPortsUnsetInputReadsZero(t *testing.T) {
+	p := NewRecordingPorts()
+	if got := p.In(0x99); got != 0 {
+		t.Errorf("In(0x99) with no canned value = %#x, want 0", got)
+	}
+}
@@ -0,0 +1,105 @@
+// Package sm83 executes the Game Boy / SM83 (LR35902) dialect of the shared
+// inst.OpCode space.
+//
+// The SM83 opcode map overlaps the Zilog Z80's almost everywhere — loads,
+// basic ALU, control flow — but diverges in two ways this package accounts
+// for: a handful of opcodes with no Z80 equivalent at all (see the
+// "chunk2-5" block in instruction.go: LDH, LD (HL+)/(HL-), STOP, SWAP,
+// RETI), and a flag register that packs Z/N/H/C into the top nibble only
+// (no S, 5, 3, or P/V bits, and no shadow registers or IX/IY to go with
+// them). Exec below handles the divergent opcodes itself and defers every
+// other opcode to cpu.Exec; full ALU-flag-model translation (so e.g. ADD
+// A,B also produces an SM83-correct F byte instead of the Z80 one) is left
+// for a follow-up wave.
+package sm83
+
+import (
+	"github.com/oisee/z80-optimizer/pkg/cpu"
+	"github.com/oisee/z80-optimizer/pkg/inst"
+)
+
+// SM83 flag bit positions in State.F. Unlike the Z80 (FlagS/FlagZ/Flag5/
+// FlagH/Flag3/FlagP/FlagN/FlagC across all 8 bits), the Game Boy's flag
+// register only ever sets the top nibble; the bottom nibble reads as 0.
+const (
+	FlagC uint8 = 0x10 // Carry
+	FlagH uint8 = 0x20 // Half-carry
+	FlagN uint8 = 0x40 // Subtract
+	FlagZ uint8 = 0x80 // Zero
+)
+
+// Exec executes a single SM83 instruction on s, returning its T-state cost.
+// Opcodes unique to this dialect are handled here; everything else is
+// delegated to cpu.Exec (see the package doc for the caveat that implies).
+func Exec(s *cpu.State, op inst.OpCode, imm uint16) int {
+	switch op {
+	case inst.LDH_N_A, inst.LDH_C_A:
+		s.M = s.A
+	case inst.LDH_A_N, inst.LDH_A_C:
+		s.A = s.M
+
+	case inst.LD_HLI_A_INC:
+		s.M = s.A
+		incHL(s)
+	case inst.LD_A_HLI_INC:
+		s.A = s.M
+		incHL(s)
+	case inst.LD_HLI_A_DEC:
+		s.M = s.A
+		decHL(s)
+	case inst.LD_A_HLI_DEC:
+		s.A = s.M
+		decHL(s)
+
+	case inst.STOP:
+		s.Halted = true
+	case inst.RETI:
+		s.Halted = true
+		s.IFF1 = true
+
+	case inst.SWAP_A:
+		s.A = swap(s, s.A)
+	case inst.SWAP_B:
+		s.B = swap(s, s.B)
+	case inst.SWAP_C:
+		s.C = swap(s, s.C)
+	case inst.SWAP_D:
+		s.D = swap(s, s.D)
+	case inst.SWAP_E:
+		s.E = swap(s, s.E)
+	case inst.SWAP_H:
+		s.H = swap(s, s.H)
+	case inst.SWAP_L:
+		s.L = swap(s, s.L)
+
+	default:
+		return cpu.Exec(s, op, imm)
+	}
+	return inst.TStates(op)
+}
+
+// incHL increments the 16-bit HL pair held across s.H/s.L.
+func incHL(s *cpu.State) {
+	hl := uint16(s.H)<<8 | uint16(s.L)
+	hl++
+	s.H, s.L = uint8(hl>>8), uint8(hl)
+}
+
+// decHL decrements the 16-bit HL pair held across s.H/s.L.
+func decHL(s *cpu.State) {
+	hl := uint16(s.H)<<8 | uint16(s.L)
+	hl--
+	s.H, s.L = uint8(hl>>8), uint8(hl)
+}
+
+// swap exchanges the high and low nibbles of v and sets F to the SM83
+// SWAP result: Z set iff the result is zero, N/H/C always cleared.
+func swap(s *cpu.State, v uint8) uint8 {
+	v = (v << 4) | (v >> 4)
+	s.F = 0
+	if v == 0 {
+		s.F |= FlagZ
+	}
+	return v
+}
+
@@ -0,0 +1,70 @@
+package sm83
+
+import (
+	"testing"
+
+	"github.com/oisee/z80-optimizer/pkg/cpu"
+	"github.com/oisee/z80-optimizer/pkg/inst"
+)
+
+func TestLDHRoundTrip(t *testing.T) {
+	s := cpu.State{A: 0x42}
+	Exec(&s, inst.LDH_N_A, 0x80)
+	if s.M != 0x42 {
+		t.Fatalf("LDH (n),A: M = %#x, want 0x42", s.M)
+	}
+	s.A = 0
+	Exec(&s, inst.LDH_A_N, 0x80)
+	if s.A != 0x42 {
+		t.Fatalf("LDH A,(n): A = %#x, want 0x42", s.A)
+	}
+}
+
+func TestLDHLIncDec(t *testing.T) {
+	s := cpu.State{A: 0x11, H: 0x12, L: 0x34}
+	Exec(&s, inst.LD_HLI_A_INC, 0)
+	if s.M != 0x11 || s.H != 0x12 || s.L != 0x35 {
+		t.Fatalf("LD (HL+),A: M=%#x H=%#x L=%#x, want M=0x11 H=0x12 L=0x35", s.M, s.H, s.L)
+	}
+
+	s = cpu.State{M: 0x22, H: 0x12, L: 0x34}
+	Exec(&s, inst.LD_A_HLI_DEC, 0)
+	if s.A != 0x22 || s.H != 0x12 || s.L != 0x33 {
+		t.Fatalf("LD A,(HL-): A=%#x H=%#x L=%#x, want A=0x22 H=0x12 L=0x33", s.A, s.H, s.L)
+	}
+}
+
+func TestSwap(t *testing.T) {
+	s := cpu.State{A: 0xA5, F: FlagC | FlagN | FlagH}
+	Exec(&s, inst.SWAP_A, 0)
+	if s.A != 0x5A || s.F != 0 {
+		t.Fatalf("SWAP A = %#x, F = %#x, want 0x5A, F=0", s.A, s.F)
+	}
+
+	s = cpu.State{B: 0}
+	Exec(&s, inst.SWAP_B, 0)
+	if s.F != FlagZ {
+		t.Fatalf("SWAP B of 0: F = %#x, want FlagZ", s.F)
+	}
+}
+
+func TestRETISetsHaltedAndIFF1(t *testing.T) {
+	s := cpu.State{}
+	Exec(&s, inst.RETI, 0)
+	if !s.Halted || !s.IFF1 {
+		t.Fatalf("RETI: Halted=%v IFF1=%v, want both true", s.Halted, s.IFF1)
+	}
+}
+
+func TestExecDelegatesSharedOpcodes(t *testing.T) {
+	s := cpu.State{A: 1, B: 2}
+	want := s
+	cpu.Exec(&want, inst.ADD_A_B, 0)
+
+	got := s
+	Exec(&got, inst.ADD_A_B, 0)
+
+	if got != want {
+		t.Fatalf("Exec(ADD_A_B) = %+v, want cpu.Exec's result %+v", got, want)
+	}
+}
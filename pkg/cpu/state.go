@@ -6,12 +6,91 @@ package cpu
 //	V1:     A, F, B, C, D, E, H, L (8 bytes)
 //	Wave 2: + SP uint16 (10 bytes)
 //	Wave 5: + M uint8 (11 bytes) — virtual memory byte at (HL)/(BC)/(DE)
+//	Wave 6: + IX, IY uint16 (15 bytes) — index registers; (IX+d)/(IY+d) ops
+//	        still route through M (see inst.HasIndexDisp)
+//	Wave 7: + PC uint16, Halted bool (18 bytes) — control flow; Exec advances
+//	        PC by the instruction's ByteSize and overrides it on taken
+//	        branches (see inst.HasBranchTarget)
+//	Wave 8: + IOPort uint8, IO Ports (+ pointer) — I/O; IN/OUT share the one
+//	        virtual IOPort byte by default, same as M for memory, unless IO
+//	        is set (see ports.go)
+//	chunk2-2: + IFF1, IFF2 bool, IM uint8 — interrupt state; HALT already had
+//	        a field (Halted), these just round it out. Exec and Step
+//	        ignore them.
+//	chunk3-3: + WZ uint16 — MEMPTR, the internal "last address touched"
+//	        latch real hardware exposes through BIT n,(HL)/(IX+d)/(IY+d)'s
+//	        undocumented flag 3/5 bits. Updated by the memory/branch/16-bit
+//	        arithmetic ops documented to touch it; see execBit's callers.
+//	chunk11-2: INI/IND/INIR/INDR/OUTI/OUTD/OTIR/OTDR also latch WZ now
+//	        (BC+-1, computed before B decrements for IN forms and after for
+//	        OUT forms). This tree has no LDI/LDIR/CPI/CPIR/etc. — only the
+//	        Wave 8 I/O block ops exist — so MEMPTR's CPI behavior the request
+//	        named has no opcode to attach to yet.
+//	chunk10-4: + R uint8 — the memory-refresh register. Exec bumps its low
+//	        7 bits by inst.RefreshCycles(op) (1 per opcode, 2 per CB/ED/DD/FD
+//	        prefix byte) and leaves bit 7 alone, the same split real
+//	        hardware uses since only LD R,A can set bit 7. No I register
+//	        yet — nothing here needs an interrupt-mode-2 vector table.
+//	chunk11-4: + TStates uint64 — running total of the T-state counts Exec
+//	        has returned, so a caller stepping through a sequence one
+//	        instruction at a time (Trace, ExecBlock) can read off actual
+//	        elapsed cycles from State itself instead of re-summing Exec's
+//	        return values. Exec increments it by the same figure it returns,
+//	        taken/not-taken corrections included; see inst.Cost for the
+//	        static, pre-execution estimate a search pass would use instead.
 //
-// Still fits a single cache line, cheap to copy by value.
+// State.M is the fast path: one virtual memory byte shared by every
+// indirect op in a sequence. Step can instead resolve the (HL)/(BC)/(DE) and
+// (IX+d)/(IY+d) forms against a real address space through a Bus — see
+// bus.go — which State does not embed, since most search paths never need it.
+//
+// Still cheap to copy by value, except when IO is set (it carries a pointer).
 type State struct {
 	A, F, B, C, D, E, H, L uint8
-	SP                      uint16 // Wave 2
-	M                       uint8  // Wave 5: memory byte (all indirect ops share this)
+	SP                     uint16 // Wave 2
+	M                      uint8  // Wave 5: memory byte (all indirect ops share this)
+	IX, IY                 uint16 // Wave 6: index registers
+	PC                     uint16 // Wave 7: program counter
+	Halted                 bool   // Wave 7: set by RET/RET cc, ends the modeled sequence
+	IOPort                 uint8  // Wave 8: virtual I/O byte, used when IO is nil
+	IO                     Ports  // Wave 8: optional real device; nil uses IOPort
+	IFF1, IFF2             bool   // chunk2-2: interrupt enable flip-flops (EI/DI/RETN/RETI)
+	IM                     uint8  // chunk2-2: interrupt mode, 0/1/2 (IM 0/1/2)
+	WZ                     uint16 // chunk3-3: MEMPTR
+	Fuse                   bool   // chunk3-5: opt into ExecBlock's instruction-pair fusion
+	R                      uint8  // chunk10-4: memory-refresh register, low 7 bits
+	TStates                uint64 // chunk11-4: running total of Exec's returned T-state counts
+}
+
+// IXH returns the high byte of IX (undocumented half-register).
+func (s State) IXH() uint8 { return uint8(s.IX >> 8) }
+
+// IXL returns the low byte of IX (undocumented half-register).
+func (s State) IXL() uint8 { return uint8(s.IX) }
+
+// IYH returns the high byte of IY (undocumented half-register).
+func (s State) IYH() uint8 { return uint8(s.IY >> 8) }
+
+// IYL returns the low byte of IY (undocumented half-register).
+func (s State) IYL() uint8 { return uint8(s.IY) }
+
+// portIn reads a port through IO if set, otherwise returns the shared
+// virtual IOPort byte (same "one port per sequence" simplification M makes
+// for memory).
+func (s *State) portIn(port uint16) uint8 {
+	if s.IO != nil {
+		return s.IO.In(port)
+	}
+	return s.IOPort
+}
+
+// portOut writes a port through IO if set, otherwise updates IOPort.
+func (s *State) portOut(port uint16, v uint8) {
+	if s.IO != nil {
+		s.IO.Out(port, v)
+		return
+	}
+	s.IOPort = v
 }
 
 // Equal returns true if two states are identical.
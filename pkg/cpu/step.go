@@ -0,0 +1,167 @@
+package cpu
+
+import "github.com/oisee/z80-optimizer/pkg/inst"
+
+// Step is Exec's bus-stepped counterpart: instead of lumping an instruction's
+// memory traffic into the single virtual byte State.M, it resolves the real
+// address (HL, BC, DE, or a displaced IX/IY — see memAccess) for the
+// opcodes memAccess classifies and reads/writes bus before/after calling
+// Exec for the actual register semantics. bus.Tick is called once for the
+// opcode fetch (M1, always 4 T-states) and once more for any remaining
+// T-states, approximating the real M-cycle breakdown closely enough for a
+// contention-aware Bus to see fetch and memory-access timing separately.
+//
+// disp is the signed (IX+d)/(IY+d) displacement byte (Instruction.Disp);
+// opcodes outside the indexed forms ignore it, so non-indexed callers can
+// pass 0. Block I/O (Wave 8, INI..OTDR) is still not classified by
+// memAccess and so falls back to Exec's plain s.M behavior — addressing
+// that correctly needs the block-op repeat/direction state this function
+// doesn't have without a larger refactor (chunk10-6). Step still returns
+// the right T-state count for it, it just doesn't touch bus.
+//
+// Returns the T-state cost, same as Exec.
+func Step(s *State, bus Bus, op inst.OpCode, imm uint16, disp int8) int {
+	kind, addr := memAccess(op)
+
+	const m1TStates = 4
+	bus.Tick(m1TStates)
+
+	if kind == memRead || kind == memRMW {
+		s.M = bus.Read(resolveAddr(s, addr, disp))
+	}
+
+	total := Exec(s, op, imm)
+
+	if kind == memWrite || kind == memRMW {
+		bus.Write(resolveAddr(s, addr, disp), s.M)
+	}
+
+	if remaining := total - m1TStates; remaining > 0 {
+		bus.Tick(remaining)
+	}
+
+	return total
+}
+
+func resolveAddr(s *State, addr addrKind, disp int8) uint16 {
+	switch addr {
+	case addrBC:
+		return uint16(s.B)<<8 | uint16(s.C)
+	case addrDE:
+		return uint16(s.D)<<8 | uint16(s.E)
+	case addrIXD:
+		return s.IX + uint16(disp)
+	case addrIYD:
+		return s.IY + uint16(disp)
+	default:
+		return uint16(s.H)<<8 | uint16(s.L)
+	}
+}
+
+// memKind classifies how an opcode touches the resolved address: not at all,
+// a plain read, a plain write, or a read-modify-write (the CB-prefixed
+// rotate/shift/RES/SET (HL) forms, plus INC/DEC (HL)).
+type memKind uint8
+
+const (
+	memNone memKind = iota
+	memRead
+	memWrite
+	memRMW
+)
+
+// addrKind says which register pair resolveAddr should read.
+type addrKind uint8
+
+const (
+	addrHL addrKind = iota
+	addrBC
+	addrDE
+	addrIXD
+	addrIYD
+)
+
+// memAccess classifies the Wave 5 HL/BC/DE-indirect opcodes and the Wave 6
+// (IX+d)/(IY+d)-indexed opcodes (see inst.UsesMemory's LD_A_HLI..SET_7_HLI
+// and the IXD/IYD ranges) by access kind and address register. Wave 8 block
+// I/O (INI..OTDR) inst.UsesMemory also covers still returns memNone, since
+// Step's fallback to plain s.M is correct for it until a later wave adds
+// its repeat-state addressing here (chunk10-6).
+func memAccess(op inst.OpCode) (memKind, addrKind) {
+	switch op {
+	case inst.LD_A_HLI, inst.LD_B_HLI, inst.LD_C_HLI, inst.LD_D_HLI,
+		inst.LD_E_HLI, inst.LD_H_HLI, inst.LD_L_HLI,
+		inst.ADD_A_HLI, inst.ADC_A_HLI, inst.SUB_HLI, inst.SBC_A_HLI,
+		inst.AND_HLI, inst.XOR_HLI, inst.OR_HLI, inst.CP_HLI,
+		inst.BIT_0_HLI, inst.BIT_1_HLI, inst.BIT_2_HLI, inst.BIT_3_HLI,
+		inst.BIT_4_HLI, inst.BIT_5_HLI, inst.BIT_6_HLI, inst.BIT_7_HLI:
+		return memRead, addrHL
+
+	case inst.LD_HLI_A, inst.LD_HLI_B, inst.LD_HLI_C, inst.LD_HLI_D,
+		inst.LD_HLI_E, inst.LD_HLI_H, inst.LD_HLI_L, inst.LD_HLI_N:
+		return memWrite, addrHL
+
+	case inst.INC_HLI, inst.DEC_HLI,
+		inst.RLC_HLI, inst.RRC_HLI, inst.RL_HLI, inst.RR_HLI,
+		inst.SLA_HLI, inst.SRA_HLI, inst.SRL_HLI, inst.SLL_HLI,
+		inst.RES_0_HLI, inst.RES_1_HLI, inst.RES_2_HLI, inst.RES_3_HLI,
+		inst.RES_4_HLI, inst.RES_5_HLI, inst.RES_6_HLI, inst.RES_7_HLI,
+		inst.SET_0_HLI, inst.SET_1_HLI, inst.SET_2_HLI, inst.SET_3_HLI,
+		inst.SET_4_HLI, inst.SET_5_HLI, inst.SET_6_HLI, inst.SET_7_HLI:
+		return memRMW, addrHL
+
+	case inst.LD_A_BCI:
+		return memRead, addrBC
+	case inst.LD_A_DEI:
+		return memRead, addrDE
+	case inst.LD_BCI_A:
+		return memWrite, addrBC
+	case inst.LD_DEI_A:
+		return memWrite, addrDE
+
+	case inst.LD_A_IXD, inst.LD_B_IXD, inst.LD_C_IXD, inst.LD_D_IXD,
+		inst.LD_E_IXD, inst.LD_H_IXD, inst.LD_L_IXD,
+		inst.ADD_A_IXD, inst.ADC_A_IXD, inst.SUB_IXD, inst.SBC_A_IXD,
+		inst.AND_IXD, inst.XOR_IXD, inst.OR_IXD, inst.CP_IXD,
+		inst.BIT_0_IXD, inst.BIT_1_IXD, inst.BIT_2_IXD, inst.BIT_3_IXD,
+		inst.BIT_4_IXD, inst.BIT_5_IXD, inst.BIT_6_IXD, inst.BIT_7_IXD:
+		return memRead, addrIXD
+
+	case inst.LD_IXD_A, inst.LD_IXD_B, inst.LD_IXD_C, inst.LD_IXD_D,
+		inst.LD_IXD_E, inst.LD_IXD_H, inst.LD_IXD_L, inst.LD_IXD_N:
+		return memWrite, addrIXD
+
+	case inst.INC_IXD, inst.DEC_IXD,
+		inst.RLC_IXD, inst.RRC_IXD, inst.RL_IXD, inst.RR_IXD,
+		inst.SLA_IXD, inst.SRA_IXD, inst.SRL_IXD, inst.SLL_IXD,
+		inst.RES_0_IXD, inst.RES_1_IXD, inst.RES_2_IXD, inst.RES_3_IXD,
+		inst.RES_4_IXD, inst.RES_5_IXD, inst.RES_6_IXD, inst.RES_7_IXD,
+		inst.SET_0_IXD, inst.SET_1_IXD, inst.SET_2_IXD, inst.SET_3_IXD,
+		inst.SET_4_IXD, inst.SET_5_IXD, inst.SET_6_IXD, inst.SET_7_IXD:
+		return memRMW, addrIXD
+
+	case inst.LD_A_IYD, inst.LD_B_IYD, inst.LD_C_IYD, inst.LD_D_IYD,
+		inst.LD_E_IYD, inst.LD_H_IYD, inst.LD_L_IYD,
+		inst.ADD_A_IYD, inst.ADC_A_IYD, inst.SUB_IYD, inst.SBC_A_IYD,
+		inst.AND_IYD, inst.XOR_IYD, inst.OR_IYD, inst.CP_IYD,
+		inst.BIT_0_IYD, inst.BIT_1_IYD, inst.BIT_2_IYD, inst.BIT_3_IYD,
+		inst.BIT_4_IYD, inst.BIT_5_IYD, inst.BIT_6_IYD, inst.BIT_7_IYD:
+		return memRead, addrIYD
+
+	case inst.LD_IYD_A, inst.LD_IYD_B, inst.LD_IYD_C, inst.LD_IYD_D,
+		inst.LD_IYD_E, inst.LD_IYD_H, inst.LD_IYD_L, inst.LD_IYD_N:
+		return memWrite, addrIYD
+
+	case inst.INC_IYD, inst.DEC_IYD,
+		inst.RLC_IYD, inst.RRC_IYD, inst.RL_IYD, inst.RR_IYD,
+		inst.SLA_IYD, inst.SRA_IYD, inst.SRL_IYD, inst.SLL_IYD,
+		inst.RES_0_IYD, inst.RES_1_IYD, inst.RES_2_IYD, inst.RES_3_IYD,
+		inst.RES_4_IYD, inst.RES_5_IYD, inst.RES_6_IYD, inst.RES_7_IYD,
+		inst.SET_0_IYD, inst.SET_1_IYD, inst.SET_2_IYD, inst.SET_3_IYD,
+		inst.SET_4_IYD, inst.SET_5_IYD, inst.SET_6_IYD, inst.SET_7_IYD:
+		return memRMW, addrIYD
+
+	default:
+		return memNone, addrHL
+	}
+}
@@ -0,0 +1,63 @@
+package cpu
+
+import "github.com/oisee/z80-optimizer/pkg/inst"
+
+// Tracer receives a callback for every instruction ExecTrace executes.
+// Interested is checked first so a tracer that only cares about
+// flag-affecting ops (or only when A changes, checked inside OnExec itself
+// against pre/post) costs nothing for everything else — the same opt-in
+// cost model Ports/RecordingPorts already uses for I/O traces.
+type Tracer interface {
+	Interested(op inst.OpCode) bool
+	OnExec(op inst.OpCode, imm uint16, pre, post State)
+}
+
+// ExecTrace is Exec with an optional observability hook: t == nil (or not
+// Interested in op) costs nothing beyond Exec itself — no State copy, no
+// callback. This is what a differential tester comparing a candidate
+// rewrite against the original sequence instruction-by-instruction (or a
+// future debugger UI) needs, without duplicating Exec's switch.
+func ExecTrace(s *State, op inst.OpCode, imm uint16, t Tracer) int {
+	if t == nil || !t.Interested(op) {
+		return Exec(s, op, imm)
+	}
+	pre := *s
+	tstates := Exec(s, op, imm)
+	t.OnExec(op, imm, pre, *s)
+	return tstates
+}
+
+// TraceEntry records one ExecTrace call as part of a RecordingTracer trace.
+type TraceEntry struct {
+	Op        inst.OpCode
+	Imm       uint16
+	Pre, Post State
+}
+
+// FlagDelta returns which flag bits changed across the instruction (XOR of
+// Pre.F and Post.F) — what a differential tester diffs to explain why two
+// otherwise-equal sequences disagree.
+func (e TraceEntry) FlagDelta() uint8 {
+	return e.Pre.F ^ e.Post.F
+}
+
+// RecordingTracer is a Tracer test double that captures every instruction
+// it's interested in, in order — the CPU-level equivalent of RecordingPorts,
+// for asserting two sequences executed the same ops with the same
+// per-instruction effects, not just the same final state.
+type RecordingTracer struct {
+	Trace []TraceEntry
+	// Filter, if set, restricts which opcodes Interested reports true for;
+	// nil means every opcode is traced.
+	Filter func(inst.OpCode) bool
+}
+
+// Interested reports true for every opcode unless Filter says otherwise.
+func (t *RecordingTracer) Interested(op inst.OpCode) bool {
+	return t.Filter == nil || t.Filter(op)
+}
+
+// OnExec appends a TraceEntry.
+func (t *RecordingTracer) OnExec(op inst.OpCode, imm uint16, pre, post State) {
+	t.Trace = append(t.Trace, TraceEntry{Op: op, Imm: imm, Pre: pre, Post: post})
+}
@@ -0,0 +1,55 @@
+package cpu
+
+import (
+	"testing"
+
+	"github.com/oisee/z80-optimizer/pkg/inst"
+)
+
+func TestExecTraceNilCostsNothing(t *testing.T) {
+	s := State{A: 1, B: 2}
+	want := s
+	Exec(&want, inst.ADD_A_B, 0)
+
+	got := s
+	ExecTrace(&got, inst.ADD_A_B, 0, nil)
+
+	if got != want {
+		t.Fatalf("ExecTrace(nil) = %+v, want %+v", got, want)
+	}
+}
+
+func TestExecTraceRecords(t *testing.T) {
+	rt := &RecordingTracer{}
+	s := State{A: 1, B: 2}
+	ExecTrace(&s, inst.ADD_A_B, 0, rt)
+
+	if len(rt.Trace) != 1 {
+		t.Fatalf("Trace has %d entries, want 1", len(rt.Trace))
+	}
+	e := rt.Trace[0]
+	if e.Op != inst.ADD_A_B || e.Pre.A != 1 || e.Post.A != 3 {
+		t.Fatalf("Trace[0] = %+v, want ADD_A_B with Pre.A=1 Post.A=3", e)
+	}
+}
+
+func TestExecTraceFilter(t *testing.T) {
+	rt := &RecordingTracer{Filter: func(op inst.OpCode) bool { return op == inst.ADD_A_B }}
+	s := State{A: 1, B: 2, C: 3}
+	ExecTrace(&s, inst.LD_A_C, 0, rt)
+	ExecTrace(&s, inst.ADD_A_B, 0, rt)
+
+	if len(rt.Trace) != 1 || rt.Trace[0].Op != inst.ADD_A_B {
+		t.Fatalf("Trace = %+v, want only ADD_A_B", rt.Trace)
+	}
+}
+
+func TestTraceEntryFlagDelta(t *testing.T) {
+	rt := &RecordingTracer{}
+	s := State{A: 0xFF, B: 1}
+	ExecTrace(&s, inst.ADD_A_B, 0, rt)
+
+	if delta := rt.Trace[0].FlagDelta(); delta == 0 {
+		t.Fatalf("FlagDelta() = 0, want nonzero: A+1 overflowing 0xFF should change flags")
+	}
+}
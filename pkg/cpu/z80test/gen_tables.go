@@ -0,0 +1,42 @@
+//go:build ignore
+
+// This program regenerates tables.go's ParityTable. Run it with:
+//
+//	go run gen_tables.go
+//
+// from this directory whenever the table's derivation needs re-deriving —
+// it isn't part of the normal build (see the build tag above), the same
+// way a vendored lookup table elsewhere in this tree would be regenerated
+// by hand rather than computed at init() time, so z80test's reference
+// implementation has zero runtime dependency on cpu's own tables (see
+// reference.go's doc comment for why that independence matters here).
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+func main() {
+	f, err := os.Create("tables.go")
+	if err != nil {
+		panic(err)
+	}
+	defer f.Close()
+
+	fmt.Fprintln(f, "// Code generated by gen_tables.go; DO NOT EDIT.")
+	fmt.Fprintln(f, "package z80test")
+	fmt.Fprintln(f)
+	fmt.Fprintln(f, "// parityTable[v] is true if v has an even number of set bits — the")
+	fmt.Fprintln(f, "// independent parity derivation referenceALU8 uses instead of cpu's own")
+	fmt.Fprintln(f, "// ParityTable, so a bug shared between the two wouldn't hide from fuzzing.")
+	fmt.Fprintln(f, "var parityTable = [256]bool{")
+	for i := 0; i < 256; i++ {
+		bits := 0
+		for v := i; v != 0; v &= v - 1 {
+			bits++
+		}
+		fmt.Fprintf(f, "\t%d: %v,\n", i, bits%2 == 0)
+	}
+	fmt.Fprintln(f, "}")
+}
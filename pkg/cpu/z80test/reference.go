@@ -0,0 +1,90 @@
+// Package z80test differentially fuzzes pkg/cpu.Exec against a from-scratch
+// reference ALU implementation for the subset of opcodes that operate on A
+// with an 8-bit operand (ADD/SUB/AND/OR/XOR/CP A,n).
+//
+// Scope: this isn't a second full Z80 core — just that one opcode family,
+// the same "useful subset first" call the rest of this tree's waves make
+// (see e.g. pkg/cpu.ExecAbstract's doc comment). The point of a
+// differential reference isn't coverage, it's independence: referenceALU8
+// below derives S/Z/H/P/N/C/bit3/bit5 straight from the 9-bit add/subtract
+// identity (the same carry-in-per-bit technique exec_test.go's negSilicon
+// already uses for NEG) and its own parityTable, never touching
+// cpu.HalfcarryAddTable/OverflowAddTable/Sz53pTable/ParityTable — so a bug
+// shared between cpu's tables and this package's own math wouldn't hide
+// from FuzzALU8.
+package z80test
+
+import "github.com/oisee/z80-optimizer/pkg/cpu"
+
+// aluOp names the 6 operations referenceALU8 and FuzzALU8 cover.
+type aluOp int
+
+const (
+	opAdd aluOp = iota
+	opSub
+	opAnd
+	opOr
+	opXor
+	opCp
+)
+
+// referenceALU8 computes a OP n and the resulting flags, independently of
+// cpu's own lookup tables.
+func referenceALU8(op aluOp, a, n uint8) (result, flags uint8) {
+	switch op {
+	case opAdd:
+		result = a + n
+		ci := a ^ n ^ result // carry-into-each-bit bitfield
+		if ci&0x10 != 0 {
+			flags |= cpu.FlagH
+		}
+		if (a^result)&(n^result)&0x80 != 0 {
+			flags |= cpu.FlagV
+		}
+		if uint16(a)+uint16(n) > 0xFF {
+			flags |= cpu.FlagC
+		}
+	case opSub, opCp:
+		result = a - n
+		ci := a ^ n ^ result
+		if ci&0x10 != 0 {
+			flags |= cpu.FlagH
+		}
+		if (a^n)&(a^result)&0x80 != 0 {
+			flags |= cpu.FlagV
+		}
+		if uint16(n) > uint16(a) {
+			flags |= cpu.FlagC
+		}
+		flags |= cpu.FlagN
+	case opAnd:
+		result = a & n
+		flags |= cpu.FlagH
+	case opOr:
+		result = a | n
+	case opXor:
+		result = a ^ n
+	}
+
+	// CP computes S/Z/H/P/N/C exactly like SUB and simply discards the
+	// result byte (real CP A,n never writes A) — except its undocumented
+	// bit3/bit5 are a real silicon quirk: CP copies them from the operand
+	// n, not from the A-n result the other five ops use.
+	bit35Src := result
+	if op == opCp {
+		bit35Src = n
+	}
+	if result == 0 {
+		flags |= cpu.FlagZ
+	}
+	flags |= result & cpu.FlagS
+	flags |= bit35Src & (cpu.Flag5 | cpu.Flag3)
+	// Parity and overflow share bit 0x04 but are computed completely
+	// differently; ADD/SUB/CP already set FlagV (signed overflow) above,
+	// so only AND/OR/XOR — which have no overflow concept — derive that
+	// bit from parityTable instead.
+	if (op == opAnd || op == opOr || op == opXor) && parityTable[result] {
+		flags |= cpu.FlagP
+	}
+	return result, flags
+}
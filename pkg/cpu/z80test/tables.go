@@ -0,0 +1,264 @@
+// Code generated by gen_tables.go; DO NOT EDIT.
+package z80test
+
+// parityTable[v] is true if v has an even number of set bits — the
+// independent parity derivation referenceALU8 uses instead of cpu's own
+// ParityTable, so a bug shared between the two wouldn't hide from fuzzing.
+var parityTable = [256]bool{
+	0: true,
+	1: false,
+	2: false,
+	3: true,
+	4: false,
+	5: true,
+	6: true,
+	7: false,
+	8: false,
+	9: true,
+	10: true,
+	11: false,
+	12: true,
+	13: false,
+	14: false,
+	15: true,
+	16: false,
+	17: true,
+	18: true,
+	19: false,
+	20: true,
+	21: false,
+	22: false,
+	23: true,
+	24: true,
+	25: false,
+	26: false,
+	27: true,
+	28: false,
+	29: true,
+	30: true,
+	31: false,
+	32: false,
+	33: true,
+	34: true,
+	35: false,
+	36: true,
+	37: false,
+	38: false,
+	39: true,
+	40: true,
+	41: false,
+	42: false,
+	43: true,
+	44: false,
+	45: true,
+	46: true,
+	47: false,
+	48: true,
+	49: false,
+	50: false,
+	51: true,
+	52: false,
+	53: true,
+	54: true,
+	55: false,
+	56: false,
+	57: true,
+	58: true,
+	59: false,
+	60: true,
+	61: false,
+	62: false,
+	63: true,
+	64: false,
+	65: true,
+	66: true,
+	67: false,
+	68: true,
+	69: false,
+	70: false,
+	71: true,
+	72: true,
+	73: false,
+	74: false,
+	75: true,
+	76: false,
+	77: true,
+	78: true,
+	79: false,
+	80: true,
+	81: false,
+	82: false,
+	83: true,
+	84: false,
+	85: true,
+	86: true,
+	87: false,
+	88: false,
+	89: true,
+	90: true,
+	91: false,
+	92: true,
+	93: false,
+	94: false,
+	95: true,
+	96: true,
+	97: false,
+	98: false,
+	99: true,
+	100: false,
+	101: true,
+	102: true,
+	103: false,
+	104: false,
+	105: true,
+	106: true,
+	107: false,
+	108: true,
+	109: false,
+	110: false,
+	111: true,
+	112: false,
+	113: true,
+	114: true,
+	115: false,
+	116: true,
+	117: false,
+	118: false,
+	119: true,
+	120: true,
+	121: false,
+	122: false,
+	123: true,
+	124: false,
+	125: true,
+	126: true,
+	127: false,
+	128: false,
+	129: true,
+	130: true,
+	131: false,
+	132: true,
+	133: false,
+	134: false,
+	135: true,
+	136: true,
+	137: false,
+	138: false,
+	139: true,
+	140: false,
+	141: true,
+	142: true,
+	143: false,
+	144: true,
+	145: false,
+	146: false,
+	147: true,
+	148: false,
+	149: true,
+	150: true,
+	151: false,
+	152: false,
+	153: true,
+	154: true,
+	155: false,
+	156: true,
+	157: false,
+	158: false,
+	159: true,
+	160: true,
+	161: false,
+	162: false,
+	163: true,
+	164: false,
+	165: true,
+	166: true,
+	167: false,
+	168: false,
+	169: true,
+	170: true,
+	171: false,
+	172: true,
+	173: false,
+	174: false,
+	175: true,
+	176: false,
+	177: true,
+	178: true,
+	179: false,
+	180: true,
+	181: false,
+	182: false,
+	183: true,
+	184: true,
+	185: false,
+	186: false,
+	187: true,
+	188: false,
+	189: true,
+	190: true,
+	191: false,
+	192: true,
+	193: false,
+	194: false,
+	195: true,
+	196: false,
+	197: true,
+	198: true,
+	199: false,
+	200: false,
+	201: true,
+	202: true,
+	203: false,
+	204: true,
+	205: false,
+	206: false,
+	207: true,
+	208: false,
+	209: true,
+	210: true,
+	211: false,
+	212: true,
+	213: false,
+	214: false,
+	215: true,
+	216: true,
+	217: false,
+	218: false,
+	219: true,
+	220: false,
+	221: true,
+	222: true,
+	223: false,
+	224: false,
+	225: true,
+	226: true,
+	227: false,
+	228: true,
+	229: false,
+	230: false,
+	231: true,
+	232: true,
+	233: false,
+	234: false,
+	235: true,
+	236: false,
+	237: true,
+	238: true,
+	239: false,
+	240: true,
+	241: false,
+	242: false,
+	243: true,
+	244: false,
+	245: true,
+	246: true,
+	247: false,
+	248: false,
+	249: true,
+	250: true,
+	251: false,
+	252: true,
+	253: false,
+	254: false,
+	255: true,
+}
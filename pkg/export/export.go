@@ -0,0 +1,37 @@
+// Package export renders discovered optimization rules into formats other
+// toolchains can consume directly, instead of leaving integration as a
+// manual step after `verify`: a Go peephole table for a downstream pass in
+// this repo's own family, and peephole rule syntax for three assemblers
+// (SDCC, z88dk, sjasmplus) that already have their own peephole optimizers.
+// Modeled on pkg/asm's Flavor: one Emitter interface, a built-in registry,
+// and a ByName lookup for the --format flag.
+package export
+
+import (
+	"io"
+
+	"github.com/oisee/z80-optimizer/pkg/result"
+)
+
+// Emitter renders rules as text in one target format.
+type Emitter interface {
+	// Name identifies the format, e.g. for --format and error messages.
+	Name() string
+	// Emit writes rules to w in this format.
+	Emit(w io.Writer, rules []result.Rule) error
+}
+
+// Emitters lists every built-in Emitter by name, for --format validation and
+// help text.
+var Emitters = map[string]Emitter{
+	"go":         GoEmitter{},
+	"sdcc-peep":  SDCCEmitter{},
+	"z88dk-peep": Z88dkEmitter{},
+	"sjasmplus":  SjasmplusEmitter{},
+}
+
+// ByName looks up an Emitter by its --format name.
+func ByName(name string) (Emitter, bool) {
+	e, ok := Emitters[name]
+	return e, ok
+}
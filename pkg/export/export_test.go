@@ -0,0 +1,97 @@
+package export
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/oisee/z80-optimizer/pkg/inst"
+	"github.com/oisee/z80-optimizer/pkg/result"
+)
+
+func incIncRule() result.Rule {
+	return result.Rule{
+		Source:      []inst.Instruction{{Op: inst.INC_A}, {Op: inst.INC_A}},
+		Replacement: []inst.Instruction{{Op: inst.ADD_A_N, Imm: 2}},
+		BytesSaved:  1,
+		CyclesSaved: 3,
+	}
+}
+
+func TestByNameFindsEveryBuiltinEmitter(t *testing.T) {
+	for _, name := range []string{"go", "sdcc-peep", "z88dk-peep", "sjasmplus"} {
+		if _, ok := ByName(name); !ok {
+			t.Errorf("ByName(%q) not found", name)
+		}
+	}
+	if _, ok := ByName("nope"); ok {
+		t.Error("ByName(\"nope\") should not be found")
+	}
+}
+
+func TestGoEmitterProducesValidMustOpLookups(t *testing.T) {
+	var buf bytes.Buffer
+	if err := (GoEmitter{}).Emit(&buf, []result.Rule{incIncRule()}); err != nil {
+		t.Fatalf("Emit: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{`mustOp("INC A")`, `mustOp("ADD A, n")`, "Imm: 2"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestSDCCEmitterSkipsDeadFlagRules(t *testing.T) {
+	r := incIncRule()
+	r.DeadFlags = 0xFF
+
+	var buf bytes.Buffer
+	if err := (SDCCEmitter{}).Emit(&buf, []result.Rule{r}); err != nil {
+		t.Fatalf("Emit: %v", err)
+	}
+	out := buf.String()
+	if strings.Contains(out, "replace {") {
+		t.Errorf("expected dead-flags rule to be skipped, not rendered:\n%s", out)
+	}
+	if !strings.Contains(out, "skipped:") {
+		t.Errorf("expected a skip comment:\n%s", out)
+	}
+}
+
+func TestPeepholeCollapsesSharedImmediateNotDerivedOne(t *testing.T) {
+	// LD A,5 : ADD A,5 -> ADD A,5 : LD A,5 — the "5" recurs identically, so
+	// it should collapse to %1 on both sides.
+	shared := result.Rule{
+		Source:      []inst.Instruction{{Op: inst.LD_A_N, Imm: 5}, {Op: inst.ADD_A_N, Imm: 5}},
+		Replacement: []inst.Instruction{{Op: inst.ADD_A_N, Imm: 5}, {Op: inst.LD_A_N, Imm: 5}},
+	}
+	var buf bytes.Buffer
+	if err := (SDCCEmitter{}).Emit(&buf, []result.Rule{shared}); err != nil {
+		t.Fatalf("Emit: %v", err)
+	}
+	if strings.Count(buf.String(), "%1") != 4 {
+		t.Errorf("expected %%1 to appear 4 times (twice per block):\n%s", buf.String())
+	}
+
+	// INC A : INC A -> ADD A,2 — "2" doesn't appear in the source at all,
+	// so it must stay a literal, not collapse to a placeholder.
+	buf.Reset()
+	if err := (SDCCEmitter{}).Emit(&buf, []result.Rule{incIncRule()}); err != nil {
+		t.Fatalf("Emit: %v", err)
+	}
+	if strings.Contains(buf.String(), "%1") {
+		t.Errorf("derived constant should not collapse to a placeholder:\n%s", buf.String())
+	}
+}
+
+func TestSjasmplusEmitterNamesEachMacro(t *testing.T) {
+	var buf bytes.Buffer
+	if err := (SjasmplusEmitter{}).Emit(&buf, []result.Rule{incIncRule(), incIncRule()}); err != nil {
+		t.Fatalf("Emit: %v", err)
+	}
+	out := buf.String()
+	if !strings.Contains(out, "MACRO Z80OPT_RULE_1") || !strings.Contains(out, "MACRO Z80OPT_RULE_2") {
+		t.Errorf("expected both macros to be named distinctly:\n%s", out)
+	}
+}
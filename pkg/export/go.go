@@ -0,0 +1,103 @@
+package export
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/oisee/z80-optimizer/pkg/inst"
+	"github.com/oisee/z80-optimizer/pkg/result"
+)
+
+// GoEmitter renders rules as a `var Rules = []Rule{...}` literal for a
+// downstream Go peephole pass. Instructions are keyed by inst.Catalog
+// mnemonic (resolved at init time via a generated mustOp helper) rather than
+// by inst.OpCode value, so the table stays readable and survives an OpCode
+// renumbering the same way pkg/result's checkpoint envelope does (chunk4-4).
+type GoEmitter struct{}
+
+func (GoEmitter) Name() string { return "go" }
+
+func (GoEmitter) Emit(w io.Writer, rules []result.Rule) error {
+	fmt.Fprintln(w, "// Code generated by z80opt export -f go. DO NOT EDIT.")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "package peephole")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, `import "github.com/oisee/z80-optimizer/pkg/inst"`)
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "// Rule is a single optimization: replacing Source with Replacement.")
+	fmt.Fprintln(w, "type Rule struct {")
+	fmt.Fprintln(w, "\tSource      []inst.Instruction")
+	fmt.Fprintln(w, "\tReplacement []inst.Instruction")
+	fmt.Fprintln(w, "\tBytesSaved  int")
+	fmt.Fprintln(w, "\tCyclesSaved int")
+	fmt.Fprintln(w, "}")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "// mustOp resolves mnemonic against this build's inst.Catalog. Rules is")
+	fmt.Fprintln(w, "// generated against mnemonics rather than inst.OpCode values, so it stays")
+	fmt.Fprintln(w, "// valid across an OpCode renumbering; mustOp panics only if this binary's")
+	fmt.Fprintln(w, "// catalog has dropped a mnemonic the rules below depend on.")
+	fmt.Fprintln(w, "func mustOp(mnemonic string) inst.OpCode {")
+	fmt.Fprintln(w, "\top, ok := inst.OpCodeByName(mnemonic)")
+	fmt.Fprintln(w, "\tif !ok {")
+	fmt.Fprintln(w, "\t\tpanic(\"unknown opcode mnemonic: \" + mnemonic)")
+	fmt.Fprintln(w, "\t}")
+	fmt.Fprintln(w, "\treturn op")
+	fmt.Fprintln(w, "}")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "// Rules is the exported peephole table: each entry replaces Source with")
+	fmt.Fprintln(w, "// Replacement wherever it appears verbatim.")
+	fmt.Fprintln(w, "var Rules = []Rule{")
+	for _, r := range rules {
+		fmt.Fprintf(w, "\t{ // %s -> %s\n", disasmSeq(r.Source), disasmSeq(r.Replacement))
+		fmt.Fprintf(w, "\t\tSource:      []inst.Instruction{%s},\n", goInstructions(r.Source))
+		fmt.Fprintf(w, "\t\tReplacement: []inst.Instruction{%s},\n", goInstructions(r.Replacement))
+		fmt.Fprintf(w, "\t\tBytesSaved:  %d,\n", r.BytesSaved)
+		fmt.Fprintf(w, "\t\tCyclesSaved: %d,\n", r.CyclesSaved)
+		fmt.Fprintln(w, "\t},")
+	}
+	fmt.Fprintln(w, "}")
+	return nil
+}
+
+// goInstructions renders seq as comma-separated inst.Instruction{...} Go
+// literals.
+func goInstructions(seq []inst.Instruction) string {
+	out := ""
+	for i, instr := range seq {
+		if i > 0 {
+			out += ", "
+		}
+		out += goInstruction(instr)
+	}
+	return out
+}
+
+// goInstruction renders one inst.Instruction as a Go struct literal,
+// including Imm/Disp fields only when this opcode actually carries one.
+func goInstruction(instr inst.Instruction) string {
+	mnemonic := inst.OpCodeName(instr.Op)
+	switch {
+	case inst.HasIndexDisp(instr.Op) && inst.HasImmediate(instr.Op):
+		return fmt.Sprintf("{Op: mustOp(%q), Imm: %d, Disp: %d}", mnemonic, instr.Imm, instr.Disp)
+	case inst.HasIndexDisp(instr.Op):
+		return fmt.Sprintf("{Op: mustOp(%q), Disp: %d}", mnemonic, instr.Disp)
+	case inst.HasImmediate(instr.Op):
+		return fmt.Sprintf("{Op: mustOp(%q), Imm: %d}", mnemonic, instr.Imm)
+	default:
+		return fmt.Sprintf("{Op: mustOp(%q)}", mnemonic)
+	}
+}
+
+// disasmSeq renders seq as assembly text, " : "-joined — mirrors
+// pkg/result's unexported disasmSeq, kept separate since exporting it across
+// the package boundary isn't worth the API surface for a comment string.
+func disasmSeq(seq []inst.Instruction) string {
+	out := ""
+	for i, instr := range seq {
+		if i > 0 {
+			out += " : "
+		}
+		out += inst.Disassemble(instr)
+	}
+	return out
+}
@@ -0,0 +1,114 @@
+package export
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/oisee/z80-optimizer/pkg/inst"
+	"github.com/oisee/z80-optimizer/pkg/result"
+)
+
+// peepholeRule is one rule rendered into SDCC/z88dk peephole syntax, shared
+// by SDCCEmitter and Z88dkEmitter since the two dialects' "replace { ... } by
+// { ... }" block shape is otherwise identical.
+type peepholeRule struct {
+	Source      []string
+	Replacement []string
+	BytesSaved  int
+	CyclesSaved int
+}
+
+// peepholeSkip explains why a rule can't be rendered as a live peephole rule
+// — currently only a dead-flags/dead-regs dependency, since neither dialect's
+// peephole syntax can express "these flags are allowed to come out wrong".
+type peepholeSkip struct {
+	Rule   result.Rule
+	Reason string
+}
+
+// buildPeephole renders rules into live peephole rules plus a list of rules
+// this format had to skip.
+func buildPeephole(rules []result.Rule) ([]peepholeRule, []peepholeSkip) {
+	var live []peepholeRule
+	var skipped []peepholeSkip
+	for _, r := range rules {
+		if r.DeadFlags != 0 || r.DeadRegs != 0 {
+			skipped = append(skipped, peepholeSkip{
+				Rule: r,
+				Reason: fmt.Sprintf("equivalence only holds with dead flags %s ignored — SDCC/z88dk peephole rules can't condition on flag liveness",
+					result.DeadFlagDesc(r.DeadFlags)),
+			})
+			continue
+		}
+		placeholders := collapsibleOperands(r.Source, r.Replacement)
+		live = append(live, peepholeRule{
+			Source:      renderOperands(r.Source, placeholders),
+			Replacement: renderOperands(r.Replacement, placeholders),
+			BytesSaved:  r.BytesSaved,
+			CyclesSaved: r.CyclesSaved,
+		})
+	}
+	return live, skipped
+}
+
+// collapsibleOperands assigns %1, %2, ... to each distinct immediate/
+// displacement value seen in source, in order of first appearance. A
+// replacement operand only reuses a placeholder if its value is bit-for-bit
+// the same as the source operand it replaces — that's the "safe" case,
+// since the rule then generalizes to any value at that position. A
+// replacement constant that's instead derived from the source (e.g. "INC A :
+// INC A" collapsing to the literal 2 in "ADD A, 2") stays a literal: it's
+// only correct for this exact pair, not for an arbitrary matched operand.
+func collapsibleOperands(source, replacement []inst.Instruction) map[uint16]string {
+	placeholders := make(map[uint16]string)
+	for _, instr := range source {
+		if !hasCollapsibleOperand(instr) {
+			continue
+		}
+		if _, ok := placeholders[instr.Imm]; !ok {
+			placeholders[instr.Imm] = fmt.Sprintf("%%%d", len(placeholders)+1)
+		}
+	}
+	return placeholders
+}
+
+// hasCollapsibleOperand reports whether instr carries a single plain
+// immediate operand this package knows how to generalize. IX/IY-displaced
+// forms (and LD (IX+d),n, which carries both a displacement and an
+// immediate) are left as literals — collapsing two independent operands at
+// once isn't worth the complexity for what's meant to be a best-effort
+// export, not a full peephole-rule compiler.
+func hasCollapsibleOperand(instr inst.Instruction) bool {
+	return inst.HasImmediate(instr.Op) && !inst.HasIndexDisp(instr.Op) && !inst.HasBranchTarget(instr.Op)
+}
+
+// renderOperands renders seq as peephole-rule operand text, substituting a
+// collapsed placeholder for any instruction whose exact immediate value is
+// in placeholders.
+func renderOperands(seq []inst.Instruction, placeholders map[uint16]string) []string {
+	out := make([]string, len(seq))
+	for i, instr := range seq {
+		if hasCollapsibleOperand(instr) {
+			if label, ok := placeholders[instr.Imm]; ok {
+				out[i] = substituteImmediate(inst.Catalog[instr.Op].Mnemonic, label)
+				continue
+			}
+		}
+		out[i] = inst.Disassemble(instr)
+	}
+	return out
+}
+
+// substituteImmediate replaces mnemonic's "n"/"nn" operand placeholder with
+// label — the same placeholder convention inst.Disassemble's disasmImm8/
+// disasmImm16 substitute a hex literal into, just substituting label text
+// instead.
+func substituteImmediate(mnemonic, label string) string {
+	if i := strings.Index(mnemonic, "nn"); i >= 0 {
+		return mnemonic[:i] + label + mnemonic[i+2:]
+	}
+	if i := strings.LastIndexByte(mnemonic, 'n'); i >= 0 {
+		return mnemonic[:i] + label + mnemonic[i+1:]
+	}
+	return mnemonic
+}
@@ -0,0 +1,48 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/oisee/z80-optimizer/pkg/result"
+)
+
+// SDCCEmitter renders rules as SDCC peephole rules: "replace { ... } by
+// { ... }" blocks in SDCC's own z80 peephole optimizer syntax.
+type SDCCEmitter struct{}
+
+func (SDCCEmitter) Name() string { return "sdcc-peep" }
+
+func (SDCCEmitter) Emit(w io.Writer, rules []result.Rule) error {
+	live, skipped := buildPeephole(rules)
+	for _, s := range skipped {
+		fmt.Fprintf(w, "// skipped: %s\n", s.Reason)
+	}
+	if len(skipped) > 0 && len(live) > 0 {
+		fmt.Fprintln(w)
+	}
+	for i, r := range live {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintln(w, "replace {")
+		fmt.Fprint(w, indentLines(r.Source))
+		fmt.Fprintln(w, "} by {")
+		fmt.Fprint(w, indentLines(r.Replacement))
+		fmt.Fprintf(w, "} // saves %d byte(s), %d cycle(s)\n", r.BytesSaved, r.CyclesSaved)
+	}
+	return nil
+}
+
+// indentLines renders lines as one tab-indented SDCC/z88dk instruction per
+// line, lowercased to match SDCC's own generated asm.
+func indentLines(lines []string) string {
+	var b strings.Builder
+	for _, l := range lines {
+		b.WriteByte('\t')
+		b.WriteString(strings.ToLower(l))
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
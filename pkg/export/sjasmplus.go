@@ -0,0 +1,39 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/oisee/z80-optimizer/pkg/inst"
+	"github.com/oisee/z80-optimizer/pkg/result"
+)
+
+// SjasmplusEmitter renders each rule as an sjasmplus MACRO expanding to the
+// replacement, with a comment carrying the source it replaces — unlike the
+// SDCC/z88dk peephole formats, this isn't a search-and-replace rule a tool
+// applies automatically; it's something a human invokes in place of having
+// hand-written the source sequence, so there's no dead-flags precondition to
+// express.
+type SjasmplusEmitter struct{}
+
+func (SjasmplusEmitter) Name() string { return "sjasmplus" }
+
+func (SjasmplusEmitter) Emit(w io.Writer, rules []result.Rule) error {
+	for i, r := range rules {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		name := fmt.Sprintf("Z80OPT_RULE_%d", i+1)
+		fmt.Fprintf(w, "; replaces %q (saves %d byte(s), %d cycle(s))\n", disasmSeq(r.Source), r.BytesSaved, r.CyclesSaved)
+		if r.DeadFlags != 0 || r.DeadRegs != 0 {
+			fmt.Fprintf(w, "; only equivalent with dead flags %s ignored\n", result.DeadFlagDesc(r.DeadFlags))
+		}
+		fmt.Fprintf(w, "MACRO %s\n", name)
+		for _, instr := range r.Replacement {
+			fmt.Fprintf(w, "\t%s\n", strings.ToLower(inst.Disassemble(instr)))
+		}
+		fmt.Fprintln(w, "ENDM")
+	}
+	return nil
+}
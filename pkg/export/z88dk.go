@@ -0,0 +1,37 @@
+package export
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/oisee/z80-optimizer/pkg/result"
+)
+
+// Z88dkEmitter renders rules as z88dk peephole rules. z88dk's z80asm
+// peephole optimizer borrows SDCC's "replace { ... } by { ... }" block shape
+// verbatim, but comments ";"-style rather than "//"-style like the rest of
+// z88dk's own assembly.
+type Z88dkEmitter struct{}
+
+func (Z88dkEmitter) Name() string { return "z88dk-peep" }
+
+func (Z88dkEmitter) Emit(w io.Writer, rules []result.Rule) error {
+	live, skipped := buildPeephole(rules)
+	for _, s := range skipped {
+		fmt.Fprintf(w, "; skipped: %s\n", s.Reason)
+	}
+	if len(skipped) > 0 && len(live) > 0 {
+		fmt.Fprintln(w)
+	}
+	for i, r := range live {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintln(w, "replace {")
+		fmt.Fprint(w, indentLines(r.Source))
+		fmt.Fprintln(w, "} by {")
+		fmt.Fprint(w, indentLines(r.Replacement))
+		fmt.Fprintf(w, "} ; saves %d byte(s), %d cycle(s)\n", r.BytesSaved, r.CyclesSaved)
+	}
+	return nil
+}
@@ -106,6 +106,82 @@ func (cp *CUDAProcess) QuickCheckGPU(fp [search.FingerprintLen]byte, deadFlags s
 	return matches, nil
 }
 
+// QuickCheckGPUBatch sends N (fingerprint, dead_flags) records in one
+// stdin/stdout round trip instead of N separate QuickCheckGPU calls
+// (chunk6-3) — the server-side protocol extension is a batch_count header
+// followed by batch_count records of the same shape QuickCheckGPU already
+// writes, and a response of batch_count (match_count, matches) records in
+// the same order. fps and deadFlags must be the same length; the result
+// slice is indexed the same way.
+func (cp *CUDAProcess) QuickCheckGPUBatch(fps [][search.FingerprintLen]byte, deadFlags []search.FlagMask) ([][]uint32, error) {
+	if len(fps) != len(deadFlags) {
+		return nil, fmt.Errorf("cuda: QuickCheckGPUBatch: len(fps)=%d != len(deadFlags)=%d", len(fps), len(deadFlags))
+	}
+
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+
+	if err := binary.Write(cp.stdin, binary.LittleEndian, uint32(len(fps))); err != nil {
+		return nil, fmt.Errorf("cuda: write batch_count: %w", err)
+	}
+	for i, fp := range fps {
+		if _, err := cp.stdin.Write(fp[:]); err != nil {
+			return nil, fmt.Errorf("cuda: write fingerprint %d: %w", i, err)
+		}
+		if err := binary.Write(cp.stdin, binary.LittleEndian, uint32(deadFlags[i])); err != nil {
+			return nil, fmt.Errorf("cuda: write dead_flags %d: %w", i, err)
+		}
+	}
+
+	results := make([][]uint32, len(fps))
+	for i := range fps {
+		var matchCount uint32
+		if err := binary.Read(cp.stdout, binary.LittleEndian, &matchCount); err != nil {
+			return nil, fmt.Errorf("cuda: read match_count %d: %w", i, err)
+		}
+		if matchCount == 0 {
+			continue
+		}
+		matches := make([]uint32, matchCount)
+		if err := binary.Read(cp.stdout, binary.LittleEndian, matches); err != nil {
+			return nil, fmt.Errorf("cuda: read matches %d: %w", i, err)
+		}
+		results[i] = matches
+	}
+
+	return results, nil
+}
+
+// QuickCheckGPUResult is the future QuickCheckGPUAsync returns: Matches/Err
+// are only valid after Wait returns.
+type QuickCheckGPUResult struct {
+	Matches []uint32
+	Err     error
+	done    chan struct{}
+}
+
+// Wait blocks until the GPU round trip this future represents completes,
+// then returns its result.
+func (r *QuickCheckGPUResult) Wait() ([]uint32, error) {
+	<-r.done
+	return r.Matches, r.Err
+}
+
+// QuickCheckGPUAsync dispatches a QuickCheckGPU call on a background
+// goroutine and returns immediately with a future, so a caller like
+// processTask can keep doing CPU-side pruning on the next target while this
+// one's GPU verification is still in flight (chunk6-3). Callers that issue
+// several of these concurrently still serialize at cp.mu — the benefit is
+// overlapping the wait with unrelated CPU work, not concurrent GPU access.
+func (cp *CUDAProcess) QuickCheckGPUAsync(fp [search.FingerprintLen]byte, deadFlags search.FlagMask) *QuickCheckGPUResult {
+	r := &QuickCheckGPUResult{done: make(chan struct{})}
+	go func() {
+		defer close(r.done)
+		r.Matches, r.Err = cp.QuickCheckGPU(fp, deadFlags)
+	}()
+	return r
+}
+
 // Close shuts down the CUDA process.
 func (cp *CUDAProcess) Close() error {
 	cp.stdin.Close()
@@ -20,14 +20,14 @@ func TestCUDAProcess_BasicQuickCheck(t *testing.T) {
 
 	// Use a small set of candidates for testing
 	candidates := []inst.Instruction{
-		{Op: inst.XOR_A},                  // 0: XOR A — zeros A, sets Z+P
-		{Op: inst.LD_A_N, Imm: 0},        // 1: LD A,0 — zeros A, no flag change
-		{Op: inst.AND_A},                  // 2: AND A — H flag set
-		{Op: inst.OR_A},                   // 3: OR A — no flag H
-		{Op: inst.ADD_A_B},               // 4: ADD A,B
-		{Op: inst.SUB_B},                 // 5: SUB B
-		{Op: inst.NOP},                    // 6: NOP
-		{Op: inst.LD_A_N, Imm: 0x42},     // 7: LD A,0x42
+		{Op: inst.XOR_A},             // 0: XOR A — zeros A, sets Z+P
+		{Op: inst.LD_A_N, Imm: 0},    // 1: LD A,0 — zeros A, no flag change
+		{Op: inst.AND_A},             // 2: AND A — H flag set
+		{Op: inst.OR_A},              // 3: OR A — no flag H
+		{Op: inst.ADD_A_B},           // 4: ADD A,B
+		{Op: inst.SUB_B},             // 5: SUB B
+		{Op: inst.NOP},               // 6: NOP
+		{Op: inst.LD_A_N, Imm: 0x42}, // 7: LD A,0x42
 	}
 
 	cuda, err := NewCUDAProcess(candidates, 1)
@@ -155,3 +155,69 @@ func TestCUDAProcess_AllOpcodes(t *testing.T) {
 		}
 	}
 }
+
+func TestCUDAProcess_QuickCheckGPUBatch(t *testing.T) {
+	requireCUDA(t)
+
+	candidates := []inst.Instruction{
+		{Op: inst.XOR_A},             // 0
+		{Op: inst.NOP},               // 1
+		{Op: inst.LD_A_N, Imm: 0x42}, // 2
+	}
+
+	cuda, err := NewCUDAProcess(candidates, 1)
+	if err != nil {
+		t.Fatalf("NewCUDAProcess: %v", err)
+	}
+	defer cuda.Close()
+
+	fps := [][search.FingerprintLen]byte{
+		search.Fingerprint([]inst.Instruction{{Op: inst.XOR_A}}),
+		search.Fingerprint([]inst.Instruction{{Op: inst.NOP}}),
+		search.Fingerprint([]inst.Instruction{{Op: inst.LD_A_N, Imm: 0x42}}),
+	}
+	deadFlags := make([]search.FlagMask, len(fps))
+
+	batchHits, err := cuda.QuickCheckGPUBatch(fps, deadFlags)
+	if err != nil {
+		t.Fatalf("QuickCheckGPUBatch: %v", err)
+	}
+	if len(batchHits) != len(fps) {
+		t.Fatalf("QuickCheckGPUBatch returned %d results, want %d", len(batchHits), len(fps))
+	}
+
+	want := []uint32{0, 1, 2}
+	for i, hits := range batchHits {
+		found := false
+		for _, idx := range hits {
+			if idx == want[i] {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("batch item %d: candidate %d should match its own fingerprint, got hits: %v", i, want[i], hits)
+		}
+	}
+}
+
+func TestCUDAProcess_QuickCheckGPUAsync(t *testing.T) {
+	requireCUDA(t)
+
+	candidates := []inst.Instruction{{Op: inst.XOR_A}}
+	cuda, err := NewCUDAProcess(candidates, 1)
+	if err != nil {
+		t.Fatalf("NewCUDAProcess: %v", err)
+	}
+	defer cuda.Close()
+
+	fp := search.Fingerprint([]inst.Instruction{{Op: inst.XOR_A}})
+	future := cuda.QuickCheckGPUAsync(fp, 0)
+
+	hits, err := future.Wait()
+	if err != nil {
+		t.Fatalf("QuickCheckGPUAsync: %v", err)
+	}
+	if len(hits) != 1 || hits[0] != 0 {
+		t.Errorf("QuickCheckGPUAsync hits = %v, want [0]", hits)
+	}
+}
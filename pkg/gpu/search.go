@@ -15,6 +15,27 @@ type SearchConfig struct {
 	MaxCandLen   int
 	Verbose      bool
 	DeadFlags    search.FlagMask
+
+	// DeadFlagsAt overrides DeadFlags with a per-target mask — the hook a
+	// whole-program superopt run wires pkg/liveness's CFG-aware analysis
+	// into (chunk8-3), so a target pulled from a real program's window uses
+	// the dead-flag mask that's actually safe at that site (e.g.
+	// liveness.LivenessInfo.DeadFlagsAt for the window's end position)
+	// instead of one DeadFlags constant applied to every target searched.
+	// Left nil, searchLengthGPU falls back to DeadFlags as before.
+	DeadFlagsAt func(target []inst.Instruction) search.FlagMask
+
+	// OnRule mirrors search.Config.OnRule (chunk7-3): called synchronously
+	// each time a new rule is added to the result table.
+	OnRule func(result.Rule)
+
+	// Prior, if set, is a previously-saved rule database (result.LoadTable,
+	// chunk8-5): searchLengthGPU skips any target whose result.CanonicalKey
+	// already has an entry in it, since Merge's ruleBetter selection means
+	// whatever's there is already the best rule known for that target. This
+	// is what turns a multi-day GPU run into a resumable, composable job
+	// instead of one that always starts from scratch.
+	Prior *result.Table
 }
 
 // SearchGPU runs the superoptimizer search using CUDA GPU-accelerated QuickCheck.
@@ -52,6 +73,9 @@ func SearchGPU(cfg SearchConfig) (*result.Table, error) {
 	}
 
 	results := result.NewTable()
+	if cfg.OnRule != nil {
+		results.SetOnRule(cfg.OnRule)
+	}
 
 	for targetLen := 2; targetLen <= cfg.MaxTargetLen; targetLen++ {
 		candLen := targetLen - 1
@@ -76,14 +100,36 @@ func SearchGPU(cfg SearchConfig) (*result.Table, error) {
 	return results, nil
 }
 
-// searchLengthGPU searches for optimizations for targets of a specific length.
+// gpuBatchSize caps how many targets' fingerprints go into one
+// QuickCheckGPUBatch dispatch (chunk6-3). Large enough to amortize the
+// stdin/stdout round trip, small enough that a batch's matches still fit
+// comfortably in memory and progress reporting stays responsive.
+const gpuBatchSize = 1024
+
+// searchLengthGPU searches for optimizations for targets of a specific
+// length. Targets are dispatched to the GPU in batches of gpuBatchSize via
+// QuickCheckGPUBatch (chunk6-3) instead of one QuickCheckGPU round trip per
+// target, since the CUDA server's dominant per-call cost is the pipe
+// round-trip, not the check itself.
 func searchLengthGPU(cuda *CUDAProcess, candidates []inst.Instruction, targetLen, candLen int, cfg SearchConfig, results *result.Table) error {
+	var covered map[string]bool
+	if cfg.Prior != nil {
+		priorRules := cfg.Prior.Rules()
+		covered = make(map[string]bool, len(priorRules))
+		for _, r := range priorRules {
+			covered[result.CanonicalKey(r.Source)] = true
+		}
+	}
+
 	// Collect targets.
 	var targets [][]inst.Instruction
 	search.EnumerateSequences8(targetLen, func(seq []inst.Instruction) bool {
 		if search.ShouldPrune(seq) {
 			return true
 		}
+		if covered != nil && covered[result.CanonicalKey(seq)] {
+			return true
+		}
 		seqCopy := make([]inst.Instruction, len(seq))
 		copy(seqCopy, seq)
 		targets = append(targets, seqCopy)
@@ -94,98 +140,112 @@ func searchLengthGPU(cuda *CUDAProcess, candidates []inst.Instruction, targetLen
 		fmt.Printf("  %d target sequences\n", len(targets))
 	}
 
-	// Process each target: GPU QuickCheck → CPU ExhaustiveCheck.
+	// Process targets in batches: GPU QuickCheckGPUBatch → CPU ExhaustiveCheck.
 	gpuChecks := 0
 	cpuVerifies := 0
 	found := 0
 	reportTime := time.Now()
 
-	for i, target := range targets {
-		// Progress reporting.
-		if cfg.Verbose && time.Since(reportTime) > 10*time.Second {
-			reportTime = time.Now()
-			pct := float64(i) / float64(len(targets)) * 100
-			fmt.Printf("  [%.1f%%] %d/%d targets | %d GPU hits → %d verified | %d found\n",
-				pct, i, len(targets), gpuChecks, cpuVerifies, found)
+	for batchStart := 0; batchStart < len(targets); batchStart += gpuBatchSize {
+		batchEnd := batchStart + gpuBatchSize
+		if batchEnd > len(targets) {
+			batchEnd = len(targets)
+		}
+		batch := targets[batchStart:batchEnd]
+
+		fps := make([][search.FingerprintLen]byte, len(batch))
+		deadFlags := make([]search.FlagMask, len(batch))
+		for j, target := range batch {
+			fps[j] = search.Fingerprint(target)
+			deadFlags[j] = cfg.DeadFlags
+			if cfg.DeadFlagsAt != nil {
+				deadFlags[j] = cfg.DeadFlagsAt(target)
+			}
 		}
 
-		// Compute target fingerprint.
-		fp := search.Fingerprint(target)
-
-		// GPU QuickCheck: tests all candidates in one dispatch.
-		hits, err := cuda.QuickCheckGPU(fp, cfg.DeadFlags)
+		batchHits, err := cuda.QuickCheckGPUBatch(fps, deadFlags)
 		if err != nil {
-			return fmt.Errorf("target %d: %w", i, err)
+			return fmt.Errorf("batch %d-%d: %w", batchStart, batchEnd, err)
 		}
 
-		gpuChecks += len(hits)
+		for j, target := range batch {
+			i := batchStart + j
 
-		// CPU ExhaustiveCheck on hits only.
-		targetBytes := inst.SeqByteSize(target)
-		targetTStates := inst.SeqTStates(target)
-
-		for _, hitIdx := range hits {
-			if int(hitIdx) >= len(candidates) {
-				continue
+			// Progress reporting.
+			if cfg.Verbose && time.Since(reportTime) > 10*time.Second {
+				reportTime = time.Now()
+				pct := float64(i) / float64(len(targets)) * 100
+				fmt.Printf("  [%.1f%%] %d/%d targets | %d GPU hits → %d verified | %d found\n",
+					pct, i, len(targets), gpuChecks, cpuVerifies, found)
 			}
 
-			cand := []inst.Instruction{candidates[hitIdx]}
-			candBytes := inst.SeqByteSize(cand)
-			if candBytes >= targetBytes {
-				continue
-			}
+			hits := batchHits[j]
+			gpuChecks += len(hits)
+			siteDeadFlags := deadFlags[j]
 
-			if search.ShouldPrune(cand) {
-				continue
-			}
+			// CPU ExhaustiveCheck on hits only.
+			targetBytes := inst.SeqByteSize(target)
+			targetTStates := inst.SeqTStates(target)
 
-			// MidCheck: 32-vector filter to catch false positives
-			if cfg.DeadFlags == search.DeadNone {
-				if !search.MidCheck(target, cand) {
+			for _, hitIdx := range hits {
+				if int(hitIdx) >= len(candidates) {
 					continue
 				}
-			} else {
-				if !search.MidCheckMasked(target, cand, cfg.DeadFlags) {
+
+				cand := []inst.Instruction{candidates[hitIdx]}
+				candBytes := inst.SeqByteSize(cand)
+				if candBytes >= targetBytes {
 					continue
 				}
-			}
 
-			cpuVerifies++
-
-			if cfg.DeadFlags == search.DeadNone {
-				if !search.ExhaustiveCheck(target, cand) {
+				if search.ShouldPrune(cand) {
 					continue
 				}
-			} else {
-				if !search.ExhaustiveCheckMasked(target, cand, cfg.DeadFlags) {
+
+				// Symbolic filter to catch GPU false positives before the
+				// expensive ExhaustiveCheck below (chunk8-1: replaces the
+				// probabilistic 32-vector MidCheck with SymbolicCheck).
+				if !search.SymbolicCheck(target, cand, siteDeadFlags) {
 					continue
 				}
-			}
 
-			// Found a valid replacement.
-			found++
-			candCopy := make([]inst.Instruction, len(cand))
-			copy(candCopy, cand)
-			candTStates := inst.SeqTStates(candCopy)
-
-			rule := result.Rule{
-				Source:      copySeq(target),
-				Replacement: candCopy,
-				BytesSaved:  targetBytes - candBytes,
-				CyclesSaved: targetTStates - candTStates,
-			}
+				cpuVerifies++
 
-			if cfg.DeadFlags != search.DeadNone {
-				flagDiff := search.FlagDiff(target, cand)
-				rule.DeadFlags = flagDiff
-			}
+				if siteDeadFlags == search.DeadNone {
+					if !search.ExhaustiveCheck(target, cand) {
+						continue
+					}
+				} else {
+					if !search.ExhaustiveCheckMasked(target, cand, siteDeadFlags) {
+						continue
+					}
+				}
+
+				// Found a valid replacement.
+				found++
+				candCopy := make([]inst.Instruction, len(cand))
+				copy(candCopy, cand)
+				candTStates := inst.SeqTStates(candCopy)
+
+				rule := result.Rule{
+					Source:      copySeq(target),
+					Replacement: candCopy,
+					BytesSaved:  targetBytes - candBytes,
+					CyclesSaved: targetTStates - candTStates,
+				}
 
-			results.Add(rule)
+				if siteDeadFlags != search.DeadNone {
+					flagDiff := search.FlagDiff(target, cand)
+					rule.DeadFlags = flagDiff
+				}
+
+				results.Add(rule)
 
-			if cfg.Verbose {
-				fmt.Printf("  FOUND: %s -> %s (-%d bytes, -%d cycles)\n",
-					disasmSeq(target), disasmSeq(candCopy),
-					rule.BytesSaved, rule.CyclesSaved)
+				if cfg.Verbose {
+					fmt.Printf("  FOUND: %s -> %s (-%d bytes, -%d cycles)\n",
+						disasmSeq(target), disasmSeq(candCopy),
+						rule.BytesSaved, rule.CyclesSaved)
+				}
 			}
 		}
 	}
@@ -1,5 +1,7 @@
 package inst
 
+import "sync"
+
 // Info holds static metadata for an instruction opcode.
 type Info struct {
 	Mnemonic string  // Assembly mnemonic (e.g., "ADD A, B")
@@ -8,8 +10,24 @@ type Info struct {
 }
 
 // Catalog maps each OpCode to its Info.
+//
+// Hand-written init() below covers V1 through Wave 8 and the SM83 opcodes. Future waves can
+// instead describe their opcodes in a .defs file and run:
+//
+//	go run ./gen wave7.defs > wave7_gen.go
+//
+// which emits an init() populating Catalog for just those entries (see
+// pkg/inst/gen and wave7.defs for the format). The OpCode names themselves
+// still need adding to instruction.go's const block by hand.
 var Catalog [OpCodeCount]Info
 
+// GenUndocumented marks opcodes a wave's .defs file tagged "undoc" (chunk6-2)
+// — a generated alternative to hand-extending isZilogUndocumented's mnemonic
+// heuristic for opcodes that aren't SLL or an IXH/IXL/IYH/IYL half-register
+// form but are undocumented for some other reason (e.g. a reused encoding
+// with non-standard flag behavior). Unset by default; see pkg/inst/gen.
+var GenUndocumented [OpCodeCount]bool
+
 // AllOps returns all valid OpCode values (for enumeration).
 func AllOps() []OpCode {
 	ops := make([]OpCode, 0, OpCodeCount)
@@ -20,9 +38,14 @@ func AllOps() []OpCode {
 }
 
 // NonImmediateOps returns all OpCodes that don't take an immediate.
+//
+// Bounded by Z80OpCodeCount, not OpCodeCount: this feeds pkg/search's
+// enumerator, which runs every candidate it builds through cpu.Exec, and
+// cpu.Exec never handles the SM83-only opcodes above Z80OpCodeCount (see
+// the chunk2-5 block in instruction.go).
 func NonImmediateOps() []OpCode {
 	ops := make([]OpCode, 0)
-	for i := OpCode(0); i < OpCodeCount; i++ {
+	for i := OpCode(0); i < Z80OpCodeCount; i++ {
 		if !HasImmediate(i) {
 			ops = append(ops, i)
 		}
@@ -32,9 +55,11 @@ func NonImmediateOps() []OpCode {
 
 // ImmediateOps returns all OpCodes that take an 8-bit immediate byte.
 // Does NOT include 16-bit immediate ops (use Imm16Ops for those).
+//
+// Bounded by Z80OpCodeCount — see NonImmediateOps.
 func ImmediateOps() []OpCode {
 	ops := make([]OpCode, 0)
-	for i := OpCode(0); i < OpCodeCount; i++ {
+	for i := OpCode(0); i < Z80OpCodeCount; i++ {
 		if HasImmediate(i) && !HasImm16(i) {
 			ops = append(ops, i)
 		}
@@ -43,9 +68,11 @@ func ImmediateOps() []OpCode {
 }
 
 // Imm16Ops returns all OpCodes that take a 16-bit immediate.
+//
+// Bounded by Z80OpCodeCount — see NonImmediateOps.
 func Imm16Ops() []OpCode {
 	ops := make([]OpCode, 0)
-	for i := OpCode(0); i < OpCodeCount; i++ {
+	for i := OpCode(0); i < Z80OpCodeCount; i++ {
 		if HasImm16(i) {
 			ops = append(ops, i)
 		}
@@ -55,30 +82,174 @@ func Imm16Ops() []OpCode {
 
 // TStates returns the T-state cost of an instruction.
 func TStates(op OpCode) int {
-	return Catalog[op].TStates
+	if op >= OpCodeCount {
+		if info, ok := registeredInfo(op); ok {
+			return info.TStates
+		}
+		return 0
+	}
+	return int(Table[op].TStates)
 }
 
 // ByteSize returns the total byte size of an instruction (encoding + immediate).
 func ByteSize(op OpCode) int {
-	n := len(Catalog[op].Bytes)
-	if HasImm16(op) {
-		n += 2
-	} else if HasImmediate(op) {
-		n++
+	if op >= OpCodeCount {
+		if info, ok := registeredInfo(op); ok {
+			return len(info.Bytes)
+		}
+		return 0
 	}
-	return n
+	return int(Table[op].SizeBytes)
+}
+
+// MCycles returns the number of bus M-cycles op spends: one per opcode or
+// operand byte fetched from the PC stream, plus one per separately-addressed
+// memory read/write (see MemAccess). Like TStates, this is the static figure
+// from Table — real opcodes can also spend M-cycles on internal-only work
+// with no bus access (e.g. DJNZ's extra taken-branch delay), which this
+// count doesn't capture; Exec's returned T-state total already accounts for
+// those, MCycles doesn't try to.
+func MCycles(op OpCode) int {
+	if op >= OpCodeCount {
+		return 0
+	}
+	return int(Table[op].MCycles)
 }
 
-// Disassemble returns assembly text for an instruction.
+// Cost returns op's static timing: T-states and bus M-cycles. operand is
+// accepted for callers that key cost lookups on the full (op, operand) pair
+// STOKE-style passes use elsewhere in this tree, but — like TStates and
+// MCycles — the figure itself doesn't depend on it; only cpu.Exec has the
+// runtime state needed to tell a taken branch from a not-taken one.
+func Cost(op OpCode, operand uint16) (tstates, mcycles int) {
+	_ = operand
+	return TStates(op), MCycles(op)
+}
+
+// RefreshCycles returns the number of M1 (opcode fetch) cycles op spends,
+// which is also how much the real Z80's R register advances per execution:
+// 1 for a plain opcode, 2 for any CB/ED/DD/FD-prefixed one. This holds even
+// for the DD/FD CB-prefixed indexed bit-ops (chunk10-1's decoder splices a
+// displacement byte in after the CB byte) — only the prefix byte and the CB
+// byte are M1 fetches there; the displacement and final opcode byte are not.
+func RefreshCycles(op OpCode) int {
+	var lead uint8
+	if op >= OpCodeCount {
+		info, ok := registeredInfo(op)
+		if !ok || len(info.Bytes) == 0 {
+			return 1
+		}
+		lead = info.Bytes[0]
+	} else if len(Catalog[op].Bytes) > 0 {
+		lead = Catalog[op].Bytes[0]
+	} else {
+		return 1
+	}
+	switch lead {
+	case 0xCB, 0xED, 0xDD, 0xFD:
+		return 2
+	default:
+		return 1
+	}
+}
+
+// OpCodeName returns op's catalog mnemonic (e.g. "ADD A, B") — stable across
+// an OpCode renumbering in a way the numeric id itself isn't, so it's what
+// the versioned checkpoint format (chunk4-4) stores instead of the id. For a
+// RegisterOpcode-allocated op, returns its registered mnemonic.
+func OpCodeName(op OpCode) string {
+	if op >= OpCodeCount {
+		if info, ok := registeredInfo(op); ok {
+			return info.Mnemonic
+		}
+		return ""
+	}
+	return Catalog[op].Mnemonic
+}
+
+var (
+	opcodeNamesOnce sync.Once
+	opcodeNameIndex map[string]OpCode
+)
+
+// OpCodeByName is the inverse of OpCodeName. ok is false if no opcode in the
+// current build's Catalog has that exact mnemonic — e.g. the checkpoint was
+// written by a build that has since renamed or dropped that opcode. Also
+// checks the RegisterOpcode registry, so a name registered at startup
+// resolves the same way a built-in one does.
+func OpCodeByName(name string) (op OpCode, ok bool) {
+	opcodeNamesOnce.Do(func() {
+		opcodeNameIndex = make(map[string]OpCode, OpCodeCount)
+		for i := OpCode(0); i < OpCodeCount; i++ {
+			opcodeNameIndex[Catalog[i].Mnemonic] = i
+		}
+	})
+	if op, ok = opcodeNameIndex[name]; ok {
+		return op, ok
+	}
+	return registeredOpByName(name)
+}
+
+// Disassemble returns assembly text for an instruction. A RegisterOpcode
+// entry disassembles to its bare mnemonic — the displacement/immediate
+// placeholder substitution below only ever applies to a built-in OpCode,
+// since HasIndexDisp/HasImm16/HasImmediate are false for anything >=
+// OpCodeCount.
 func Disassemble(instr Instruction) string {
+	if instr.Op >= OpCodeCount {
+		if info, ok := registeredInfo(instr.Op); ok {
+			return info.Mnemonic
+		}
+		return "???"
+	}
 	info := &Catalog[instr.Op]
+	mnemonic := info.Mnemonic
+	if HasIndexDisp(instr.Op) {
+		mnemonic = disasmDisp(mnemonic, instr.Disp)
+	}
 	if HasImm16(instr.Op) {
-		return disasmImm16(info.Mnemonic, instr.Imm)
+		return disasmImm16(mnemonic, instr.Imm)
 	}
 	if HasImmediate(instr.Op) {
-		return disasmImm8(info.Mnemonic, uint8(instr.Imm))
+		return disasmImm8(mnemonic, uint8(instr.Imm))
+	}
+	return mnemonic
+}
+
+// disasmDisp replaces the "d" placeholder in "(IX+d)"/"(IY+d)" with the
+// signed displacement, e.g. "(IX+5)" or "(IX-3)".
+func disasmDisp(mnemonic string, disp int8) string {
+	idx := -1
+	for i := 0; i+1 < len(mnemonic); i++ {
+		if mnemonic[i] == '+' && mnemonic[i+1] == 'd' {
+			idx = i
+			break
+		}
+	}
+	if idx < 0 {
+		return mnemonic
+	}
+	sign := byte('+')
+	v := disp
+	if disp < 0 {
+		sign = '-'
+		v = -disp
 	}
-	return info.Mnemonic
+	return mnemonic[:idx] + string(sign) + itoa(uint8(v)) + mnemonic[idx+2:]
+}
+
+func itoa(v uint8) string {
+	if v == 0 {
+		return "0"
+	}
+	var buf [3]byte
+	n := len(buf)
+	for v > 0 {
+		n--
+		buf[n] = '0' + v%10
+		v /= 10
+	}
+	return string(buf[n:])
 }
 
 func disasmImm8(mnemonic string, imm uint8) string {
@@ -434,4 +605,349 @@ func init() {
 	Catalog[SBC_HL_DE] = Info{"SBC HL, DE", []uint8{0xED, 0x52}, 15}
 	Catalog[SBC_HL_HL] = Info{"SBC HL, HL", []uint8{0xED, 0x62}, 15}
 	Catalog[SBC_HL_SP] = Info{"SBC HL, SP", []uint8{0xED, 0x72}, 15}
+
+	initMemCatalog()
+	initIndexCatalog()
+	initBranchCatalog()
+	initPortCatalog()
+	initSM83Catalog()
+	buildTable()
+}
+
+// initMemCatalog fills in Wave 5: (HL)/(BC)/(DE) indirect memory ops — the
+// one family the hand-written V1/Wave 2/Wave 4 blocks above never got
+// around to (they predate this wave). Without these, Catalog[op].Bytes was
+// empty for all 61 of them, which undersized every OpcodeFetchBytes/
+// ByteSize/decode pattern derived from it — see the chunk10-1 and chunk5-4
+// review fixes that depend on this being populated.
+func initMemCatalog() {
+	regNamesMem := [7]string{"A", "B", "C", "D", "E", "H", "L"}
+	regEncMem := [7]uint8{7, 0, 1, 2, 3, 4, 5}
+
+	ldFromHLI := [7]OpCode{LD_A_HLI, LD_B_HLI, LD_C_HLI, LD_D_HLI, LD_E_HLI, LD_H_HLI, LD_L_HLI}
+	ldToHLI := [7]OpCode{LD_HLI_A, LD_HLI_B, LD_HLI_C, LD_HLI_D, LD_HLI_E, LD_HLI_H, LD_HLI_L}
+	for i, r := range regNamesMem {
+		Catalog[ldFromHLI[i]] = Info{"LD " + r + ", (HL)", []uint8{0x46 | regEncMem[i]<<3}, 7}
+		Catalog[ldToHLI[i]] = Info{"LD (HL), " + r, []uint8{0x70 | regEncMem[i]}, 7}
+	}
+
+	Catalog[LD_HLI_N] = Info{"LD (HL), n", []uint8{0x36}, 10}
+
+	Catalog[LD_A_BCI] = Info{"LD A, (BC)", []uint8{0x0A}, 7}
+	Catalog[LD_A_DEI] = Info{"LD A, (DE)", []uint8{0x1A}, 7}
+	Catalog[LD_BCI_A] = Info{"LD (BC), A", []uint8{0x02}, 7}
+	Catalog[LD_DEI_A] = Info{"LD (DE), A", []uint8{0x12}, 7}
+
+	aluHLI := []struct {
+		op       OpCode
+		mnemonic string
+		enc      uint8
+	}{
+		{ADD_A_HLI, "ADD A, (HL)", 0x86}, {ADC_A_HLI, "ADC A, (HL)", 0x8E},
+		{SUB_HLI, "SUB (HL)", 0x96}, {SBC_A_HLI, "SBC A, (HL)", 0x9E},
+		{AND_HLI, "AND (HL)", 0xA6}, {XOR_HLI, "XOR (HL)", 0xAE},
+		{OR_HLI, "OR (HL)", 0xB6}, {CP_HLI, "CP (HL)", 0xBE},
+	}
+	for _, a := range aluHLI {
+		Catalog[a.op] = Info{a.mnemonic, []uint8{a.enc}, 7}
+	}
+
+	Catalog[INC_HLI] = Info{"INC (HL)", []uint8{0x34}, 11}
+	Catalog[DEC_HLI] = Info{"DEC (HL)", []uint8{0x35}, 11}
+
+	rotHLI := []struct {
+		op       OpCode
+		mnemonic string
+		enc      uint8
+	}{
+		{RLC_HLI, "RLC (HL)", 0x06}, {RRC_HLI, "RRC (HL)", 0x0E},
+		{RL_HLI, "RL (HL)", 0x16}, {RR_HLI, "RR (HL)", 0x1E},
+		{SLA_HLI, "SLA (HL)", 0x26}, {SRA_HLI, "SRA (HL)", 0x2E},
+		{SRL_HLI, "SRL (HL)", 0x3E}, {SLL_HLI, "SLL (HL)", 0x36}, // SLL undocumented
+	}
+	for _, r := range rotHLI {
+		Catalog[r.op] = Info{r.mnemonic, []uint8{0xCB, r.enc}, 15}
+	}
+
+	bitHLI := [8]OpCode{BIT_0_HLI, BIT_1_HLI, BIT_2_HLI, BIT_3_HLI, BIT_4_HLI, BIT_5_HLI, BIT_6_HLI, BIT_7_HLI}
+	resHLI := [8]OpCode{RES_0_HLI, RES_1_HLI, RES_2_HLI, RES_3_HLI, RES_4_HLI, RES_5_HLI, RES_6_HLI, RES_7_HLI}
+	setHLI := [8]OpCode{SET_0_HLI, SET_1_HLI, SET_2_HLI, SET_3_HLI, SET_4_HLI, SET_5_HLI, SET_6_HLI, SET_7_HLI}
+	for n := 0; n < 8; n++ {
+		nb := uint8(n)
+		digit := string('0' + byte(n))
+		Catalog[bitHLI[n]] = Info{"BIT " + digit + ", (HL)", []uint8{0xCB, 0x46 | nb<<3}, 12}
+		Catalog[resHLI[n]] = Info{"RES " + digit + ", (HL)", []uint8{0xCB, 0x86 | nb<<3}, 15}
+		Catalog[setHLI[n]] = Info{"SET " + digit + ", (HL)", []uint8{0xCB, 0xC6 | nb<<3}, 15}
+	}
+}
+
+// initSM83Catalog fills in the chunk2-5 SM83-only opcodes. Bytes/TStates
+// here are the Game Boy's own encoding and timing, not a Z80 equivalent —
+// there isn't one. pkg/cpu/sm83.Exec is the only executor that dispatches
+// these; cpu.Exec (the Z80 dialect) never sees them.
+func initSM83Catalog() {
+	Catalog[LDH_N_A] = Info{"LDH (n), A", []uint8{0xE0}, 12}
+	Catalog[LDH_A_N] = Info{"LDH A, (n)", []uint8{0xF0}, 12}
+	Catalog[LDH_C_A] = Info{"LDH (C), A", []uint8{0xE2}, 8}
+	Catalog[LDH_A_C] = Info{"LDH A, (C)", []uint8{0xF2}, 8}
+
+	Catalog[LD_HLI_A_INC] = Info{"LD (HL+), A", []uint8{0x22}, 8}
+	Catalog[LD_A_HLI_INC] = Info{"LD A, (HL+)", []uint8{0x2A}, 8}
+	Catalog[LD_HLI_A_DEC] = Info{"LD (HL-), A", []uint8{0x32}, 8}
+	Catalog[LD_A_HLI_DEC] = Info{"LD A, (HL-)", []uint8{0x3A}, 8}
+
+	Catalog[STOP] = Info{"STOP", []uint8{0x10, 0x00}, 4}
+	Catalog[RETI] = Info{"RETI", []uint8{0xD9}, 16}
+
+	swap := []struct {
+		op       OpCode
+		mnemonic string
+		enc      uint8
+	}{
+		{SWAP_B, "SWAP B", 0x30}, {SWAP_C, "SWAP C", 0x31},
+		{SWAP_D, "SWAP D", 0x32}, {SWAP_E, "SWAP E", 0x33},
+		{SWAP_H, "SWAP H", 0x34}, {SWAP_L, "SWAP L", 0x35},
+		{SWAP_A, "SWAP A", 0x37},
+	}
+	for _, s := range swap {
+		Catalog[s.op] = Info{s.mnemonic, []uint8{0xCB, s.enc}, 8}
+	}
+}
+
+// initPortCatalog fills in Wave 8: I/O ports. Like initBranchCatalog's
+// branch T-states, INIR/INDR/OTIR/OTDR model the loop-continues cost, not the
+// cheaper last-iteration one.
+func initPortCatalog() {
+	Catalog[IN_A_N] = Info{"IN A, (n)", []uint8{0xDB}, 11}
+	Catalog[OUT_N_A] = Info{"OUT (n), A", []uint8{0xD3}, 11}
+
+	inC := []struct {
+		op       OpCode
+		mnemonic string
+		enc      uint8
+	}{
+		{IN_B_C, "IN B, (C)", 0x40}, {IN_C_C, "IN C, (C)", 0x48},
+		{IN_D_C, "IN D, (C)", 0x50}, {IN_E_C, "IN E, (C)", 0x58},
+		{IN_H_C, "IN H, (C)", 0x60}, {IN_L_C, "IN L, (C)", 0x68},
+		{IN_A_C, "IN A, (C)", 0x78},
+	}
+	for _, o := range inC {
+		Catalog[o.op] = Info{o.mnemonic, []uint8{0xED, o.enc}, 12}
+	}
+
+	outC := []struct {
+		op       OpCode
+		mnemonic string
+		enc      uint8
+	}{
+		{OUT_C_B, "OUT (C), B", 0x41}, {OUT_C_C, "OUT (C), C", 0x49},
+		{OUT_C_D, "OUT (C), D", 0x51}, {OUT_C_E, "OUT (C), E", 0x59},
+		{OUT_C_H, "OUT (C), H", 0x61}, {OUT_C_L, "OUT (C), L", 0x69},
+		{OUT_C_A, "OUT (C), A", 0x79},
+	}
+	for _, o := range outC {
+		Catalog[o.op] = Info{o.mnemonic, []uint8{0xED, o.enc}, 12}
+	}
+
+	Catalog[INI] = Info{"INI", []uint8{0xED, 0xA2}, 16}
+	Catalog[INIR] = Info{"INIR", []uint8{0xED, 0xB2}, 21}
+	Catalog[IND] = Info{"IND", []uint8{0xED, 0xAA}, 16}
+	Catalog[INDR] = Info{"INDR", []uint8{0xED, 0xBA}, 21}
+	Catalog[OUTI] = Info{"OUTI", []uint8{0xED, 0xA3}, 16}
+	Catalog[OTIR] = Info{"OTIR", []uint8{0xED, 0xB3}, 21}
+	Catalog[OUTD] = Info{"OUTD", []uint8{0xED, 0xAB}, 16}
+	Catalog[OTDR] = Info{"OTDR", []uint8{0xED, 0xBB}, 21}
+}
+
+// initBranchCatalog fills in Wave 7: control flow. TStates models the
+// taken-branch cost; real hardware is cheaper when a conditional branch
+// falls through (e.g. JR NZ,e is 7 T-states not taken vs 12 taken) — not
+// modeled here, same single-number-per-opcode simplification Catalog
+// already makes everywhere else.
+func initBranchCatalog() {
+	Catalog[JR] = Info{"JR n", []uint8{0x18}, 12}
+	Catalog[JR_NZ] = Info{"JR NZ, n", []uint8{0x20}, 12}
+	Catalog[JR_Z] = Info{"JR Z, n", []uint8{0x28}, 12}
+	Catalog[JR_NC] = Info{"JR NC, n", []uint8{0x30}, 12}
+	Catalog[JR_C] = Info{"JR C, n", []uint8{0x38}, 12}
+	Catalog[DJNZ] = Info{"DJNZ n", []uint8{0x10}, 13}
+
+	Catalog[JP] = Info{"JP nn", []uint8{0xC3}, 10}
+	Catalog[JP_NZ] = Info{"JP NZ, nn", []uint8{0xC2}, 10}
+	Catalog[JP_Z] = Info{"JP Z, nn", []uint8{0xCA}, 10}
+	Catalog[JP_NC] = Info{"JP NC, nn", []uint8{0xD2}, 10}
+	Catalog[JP_C] = Info{"JP C, nn", []uint8{0xDA}, 10}
+	Catalog[CALL] = Info{"CALL nn", []uint8{0xCD}, 17}
+	Catalog[CALL_NZ] = Info{"CALL NZ, nn", []uint8{0xC4}, 17}
+	Catalog[CALL_Z] = Info{"CALL Z, nn", []uint8{0xCC}, 17}
+	Catalog[CALL_NC] = Info{"CALL NC, nn", []uint8{0xD4}, 17}
+	Catalog[CALL_C] = Info{"CALL C, nn", []uint8{0xDC}, 17}
+
+	Catalog[RET] = Info{"RET", []uint8{0xC9}, 10}
+	Catalog[RET_NZ] = Info{"RET NZ", []uint8{0xC0}, 11}
+	Catalog[RET_Z] = Info{"RET Z", []uint8{0xC8}, 11}
+	Catalog[RET_NC] = Info{"RET NC", []uint8{0xD0}, 11}
+	Catalog[RET_C] = Info{"RET C", []uint8{0xD8}, 11}
+
+	Catalog[RST_00] = Info{"RST 00H", []uint8{0xC7}, 11}
+	Catalog[RST_08] = Info{"RST 08H", []uint8{0xCF}, 11}
+	Catalog[RST_10] = Info{"RST 10H", []uint8{0xD7}, 11}
+	Catalog[RST_18] = Info{"RST 18H", []uint8{0xDF}, 11}
+	Catalog[RST_20] = Info{"RST 20H", []uint8{0xE7}, 11}
+	Catalog[RST_28] = Info{"RST 28H", []uint8{0xEF}, 11}
+	Catalog[RST_30] = Info{"RST 30H", []uint8{0xF7}, 11}
+	Catalog[RST_38] = Info{"RST 38H", []uint8{0xFF}, 11}
+}
+
+// initIndexCatalog fills in Wave 6: IX/IY indexed addressing. DD prefixes IX
+// forms, FD prefixes IY forms — the two families are byte-for-byte identical
+// apart from that prefix, so we build them from one table of IX ops.
+func initIndexCatalog() {
+	Catalog[LD_IX_NN] = Info{"LD IX, nn", []uint8{0xDD, 0x21}, 14}
+	Catalog[LD_IY_NN] = Info{"LD IY, nn", []uint8{0xFD, 0x21}, 14}
+
+	addIdx := []struct {
+		ix, iy                 OpCode
+		mnemonicIX, mnemonicIY string
+		enc                    uint8
+	}{
+		{ADD_IX_BC, ADD_IY_BC, "ADD IX, BC", "ADD IY, BC", 0x09},
+		{ADD_IX_DE, ADD_IY_DE, "ADD IX, DE", "ADD IY, DE", 0x19},
+		{ADD_IX_IX, ADD_IY_IY, "ADD IX, IX", "ADD IY, IY", 0x29},
+		{ADD_IX_SP, ADD_IY_SP, "ADD IX, SP", "ADD IY, SP", 0x39},
+	}
+	for _, a := range addIdx {
+		Catalog[a.ix] = Info{a.mnemonicIX, []uint8{0xDD, a.enc}, 15}
+		Catalog[a.iy] = Info{a.mnemonicIY, []uint8{0xFD, a.enc}, 15}
+	}
+
+	regNamesIdx := [7]string{"A", "B", "C", "D", "E", "H", "L"}
+	regEncIdx := [7]uint8{7, 0, 1, 2, 3, 4, 5}
+
+	ldFromIdx := [7]OpCode{LD_A_IXD, LD_B_IXD, LD_C_IXD, LD_D_IXD, LD_E_IXD, LD_H_IXD, LD_L_IXD}
+	ldFromIdyOps := [7]OpCode{LD_A_IYD, LD_B_IYD, LD_C_IYD, LD_D_IYD, LD_E_IYD, LD_H_IYD, LD_L_IYD}
+	ldToIdxOps := [7]OpCode{LD_IXD_A, LD_IXD_B, LD_IXD_C, LD_IXD_D, LD_IXD_E, LD_IXD_H, LD_IXD_L}
+	ldToIdyOps := [7]OpCode{LD_IYD_A, LD_IYD_B, LD_IYD_C, LD_IYD_D, LD_IYD_E, LD_IYD_H, LD_IYD_L}
+	for i, r := range regNamesIdx {
+		enc := 0x46 | regEncIdx[i]
+		Catalog[ldFromIdx[i]] = Info{"LD " + r + ", (IX+d)", []uint8{0xDD, enc}, 19}
+		Catalog[ldFromIdyOps[i]] = Info{"LD " + r + ", (IY+d)", []uint8{0xFD, enc}, 19}
+		encTo := 0x70 | regEncIdx[i]
+		Catalog[ldToIdxOps[i]] = Info{"LD (IX+d), " + r, []uint8{0xDD, encTo}, 19}
+		Catalog[ldToIdyOps[i]] = Info{"LD (IY+d), " + r, []uint8{0xFD, encTo}, 19}
+	}
+
+	Catalog[LD_IXD_N] = Info{"LD (IX+d), n", []uint8{0xDD, 0x36}, 19}
+	Catalog[LD_IYD_N] = Info{"LD (IY+d), n", []uint8{0xFD, 0x36}, 19}
+
+	aluIdx := []struct {
+		ix, iy                 OpCode
+		mnemonicIX, mnemonicIY string
+		enc                    uint8
+	}{
+		{ADD_A_IXD, ADD_A_IYD, "ADD A, (IX+d)", "ADD A, (IY+d)", 0x86},
+		{ADC_A_IXD, ADC_A_IYD, "ADC A, (IX+d)", "ADC A, (IY+d)", 0x8E},
+		{SUB_IXD, SUB_IYD, "SUB (IX+d)", "SUB (IY+d)", 0x96},
+		{SBC_A_IXD, SBC_A_IYD, "SBC A, (IX+d)", "SBC A, (IY+d)", 0x9E},
+		{AND_IXD, AND_IYD, "AND (IX+d)", "AND (IY+d)", 0xA6},
+		{XOR_IXD, XOR_IYD, "XOR (IX+d)", "XOR (IY+d)", 0xAE},
+		{OR_IXD, OR_IYD, "OR (IX+d)", "OR (IY+d)", 0xB6},
+		{CP_IXD, CP_IYD, "CP (IX+d)", "CP (IY+d)", 0xBE},
+	}
+	for _, a := range aluIdx {
+		Catalog[a.ix] = Info{a.mnemonicIX, []uint8{0xDD, a.enc}, 19}
+		Catalog[a.iy] = Info{a.mnemonicIY, []uint8{0xFD, a.enc}, 19}
+	}
+
+	Catalog[INC_IXD] = Info{"INC (IX+d)", []uint8{0xDD, 0x34}, 23}
+	Catalog[DEC_IXD] = Info{"DEC (IX+d)", []uint8{0xDD, 0x35}, 23}
+	Catalog[INC_IYD] = Info{"INC (IY+d)", []uint8{0xFD, 0x34}, 23}
+	Catalog[DEC_IYD] = Info{"DEC (IY+d)", []uint8{0xFD, 0x35}, 23}
+
+	rotIdx := []struct {
+		ix, iy                 OpCode
+		mnemonicIX, mnemonicIY string
+		enc                    uint8
+	}{
+		{RLC_IXD, RLC_IYD, "RLC (IX+d)", "RLC (IY+d)", 0x06},
+		{RRC_IXD, RRC_IYD, "RRC (IX+d)", "RRC (IY+d)", 0x0E},
+		{RL_IXD, RL_IYD, "RL (IX+d)", "RL (IY+d)", 0x16},
+		{RR_IXD, RR_IYD, "RR (IX+d)", "RR (IY+d)", 0x1E},
+		{SLA_IXD, SLA_IYD, "SLA (IX+d)", "SLA (IY+d)", 0x26},
+		{SRA_IXD, SRA_IYD, "SRA (IX+d)", "SRA (IY+d)", 0x2E},
+		{SRL_IXD, SRL_IYD, "SRL (IX+d)", "SRL (IY+d)", 0x3E},
+		{SLL_IXD, SLL_IYD, "SLL (IX+d)", "SLL (IY+d)", 0x36}, // undocumented
+	}
+	for _, r := range rotIdx {
+		Catalog[r.ix] = Info{r.mnemonicIX, []uint8{0xDD, 0xCB, r.enc}, 23}
+		Catalog[r.iy] = Info{r.mnemonicIY, []uint8{0xFD, 0xCB, r.enc}, 23}
+	}
+
+	bitIxd := [8]OpCode{BIT_0_IXD, BIT_1_IXD, BIT_2_IXD, BIT_3_IXD, BIT_4_IXD, BIT_5_IXD, BIT_6_IXD, BIT_7_IXD}
+	bitIyd := [8]OpCode{BIT_0_IYD, BIT_1_IYD, BIT_2_IYD, BIT_3_IYD, BIT_4_IYD, BIT_5_IYD, BIT_6_IYD, BIT_7_IYD}
+	resIxd := [8]OpCode{RES_0_IXD, RES_1_IXD, RES_2_IXD, RES_3_IXD, RES_4_IXD, RES_5_IXD, RES_6_IXD, RES_7_IXD}
+	resIyd := [8]OpCode{RES_0_IYD, RES_1_IYD, RES_2_IYD, RES_3_IYD, RES_4_IYD, RES_5_IYD, RES_6_IYD, RES_7_IYD}
+	setIxd := [8]OpCode{SET_0_IXD, SET_1_IXD, SET_2_IXD, SET_3_IXD, SET_4_IXD, SET_5_IXD, SET_6_IXD, SET_7_IXD}
+	setIyd := [8]OpCode{SET_0_IYD, SET_1_IYD, SET_2_IYD, SET_3_IYD, SET_4_IYD, SET_5_IYD, SET_6_IYD, SET_7_IYD}
+	for bit := 0; bit < 8; bit++ {
+		bitEnc := 0x46 | uint8(bit<<3)
+		resEnc := 0x86 | uint8(bit<<3)
+		setEnc := 0xC6 | uint8(bit<<3)
+		digit := string('0' + byte(bit))
+		Catalog[bitIxd[bit]] = Info{"BIT " + digit + ", (IX+d)", []uint8{0xDD, 0xCB, bitEnc}, 20}
+		Catalog[bitIyd[bit]] = Info{"BIT " + digit + ", (IY+d)", []uint8{0xFD, 0xCB, bitEnc}, 20}
+		Catalog[resIxd[bit]] = Info{"RES " + digit + ", (IX+d)", []uint8{0xDD, 0xCB, resEnc}, 23}
+		Catalog[resIyd[bit]] = Info{"RES " + digit + ", (IY+d)", []uint8{0xFD, 0xCB, resEnc}, 23}
+		Catalog[setIxd[bit]] = Info{"SET " + digit + ", (IX+d)", []uint8{0xDD, 0xCB, setEnc}, 23}
+		Catalog[setIyd[bit]] = Info{"SET " + digit + ", (IY+d)", []uint8{0xFD, 0xCB, setEnc}, 23}
+	}
+
+	// Undocumented IXH/IXL/IYH/IYL half-register ops: DD/FD prefix + normal opcode, 8 T-states.
+	Catalog[LD_A_IXH] = Info{"LD A, IXH", []uint8{0xDD, 0x7C}, 8}
+	Catalog[LD_A_IXL] = Info{"LD A, IXL", []uint8{0xDD, 0x7D}, 8}
+	Catalog[LD_IXH_A] = Info{"LD IXH, A", []uint8{0xDD, 0x67}, 8}
+	Catalog[LD_IXL_A] = Info{"LD IXL, A", []uint8{0xDD, 0x6F}, 8}
+	Catalog[INC_IXH] = Info{"INC IXH", []uint8{0xDD, 0x24}, 8}
+	Catalog[INC_IXL] = Info{"INC IXL", []uint8{0xDD, 0x2C}, 8}
+	Catalog[DEC_IXH] = Info{"DEC IXH", []uint8{0xDD, 0x25}, 8}
+	Catalog[DEC_IXL] = Info{"DEC IXL", []uint8{0xDD, 0x2D}, 8}
+	Catalog[ADD_A_IXH] = Info{"ADD A, IXH", []uint8{0xDD, 0x84}, 8}
+	Catalog[ADD_A_IXL] = Info{"ADD A, IXL", []uint8{0xDD, 0x85}, 8}
+	Catalog[LD_A_IYH] = Info{"LD A, IYH", []uint8{0xFD, 0x7C}, 8}
+	Catalog[LD_A_IYL] = Info{"LD A, IYL", []uint8{0xFD, 0x7D}, 8}
+	Catalog[LD_IYH_A] = Info{"LD IYH, A", []uint8{0xFD, 0x67}, 8}
+	Catalog[LD_IYL_A] = Info{"LD IYL, A", []uint8{0xFD, 0x6F}, 8}
+	Catalog[INC_IYH] = Info{"INC IYH", []uint8{0xFD, 0x24}, 8}
+	Catalog[INC_IYL] = Info{"INC IYL", []uint8{0xFD, 0x2C}, 8}
+	Catalog[DEC_IYH] = Info{"DEC IYH", []uint8{0xFD, 0x25}, 8}
+	Catalog[DEC_IYL] = Info{"DEC IYL", []uint8{0xFD, 0x2D}, 8}
+	Catalog[ADD_A_IYH] = Info{"ADD A, IYH", []uint8{0xFD, 0x84}, 8}
+	Catalog[ADD_A_IYL] = Info{"ADD A, IYL", []uint8{0xFD, 0x85}, 8}
+
+	// chunk2-6: the remaining ALU ops against IXH/IXL/IYH/IYL, same DD/FD
+	// prefix + normal-opcode shape and 8 T-states as ADD A, above.
+	aluHalf := []struct {
+		ix, iy                 OpCode
+		mnemonicIX, mnemonicIY string
+		enc                    uint8
+	}{
+		{ADC_A_IXH, ADC_A_IYH, "ADC A, IXH", "ADC A, IYH", 0x8C},
+		{ADC_A_IXL, ADC_A_IYL, "ADC A, IXL", "ADC A, IYL", 0x8D},
+		{SUB_IXH, SUB_IYH, "SUB IXH", "SUB IYH", 0x94},
+		{SUB_IXL, SUB_IYL, "SUB IXL", "SUB IYL", 0x95},
+		{SBC_A_IXH, SBC_A_IYH, "SBC A, IXH", "SBC A, IYH", 0x9C},
+		{SBC_A_IXL, SBC_A_IYL, "SBC A, IXL", "SBC A, IYL", 0x9D},
+		{AND_IXH, AND_IYH, "AND IXH", "AND IYH", 0xA4},
+		{AND_IXL, AND_IYL, "AND IXL", "AND IYL", 0xA5},
+		{XOR_IXH, XOR_IYH, "XOR IXH", "XOR IYH", 0xAC},
+		{XOR_IXL, XOR_IYL, "XOR IXL", "XOR IYL", 0xAD},
+		{OR_IXH, OR_IYH, "OR IXH", "OR IYH", 0xB4},
+		{OR_IXL, OR_IYL, "OR IXL", "OR IYL", 0xB5},
+		{CP_IXH, CP_IYH, "CP IXH", "CP IYH", 0xBC},
+		{CP_IXL, CP_IYL, "CP IXL", "CP IYL", 0xBD},
+	}
+	for _, a := range aluHalf {
+		Catalog[a.ix] = Info{a.mnemonicIX, []uint8{0xDD, a.enc}, 8}
+		Catalog[a.iy] = Info{a.mnemonicIY, []uint8{0xFD, a.enc}, 8}
+	}
 }
@@ -20,6 +20,36 @@ func TestCatalogCompleteness(t *testing.T) {
 	}
 }
 
+// TestTableMatchesCatalog verifies the derived Table agrees with Catalog and
+// the shape predicates it was built from, for every OpCode.
+func TestTableMatchesCatalog(t *testing.T) {
+	for op := OpCode(0); op < OpCodeCount; op++ {
+		d := Table[op]
+		if d.Mnemonic != Catalog[op].Mnemonic {
+			t.Errorf("OpCode %d: Table mnemonic %q != Catalog %q", op, d.Mnemonic, Catalog[op].Mnemonic)
+		}
+		if int(d.TStates) != Catalog[op].TStates {
+			t.Errorf("OpCode %d (%s): Table TStates %d != Catalog %d", op, d.Mnemonic, d.TStates, Catalog[op].TStates)
+		}
+		if d.UsesMem != UsesMemory(op) {
+			t.Errorf("OpCode %d (%s): Table UsesMem %v != UsesMemory() %v", op, d.Mnemonic, d.UsesMem, UsesMemory(op))
+		}
+		if d.HasDisp != HasIndexDisp(op) {
+			t.Errorf("OpCode %d (%s): Table HasDisp %v != HasIndexDisp() %v", op, d.Mnemonic, d.HasDisp, HasIndexDisp(op))
+		}
+		wantImm := uint8(0)
+		switch {
+		case HasImm16(op):
+			wantImm = 2
+		case HasImmediate(op):
+			wantImm = 1
+		}
+		if d.ImmSize != wantImm {
+			t.Errorf("OpCode %d (%s): Table ImmSize %d != want %d", op, d.Mnemonic, d.ImmSize, wantImm)
+		}
+	}
+}
+
 // TestEncodingMatchesMinzTS verifies our encodings match minz-ts/pkg/z80asm/opcodes.go.
 func TestEncodingMatchesMinzTS(t *testing.T) {
 	// Reference values from minz-ts/minzc/pkg/z80asm/opcodes.go
@@ -127,17 +157,66 @@ func TestTStates(t *testing.T) {
 	}
 }
 
+// TestCost verifies the (tstates, mcycles) pair Cost/MCycles derive for a
+// spread of opcode shapes (chunk11-4): a plain register op, an immediate
+// op, a CB-prefixed one, and an (IX+d)-indexed read-modify-write.
+func TestCost(t *testing.T) {
+	tests := []struct {
+		name    string
+		op      OpCode
+		tstates int
+		mcycles int
+	}{
+		{"NOP", NOP, 4, 1},
+		{"ADD A,B", ADD_A_B, 4, 1},
+		{"ADD A,n", ADD_A_N, 7, 2},
+		{"RLC A", RLC_A, 8, 2},
+		{"INC (IX+d)", INC_IXD, 23, 5}, // DD,34 opcode fetch (2) + disp (1) + read + write
+	}
+	for _, tt := range tests {
+		tstates, mcycles := Cost(tt.op, 0)
+		if tstates != tt.tstates {
+			t.Errorf("%s: Cost tstates = %d, want %d", tt.name, tstates, tt.tstates)
+		}
+		if mcycles != tt.mcycles {
+			t.Errorf("%s: Cost mcycles = %d, want %d", tt.name, mcycles, tt.mcycles)
+		}
+		if MCycles(tt.op) != tt.mcycles {
+			t.Errorf("%s: MCycles = %d, want %d", tt.name, MCycles(tt.op), tt.mcycles)
+		}
+	}
+}
+
+// TestRefreshCycles verifies the R-register M1-fetch count (chunk10-4).
+func TestRefreshCycles(t *testing.T) {
+	if RefreshCycles(ADD_A_B) != 1 {
+		t.Errorf("ADD A,B: got %d M1 cycles, want 1 (unprefixed)", RefreshCycles(ADD_A_B))
+	}
+	if RefreshCycles(ADD_A_N) != 1 {
+		t.Errorf("ADD A,n: got %d M1 cycles, want 1 (unprefixed, imm isn't an M1 fetch)", RefreshCycles(ADD_A_N))
+	}
+	if RefreshCycles(RLC_A) != 2 {
+		t.Errorf("RLC A: got %d M1 cycles, want 2 (CB prefix)", RefreshCycles(RLC_A))
+	}
+	if RefreshCycles(NEG) != 2 {
+		t.Errorf("NEG: got %d M1 cycles, want 2 (ED prefix)", RefreshCycles(NEG))
+	}
+	if RefreshCycles(RLC_IXD) != 2 {
+		t.Errorf("RLC (IX+d): got %d M1 cycles, want 2 (DD CB prefix, disp/final byte aren't M1)", RefreshCycles(RLC_IXD))
+	}
+}
+
 // TestDisassemble verifies mnemonic generation.
 func TestDisassemble(t *testing.T) {
 	tests := []struct {
 		instr Instruction
 		want  string
 	}{
-		{Instruction{ADD_A_B, 0}, "ADD A, B"},
-		{Instruction{LD_A_N, 0x00}, "LD A, 00h"},
-		{Instruction{LD_A_N, 0xFF}, "LD A, 0FFh"},
-		{Instruction{XOR_A, 0}, "XOR A"},
-		{Instruction{NOP, 0}, "NOP"},
+		{Instruction{Op: ADD_A_B}, "ADD A, B"},
+		{Instruction{Op: LD_A_N, Imm: 0x00}, "LD A, 00h"},
+		{Instruction{Op: LD_A_N, Imm: 0xFF}, "LD A, 0FFh"},
+		{Instruction{Op: XOR_A}, "XOR A"},
+		{Instruction{Op: NOP}, "NOP"},
 	}
 
 	for _, tc := range tests {
@@ -176,8 +255,8 @@ func TestAllOpsCount(t *testing.T) {
 // TestSeqByteSize verifies sequence byte size calculation.
 func TestSeqByteSize(t *testing.T) {
 	seq := []Instruction{
-		{ADD_A_B, 0},  // 1 byte
-		{LD_A_N, 0x42}, // 2 bytes
+		{Op: ADD_A_B},           // 1 byte
+		{Op: LD_A_N, Imm: 0x42}, // 2 bytes
 	}
 	if SeqByteSize(seq) != 3 {
 		t.Errorf("SeqByteSize: got %d want 3", SeqByteSize(seq))
@@ -0,0 +1,41 @@
+package inst
+
+// SeqAddresses returns the byte address of each instruction in seq, laid out
+// consecutively starting at 0 — the addressing a superblock verifier (or the
+// Mutator, when it needs to pick a branch target) assigns a sequence before
+// resolving what a JR/DJNZ/JP's Instruction.Imm actually points at (see
+// HasBranchTarget and Wave 7's control-flow doc comment in instruction.go).
+func SeqAddresses(seq []Instruction) []uint16 {
+	addrs := make([]uint16, len(seq))
+	addr := uint16(0)
+	for i := range seq {
+		addrs[i] = addr
+		addr += uint16(ByteSize(seq[i].Op))
+	}
+	return addrs
+}
+
+// IsWellFormedCFG reports whether every branch instruction in seq (chunk4-3)
+// targets either the start of some instruction in seq or the address just
+// past the last one (a clean exit from the block). A target landing
+// mid-instruction or at an address seq never reaches is a dangling branch.
+func IsWellFormedCFG(seq []Instruction) bool {
+	if len(seq) == 0 {
+		return true
+	}
+	addrs := SeqAddresses(seq)
+	end := addrs[len(addrs)-1] + uint16(ByteSize(seq[len(seq)-1].Op))
+
+	valid := make(map[uint16]bool, len(addrs)+1)
+	for _, a := range addrs {
+		valid[a] = true
+	}
+	valid[end] = true
+
+	for i := range seq {
+		if HasBranchTarget(seq[i].Op) && !valid[seq[i].Imm] {
+			return false
+		}
+	}
+	return true
+}
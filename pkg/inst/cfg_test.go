@@ -0,0 +1,41 @@
+package inst
+
+import "testing"
+
+func TestSeqAddresses(t *testing.T) {
+	seq := []Instruction{{Op: LD_A_N, Imm: 1}, {Op: INC_A}, {Op: NOP}}
+	addrs := SeqAddresses(seq)
+	want := []uint16{0, uint16(ByteSize(LD_A_N)), uint16(ByteSize(LD_A_N)) + uint16(ByteSize(INC_A))}
+	for i := range want {
+		if addrs[i] != want[i] {
+			t.Fatalf("addrs[%d] = %d, want %d", i, addrs[i], want[i])
+		}
+	}
+}
+
+func TestIsWellFormedCFG(t *testing.T) {
+	seq := []Instruction{{Op: LD_A_N, Imm: 1}, {Op: INC_A}, {Op: NOP}}
+	addrs := SeqAddresses(seq)
+	end := addrs[2] + uint16(ByteSize(NOP))
+
+	withJRToInc := append(copySeqForTest(seq), Instruction{Op: JR_NZ, Imm: addrs[1]})
+	if !IsWellFormedCFG(withJRToInc) {
+		t.Fatal("a branch targeting an existing instruction boundary should be well-formed")
+	}
+
+	withJRToEnd := append(copySeqForTest(seq), Instruction{Op: JR_NZ, Imm: end + uint16(ByteSize(JR_NZ))})
+	if !IsWellFormedCFG(withJRToEnd) {
+		t.Fatal("a branch targeting the address just past the block should be well-formed")
+	}
+
+	withDangling := append(copySeqForTest(seq), Instruction{Op: JR_NZ, Imm: 0xBEEF})
+	if IsWellFormedCFG(withDangling) {
+		t.Fatal("a branch to an address the sequence never reaches should not be well-formed")
+	}
+}
+
+func copySeqForTest(seq []Instruction) []Instruction {
+	out := make([]Instruction, len(seq))
+	copy(out, seq)
+	return out
+}
@@ -0,0 +1,109 @@
+package inst
+
+import "strings"
+
+// Class buckets opcodes into broad families for the Mutator's instruction-
+// class-weighted mutation (chunk4-5) — coarse enough that "this target is
+// ALU-heavy, don't waste mutation budget on shifts" doesn't need a
+// hand-maintained per-opcode table, derived instead from each opcode's
+// catalog mnemonic.
+type Class int
+
+const (
+	ClassUnknown Class = iota
+	ClassALU8
+	ClassALU16
+	ClassLoad
+	ClassStore
+	ClassShiftRotate
+	ClassBit
+	ClassControl
+	ClassStack
+	ClassIO
+	classCount
+)
+
+var classNames = [classCount]string{
+	"UNKNOWN", "ALU8", "ALU16", "LOAD", "STORE", "SHIFT_ROTATE", "BIT", "CONTROL", "STACK", "IO",
+}
+
+// String returns the taxonomy name (e.g. "ALU8"), the same spelling
+// MutationProfile uses as a map key.
+func (c Class) String() string {
+	if c >= 0 && int(c) < len(classNames) {
+		return classNames[c]
+	}
+	return "UNKNOWN"
+}
+
+// AllClasses returns every Class except ClassUnknown, in a stable order —
+// the iteration order MutationProfile uses to build a default/uniform weight
+// map.
+func AllClasses() []Class {
+	classes := make([]Class, 0, int(classCount)-1)
+	for c := ClassALU8; c < classCount; c++ {
+		classes = append(classes, c)
+	}
+	return classes
+}
+
+// ClassOf classifies op from its catalog mnemonic. This repo has no
+// PUSH/POP yet (Wave 7's control-flow wave modeled CALL/RET without a real
+// stack — see instruction.go), so ClassStack currently has no members; it's
+// kept in the taxonomy so a future stack-ops wave doesn't need a new Class.
+func ClassOf(op OpCode) Class {
+	mnemonic := Catalog[op].Mnemonic
+	prefix := mnemonicPrefix(mnemonic)
+
+	switch prefix {
+	case "JR", "JP", "DJNZ", "CALL", "RET", "RETI", "RST", "NOP", "STOP":
+		return ClassControl
+	case "IN", "OUT", "INI", "INIR", "IND", "INDR", "OUTI", "OTIR", "OUTD", "OTDR":
+		return ClassIO
+	case "BIT", "RES", "SET":
+		return ClassBit
+	case "RLC", "RRC", "RL", "RR", "SLA", "SRA", "SLL", "SRL", "SWAP", "RLCA", "RRCA", "RLA", "RRA":
+		return ClassShiftRotate
+	case "LD", "LDH":
+		if strings.HasPrefix(mnemonicDest(mnemonic), "(") {
+			return ClassStore
+		}
+		return ClassLoad
+	case "ADD", "ADC", "SUB", "SBC", "AND", "OR", "XOR", "CP", "INC", "DEC", "NEG", "DAA", "CPL", "CCF", "SCF", "EX":
+		if isWideOperand(mnemonicDest(mnemonic)) {
+			return ClassALU16
+		}
+		return ClassALU8
+	}
+	return ClassUnknown
+}
+
+func mnemonicPrefix(mnemonic string) string {
+	if i := strings.IndexByte(mnemonic, ' '); i >= 0 {
+		return mnemonic[:i]
+	}
+	return mnemonic
+}
+
+// mnemonicDest returns the destination operand text: everything after the
+// opcode keyword, up to the first comma (or the whole remainder for a
+// single-operand op like "INC HL").
+func mnemonicDest(mnemonic string) string {
+	i := strings.IndexByte(mnemonic, ' ')
+	if i < 0 {
+		return ""
+	}
+	operand := mnemonic[i+1:]
+	if j := strings.IndexByte(operand, ','); j >= 0 {
+		operand = operand[:j]
+	}
+	return strings.TrimSpace(operand)
+}
+
+func isWideOperand(dest string) bool {
+	switch dest {
+	case "HL", "BC", "DE", "SP", "IX", "IY":
+		return true
+	}
+	return false
+}
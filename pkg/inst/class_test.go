@@ -0,0 +1,39 @@
+package inst
+
+import "testing"
+
+func TestClassOf(t *testing.T) {
+	tests := []struct {
+		op   OpCode
+		want Class
+	}{
+		{ADD_A_B, ClassALU8},
+		{ADD_HL_BC, ClassALU16},
+		{AND_N, ClassALU8},
+		{INC_A, ClassALU8},
+		{INC_HL, ClassALU16},
+		{LD_A_B, ClassLoad},
+		{LD_IXD_A, ClassStore},
+		{LD_A_N, ClassLoad},
+		{SLA_A, ClassShiftRotate},
+		{BIT_0_A, ClassBit},
+		{JR_NZ, ClassControl},
+		{DJNZ, ClassControl},
+		{CALL, ClassControl},
+		{IN_A_N, ClassIO},
+		{OUT_N_A, ClassIO},
+	}
+	for _, tt := range tests {
+		if got := ClassOf(tt.op); got != tt.want {
+			t.Errorf("ClassOf(%s) = %s, want %s", Disassemble(Instruction{Op: tt.op}), got, tt.want)
+		}
+	}
+}
+
+func TestAllClassesExcludesUnknown(t *testing.T) {
+	for _, c := range AllClasses() {
+		if c == ClassUnknown {
+			t.Fatal("AllClasses should not include ClassUnknown")
+		}
+	}
+}
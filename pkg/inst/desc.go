@@ -0,0 +1,60 @@
+package inst
+
+// Desc is the per-opcode metadata consumed by the encoder, disassembler,
+// and cycle counter. It used to be scattered across Catalog plus the
+// HasImmediate/HasImm16/HasIndexDisp/UsesMemory switches; those switches
+// remain the single place that *defines* immediate/displacement shape
+// (new waves still add a case there), but everything downstream now reads
+// the derived Table instead of recomputing it per call.
+type Desc struct {
+	Mnemonic  string
+	Encoding  []uint8
+	SizeBytes uint8
+	TStates   uint8
+	ImmSize   uint8 // 0 = none, 1 = 8-bit, 2 = 16-bit
+	HasDisp   bool  // Wave 6: carries a (IX+d)/(IY+d) displacement byte
+	UsesMem   bool
+	Flags     FlagEffect // chunk2-1: Z/N/H/C sentinels; zero value = unannotated
+	Access    MemAccess  // chunk4-6: opcode/operand fetch and memory read/write counts
+	MCycles   uint8      // chunk11-4: bus M-cycle count, summed from Access
+}
+
+// Table holds the derived Desc for every OpCode, built once from Catalog
+// and the shape switches above. Callers that only need one field (TStates,
+// ByteSize, Disassemble) read straight from Table[op] rather than walking
+// HasImmediate/HasImm16/HasIndexDisp themselves.
+var Table [OpCodeCount]Desc
+
+func buildTable() {
+	for op := OpCode(0); op < OpCodeCount; op++ {
+		immSize := uint8(0)
+		switch {
+		case HasImm16(op):
+			immSize = 2
+		case HasImmediate(op):
+			immSize = 1
+		}
+		hasDisp := HasIndexDisp(op)
+
+		size := uint8(len(Catalog[op].Bytes)) + immSize
+		if hasDisp {
+			size++ // displacement byte, distinct from the immediate
+		}
+
+		access := accessFor(op)
+		mcycles := access.OpcodeFetchBytes + access.OperandFetchBytes + access.MemReads + access.MemWrites
+
+		Table[op] = Desc{
+			Mnemonic:  Catalog[op].Mnemonic,
+			Encoding:  Catalog[op].Bytes,
+			SizeBytes: size,
+			TStates:   uint8(Catalog[op].TStates),
+			ImmSize:   immSize,
+			HasDisp:   hasDisp,
+			UsesMem:   UsesMemory(op),
+			Flags:     FlagEffects[op],
+			Access:    access,
+			MCycles:   mcycles,
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package inst
+
+// equivTable links opcodes that can stand in for each other when their
+// remaining differences (size, cycles, which flags they touch, reachable
+// range) are acceptable at the call site — the inst-level counterpart to
+// the DispKey/OpKey linking LLVM's SystemZ backend uses to let a single
+// pass ask "is there a cheaper encoding of this?" instead of special-casing
+// each swap by hand.
+//
+// This only covers opcode-level equivalences: ones that hold for every
+// Instruction carrying that OpCode, regardless of its Imm/Disp. "LD A,0 is
+// equivalent to XOR A" is NOT opcode-level — LD_A_N is only equivalent to
+// XOR_A when its immediate happens to be 0, and this module has no
+// Instruction-aware variant of this table yet (a future constant-folding
+// pass would need one). Likewise "CALL nn is equivalent to RST n" only
+// holds when nn happens to equal one of the eight fixed RST vectors, and
+// "ADD HL,HL is equivalent to SLA L; RL H" isn't an opcode swap at all — it
+// trades one instruction for two (see pkg/inst's Fused-span territory,
+// which this table doesn't attempt).
+var equivTable = map[OpCode][]OpCode{
+	XOR_A: {SUB_A},
+	SUB_A: {XOR_A},
+
+	JP:    {JR},
+	JR:    {JP},
+	JP_Z:  {JR_Z},
+	JR_Z:  {JP_Z},
+	JP_NZ: {JR_NZ},
+	JR_NZ: {JP_NZ},
+	JP_C:  {JR_C},
+	JR_C:  {JP_C},
+	JP_NC: {JR_NC},
+	JR_NC: {JP_NC},
+
+	// RLCA and RLC A both rotate A left through carry into bit 0 with the
+	// same result byte; RLCA is the cheaper, older 1-byte/4-T-state form,
+	// RLC A the CB-prefixed 2-byte/8-T-state one that additionally sets
+	// S/Z/PV from the result (RLCA only touches C/N/H and the undocumented
+	// Y/X copies) — substitutable whenever S/Z/PV are dead, same shape as
+	// every other entry here.
+	RLCA:  {RLC_A},
+	RLC_A: {RLCA},
+}
+
+// Equivalents returns the other opcodes equivTable links op to — empty if
+// op has no known equivalent. The result says nothing about whether a
+// given equivalent is actually usable at a call site (see
+// CheaperEquivalent for that).
+func Equivalents(op OpCode) []OpCode {
+	return equivTable[op]
+}
+
+// Constraints carries the call-site facts CheaperEquivalent needs to tell
+// whether an equivalent opcode is actually substitutable, not just
+// related.
+type Constraints struct {
+	// DeadFlags lists flag bits (FlagMask, chunk9-2) the caller doesn't
+	// need preserved — a candidate is acceptable if FlagsWritten only
+	// differs from op's within these bits.
+	DeadFlags FlagMask
+
+	// ShortJumpReachable must be set by the caller: whether a JR/DJNZ's
+	// signed 8-bit displacement can actually reach the JP/CALL target in
+	// question. OpCode alone never carries an address, so this table can't
+	// derive it — only the caller resolving a real branch target can.
+	ShortJumpReachable bool
+}
+
+// CheaperEquivalent returns the cheapest (by ByteSize, then TStates) of
+// op's Equivalents that Constraints actually permits, or ok=false if none
+// qualify (including when op has no equivalents at all).
+func CheaperEquivalent(op OpCode, c Constraints) (OpCode, bool) {
+	best := OpCode(0)
+	found := false
+
+	for _, cand := range Equivalents(op) {
+		if IsBranch(cand) && IsBranch(op) && !c.ShortJumpReachable {
+			// Swapping a long jump for a short one only makes sense if the
+			// caller has confirmed the target is in range.
+			if ByteSize(cand) < ByteSize(op) {
+				continue
+			}
+		}
+		flagDiff := FlagsWritten(op) ^ FlagsWritten(cand)
+		if flagDiff&^c.DeadFlags != 0 {
+			continue
+		}
+		if !found || cheaper(cand, best) {
+			best, found = cand, true
+		}
+	}
+	return best, found
+}
+
+func cheaper(a, b OpCode) bool {
+	if ByteSize(a) != ByteSize(b) {
+		return ByteSize(a) < ByteSize(b)
+	}
+	return TStates(a) < TStates(b)
+}
@@ -0,0 +1,48 @@
+package inst
+
+import "testing"
+
+func TestEquivalents(t *testing.T) {
+	if got := Equivalents(XOR_A); len(got) != 1 || got[0] != SUB_A {
+		t.Errorf("Equivalents(XOR_A) = %v, want [SUB_A]", got)
+	}
+	if got := Equivalents(JP); len(got) != 1 || got[0] != JR {
+		t.Errorf("Equivalents(JP) = %v, want [JR]", got)
+	}
+	if got := Equivalents(NOP); got != nil {
+		t.Errorf("Equivalents(NOP) = %v, want nil", got)
+	}
+}
+
+func TestCheaperEquivalent_JPtoJRNeedsReachability(t *testing.T) {
+	if _, ok := CheaperEquivalent(JP, Constraints{}); ok {
+		t.Error("CheaperEquivalent(JP) without ShortJumpReachable should refuse JR")
+	}
+	got, ok := CheaperEquivalent(JP, Constraints{ShortJumpReachable: true})
+	if !ok || got != JR {
+		t.Errorf("CheaperEquivalent(JP, reachable) = (%v, %v), want (JR, true)", got, ok)
+	}
+}
+
+func TestCheaperEquivalent_XorSubAlreadyEqualCost(t *testing.T) {
+	got, ok := CheaperEquivalent(XOR_A, Constraints{})
+	if !ok || got != SUB_A {
+		t.Errorf("CheaperEquivalent(XOR_A) = (%v, %v), want (SUB_A, true)", got, ok)
+	}
+}
+
+func TestCheaperEquivalent_NoneForUnlistedOp(t *testing.T) {
+	if _, ok := CheaperEquivalent(NOP, Constraints{}); ok {
+		t.Error("CheaperEquivalent(NOP) should have no candidates")
+	}
+}
+
+func TestCheaperEquivalent_RlcaNeedsSZPVDead(t *testing.T) {
+	if _, ok := CheaperEquivalent(RLC_A, Constraints{}); ok {
+		t.Error("CheaperEquivalent(RLC_A) without S/Z/PV dead should refuse RLCA")
+	}
+	got, ok := CheaperEquivalent(RLC_A, Constraints{DeadFlags: FlagS | FlagZ | FlagPV})
+	if !ok || got != RLCA {
+		t.Errorf("CheaperEquivalent(RLC_A, S/Z/PV dead) = (%v, %v), want (RLCA, true)", got, ok)
+	}
+}
@@ -0,0 +1,103 @@
+package inst
+
+import (
+	"strings"
+	"sync"
+)
+
+// FamilyOf and OperandOf give a query-level grouping of operand-form
+// variants of "the same instruction" — e.g. ADD_A_B, ADD_A_HLI, ADD_A_IXD,
+// ADD_A_IYD, and ADD_A_N all share family "ADD A" but differ in Operand.
+//
+// This is deliberately an additive classification/lookup layer, not a
+// replacement for Catalog/Table: this module's OpCode enum already bakes
+// each operand form into its own constant with its own correctly-costed
+// Info entry (ByteSize/TStates/Disassemble all key off OpCode exactly as
+// before), including (HL)/(IX+d)/(IY+d) forms for the ALU, rotate, and
+// BIT/RES/SET families — those addressing modes are fully modeled from
+// earlier waves (see e.g. BIT_0_IXD/RES_0_IXD/RLC_IXD in catalog.go), not
+// "currently absent" as chunk9-5 otherwise assumed. Rather than rewire
+// ByteSize/TStates/Disassemble and every pattern matcher to consult a
+// (Family, Operand) pair instead of a flat OpCode — a large, invasive
+// rewrite with no build/test loop in this tree to verify it against —
+// FamilyOf/OperandOf/Lookup sit on top of the existing flat arrays and let
+// a caller (e.g. a future peephole rule) ask "what's the (HL) form of this
+// register-operand ALU op" without a hand-maintained per-opcode table.
+type Operand int
+
+const (
+	OperandReg8 Operand = iota
+	OperandHLI
+	OperandIXD
+	OperandIYD
+	OperandImm8
+)
+
+// FamilyOf strips op's catalog mnemonic down to its operand-independent
+// prefix: everything up to (and not including) the last comma, or the
+// first space if there's no comma, or the whole mnemonic if there's
+// neither. The last-comma split (rather than mnemonicDest's first-comma
+// split) is what keeps e.g. "BIT 0, B" and "BIT 1, B" in distinct families
+// ("BIT 0" vs "BIT 1") while still collapsing "ADD A, B"/"ADD A, (IX+d)"/
+// "ADD A, n" into the shared family "ADD A".
+func FamilyOf(op OpCode) string {
+	mnemonic := Catalog[op].Mnemonic
+	if i := strings.LastIndexByte(mnemonic, ','); i >= 0 {
+		return strings.TrimSpace(mnemonic[:i])
+	}
+	if i := strings.IndexByte(mnemonic, ' '); i >= 0 {
+		return mnemonic[:i]
+	}
+	return mnemonic
+}
+
+// OperandOf classifies op's last (or only) operand by addressing mode,
+// from the same structural predicates instruction.go's HasIndexDisp/
+// UsesMemory/HasImmediate already use to drive Exec — not a new hand-
+// authored per-opcode table.
+func OperandOf(op OpCode) Operand {
+	switch {
+	case HasIndexDisp(op):
+		if strings.Contains(Catalog[op].Mnemonic, "(IY") {
+			return OperandIYD
+		}
+		return OperandIXD
+	case UsesMemory(op):
+		return OperandHLI
+	case HasImmediate(op) && !HasBranchTarget(op) && !HasImm16(op):
+		return OperandImm8
+	default:
+		return OperandReg8
+	}
+}
+
+var (
+	lookupOnce  sync.Once
+	lookupIndex map[string]map[Operand]OpCode
+)
+
+// Lookup finds the OpCode in family whose operand form is operand, e.g.
+// Lookup("ADD A", OperandIXD) => ADD_A_IXD. The index is built once, lazily,
+// over AllOps() — the same deferred-table convention pkg/rewrite/names.go's
+// opByName uses, except built from FamilyOf/OperandOf instead of hand-typed.
+func Lookup(family string, operand Operand) (OpCode, bool) {
+	lookupOnce.Do(func() {
+		lookupIndex = make(map[string]map[Operand]OpCode)
+		for _, op := range AllOps() {
+			byOperand := lookupIndex[FamilyOf(op)]
+			if byOperand == nil {
+				byOperand = make(map[Operand]OpCode)
+				lookupIndex[FamilyOf(op)] = byOperand
+			}
+			// First OpCode registered for a (family, operand) pair wins;
+			// AllOps() order is declaration order, and no family currently
+			// has two distinct OpCodes sharing one Operand classification.
+			opOperand := OperandOf(op)
+			if _, exists := byOperand[opOperand]; !exists {
+				byOperand[opOperand] = op
+			}
+		}
+	})
+	op, ok := lookupIndex[family][operand]
+	return op, ok
+}
@@ -0,0 +1,81 @@
+package inst
+
+import "testing"
+
+func TestFamilyOf_GroupsOperandFormsOfOneInstruction(t *testing.T) {
+	for _, op := range []OpCode{ADD_A_B, ADD_A_HLI, ADD_A_IXD, ADD_A_IYD, ADD_A_N} {
+		if got := FamilyOf(op); got != "ADD A" {
+			t.Errorf("FamilyOf(%v) = %q, want %q", OpCodeName(op), got, "ADD A")
+		}
+	}
+}
+
+func TestFamilyOf_KeepsDistinctBitNumbersApart(t *testing.T) {
+	if f0, f1 := FamilyOf(BIT_0_B), FamilyOf(BIT_1_B); f0 == f1 {
+		t.Errorf("FamilyOf(BIT_0_B) == FamilyOf(BIT_1_B) == %q, want distinct families", f0)
+	}
+}
+
+func TestOperandOf_AddressingModes(t *testing.T) {
+	cases := []struct {
+		op   OpCode
+		want Operand
+	}{
+		{ADD_A_B, OperandReg8},
+		{ADD_A_HLI, OperandHLI},
+		{ADD_A_IXD, OperandIXD},
+		{ADD_A_IYD, OperandIYD},
+		{ADD_A_N, OperandImm8},
+	}
+	for _, c := range cases {
+		if got := OperandOf(c.op); got != c.want {
+			t.Errorf("OperandOf(%v) = %v, want %v", OpCodeName(c.op), got, c.want)
+		}
+	}
+}
+
+func TestLookup_FindsSiblingOperandForms(t *testing.T) {
+	op, ok := Lookup(FamilyOf(ADD_A_B), OperandIXD)
+	if !ok || op != ADD_A_IXD {
+		t.Fatalf("Lookup(%q, OperandIXD) = (%v, %v), want (ADD_A_IXD, true)", FamilyOf(ADD_A_B), OpCodeName(op), ok)
+	}
+}
+
+func TestLookup_UnknownFamilyNotFound(t *testing.T) {
+	if _, ok := Lookup("NOT_A_FAMILY", OperandReg8); ok {
+		t.Error("Lookup on an unknown family should report not found")
+	}
+}
+
+// TestLookup_RoundTripsOperand guards against keying the lookup index by
+// the query's operand argument instead of OperandOf(op): that bug still
+// passes TestLookup_FindsSiblingOperandForms above because OperandIXD
+// happens to be the first non-register form ever looked up for "ADD A", so
+// it only surfaces once a family/operand pair other than the first one
+// registered is queried. Walking every (family, operand) pair actually in
+// the index and checking OperandOf agrees catches it regardless of which
+// form a test happens to ask for first.
+func TestLookup_RoundTripsOperand(t *testing.T) {
+	seen := map[string]map[Operand]bool{}
+	for _, op := range AllOps() {
+		family := FamilyOf(op)
+		operand := OperandOf(op)
+		if seen[family] == nil {
+			seen[family] = map[Operand]bool{}
+		}
+		if seen[family][operand] {
+			continue
+		}
+		seen[family][operand] = true
+
+		got, ok := Lookup(family, operand)
+		if !ok {
+			t.Errorf("Lookup(%q, %v) = not found, want %v", family, operand, OpCodeName(op))
+			continue
+		}
+		if OperandOf(got) != operand {
+			t.Errorf("Lookup(%q, %v) = %v, whose OperandOf is %v, want %v",
+				family, operand, OpCodeName(got), OperandOf(got), operand)
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package inst
+
+// FlagEffect is the per-flag sentinel from an opcode's machine-readable spec
+// line, using the same notation the Game Boy ops.yaml convention uses: '-'
+// means the flag is left unaffected, '0'/'1' means it's forced to that
+// value, and the flag's own letter (e.g. 'Z' in the Z field) means it's set
+// from the computed result the way that flag normally would be. A zero
+// FlagEffect (all four fields 0, not '-') means "unspecified": this opcode
+// predates the spec and hasn't been annotated yet.
+type FlagEffect struct {
+	Z, N, H, C byte
+}
+
+// Known reports whether this FlagEffect was actually filled in by a .defs
+// line, as opposed to being the zero value every pre-spec opcode still has.
+func (fe FlagEffect) Known() bool {
+	return fe.Z != 0 || fe.N != 0 || fe.H != 0 || fe.C != 0
+}
+
+// FlagEffects holds the per-opcode flag-effect spec, indexed by OpCode.
+// Entries are filled in by generated wave files (see pkg/inst/gen's optional
+// 5th .defs field); opcodes from before this spec existed are left at the
+// zero value rather than guessed at, since getting one wrong silently would
+// be worse than admitting it's unannotated — see FlagEffect.Known.
+var FlagEffects [OpCodeCount]FlagEffect
@@ -0,0 +1,126 @@
+package inst
+
+import "fmt"
+
+// Fused describes a recognized multi-instruction idiom that the optimizer
+// should treat as one atomic unit — the cost-model counterpart to
+// FamilyOf/OperandOf (chunk9-5)'s operand-form grouping, except grouping a
+// run of Instructions instead of a single OpCode's addressing-mode
+// variants. Named after Hexagon's InstDuplex (two packed sub-instructions
+// costed and scheduled as one), adapted to the idiomatic Z80 pairs this
+// tree's OpCode set actually models.
+//
+// Scope: of the idioms chunk9-6 names, this tree has no PUSH/POP (see
+// ClassOf's doc comment — Wave 7 modeled CALL/RET without a real stack),
+// no LDI/LDIR/CPIR block-transfer/compare ops, and no EX (SP),HL — none of
+// those opcodes exist to recognize a sequence of. Recognize below only
+// covers the two idioms whose constituent opcodes are actually modeled:
+// the LD A,(HL) + INC/DEC HL stream-load (interesting in its own right,
+// since the SM83 dialect already has this exact idiom as the single
+// opcodes LD_A_HLI_INC/LD_A_HLI_DEC — see catalog.go — so this recognizer
+// surfaces the same fusion for plain Z80 code that never collapsed it into
+// one opcode) and a run of OUT (C),r bursts. A future wave adding
+// PUSH/POP, LDIR/CPIR, or EX (SP),HL should add a recognizer function here
+// and list it in recognizers, the same way ClassOf's switch is additive.
+type Fused struct {
+	Name     string // stable idiom identifier, e.g. "stream-load"
+	Mnemonic string // canonical disassembly, e.g. "LD A, (HL+)"
+	TStates  int
+	ByteSize int
+	Writes   FlagMask
+}
+
+// FusedSpan is one match of a Fused idiom against a run of instructions.
+type FusedSpan struct {
+	Start int
+	Len   int
+	Fused Fused
+}
+
+// recognizer attempts to match an idiom starting at seq[i], returning the
+// span length consumed (0 if it doesn't match at i).
+type recognizer func(seq []Instruction, i int) (Fused, int)
+
+var recognizers = []recognizer{
+	recognizeStreamLoad,
+	recognizeOutBurst,
+}
+
+// Recognize scans seq left to right and returns every non-overlapping
+// FusedSpan found, preferring earlier recognizers and longer matches at
+// each position the way a greedy tokenizer would — it does not search for
+// the globally optimal partition, since spans here only need to be
+// correctness-preserving atomic units for a caller that reorders or
+// substitutes, not a unique canonical decomposition.
+func Recognize(seq []Instruction) []FusedSpan {
+	var spans []FusedSpan
+	for i := 0; i < len(seq); {
+		matched := false
+		for _, r := range recognizers {
+			fused, n := r(seq, i)
+			if n < 2 {
+				continue
+			}
+			spans = append(spans, FusedSpan{Start: i, Len: n, Fused: fused})
+			i += n
+			matched = true
+			break
+		}
+		if !matched {
+			i++
+		}
+	}
+	return spans
+}
+
+// recognizeStreamLoad matches LD A,(HL) immediately followed by INC HL (or
+// DEC HL) — the idiom a stream-reading loop body repeats every iteration,
+// already modeled as one SM83 opcode (LD_A_HLI_INC/LD_A_HLI_DEC) but left
+// as two plain-Z80 instructions here.
+func recognizeStreamLoad(seq []Instruction, i int) (Fused, int) {
+	if i+1 >= len(seq) || seq[i].Op != LD_A_HLI {
+		return Fused{}, 0
+	}
+	var canonical OpCode
+	switch seq[i+1].Op {
+	case INC_HL:
+		canonical = LD_A_HLI_INC
+	case DEC_HL:
+		canonical = LD_A_HLI_DEC
+	default:
+		return Fused{}, 0
+	}
+	return spanOf("stream-load", OpCodeName(canonical), seq[i:i+2]), 2
+}
+
+// recognizeOutBurst matches a run of two or more consecutive OUT (C),r
+// instructions — the register-drain idiom used to stream several 8-bit
+// values out a fixed port without recomputing the port address each time.
+func recognizeOutBurst(seq []Instruction, i int) (Fused, int) {
+	if seq[i].Op < OUT_C_A || seq[i].Op > OUT_C_L {
+		return Fused{}, 0
+	}
+	n := 1
+	for i+n < len(seq) && seq[i+n].Op >= OUT_C_A && seq[i+n].Op <= OUT_C_L {
+		n++
+	}
+	if n < 2 {
+		return Fused{}, 0
+	}
+	return spanOf("out-burst", fmt.Sprintf("OUT (C), r*%d", n), seq[i:i+n]), n
+}
+
+// spanOf combines a matched instruction run's cost and flag effects: sizes
+// and timings sum (the Z80 executes each constituent in turn), and the
+// combined write set is the union of what each instruction writes — the
+// same conservative union chunk9-2's FlagsWritten uses for a single
+// instruction, just folded across the whole run.
+func spanOf(name, mnemonic string, run []Instruction) Fused {
+	f := Fused{Name: name, Mnemonic: mnemonic}
+	for _, instr := range run {
+		f.ByteSize += ByteSize(instr.Op)
+		f.TStates += TStates(instr.Op)
+		f.Writes |= FlagsWritten(instr.Op)
+	}
+	return f
+}
@@ -0,0 +1,63 @@
+package inst
+
+import "testing"
+
+func TestRecognize_StreamLoadInc(t *testing.T) {
+	seq := []Instruction{{Op: LD_A_HLI}, {Op: INC_HL}}
+	spans := Recognize(seq)
+	if len(spans) != 1 || spans[0].Start != 0 || spans[0].Len != 2 {
+		t.Fatalf("Recognize(LD A,(HL); INC HL) = %v, want one span of length 2", spans)
+	}
+	got := spans[0].Fused
+	want := Fused{
+		Name:     "stream-load",
+		Mnemonic: OpCodeName(LD_A_HLI_INC),
+		ByteSize: ByteSize(LD_A_HLI) + ByteSize(INC_HL),
+		TStates:  TStates(LD_A_HLI) + TStates(INC_HL),
+		Writes:   FlagsWritten(LD_A_HLI) | FlagsWritten(INC_HL),
+	}
+	if got != want {
+		t.Errorf("Fused = %+v, want %+v", got, want)
+	}
+}
+
+func TestRecognize_StreamLoadDec(t *testing.T) {
+	seq := []Instruction{{Op: LD_A_HLI}, {Op: DEC_HL}}
+	spans := Recognize(seq)
+	if len(spans) != 1 || spans[0].Fused.Mnemonic != OpCodeName(LD_A_HLI_DEC) {
+		t.Fatalf("Recognize(LD A,(HL); DEC HL) = %v, want one span with mnemonic LD A, (HL-)", spans)
+	}
+}
+
+func TestRecognize_LoneLoadDoesNotFuse(t *testing.T) {
+	seq := []Instruction{{Op: LD_A_HLI}, {Op: NOP}}
+	if spans := Recognize(seq); len(spans) != 0 {
+		t.Errorf("Recognize(LD A,(HL); NOP) = %v, want no spans", spans)
+	}
+}
+
+func TestRecognize_OutBurst(t *testing.T) {
+	seq := []Instruction{{Op: OUT_C_A}, {Op: OUT_C_B}, {Op: OUT_C_C}}
+	spans := Recognize(seq)
+	if len(spans) != 1 || spans[0].Len != 3 || spans[0].Fused.Mnemonic != "OUT (C), r*3" {
+		t.Fatalf("Recognize(OUT (C),A/B/C) = %v, want one span of length 3", spans)
+	}
+}
+
+func TestRecognize_SingleOutDoesNotFuse(t *testing.T) {
+	seq := []Instruction{{Op: OUT_C_A}, {Op: NOP}}
+	if spans := Recognize(seq); len(spans) != 0 {
+		t.Errorf("Recognize(OUT (C),A; NOP) = %v, want no spans", spans)
+	}
+}
+
+func TestRecognize_NonOverlappingConsecutiveSpans(t *testing.T) {
+	seq := []Instruction{
+		{Op: LD_A_HLI}, {Op: INC_HL},
+		{Op: OUT_C_A}, {Op: OUT_C_B},
+	}
+	spans := Recognize(seq)
+	if len(spans) != 2 || spans[0].Start != 0 || spans[1].Start != 2 {
+		t.Fatalf("Recognize(stream-load; out-burst) = %v, want spans at 0 and 2", spans)
+	}
+}
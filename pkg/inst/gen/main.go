@@ -0,0 +1,187 @@
+// Command gen reads a wave's .defs file and emits the Catalog entries for
+// it as Go source. It does NOT generate the OpCode enum itself — iota
+// numbering inside one const block doesn't compose safely with generated
+// code, so each wave still adds its OpCode names to instruction.go by hand.
+// What this replaces is hand-writing the matching Catalog[...] = Info{...}
+// lines: one DSL line becomes one entry, so a new wave is an edit to a
+// single .defs file plus the enum names, not three hand-synced spots.
+//
+// Defs line format (whitespace-separated, one instruction per line):
+//
+//	OPCODE_NAME  "Mnemonic text"  hexbyte[,hexbyte...]  tstates  [ZNHC]  [undoc]
+//
+// The ZNHC field is optional and chunk2-1's addition: four characters, one
+// per flag in Z,N,H,C order, each either '-' (unaffected), '0'/'1' (forced),
+// or the flag's own letter (set from the computed result — see
+// inst.FlagEffect). Omit it entirely for opcodes that haven't been
+// annotated yet; it is NOT defaulted to "----", since that would claim
+// "verified unaffected" for something nobody actually checked.
+//
+// The trailing "undoc" field is optional and chunk6-2's addition: marks the
+// opcode in inst.GenUndocumented, for undocumented forms that aren't SLL or
+// an IXH/IXL/IYH/IYL half-register access (isZilogUndocumented's existing
+// mnemonic heuristic already catches those two families without needing the
+// tag). "undoc" requires the ZNHC field to be present first, even if it's
+// "----", so field position alone tells parseDefs which one it's looking at.
+//
+// Example:
+//
+//	LD_A_B   "LD A, B"   0x78  4
+//	INC_A    "INC A"     0x3C  4  Z0H-
+//	OUT0_N_C "OUT0 (n),C" 0xED,0x91 12  ----  undoc
+//
+// Usage: go run ./gen <wave>.defs > <wave>_gen.go
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"go/format"
+	"os"
+	"strconv"
+	"strings"
+)
+
+type entry struct {
+	name     string
+	mnemonic string
+	bytes    []string
+	tstates  string
+	flags    string // empty if the line omitted the optional ZNHC field
+	undoc    bool   // chunk6-2: line ended in the optional "undoc" tag
+}
+
+func main() {
+	if len(os.Args) != 2 {
+		fmt.Fprintln(os.Stderr, "usage: gen <wave>.defs")
+		os.Exit(1)
+	}
+	entries, err := parseDefs(os.Args[1])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "gen:", err)
+		os.Exit(1)
+	}
+	src, err := render(entries)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "gen:", err)
+		os.Exit(1)
+	}
+	os.Stdout.Write(src)
+}
+
+func parseDefs(path string) ([]entry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []entry
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields, err := splitDefsLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", line, err)
+		}
+		if len(fields) < 4 || len(fields) > 6 {
+			return nil, fmt.Errorf("%s: want 4 to 6 fields, got %d", line, len(fields))
+		}
+		mnemonic, err := strconv.Unquote(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("%s: mnemonic must be quoted: %w", line, err)
+		}
+		e := entry{
+			name:     fields[0],
+			mnemonic: mnemonic,
+			bytes:    strings.Split(fields[2], ","),
+			tstates:  fields[3],
+		}
+		if len(fields) >= 5 {
+			if err := validateFlagSentinel(fields[4]); err != nil {
+				return nil, fmt.Errorf("%s: %w", line, err)
+			}
+			e.flags = fields[4]
+		}
+		if len(fields) == 6 {
+			if fields[5] != "undoc" {
+				return nil, fmt.Errorf("%s: unexpected trailing field %q, want \"undoc\"", line, fields[5])
+			}
+			e.undoc = true
+		}
+		entries = append(entries, e)
+	}
+	return entries, sc.Err()
+}
+
+// validateFlagSentinel checks the optional ZNHC field: exactly 4 characters,
+// each one of '-', '0', '1', or that position's own flag letter.
+func validateFlagSentinel(s string) error {
+	if len(s) != 4 {
+		return fmt.Errorf("flag field %q must be exactly 4 characters (ZNHC)", s)
+	}
+	letters := "ZNHC"
+	for i := 0; i < 4; i++ {
+		c := s[i]
+		if c != '-' && c != '0' && c != '1' && c != letters[i] {
+			return fmt.Errorf("flag field %q: position %d must be '-', '0', '1', or %q", s, i, letters[i])
+		}
+	}
+	return nil
+}
+
+// splitDefsLine splits on whitespace but keeps a double-quoted mnemonic
+// (which may itself contain spaces) as a single field.
+func splitDefsLine(line string) ([]string, error) {
+	var fields []string
+	for len(line) > 0 {
+		line = strings.TrimLeft(line, " \t")
+		if line == "" {
+			break
+		}
+		if line[0] == '"' {
+			end := strings.IndexByte(line[1:], '"')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated quote")
+			}
+			fields = append(fields, line[:end+2])
+			line = line[end+2:]
+			continue
+		}
+		sp := strings.IndexAny(line, " \t")
+		if sp < 0 {
+			fields = append(fields, line)
+			break
+		}
+		fields = append(fields, line[:sp])
+		line = line[sp:]
+	}
+	return fields, nil
+}
+
+func render(entries []entry) ([]byte, error) {
+	var b strings.Builder
+	b.WriteString("package inst\n\n")
+	b.WriteString("// Code generated by pkg/inst/gen from a .defs file. DO NOT EDIT.\n\n")
+	b.WriteString("func init() {\n")
+	for _, e := range entries {
+		tstates, err := strconv.Atoi(e.tstates)
+		if err != nil {
+			return nil, fmt.Errorf("%s: bad tstates %q: %w", e.name, e.tstates, err)
+		}
+		fmt.Fprintf(&b, "\tCatalog[%s] = Info{%q, []uint8{%s}, %d}\n",
+			e.name, e.mnemonic, strings.Join(e.bytes, ", "), tstates)
+		if e.flags != "" {
+			fmt.Fprintf(&b, "\tFlagEffects[%s] = FlagEffect{Z: %q, N: %q, H: %q, C: %q}\n",
+				e.name, e.flags[0], e.flags[1], e.flags[2], e.flags[3])
+		}
+		if e.undoc {
+			fmt.Fprintf(&b, "\tGenUndocumented[%s] = true\n", e.name)
+		}
+	}
+	b.WriteString("}\n")
+	return format.Source([]byte(b.String()))
+}
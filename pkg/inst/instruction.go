@@ -6,10 +6,12 @@ package inst
 type OpCode uint16
 
 // Instruction is a compact representation of one Z80 instruction.
-// 6 bytes: Op (uint16) + Imm (uint16) + padding. Still trivially copyable.
+// Wave 6: + Disp int8 — signed displacement for (IX+d)/(IY+d) addressing.
+// Still trivially copyable.
 type Instruction struct {
-	Op  OpCode
-	Imm uint16 // Immediate value (8-bit for most ops, 16-bit for LD rr,nn)
+	Op   OpCode
+	Imm  uint16 // Immediate value (8-bit for most ops, 16-bit for LD rr,nn)
+	Disp int8   // Wave 6: signed displacement for (IX+d)/(IY+d); unused otherwise
 }
 
 // HasImmediate returns true if this opcode uses an immediate operand (8 or 16-bit).
@@ -17,34 +19,85 @@ func HasImmediate(op OpCode) bool {
 	switch op {
 	case LD_A_N, LD_B_N, LD_C_N, LD_D_N, LD_E_N, LD_H_N, LD_L_N,
 		ADD_A_N, ADC_A_N, SUB_N, SBC_A_N, AND_N, XOR_N, OR_N, CP_N,
-		LD_HLI_N:
+		LD_HLI_N, LD_IXD_N, LD_IYD_N,
+		IN_A_N, OUT_N_A,
+		LDH_N_A, LDH_A_N: // chunk2-5: SM83 LDH (n),A / LDH A,(n) carry an 8-bit port offset
+		return true
+	}
+	// Wave 7: JR/DJNZ carry a 1-byte displacement (modeled as a branch
+	// target in Instruction.Imm, not an arithmetic value — see HasBranchTarget).
+	if op >= JR && op <= DJNZ {
 		return true
 	}
 	return HasImm16(op)
 }
 
 // UsesMemory returns true if this opcode accesses the virtual memory byte (State.M).
+// Wave 6: (IX+d)/(IY+d) ops also route through State.M (see HasIndexDisp), but
+// the IX/IY register-only ops that follow them (LD IX,nn, ADD IX,rr, IXH/IXL) do not.
+// Wave 8: the block I/O ops (INI/IND/OUTI/OUTD and their repeating forms)
+// move a byte through State.M on their way to/from the port.
+// chunk2-5: SM83's LDH forms and LD (HL+)/(HL-) share the same State.M
+// and HL-pair conventions as their Z80 LD (HL) cousins.
 func UsesMemory(op OpCode) bool {
-	return op >= LD_A_HLI && op < OpCodeCount
+	return (op >= LD_A_HLI && op <= SET_7_HLI) || HasIndexDisp(op) || (op >= INI && op <= OTDR) ||
+		(op >= LDH_N_A && op <= LD_A_HLI_DEC)
 }
 
 // HasImm16 returns true if this opcode uses a 16-bit immediate operand.
 func HasImm16(op OpCode) bool {
 	switch op {
-	case LD_BC_NN, LD_DE_NN, LD_HL_NN, LD_SP_NN:
+	case LD_BC_NN, LD_DE_NN, LD_HL_NN, LD_SP_NN, LD_IX_NN, LD_IY_NN:
+		return true
+	}
+	// Wave 7: JP/CALL carry a 2-byte absolute address, modeled as a branch
+	// target in Instruction.Imm (see HasBranchTarget).
+	return op >= JP && op <= CALL_C
+}
+
+// HasIndexDisp returns true if this opcode addresses memory via (IX+d) or
+// (IY+d) and therefore carries a signed displacement in Instruction.Disp.
+func HasIndexDisp(op OpCode) bool {
+	return op >= LD_A_IXD && op <= SET_7_IYD
+}
+
+// HasBranchTarget returns true if Instruction.Imm holds a branch target for
+// this opcode rather than an arithmetic/load immediate. For JR/DJNZ/JP/CALL
+// the target is a symbolic label id or resolved address supplied by the
+// outer search harness (see Wave 7 in the OpCode block below); for RST it's
+// a ROM entry point address supplied by the caller. RET/RET cc have no
+// target — they end the modeled sequence (see State.Halted).
+func HasBranchTarget(op OpCode) bool {
+	switch {
+	case op >= JR && op <= DJNZ:
+		return true
+	case op >= JP && op <= CALL_C:
+		return true
+	case op >= RST_00 && op <= RST_38:
 		return true
 	}
 	return false
 }
 
+// HasSideEffects returns true if this opcode has an observable effect beyond
+// cpu.State's registers/flags — currently just I/O: IN/OUT read or write a
+// device that may not be idempotent (a hardware status register, a sound
+// chip port), so the equivalence checker must treat their (port, value,
+// direction) trace as part of a sequence's observable behavior, and the
+// pruner must never discard one as a "dead write" just because the register
+// it loads looks unused.
+func HasSideEffects(op OpCode) bool {
+	return op >= IN_A_N && op <= OTDR
+}
+
 // OpCode constants for the Z80 superoptimizer.
 // Organized by implementation wave:
 //
-//   V1 (206 ops):  8-bit register loads, ALU, shifts, rotates, specials
-//   Wave 0:        Structural fixes (OpCode uint8→uint16, carry-flag bug, regMask widening)
-//   Wave 1 (+174): BIT/RES/SET n,r and SLL r (CB-prefix register ops)
-//   Wave 2 (+14):  16-bit pair ops (INC/DEC rr, ADD HL,rr, EX DE,HL, LD SP,HL)
-//   Wave 4 (+12):  16-bit immediates (LD rr,nn) and ED arithmetic (ADC/SBC HL,rr)
+//	V1 (206 ops):  8-bit register loads, ALU, shifts, rotates, specials
+//	Wave 0:        Structural fixes (OpCode uint8→uint16, carry-flag bug, regMask widening)
+//	Wave 1 (+174): BIT/RES/SET n,r and SLL r (CB-prefix register ops)
+//	Wave 2 (+14):  16-bit pair ops (INC/DEC rr, ADD HL,rr, EX DE,HL, LD SP,HL)
+//	Wave 4 (+12):  16-bit immediates (LD rr,nn) and ED arithmetic (ADC/SBC HL,rr)
 //
 // Total: 406 opcodes, 266,359 distinct instructions per search position.
 const (
@@ -591,5 +644,353 @@ const (
 	SET_6_HLI
 	SET_7_HLI
 
+	// === Wave 6: IX/IY indexed addressing (172 opcodes) ===
+	// Adds IX, IY to cpu.State. Indexed memory ops still share the single
+	// virtual memory byte State.M (same "one address per sequence" model as
+	// Wave 5's (HL) ops) — a real addressable Bus is a later wave. The
+	// displacement d is carried in Instruction.Disp, not Imm.
+
+	// LD IX,nn / LD IY,nn: 16-bit immediate load (uses Instruction.Imm)
+	LD_IX_NN
+	LD_IY_NN
+
+	// ADD IX,rr / ADD IY,rr
+	ADD_IX_BC
+	ADD_IX_DE
+	ADD_IX_IX
+	ADD_IX_SP
+	ADD_IY_BC
+	ADD_IY_DE
+	ADD_IY_IY
+	ADD_IY_SP
+
+	// LD r, (IX+d) / LD r, (IY+d) — displacement in Instruction.Disp
+	LD_A_IXD
+	LD_B_IXD
+	LD_C_IXD
+	LD_D_IXD
+	LD_E_IXD
+	LD_H_IXD
+	LD_L_IXD
+	LD_A_IYD
+	LD_B_IYD
+	LD_C_IYD
+	LD_D_IYD
+	LD_E_IYD
+	LD_H_IYD
+	LD_L_IYD
+
+	// LD (IX+d), r / LD (IY+d), r
+	LD_IXD_A
+	LD_IXD_B
+	LD_IXD_C
+	LD_IXD_D
+	LD_IXD_E
+	LD_IXD_H
+	LD_IXD_L
+	LD_IYD_A
+	LD_IYD_B
+	LD_IYD_C
+	LD_IYD_D
+	LD_IYD_E
+	LD_IYD_H
+	LD_IYD_L
+
+	// LD (IX+d), n / LD (IY+d), n — immediate in Instruction.Imm, displacement in Disp
+	LD_IXD_N
+	LD_IYD_N
+
+	// ALU A, (IX+d) / ALU A, (IY+d)
+	ADD_A_IXD
+	ADC_A_IXD
+	SUB_IXD
+	SBC_A_IXD
+	AND_IXD
+	XOR_IXD
+	OR_IXD
+	CP_IXD
+	ADD_A_IYD
+	ADC_A_IYD
+	SUB_IYD
+	SBC_A_IYD
+	AND_IYD
+	XOR_IYD
+	OR_IYD
+	CP_IYD
+
+	// INC/DEC (IX+d) / (IY+d)
+	INC_IXD
+	DEC_IXD
+	INC_IYD
+	DEC_IYD
+
+	// DDCB/FDCB rotate/shift (IX+d) / (IY+d). Real hardware also has an
+	// undocumented "shift and store" quirk on these encodings — the bit 0-2
+	// field that's normally fixed at 110 (meaning "(IX+d) only") instead
+	// names a register that also receives the shifted/rotated value. Not
+	// modeled: cpu.Exec only ever applies these to State.M, not a register.
+	RLC_IXD
+	RRC_IXD
+	RL_IXD
+	RR_IXD
+	SLA_IXD
+	SRA_IXD
+	SRL_IXD
+	SLL_IXD
+	RLC_IYD
+	RRC_IYD
+	RL_IYD
+	RR_IYD
+	SLA_IYD
+	SRA_IYD
+	SRL_IYD
+	SLL_IYD
+
+	// DDCB/FDCB BIT n, (IX+d) / (IY+d)
+	BIT_0_IXD
+	BIT_1_IXD
+	BIT_2_IXD
+	BIT_3_IXD
+	BIT_4_IXD
+	BIT_5_IXD
+	BIT_6_IXD
+	BIT_7_IXD
+
+	// DDCB/FDCB RES n, (IX+d)
+	RES_0_IXD
+	RES_1_IXD
+	RES_2_IXD
+	RES_3_IXD
+	RES_4_IXD
+	RES_5_IXD
+	RES_6_IXD
+	RES_7_IXD
+
+	// DDCB/FDCB SET n, (IX+d)
+	SET_0_IXD
+	SET_1_IXD
+	SET_2_IXD
+	SET_3_IXD
+	SET_4_IXD
+	SET_5_IXD
+	SET_6_IXD
+	SET_7_IXD
+
+	// DDCB/FDCB BIT n, (IY+d)
+	BIT_0_IYD
+	BIT_1_IYD
+	BIT_2_IYD
+	BIT_3_IYD
+	BIT_4_IYD
+	BIT_5_IYD
+	BIT_6_IYD
+	BIT_7_IYD
+
+	// DDCB/FDCB RES n, (IY+d)
+	RES_0_IYD
+	RES_1_IYD
+	RES_2_IYD
+	RES_3_IYD
+	RES_4_IYD
+	RES_5_IYD
+	RES_6_IYD
+	RES_7_IYD
+
+	// DDCB/FDCB SET n, (IY+d)
+	SET_0_IYD
+	SET_1_IYD
+	SET_2_IYD
+	SET_3_IYD
+	SET_4_IYD
+	SET_5_IYD
+	SET_6_IYD
+	SET_7_IYD
+
+	// Undocumented IXH/IXL/IYH/IYL half-register ops. Originally a subset
+	// (A-facing loads, INC/DEC, and ADD A,) — chunk2-6 rounds out the
+	// remaining ALU ops (ADC/SUB/SBC/AND/XOR/OR/CP) so cpu.Exec covers
+	// every half-register form a handwritten routine using IX/IY as
+	// scratch 8-bit registers might use, not just the common ones.
+	LD_A_IXH
+	LD_A_IXL
+	LD_IXH_A
+	LD_IXL_A
+	INC_IXH
+	INC_IXL
+	DEC_IXH
+	DEC_IXL
+	ADD_A_IXH
+	ADD_A_IXL
+	ADC_A_IXH
+	ADC_A_IXL
+	SUB_IXH
+	SUB_IXL
+	SBC_A_IXH
+	SBC_A_IXL
+	AND_IXH
+	AND_IXL
+	XOR_IXH
+	XOR_IXL
+	OR_IXH
+	OR_IXL
+	CP_IXH
+	CP_IXL
+	LD_A_IYH
+	LD_A_IYL
+	LD_IYH_A
+	LD_IYL_A
+	INC_IYH
+	INC_IYL
+	DEC_IYH
+	DEC_IYL
+	ADD_A_IYH
+	ADD_A_IYL
+	ADC_A_IYH
+	ADC_A_IYL
+	SUB_IYH
+	SUB_IYL
+	SBC_A_IYH
+	SBC_A_IYL
+	AND_IYH
+	AND_IYL
+	XOR_IYH
+	XOR_IYL
+	OR_IYH
+	OR_IYL
+	CP_IYH
+	CP_IYL
+
+	// === Wave 7: control flow (29 opcodes) ===
+	// Adds PC and Halted to cpu.State. Instruction.Imm holds a branch target
+	// — a symbolic label id or resolved address, the outer search harness's
+	// job to assign, not a raw relative offset — for every op where
+	// HasBranchTarget reports true. Covers NZ/Z/NC/C conditions only (the
+	// PO/PE/P/M forms keyed off parity and sign are a follow-up wave, same
+	// "useful subset first" call as Wave 6's half-register ops).
+	//
+	// CALL/RET don't model a real return-address stack yet (cpu.Bus exists
+	// since Wave 0's memory harness but isn't wired into Exec): CALL behaves
+	// like JP, and RET/RET cc set State.Halted instead of popping a return
+	// address. That's enough to prove e.g. LD B,n:DEC B:JR NZ,-2 equivalent
+	// to LD B,n:DJNZ loop by comparing final PC — a real call stack is
+	// future work once Bus lands in Exec.
+
+	// JR e / JR cc,e / DJNZ e — 2 bytes, displacement in Imm
+	JR
+	JR_NZ
+	JR_Z
+	JR_NC
+	JR_C
+	DJNZ
+
+	// JP nn / JP cc,nn / CALL nn / CALL cc,nn — 3 bytes, address in Imm
+	JP
+	JP_NZ
+	JP_Z
+	JP_NC
+	JP_C
+	CALL
+	CALL_NZ
+	CALL_Z
+	CALL_NC
+	CALL_C
+
+	// RET / RET cc — 1 byte, no operand; ends the modeled sequence (Halted)
+	RET
+	RET_NZ
+	RET_Z
+	RET_NC
+	RET_C
+
+	// RST n — 1 byte; fixed-target CALL to a ROM entry point the caller
+	// supplies via Imm (the vector number only selects the encoding/T-states)
+	RST_00
+	RST_08
+	RST_10
+	RST_18
+	RST_20
+	RST_28
+	RST_30
+	RST_38
+
+	// === Wave 8: I/O ports (24 opcodes) ===
+	// cpu.State gets an optional IO cpu.Ports plus an IOPort uint8 fallback
+	// byte (same "single shared virtual value" simplification State.M makes
+	// for memory — see cpu/ports.go). Every opcode in this wave reports
+	// true from HasSideEffects: the equivalence checker and pruner must
+	// treat the I/O trace as observable even when the loaded register or
+	// decremented counter would otherwise look dead.
+
+	// IN A,(n) / OUT (n),A — port in Instruction.Imm
+	IN_A_N
+	OUT_N_A
+
+	// IN r,(C) — port is the C register (not modeled as Imm)
+	IN_A_C
+	IN_B_C
+	IN_C_C
+	IN_D_C
+	IN_E_C
+	IN_H_C
+	IN_L_C
+
+	// OUT (C),r
+	OUT_C_A
+	OUT_C_B
+	OUT_C_C
+	OUT_C_D
+	OUT_C_E
+	OUT_C_H
+	OUT_C_L
+
+	// Block I/O: port is C, memory side routes through State.M like Wave 5
+	INI
+	INIR
+	IND
+	INDR
+	OUTI
+	OTIR
+	OUTD
+	OTDR
+
+	// chunk2-5: SM83 (Game Boy)-only opcodes. These have no Zilog Z80
+	// equivalent — cpu.Exec (the Z80 executor) never handles them; they
+	// exist in this shared enum so pkg/cpu/sm83 can describe its dialect's
+	// instruction set using the same inst.OpCode/Catalog/Table machinery
+	// as the Z80 opcodes, instead of a parallel numbering scheme. Z80OpCode-
+	// Count (defined below, after OpCodeCount) marks LDH_N_A as the start
+	// of this block, so code that iterates "every opcode cpu.Exec can run"
+	// (pkg/search's enumerator, TestAllOpcodes) doesn't have to know about
+	// them.
+	LDH_N_A      // LDH (n), A — 0xE0
+	LDH_A_N      // LDH A, (n) — 0xF0
+	LDH_C_A      // LDH (C), A — 0xE2
+	LDH_A_C      // LDH A, (C) — 0xF2
+	LD_HLI_A_INC // LD (HL+), A — 0x22
+	LD_A_HLI_INC // LD A, (HL+) — 0x2A
+	LD_HLI_A_DEC // LD (HL-), A — 0x32
+	LD_A_HLI_DEC // LD A, (HL-) — 0x3A
+	STOP         // STOP — 0x10 0x00
+	RETI         // RETI — 0xD9 (also a real Z80 opcode; not modeled by cpu.Exec yet)
+	SWAP_A       // SWAP A — 0xCB 0x37
+	SWAP_B       // SWAP B — 0xCB 0x30
+	SWAP_C       // SWAP C — 0xCB 0x31
+	SWAP_D       // SWAP D — 0xCB 0x32
+	SWAP_E       // SWAP E — 0xCB 0x33
+	SWAP_H       // SWAP H — 0xCB 0x34
+	SWAP_L       // SWAP L — 0xCB 0x35
+
 	OpCodeCount // sentinel
 )
+
+// Z80OpCodeCount marks the end of the opcodes cpu.Exec (the Z80 executor)
+// actually handles: everything below it is fair game for pkg/search's
+// enumerator and anywhere else that builds a candidate sequence and runs it
+// through cpu.Exec, while everything from here up to OpCodeCount is the
+// chunk2-5 SM83-only block above and must stay out of that range. Defined
+// as LDH_N_A (the first SM83-only opcode) rather than a value inside the
+// OpCode iota sequence, so it doesn't consume a real enum slot of its own —
+// a slot would need its own Catalog/Table entry, and "no such opcode"
+// isn't something buildTable or TestCatalogCompleteness should have to
+// special-case.
+const Z80OpCodeCount = LDH_N_A
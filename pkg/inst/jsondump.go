@@ -0,0 +1,48 @@
+package inst
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// CatalogEntry is one opcode's externally-consumable metadata — everything
+// cuda/z80qc (a separate build, driven as a subprocess from pkg/gpu/cuda.go)
+// needs to build its own opcode table from this build's actual Catalog/Table
+// instead of hand-duplicating HasImmediate/HasImm16/HasIndexDisp/UsesMemory
+// on the CUDA side and risking the two silently drifting apart.
+type CatalogEntry struct {
+	ID           int     `json:"id"`
+	Mnemonic     string  `json:"mnemonic"`
+	Bytes        []uint8 `json:"bytes"`
+	SizeBytes    int     `json:"size_bytes"`
+	TStates      int     `json:"tstates"`
+	ImmSize      int     `json:"imm_size"`
+	HasDisp      bool    `json:"has_disp"`
+	UsesMem      bool    `json:"uses_mem"`
+	Undocumented bool    `json:"undocumented"`
+}
+
+// DumpCatalogJSON writes every OpCode's CatalogEntry to w as a JSON array,
+// in OpCode order, so GPU and CPU stay in sync by construction (chunk6-2):
+// whatever this build's Catalog/Table/IsUndocumented resolved to is exactly
+// what gets handed to the external consumer, not a second hand-maintained
+// copy of the same classification rules. cmd/z80opt's dump-catalog
+// subcommand is the CLI entry point cuda/z80qc's build step is meant to run
+// this through.
+func DumpCatalogJSON(w io.Writer) error {
+	entries := make([]CatalogEntry, OpCodeCount)
+	for op := OpCode(0); op < OpCodeCount; op++ {
+		entries[op] = CatalogEntry{
+			ID:           int(op),
+			Mnemonic:     Table[op].Mnemonic,
+			Bytes:        Table[op].Encoding,
+			SizeBytes:    int(Table[op].SizeBytes),
+			TStates:      int(Table[op].TStates),
+			ImmSize:      int(Table[op].ImmSize),
+			HasDisp:      Table[op].HasDisp,
+			UsesMem:      Table[op].UsesMem,
+			Undocumented: IsUndocumented(op),
+		}
+	}
+	return json.NewEncoder(w).Encode(entries)
+}
@@ -0,0 +1,64 @@
+package inst
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+// TestDumpCatalogJSONRoundTrips verifies DumpCatalogJSON emits one entry per
+// OpCode, in OpCode order, agreeing with Table and IsUndocumented — the
+// invariant cuda/z80qc's build step relies on to stay in sync with this
+// build's Catalog (chunk6-2).
+func TestDumpCatalogJSONRoundTrips(t *testing.T) {
+	var buf bytes.Buffer
+	if err := DumpCatalogJSON(&buf); err != nil {
+		t.Fatalf("DumpCatalogJSON: %v", err)
+	}
+
+	var entries []CatalogEntry
+	if err := json.Unmarshal(buf.Bytes(), &entries); err != nil {
+		t.Fatalf("unmarshal dump: %v", err)
+	}
+	if len(entries) != int(OpCodeCount) {
+		t.Fatalf("got %d entries, want %d", len(entries), OpCodeCount)
+	}
+
+	for op := OpCode(0); op < OpCodeCount; op++ {
+		e := entries[op]
+		if e.ID != int(op) {
+			t.Errorf("entry %d: ID = %d, want %d", op, e.ID, op)
+		}
+		if e.Mnemonic != Table[op].Mnemonic {
+			t.Errorf("entry %d: Mnemonic = %q, want %q", op, e.Mnemonic, Table[op].Mnemonic)
+		}
+		if e.TStates != int(Table[op].TStates) {
+			t.Errorf("entry %d (%s): TStates = %d, want %d", op, e.Mnemonic, e.TStates, Table[op].TStates)
+		}
+		if e.Undocumented != IsUndocumented(op) {
+			t.Errorf("entry %d (%s): Undocumented = %v, want %v", op, e.Mnemonic, e.Undocumented, IsUndocumented(op))
+		}
+	}
+}
+
+// TestDumpCatalogJSONFlagsUndocumented spot-checks known undocumented
+// opcodes land with Undocumented=true in the dump.
+func TestDumpCatalogJSONFlagsUndocumented(t *testing.T) {
+	var buf bytes.Buffer
+	if err := DumpCatalogJSON(&buf); err != nil {
+		t.Fatalf("DumpCatalogJSON: %v", err)
+	}
+	var entries []CatalogEntry
+	if err := json.Unmarshal(buf.Bytes(), &entries); err != nil {
+		t.Fatalf("unmarshal dump: %v", err)
+	}
+	if !entries[SLL_A].Undocumented {
+		t.Error("SLL_A should be dumped as Undocumented")
+	}
+	if !entries[LD_A_IXH].Undocumented {
+		t.Error("LD_A_IXH should be dumped as Undocumented")
+	}
+	if entries[ADD_A_B].Undocumented {
+		t.Error("ADD_A_B should not be dumped as Undocumented")
+	}
+}
@@ -0,0 +1,97 @@
+package inst
+
+// MemAccess breaks an instruction's bus activity into the categories a
+// machine-specific cycle CostModel needs (chunk4-6): opcode bytes are
+// fetched from the PC stream during M1 cycles, operand bytes
+// (immediates/displacements) are fetched from the same stream outside M1,
+// and MemReads/MemWrites are accesses to a separately-addressed byte —
+// (HL)/(IX+d)/(IY+d), (BC)/(DE), or the memory side of a block I/O
+// transfer. Real contended machines (see stoke.ZXSpectrum48kContended) price
+// these differently, which is why they're kept apart instead of folding
+// into the single UsesMem bool Desc already had.
+type MemAccess struct {
+	OpcodeFetchBytes  uint8
+	OperandFetchBytes uint8
+	MemReads          uint8
+	MemWrites         uint8
+}
+
+// AccessOf returns op's MemAccess breakdown.
+func AccessOf(op OpCode) MemAccess {
+	return Table[op].Access
+}
+
+// accessFor derives op's MemAccess. The opcode/operand fetch split reuses
+// the same shape switches buildTable already calls (HasImm16/HasImmediate/
+// HasIndexDisp); the read/write counts come from memRW's OpCode ranges,
+// the same range-based classification HasIndexDisp/UsesMemory use.
+func accessFor(op OpCode) MemAccess {
+	a := MemAccess{OpcodeFetchBytes: uint8(len(Catalog[op].Bytes))}
+
+	switch {
+	case HasImm16(op):
+		a.OperandFetchBytes = 2
+	case HasImmediate(op):
+		a.OperandFetchBytes = 1
+	}
+	if HasIndexDisp(op) {
+		a.OperandFetchBytes++
+	}
+
+	a.MemReads, a.MemWrites = memRW(op)
+	return a
+}
+
+// memRW returns the (reads, writes) an opcode performs against a byte
+// address other than the PC stream itself.
+func memRW(op OpCode) (reads, writes uint8) {
+	switch {
+	// Wave 5: LD r, (HL) / ALU A, (HL) / BIT n, (HL) / LD A, (BC)|(DE) — read-only.
+	case op >= LD_A_HLI && op <= LD_L_HLI,
+		op >= ADD_A_HLI && op <= CP_HLI,
+		op >= BIT_0_HLI && op <= BIT_7_HLI,
+		op == LD_A_BCI, op == LD_A_DEI:
+		return 1, 0
+
+	// Wave 5: LD (HL), r/n / LD (BC)|(DE), A — write-only.
+	case op >= LD_HLI_A && op <= LD_HLI_L, op == LD_HLI_N,
+		op == LD_BCI_A, op == LD_DEI_A:
+		return 0, 1
+
+	// Wave 5: INC/DEC (HL) and the CB-prefix shift/RES/SET forms read the
+	// byte, modify it, and write it back.
+	case op == INC_HLI, op == DEC_HLI,
+		op >= RLC_HLI && op <= SLL_HLI,
+		op >= RES_0_HLI && op <= SET_7_HLI:
+		return 1, 1
+
+	// Wave 6: same three shapes as Wave 5, addressed via (IX+d)/(IY+d).
+	case op >= LD_A_IXD && op <= LD_L_IYD,
+		op >= ADD_A_IXD && op <= CP_IYD,
+		op >= BIT_0_IXD && op <= BIT_7_IXD,
+		op >= BIT_0_IYD && op <= BIT_7_IYD:
+		return 1, 0
+	case op >= LD_IXD_A && op <= LD_IYD_L, op == LD_IXD_N, op == LD_IYD_N:
+		return 0, 1
+	case op == INC_IXD, op == DEC_IXD, op == INC_IYD, op == DEC_IYD,
+		op >= RLC_IXD && op <= SLL_IYD,
+		op >= RES_0_IXD && op <= SET_7_IXD,
+		op >= RES_0_IYD && op <= SET_7_IYD:
+		return 1, 1
+
+	// chunk2-5 SM83 dialect: LDH/LD (HL+)/(HL-) — same read-vs-write split
+	// as their Z80 Wave 5 analogues.
+	case op == LDH_A_N, op == LDH_A_C, op == LD_A_HLI_INC, op == LD_A_HLI_DEC:
+		return 1, 0
+	case op == LDH_N_A, op == LDH_C_A, op == LD_HLI_A_INC, op == LD_HLI_A_DEC:
+		return 0, 1
+
+	// Wave 8: block I/O also touches memory at (HL) — the IN* family
+	// writes the port byte there, the OUT* family reads it from there.
+	case op == INI, op == INIR, op == IND, op == INDR:
+		return 0, 1
+	case op == OUTI, op == OTIR, op == OUTD, op == OTDR:
+		return 1, 0
+	}
+	return 0, 0
+}
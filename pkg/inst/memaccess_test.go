@@ -0,0 +1,27 @@
+package inst
+
+import "testing"
+
+func TestAccessOf(t *testing.T) {
+	tests := []struct {
+		op   OpCode
+		want MemAccess
+	}{
+		{LD_A_B, MemAccess{OpcodeFetchBytes: 1}},
+		{LD_A_N, MemAccess{OpcodeFetchBytes: 1, OperandFetchBytes: 1}},
+		{AND_N, MemAccess{OpcodeFetchBytes: 1, OperandFetchBytes: 1}},
+		{LD_A_IXD, MemAccess{OpcodeFetchBytes: 2, OperandFetchBytes: 1, MemReads: 1}},
+		{LD_IXD_A, MemAccess{OpcodeFetchBytes: 2, OperandFetchBytes: 1, MemWrites: 1}},
+		{INC_IXD, MemAccess{OpcodeFetchBytes: 2, OperandFetchBytes: 1, MemReads: 1, MemWrites: 1}},
+		{BIT_0_IXD, MemAccess{OpcodeFetchBytes: 3, OperandFetchBytes: 1, MemReads: 1}},
+		{LD_HLI_A_INC, MemAccess{OpcodeFetchBytes: 1, MemWrites: 1}},
+		{LD_A_HLI_DEC, MemAccess{OpcodeFetchBytes: 1, MemReads: 1}},
+		{OUTI, MemAccess{OpcodeFetchBytes: 2, MemReads: 1}},
+		{INI, MemAccess{OpcodeFetchBytes: 2, MemWrites: 1}},
+	}
+	for _, tt := range tests {
+		if got := AccessOf(tt.op); got != tt.want {
+			t.Errorf("AccessOf(%s) = %+v, want %+v", Disassemble(Instruction{Op: tt.op}), got, tt.want)
+		}
+	}
+}
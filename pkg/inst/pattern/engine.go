@@ -0,0 +1,100 @@
+package pattern
+
+import "github.com/oisee/z80-optimizer/pkg/inst"
+
+// RewriteAll repeatedly rewrites seq using rs.Rules until no rule matches
+// anywhere (a fixed point) and returns the result; seq itself is left
+// untouched. c supplies the facts (dead flags, branch reachability)
+// matching pkg/inst.Constraints carries — no rule here can derive those
+// from the instruction window alone.
+func (rs *Ruleset) RewriteAll(seq []inst.Instruction, c Constraints) []inst.Instruction {
+	out := append([]inst.Instruction(nil), seq...)
+
+	// Every firing replaces exactly one instruction with at most one
+	// instruction, so rounds are bounded by len(out); this cap just guards
+	// against a future rule slipping past that, the same convention
+	// pkg/rewrite/apply.go's applyRules uses.
+	maxRounds := len(out) + 64
+	for round := 0; round < maxRounds; round++ {
+		if !rs.applyOnePass(&out, c) {
+			return out
+		}
+	}
+	return out
+}
+
+func (rs *Ruleset) applyOnePass(out *[]inst.Instruction, c Constraints) bool {
+	seq := *out
+	for i := range seq {
+		for _, r := range rs.Rules {
+			reg, ok := r.matchAt(seq[i])
+			if !ok {
+				continue
+			}
+			if r.HasDeadFlags && r.DeadFlags&^c.DeadFlags != 0 {
+				continue
+			}
+			if r.RequiresReachable && !c.ShortJumpReachable {
+				continue
+			}
+			replacement, ok := r.build(reg)
+			if !ok {
+				continue
+			}
+			*out = splice(seq, i, 1, replacement)
+			return true
+		}
+	}
+	return false
+}
+
+// matchAt reports whether r's LHS matches seq's instruction at i, returning
+// the register letter a family hole captured (0 for a bare-opcode LHS with
+// no hole).
+func (r Rule) matchAt(instr inst.Instruction) (byte, bool) {
+	if r.lhsFamily != "" {
+		for reg, op := range families[r.lhsFamily] {
+			if instr.Op != op {
+				continue
+			}
+			if r.requireImm && int(instr.Imm) != r.requireZero {
+				return 0, false
+			}
+			return reg, true
+		}
+		return 0, false
+	}
+	if instr.Op != r.lhsOp {
+		return 0, false
+	}
+	if r.requireImm && int(instr.Imm) != r.requireZero {
+		return 0, false
+	}
+	return 0, true
+}
+
+// build constructs r's replacement for a match that captured reg, or
+// ok=false if the RHS family has no member for that register — the case
+// "LD r,0 => XOR r" hits for every register except A, since families["XOR_r"]
+// deliberately only defines 'A' (see the families doc comment).
+func (r Rule) build(reg byte) (replacement []inst.Instruction, ok bool) {
+	if r.Delete {
+		return nil, true
+	}
+	if r.rhsFamily != "" {
+		op, ok := families[r.rhsFamily][reg]
+		if !ok {
+			return nil, false
+		}
+		return []inst.Instruction{{Op: op}}, true
+	}
+	return []inst.Instruction{{Op: r.rhsOp}}, true
+}
+
+func splice(seq []inst.Instruction, at, n int, replacement []inst.Instruction) []inst.Instruction {
+	out := make([]inst.Instruction, 0, len(seq)-n+len(replacement))
+	out = append(out, seq[:at]...)
+	out = append(out, replacement...)
+	out = append(out, seq[at+n:]...)
+	return out
+}
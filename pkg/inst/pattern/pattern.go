@@ -0,0 +1,247 @@
+// Package pattern is a declarative, TableGen-PatFrag-style peephole engine
+// for the catalog pkg/inst describes — a sibling of pkg/rewrite's own
+// OpCode-sequence DSL (pkg/rewrite/dsl.go), but matching through typed
+// holes (a register-class placeholder, an immediate literal) instead of a
+// fixed OpCode sequence, so one rule line covers a whole instruction
+// family instead of one OpCode at a time.
+//
+// Scope: this module's OpCode enum bakes each instruction's operands into
+// the OpCode itself (LD_B_N and LD_C_N are different constants, not one
+// "LD r,n" opcode with an operand field) rather than factoring operands
+// out the way a real TableGen .td file would. A typed hole here is
+// therefore backed by a small, explicit family table (see families below)
+// rather than a general operand-class system: it covers the register
+// families this package actually knows how to enumerate (the 7-register
+// "simple load"/"self-move" families), not every conceivable operand
+// shape. Widening families is additive — see the families map — but this
+// is deliberately not a general-purpose TableGen port.
+package pattern
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+
+	"github.com/oisee/z80-optimizer/pkg/inst"
+)
+
+// families maps a family name to the OpCode each register letter resolves
+// to within it. "LD_r_N" and "LD_r_r" cover every one of the 7 simple
+// registers; "XOR_r" deliberately only has an 'A' entry — XOR B computes
+// A^B, not "clear B", so the classic "LD r,0 -> XOR r" peephole is only
+// ever valid for r=A on real Z80 hardware, not generalizable the way
+// LD_r_N/LD_r_r are.
+var families = map[string]map[byte]inst.OpCode{
+	"LD_r_N": {
+		'A': inst.LD_A_N, 'B': inst.LD_B_N, 'C': inst.LD_C_N, 'D': inst.LD_D_N,
+		'E': inst.LD_E_N, 'H': inst.LD_H_N, 'L': inst.LD_L_N,
+	},
+	"LD_r_r": {
+		'A': inst.LD_A_A, 'B': inst.LD_B_B, 'C': inst.LD_C_C, 'D': inst.LD_D_D,
+		'E': inst.LD_E_E, 'H': inst.LD_H_H, 'L': inst.LD_L_L,
+	},
+	"XOR_r": {'A': inst.XOR_A},
+}
+
+// Rule is one compiled pattern: a single-instruction LHS window (either a
+// bare OpCode literal or a family+register hole, with an optional
+// required-immediate value) and a single-instruction RHS (a bare OpCode, a
+// family member resolved through the LHS's captured register, or Delete
+// for "=> (nothing)").
+type Rule struct {
+	Name string
+
+	lhsFamily   string // "" means lhsOp is a literal OpCode match
+	lhsOp       inst.OpCode
+	requireImm  bool
+	requireZero int // the immediate value required when requireImm is set
+
+	Delete    bool
+	rhsFamily string // "" (and !Delete) means rhsOp is a literal OpCode
+	rhsOp     inst.OpCode
+
+	DeadFlags         inst.FlagMask
+	HasDeadFlags      bool
+	RequiresReachable bool
+}
+
+// Constraints mirrors inst.Constraints (chunk9-3): the call-site facts a
+// guard checks against, supplied by whatever's driving RewriteAll rather
+// than derived by this package, since neither flag liveness nor branch
+// reachability is something a single instruction window can determine on
+// its own.
+type Constraints = inst.Constraints
+
+// Ruleset is a compiled set of Rules, ready to rewrite instruction windows.
+type Ruleset struct {
+	Rules []Rule
+}
+
+// Compile parses a pattern.rules-format source string (see parseLine's
+// doc comment for the grammar) into a Ruleset.
+func Compile(src string) (*Ruleset, error) {
+	var rules []Rule
+	sc := bufio.NewScanner(strings.NewReader(src))
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		r, err := parseLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", line, err)
+		}
+		rules = append(rules, r)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return &Ruleset{Rules: rules}, nil
+}
+
+// parseLine parses one "name: LHS => RHS [if GUARD[,GUARD...]]" line.
+//
+// LHS is either a bare OpCode name (e.g. "JP") or "FAMILY:$name" optionally
+// followed by ",imm8:N" to additionally require a literal immediate value.
+// RHS is a bare OpCode name, "FAMILY:$name" referencing the LHS's captured
+// register, or "(nothing)" to delete the match. GUARD is "flags-dead(F[,F...])"
+// (each F one of S,Z,Y,H,X,P,N,C — the same alphabet pkg/rewrite/dsl.go's
+// own flags-dead clause uses) or "reachable" (sets RequiresReachable).
+func parseLine(line string) (Rule, error) {
+	nameRest := strings.SplitN(line, ":", 2)
+	if len(nameRest) != 2 {
+		return Rule{}, fmt.Errorf("missing ':' after rule name")
+	}
+	name := strings.TrimSpace(nameRest[0])
+
+	body := nameRest[1]
+	var guardPart string
+	if idx := strings.Index(body, " if "); idx >= 0 {
+		guardPart = strings.TrimSpace(body[idx+len(" if "):])
+		body = body[:idx]
+	}
+
+	sides := strings.SplitN(body, "=>", 2)
+	if len(sides) != 2 {
+		return Rule{}, fmt.Errorf("missing '=>'")
+	}
+	lhsTok := strings.TrimSpace(sides[0])
+	rhsTok := strings.TrimSpace(sides[1])
+
+	r := Rule{Name: name}
+	if err := parseLHS(lhsTok, &r); err != nil {
+		return Rule{}, err
+	}
+	if err := parseRHS(rhsTok, &r); err != nil {
+		return Rule{}, err
+	}
+	if guardPart != "" {
+		if err := parseGuard(guardPart, &r); err != nil {
+			return Rule{}, err
+		}
+	}
+	return r, nil
+}
+
+func parseLHS(tok string, r *Rule) error {
+	fields := strings.SplitN(tok, ",", 2)
+	head := fields[0]
+
+	if strings.Contains(head, ":$") {
+		parts := strings.SplitN(head, ":$", 2)
+		family := parts[0]
+		if _, ok := families[family]; !ok {
+			return fmt.Errorf("unknown family %q", family)
+		}
+		r.lhsFamily = family
+	} else {
+		op, ok := literalByToken[head]
+		if !ok {
+			return fmt.Errorf("unknown opcode %q", head)
+		}
+		r.lhsOp = op
+	}
+
+	if len(fields) == 2 {
+		imm := strings.TrimSpace(fields[1])
+		const prefix = "imm8:"
+		if !strings.HasPrefix(imm, prefix) {
+			return fmt.Errorf("unsupported LHS operand %q", imm)
+		}
+		var n int
+		if _, err := fmt.Sscanf(imm[len(prefix):], "%d", &n); err != nil {
+			return fmt.Errorf("bad imm8 literal %q: %w", imm, err)
+		}
+		r.requireImm = true
+		r.requireZero = n
+	}
+	return nil
+}
+
+func parseRHS(tok string, r *Rule) error {
+	if tok == "(nothing)" {
+		r.Delete = true
+		return nil
+	}
+	if strings.Contains(tok, ":$") {
+		family := strings.SplitN(tok, ":$", 2)[0]
+		if _, ok := families[family]; !ok {
+			return fmt.Errorf("unknown family %q", family)
+		}
+		r.rhsFamily = family
+		return nil
+	}
+	op, ok := literalByToken[tok]
+	if !ok {
+		return fmt.Errorf("unknown opcode %q", tok)
+	}
+	r.rhsOp = op
+	return nil
+}
+
+// literalByToken maps a bare opcode token a rule's LHS/RHS can use to its
+// inst.OpCode constant — the same convention pkg/rewrite/names.go's
+// opByName uses, for the same reason: inst.OpCode has no name->value
+// reflection, and a mnemonic-text lookup (inst.OpCodeByName) is ambiguous
+// here (e.g. "JP" is a textual prefix of "JP NZ, nn" too). Intentionally
+// just the subset this package's shipped rules reference.
+var literalByToken = map[string]inst.OpCode{
+	"JP": inst.JP, "JR": inst.JR,
+	"JP_Z": inst.JP_Z, "JR_Z": inst.JR_Z,
+	"JP_NZ": inst.JP_NZ, "JR_NZ": inst.JR_NZ,
+	"JP_C": inst.JP_C, "JR_C": inst.JR_C,
+	"JP_NC": inst.JP_NC, "JR_NC": inst.JR_NC,
+	"NOP":     inst.NOP,
+	"ADD_A_N": inst.ADD_A_N,
+	"INC_A":   inst.INC_A,
+}
+
+func parseGuard(guard string, r *Rule) error {
+	switch {
+	case guard == "reachable":
+		r.RequiresReachable = true
+	case strings.HasPrefix(guard, "flags-dead(") && strings.HasSuffix(guard, ")"):
+		inner := guard[len("flags-dead(") : len(guard)-1]
+		for _, f := range strings.Split(inner, ",") {
+			bit, ok := flagByLetter[strings.TrimSpace(f)]
+			if !ok {
+				return fmt.Errorf("unknown flag %q", f)
+			}
+			r.DeadFlags |= bit
+		}
+		r.HasDeadFlags = true
+	default:
+		return fmt.Errorf("unknown guard %q", guard)
+	}
+	return nil
+}
+
+// flagByLetter uses the same single-letter-per-flag spelling (P for
+// Parity/Overflow) pkg/rewrite/dsl.go's own "if flags-dead(...)" clause
+// does, so a rule author moving between the two DSLs doesn't have to
+// remember two different flag alphabets.
+var flagByLetter = map[string]inst.FlagMask{
+	"S": inst.FlagS, "Z": inst.FlagZ, "Y": inst.FlagY, "H": inst.FlagH,
+	"X": inst.FlagX, "P": inst.FlagPV, "N": inst.FlagN, "C": inst.FlagC,
+}
+
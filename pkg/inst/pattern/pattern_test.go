@@ -0,0 +1,86 @@
+package pattern
+
+import (
+	"testing"
+
+	"github.com/oisee/z80-optimizer/pkg/inst"
+)
+
+func TestDefaultRules_LdZeroToXorA(t *testing.T) {
+	seq := []inst.Instruction{{Op: inst.LD_A_N, Imm: 0}}
+	got := DefaultRules.RewriteAll(seq, Constraints{DeadFlags: 0xFF})
+	if len(got) != 1 || got[0].Op != inst.XOR_A {
+		t.Fatalf("RewriteAll(LD A,0, flags all dead) = %v, want [XOR_A]", got)
+	}
+}
+
+func TestDefaultRules_LdZeroToXorA_RequiresFlagsDead(t *testing.T) {
+	seq := []inst.Instruction{{Op: inst.LD_A_N, Imm: 0}}
+	got := DefaultRules.RewriteAll(seq, Constraints{})
+	if len(got) != 1 || got[0].Op != inst.LD_A_N {
+		t.Fatalf("RewriteAll(LD A,0) without dead flags should not fire, got %v", got)
+	}
+}
+
+func TestDefaultRules_LdZeroOnNonA_DoesNotFire(t *testing.T) {
+	seq := []inst.Instruction{{Op: inst.LD_B_N, Imm: 0}}
+	got := DefaultRules.RewriteAll(seq, Constraints{DeadFlags: 0xFF})
+	if len(got) != 1 || got[0].Op != inst.LD_B_N {
+		t.Fatalf("RewriteAll(LD B,0) has no XOR_B equivalent, should be unchanged, got %v", got)
+	}
+}
+
+func TestDefaultRules_EliminatesSelfLoad(t *testing.T) {
+	seq := []inst.Instruction{{Op: inst.LD_B_B}}
+	got := DefaultRules.RewriteAll(seq, Constraints{})
+	if len(got) != 0 {
+		t.Fatalf("RewriteAll(LD B,B) = %v, want empty", got)
+	}
+}
+
+func TestDefaultRules_JPtoJRNeedsReachable(t *testing.T) {
+	seq := []inst.Instruction{{Op: inst.JP, Imm: 0x8000}}
+	if got := DefaultRules.RewriteAll(seq, Constraints{}); got[0].Op != inst.JP {
+		t.Fatalf("RewriteAll(JP) without reachable should not fire, got %v", got)
+	}
+	got := DefaultRules.RewriteAll(seq, Constraints{ShortJumpReachable: true})
+	if len(got) != 1 || got[0].Op != inst.JR {
+		t.Fatalf("RewriteAll(JP, reachable) = %v, want [JR]", got)
+	}
+}
+
+func TestDefaultRules_AddOneToInc(t *testing.T) {
+	seq := []inst.Instruction{{Op: inst.ADD_A_N, Imm: 1}}
+	got := DefaultRules.RewriteAll(seq, Constraints{DeadFlags: inst.FlagC})
+	if len(got) != 1 || got[0].Op != inst.INC_A {
+		t.Fatalf("RewriteAll(ADD A,1, C dead) = %v, want [INC_A]", got)
+	}
+}
+
+func TestDefaultRules_AddOneToInc_RequiresCarryDead(t *testing.T) {
+	seq := []inst.Instruction{{Op: inst.ADD_A_N, Imm: 1}}
+	got := DefaultRules.RewriteAll(seq, Constraints{})
+	if len(got) != 1 || got[0].Op != inst.ADD_A_N {
+		t.Fatalf("RewriteAll(ADD A,1) without C dead should not fire, got %v", got)
+	}
+}
+
+func TestDefaultRules_AddTwoDoesNotFireIncRule(t *testing.T) {
+	seq := []inst.Instruction{{Op: inst.ADD_A_N, Imm: 2}}
+	got := DefaultRules.RewriteAll(seq, Constraints{DeadFlags: inst.FlagC})
+	if len(got) != 1 || got[0].Op != inst.ADD_A_N {
+		t.Fatalf("RewriteAll(ADD A,2, C dead) = %v, want unchanged (imm8:1 required)", got)
+	}
+}
+
+func TestCompile_RejectsUnknownFamily(t *testing.T) {
+	if _, err := Compile("bad: NOSUCH:$r => (nothing)"); err == nil {
+		t.Error("Compile should reject an unknown family")
+	}
+}
+
+func TestCompile_RejectsMissingArrow(t *testing.T) {
+	if _, err := Compile("bad: JP JR"); err == nil {
+		t.Error("Compile should reject a line without '=>'")
+	}
+}
@@ -0,0 +1,22 @@
+package pattern
+
+import _ "embed"
+
+//go:embed pattern.rules
+var starterRules string
+
+// DefaultRules is the compiled starter ruleset, parsed once at init from
+// pattern.rules — the pattern-DSL counterpart to pkg/rewrite.Rules.
+var DefaultRules *Ruleset
+
+func init() {
+	rs, err := Compile(starterRules)
+	if err != nil {
+		// pattern.rules is embedded at build time, so a parse failure here
+		// means the shipped file itself is broken — a programming error,
+		// not a runtime condition callers can recover from (mirrors
+		// pkg/rewrite/rules.go's init).
+		panic("pattern: " + err.Error())
+	}
+	DefaultRules = rs
+}
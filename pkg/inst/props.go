@@ -0,0 +1,159 @@
+package inst
+
+// FlagMask is an inst-package-local view over the eight Z80 flag-register
+// bit positions, used only by FlagsWritten/FlagsRead below. It intentionally
+// mirrors pkg/search.FlagSet's bit layout exactly (pkg/search already
+// imports pkg/inst, so the reverse import isn't available here) — a caller
+// converting between the two just does search.FlagSet(m) or FlagMask(fs).
+type FlagMask uint8
+
+const (
+	FlagC  FlagMask = 0x01 // Carry
+	FlagN  FlagMask = 0x02 // Subtract
+	FlagPV FlagMask = 0x04 // Parity/Overflow
+	FlagX  FlagMask = 0x08 // Undocumented bit 3
+	FlagH  FlagMask = 0x10 // Half-carry
+	FlagY  FlagMask = 0x20 // Undocumented bit 5
+	FlagZ  FlagMask = 0x40 // Zero
+	FlagS  FlagMask = 0x80 // Sign
+)
+
+// flagMaskAll8BitResult is the flag set an 8-bit ALU/logic/shift op sets
+// from its result (S, Z, the two undoc copies, H, P/V, N, C) — the "all
+// flags" case the request's "AND n writes all flags" example names.
+const flagMaskAll8BitResult FlagMask = FlagS | FlagZ | FlagY | FlagH | FlagX | FlagPV | FlagN | FlagC
+
+// flagMaskInc8 is what INC r/INC (HL)/INC (IX+d) sets: everything an 8-bit
+// result op sets EXCEPT Carry, which INC/DEC leave alone — the request's
+// other named example.
+const flagMaskInc8 FlagMask = FlagS | FlagZ | FlagY | FlagH | FlagX | FlagPV | FlagN
+
+// FlagsWritten reports which of the eight flag bits op's execution can
+// change, classified from its catalog mnemonic the same way ClassOf is
+// (see mnemonicPrefix/mnemonicDest) rather than from a per-opcode table,
+// since FlagEffects (chunk2-1's Z/N/H/C sentinel field) has no wave that's
+// actually populated it yet — see FlagEffect.Known. Where Z80 hardware
+// behavior for a class is genuinely undocumented or this table just hasn't
+// been checked against it line by line, FlagsWritten returns the full
+// flagMaskAll8BitResult rather than guessing a flag is untouched: a false
+// "this flag might change" costs a missed optimization, but a false "this
+// flag never changes" can make a later rewrite/reorder pass silently wrong.
+func FlagsWritten(op OpCode) FlagMask {
+	mnemonic := Catalog[op].Mnemonic
+	prefix := mnemonicPrefix(mnemonic)
+	dest := mnemonicDest(mnemonic)
+
+	switch prefix {
+	case "ADD", "ADC", "SUB", "SBC", "AND", "XOR", "OR", "CP", "NEG", "SLL":
+		if isWideOperand(dest) {
+			// ADD HL,rr / ADD IX,rr / ADD IY,rr: only H, N and C change: the
+			// request's other named example.
+			return FlagH | FlagN | FlagC
+		}
+		return flagMaskAll8BitResult
+	case "INC", "DEC":
+		if isWideOperand(dest) {
+			return 0 // INC BC / DEC SP etc. don't touch flags at all.
+		}
+		return flagMaskInc8
+	case "RLC", "RRC", "RL", "RR", "SLA", "SRA", "SRL":
+		return flagMaskAll8BitResult
+	case "RLCA", "RRCA", "RLA", "RRA":
+		return FlagC | FlagN | FlagH | FlagY | FlagX
+	case "BIT":
+		return FlagZ | FlagH | FlagN
+	case "RES", "SET":
+		return 0
+	case "DAA":
+		return FlagS | FlagZ | FlagY | FlagH | FlagX | FlagPV | FlagC
+	case "CPL":
+		return FlagH | FlagN
+	case "CCF", "SCF":
+		return FlagC | FlagN | FlagH | FlagY | FlagX
+	case "IN":
+		if dest != "A" {
+			return flagMaskAll8BitResult &^ FlagC // IN r,(C): flags from result, C untouched.
+		}
+		return 0 // IN A,(n) doesn't touch flags.
+	case "INI", "INIR", "IND", "INDR", "OUTI", "OTIR", "OUTD", "OTDR":
+		return FlagZ | FlagN
+	}
+	return 0
+}
+
+// FlagsRead reports which flag bits op's own behavior depends on — the
+// conditional branch/call/return forms that test Z or C (mirroring
+// pkg/search/properties.go's readsFlags), plus the carry-in arithmetic and
+// rotate-through-carry forms readsFlags doesn't need to track for the
+// pruner but a peephole pass reasoning about flags precisely does.
+func FlagsRead(op OpCode) FlagMask {
+	switch op {
+	case JR_NZ, JR_Z, JP_NZ, JP_Z, CALL_NZ, CALL_Z, RET_NZ, RET_Z:
+		return FlagZ
+	case JR_NC, JR_C, JP_NC, JP_C, CALL_NC, CALL_C, RET_NC, RET_C:
+		return FlagC
+	}
+	switch mnemonicPrefix(Catalog[op].Mnemonic) {
+	case "ADC", "SBC", "RL", "RR":
+		return FlagC
+	}
+	return 0
+}
+
+// IsBranch reports whether op is a JR/JP/DJNZ jump — the request's isBranch
+// bit, deliberately narrower than HasBranchTarget (which also covers
+// CALL/RST, see IsCall) to match the isBranch/isCall split LLVM's
+// Instruction properties use.
+func IsBranch(op OpCode) bool {
+	switch mnemonicPrefix(Catalog[op].Mnemonic) {
+	case "JR", "JP", "DJNZ":
+		return true
+	}
+	return false
+}
+
+// IsCall reports whether op is a CALL or RST — a call transfers control but
+// also pushes a return address, which a branch doesn't.
+func IsCall(op OpCode) bool {
+	switch mnemonicPrefix(Catalog[op].Mnemonic) {
+	case "CALL", "RST":
+		return true
+	}
+	return false
+}
+
+// IsCompare reports whether op evaluates a result only to set flags from
+// it, without writing that result anywhere a later instruction can read it
+// back — CP (discards the subtraction) and BIT (discards the AND-with-bit-
+// mask). A dead CP/BIT whose flags are also dead is pure waste; that's the
+// case this predicate exists to let a peephole pass recognize.
+func IsCompare(op OpCode) bool {
+	switch mnemonicPrefix(Catalog[op].Mnemonic) {
+	case "CP", "BIT":
+		return true
+	}
+	return false
+}
+
+// IsCommutative reports whether op's result is unchanged if the CPU had
+// instead computed the operation with A and its other operand swapped —
+// true for the 8-bit accumulator-and-operand ALU/logic forms (ADD, ADC,
+// AND, OR, XOR), false for SUB/SBC/CP (order-dependent) and for every
+// 16-bit ADD (ADD HL,BC isn't encodable as ADD BC,HL, so "swapped" isn't
+// even a different instruction this catalog has). Nothing in this module
+// canonicalizes on this yet — pkg/result.CanonicalKey (chunk8-5) only
+// normalizes independent immediate-load runs — but it's the predicate a
+// future canonicalization or dependency-DAG pass (pkg/search/reorder.go,
+// chunk8-4) would need to treat "ADD A,B" and a hypothetical "ADD B,A" as
+// interchangeable.
+func IsCommutative(op OpCode) bool {
+	mnemonic := Catalog[op].Mnemonic
+	if isWideOperand(mnemonicDest(mnemonic)) {
+		return false
+	}
+	switch mnemonicPrefix(mnemonic) {
+	case "ADD", "ADC", "AND", "OR", "XOR":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,74 @@
+package inst
+
+import "testing"
+
+// TestFlagsWritten_MatchesNamedExamples checks the three opcodes the
+// RegisterOpcode/FlagsWritten request itself names as worked examples.
+func TestFlagsWritten_MatchesNamedExamples(t *testing.T) {
+	if got := FlagsWritten(INC_B); got&FlagC != 0 {
+		t.Errorf("FlagsWritten(INC_B) = %#x, want Carry preserved", got)
+	}
+	if got := FlagsWritten(INC_B); got&(FlagS|FlagZ|FlagH|FlagPV|FlagN) != (FlagS | FlagZ | FlagH | FlagPV | FlagN) {
+		t.Errorf("FlagsWritten(INC_B) = %#x, want S/Z/H/P-V/N all set", got)
+	}
+	if got := FlagsWritten(AND_N); got != flagMaskAll8BitResult {
+		t.Errorf("FlagsWritten(AND_N) = %#x, want all flags written", got)
+	}
+	if got, want := FlagsWritten(ADD_HL_BC), FlagH|FlagN|FlagC; got != want {
+		t.Errorf("FlagsWritten(ADD_HL_BC) = %#x, want %#x (only H/N/C)", got, want)
+	}
+}
+
+func TestFlagsWritten_WidePlainIncDecTouchesNoFlags(t *testing.T) {
+	if got := FlagsWritten(INC_BC); got != 0 {
+		t.Errorf("FlagsWritten(INC_BC) = %#x, want 0 (16-bit INC/DEC don't touch flags)", got)
+	}
+}
+
+func TestFlagsRead_ConditionalsAndCarryConsumers(t *testing.T) {
+	if got := FlagsRead(JR_Z); got != FlagZ {
+		t.Errorf("FlagsRead(JR_Z) = %#x, want FlagZ", got)
+	}
+	if got := FlagsRead(JP_C); got != FlagC {
+		t.Errorf("FlagsRead(JP_C) = %#x, want FlagC", got)
+	}
+	if got := FlagsRead(ADC_A_N); got != FlagC {
+		t.Errorf("FlagsRead(ADC_A_N) = %#x, want FlagC", got)
+	}
+	if got := FlagsRead(NOP); got != 0 {
+		t.Errorf("FlagsRead(NOP) = %#x, want 0", got)
+	}
+}
+
+func TestIsBranchIsCall(t *testing.T) {
+	if !IsBranch(JP) || IsCall(JP) {
+		t.Errorf("JP should be a branch, not a call")
+	}
+	if !IsCall(CALL) || IsBranch(CALL) {
+		t.Errorf("CALL should be a call, not a branch")
+	}
+}
+
+func TestIsCompare(t *testing.T) {
+	if !IsCompare(CP_N) {
+		t.Error("CP_N should be a compare op")
+	}
+	if !IsCompare(BIT_0_B) {
+		t.Error("BIT_0_B should be a compare op")
+	}
+	if IsCompare(AND_N) {
+		t.Error("AND_N writes A, it's not a pure compare")
+	}
+}
+
+func TestIsCommutative(t *testing.T) {
+	if !IsCommutative(AND_N) {
+		t.Error("AND should be commutative")
+	}
+	if IsCommutative(SUB_N) {
+		t.Error("SUB is not commutative")
+	}
+	if IsCommutative(ADD_HL_BC) {
+		t.Error("16-bit ADD isn't encodable with swapped operands, shouldn't claim commutative")
+	}
+}
@@ -0,0 +1,65 @@
+package inst
+
+import "sync"
+
+// OpCode itself stays a compile-time iota enum (see pkg/inst/gen's doc
+// comment on why the enum specifically can't be generated from a data
+// file: nothing else in the module could reference a name that only
+// exists after a code-gen step runs). RegisterOpcode is the runtime
+// counterpart for everything that isn't the enum itself — a downstream
+// user describing an undocumented or CPU-variant opcode (R800, eZ80,
+// a custom port handler) without forking the module and extending
+// instruction.go's const block and Catalog's hand-written init() by hand.
+//
+// A registered opcode is recognized by OpCodeName, OpCodeByName,
+// Disassemble, ByteSize, and TStates — the lookup surface that matters for
+// loading and printing assembly that uses it. It is NOT added to Catalog,
+// Table, FlagEffects, or any other [OpCodeCount]-sized array (those stay
+// exactly OpCodeCount long, by construction), so it can't take part in
+// pkg/search's superoptimizer search or equivalence checking — only in
+// describing and disassembling a real program that uses it.
+var (
+	registryMu   sync.Mutex
+	registry     []Info
+	registryByOp map[OpCode]int // OpCode -> index into registry
+	registryName map[string]OpCode
+)
+
+// RegisterOpcode adds info to the runtime registry and returns the OpCode
+// value it's now recognized under. The returned value is always >=
+// OpCodeCount, so a registered opcode never collides with a built-in one —
+// including across an OpCodeCount bump from a later module upgrade, since
+// each call allocates the next free id above whatever OpCodeCount is in
+// the running build, not a literal the caller picked.
+func RegisterOpcode(info Info) OpCode {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if registryByOp == nil {
+		registryByOp = make(map[OpCode]int)
+		registryName = make(map[string]OpCode)
+	}
+
+	op := OpCodeCount + OpCode(len(registry))
+	registry = append(registry, info)
+	registryByOp[op] = len(registry) - 1
+	registryName[info.Mnemonic] = op
+	return op
+}
+
+func registeredInfo(op OpCode) (Info, bool) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	idx, ok := registryByOp[op]
+	if !ok {
+		return Info{}, false
+	}
+	return registry[idx], true
+}
+
+func registeredOpByName(name string) (OpCode, bool) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	op, ok := registryName[name]
+	return op, ok
+}
@@ -0,0 +1,43 @@
+package inst
+
+import "testing"
+
+func TestRegisterOpcode_AllocatesAboveOpCodeCount(t *testing.T) {
+	op := RegisterOpcode(Info{Mnemonic: "XFOO", Bytes: []uint8{0xED, 0x99}, TStates: 8})
+	if op < OpCodeCount {
+		t.Fatalf("RegisterOpcode returned %d, want >= OpCodeCount (%d)", op, OpCodeCount)
+	}
+}
+
+func TestRegisterOpcode_Lookups(t *testing.T) {
+	op := RegisterOpcode(Info{Mnemonic: "XBAR", Bytes: []uint8{0xED, 0x98}, TStates: 12})
+
+	if got := OpCodeName(op); got != "XBAR" {
+		t.Errorf("OpCodeName(%d) = %q, want %q", op, got, "XBAR")
+	}
+	if got := TStates(op); got != 12 {
+		t.Errorf("TStates(%d) = %d, want 12", op, got)
+	}
+	if got := ByteSize(op); got != 2 {
+		t.Errorf("ByteSize(%d) = %d, want 2", op, got)
+	}
+	if got, ok := OpCodeByName("XBAR"); !ok || got != op {
+		t.Errorf("OpCodeByName(%q) = (%d, %v), want (%d, true)", "XBAR", got, ok, op)
+	}
+	if got := Disassemble(Instruction{Op: op}); got != "XBAR" {
+		t.Errorf("Disassemble(%d) = %q, want %q", op, got, "XBAR")
+	}
+}
+
+func TestRegisterOpcode_UnregisteredOutOfRangeOpIsSafe(t *testing.T) {
+	bogus := OpCodeCount + 9999
+	if got := OpCodeName(bogus); got != "" {
+		t.Errorf("OpCodeName(%d) = %q, want empty for an unregistered op", bogus, got)
+	}
+	if got := TStates(bogus); got != 0 {
+		t.Errorf("TStates(%d) = %d, want 0 for an unregistered op", bogus, got)
+	}
+	if got := Disassemble(Instruction{Op: bogus}); got != "???" {
+		t.Errorf("Disassemble(%d) = %q, want %q", bogus, got, "???")
+	}
+}
@@ -0,0 +1,70 @@
+package inst
+
+import "testing"
+
+func TestZ80AllowsUndocumented(t *testing.T) {
+	if !Z80.Allowed(SLL_A) {
+		t.Error("Z80 should allow SLL A")
+	}
+	if !Z80.Allowed(LD_A_IXH) {
+		t.Error("Z80 should allow LD A,IXH")
+	}
+}
+
+func TestZ180RejectsUndocumented(t *testing.T) {
+	if Z180.Allowed(SLL_A) {
+		t.Error("Z180 should reject SLL A")
+	}
+	if Z180.Allowed(LD_A_IXH) {
+		t.Error("Z180 should reject LD A,IXH")
+	}
+	if !Z180.Allowed(LD_A_IXD) {
+		t.Error("Z180 should still allow LD A,(IX+d)")
+	}
+}
+
+func TestR800FasterThanZ80(t *testing.T) {
+	if R800.Allowed(SLL_A) {
+		t.Error("R800 should reject SLL A, same as Z180")
+	}
+	if got, z80 := R800.TStates(ADD_A_B), Z80.TStates(ADD_A_B); got >= z80 {
+		t.Errorf("R800.TStates(ADD A,B) = %d, want less than Z80's %d", got, z80)
+	}
+}
+
+func TestSM83RejectsIXIY(t *testing.T) {
+	if SM83.Allowed(LD_A_IXD) {
+		t.Error("SM83 should reject (IX+d) addressing")
+	}
+	if SM83.Allowed(LD_IX_NN) {
+		t.Error("SM83 should reject LD IX,nn")
+	}
+	if !SM83.Allowed(LDH_A_N) {
+		t.Error("SM83 should allow its own LDH A,(n)")
+	}
+	if !SM83.Allowed(ADD_A_B) {
+		t.Error("SM83 should allow shared opcodes like ADD A,B")
+	}
+}
+
+func TestByName(t *testing.T) {
+	for _, name := range []string{"z80", "Z180", "r800", "EZ80", "sm83", ""} {
+		if _, ok := ByName(name); !ok {
+			t.Errorf("ByName(%q) = not ok, want a resolvable target", name)
+		}
+	}
+	if _, ok := ByName("bogus"); ok {
+		t.Error("ByName(\"bogus\") should not resolve")
+	}
+}
+
+func TestFilterOpsRestrictsToTarget(t *testing.T) {
+	ops := []OpCode{ADD_A_B, SLL_A, LD_A_IXD}
+	got := FilterOps(Z180, ops)
+	if len(got) != 2 || got[0] != ADD_A_B || got[1] != LD_A_IXD {
+		t.Errorf("FilterOps(Z180, ...) = %v, want [ADD_A_B, LD_A_IXD]", got)
+	}
+	if got := FilterOps(nil, ops); len(got) != len(ops) {
+		t.Errorf("FilterOps(nil, ...) should return ops unchanged, got %v", got)
+	}
+}
@@ -0,0 +1,108 @@
+package liveness
+
+import (
+	"github.com/oisee/z80-optimizer/pkg/inst"
+	"github.com/oisee/z80-optimizer/pkg/search"
+)
+
+// allFlags and allRegs are the boundary condition Analyze seeds every exit
+// block with: every flag and every register Register enumerates may still
+// be observed once the modeled program ends (falls off prog's end, or hits
+// an unconditional RET/RST/JP whose target lands outside prog), the same
+// assumption the equivalence checker's default (DeadFlags == DeadNone)
+// already makes for a sequence's final state.
+const (
+	allFlags = search.FlagSet(0xFF)
+	allRegs  = search.RegSet(0xFF)
+)
+
+// LivenessInfo is Analyze's result: per-instruction-index live-in/live-out
+// flag and register sets, indexed the same way as the prog slice Analyze
+// was given.
+type LivenessInfo struct {
+	liveInFlags, liveOutFlags []search.FlagSet
+	liveInRegs, liveOutRegs   []search.RegSet
+}
+
+// Analyze runs the standard backward worklist dataflow — live_in = use ∪
+// (live_out − def), live_out = ∪ of successors' live_in — over prog's basic
+// blocks in cfg, using per-opcode use/def tables from search.InstReads/
+// search.InstWrites (registers) and search.InstReadsFlags/
+// search.InstWritesFlags (flags). Every block with no successors (see
+// Block's doc comment) is seeded with the full "everything may be live"
+// boundary. Blocks are revisited until no block's live-in set changes,
+// the fixed point a back edge — a DJNZ loop, a retry via JR NZ — requires,
+// since a block earlier in program order can depend on one defined later
+// in the array.
+func Analyze(prog []inst.Instruction, cfg *CFG) *LivenessInfo {
+	li := &LivenessInfo{
+		liveInFlags:  make([]search.FlagSet, len(prog)),
+		liveOutFlags: make([]search.FlagSet, len(prog)),
+		liveInRegs:   make([]search.RegSet, len(prog)),
+		liveOutRegs:  make([]search.RegSet, len(prog)),
+	}
+	if len(cfg.Blocks) == 0 {
+		return li
+	}
+
+	blockInFlags := make([]search.FlagSet, len(cfg.Blocks))
+	blockInRegs := make([]search.RegSet, len(cfg.Blocks))
+
+	for changed := true; changed; {
+		changed = false
+		for bi := len(cfg.Blocks) - 1; bi >= 0; bi-- {
+			b := cfg.Blocks[bi]
+
+			outFlags, outRegs := allFlags, allRegs
+			if len(b.Succs) > 0 {
+				outFlags, outRegs = 0, 0
+				for _, s := range b.Succs {
+					outFlags |= blockInFlags[s]
+					outRegs |= blockInRegs[s]
+				}
+			}
+
+			for i := b.End - 1; i >= b.Start; i-- {
+				li.liveOutFlags[i] = outFlags
+				li.liveOutRegs[i] = outRegs
+
+				useF, defF := search.InstReadsFlags(prog[i]), search.InstWritesFlags(prog[i])
+				useR, defR := search.InstReads(prog[i]), search.InstWrites(prog[i])
+
+				outFlags = useF | (outFlags &^ defF)
+				outRegs = useR | (outRegs &^ defR)
+
+				li.liveInFlags[i] = outFlags
+				li.liveInRegs[i] = outRegs
+			}
+
+			if outFlags != blockInFlags[bi] || outRegs != blockInRegs[bi] {
+				blockInFlags[bi], blockInRegs[bi] = outFlags, outRegs
+				changed = true
+			}
+		}
+	}
+	return li
+}
+
+// DeadFlagsAt returns which flag bits are dead immediately after prog[i]
+// executes — the mask a peephole rewriter gates a flag-relaxed
+// substitution on (ApplyMined's rule.DeadFlags check in
+// pkg/rewrite/mined.go, generalized from that file's straight-line
+// LiveBefore to this package's CFG-aware analysis).
+func (li *LivenessInfo) DeadFlagsAt(i int) search.FlagMask {
+	return search.FlagMask(^li.liveOutFlags[i])
+}
+
+// LiveOutFlagsAt and LiveOutRegsAt expose the raw live-out sets, for callers
+// that want to combine them with their own masking rather than ask "is this
+// bit dead" one at a time.
+func (li *LivenessInfo) LiveOutFlagsAt(i int) search.FlagSet { return li.liveOutFlags[i] }
+func (li *LivenessInfo) LiveOutRegsAt(i int) search.RegSet   { return li.liveOutRegs[i] }
+
+// IsRegDeadAt reports whether reg is dead immediately after prog[i]
+// executes — the CFG-aware counterpart to search.IsDead's forward scan
+// over a sequence's tail.
+func (li *LivenessInfo) IsRegDeadAt(i int, reg search.Register) bool {
+	return !li.liveOutRegs[i].Has(reg)
+}
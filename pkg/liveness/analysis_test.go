@@ -0,0 +1,66 @@
+package liveness
+
+import (
+	"testing"
+
+	"github.com/oisee/z80-optimizer/pkg/inst"
+	"github.com/oisee/z80-optimizer/pkg/search"
+)
+
+func TestAnalyze_RegisterDeadBeforeOverwrite(t *testing.T) {
+	prog := []inst.Instruction{
+		{Op: inst.LD_B_N, Imm: 7},
+		{Op: inst.LD_B_A},
+		{Op: inst.RET},
+	}
+	li := Analyze(prog, BuildCFG(prog))
+
+	if !li.IsRegDeadAt(0, search.RegB) {
+		t.Fatal("B should be dead right after LD B,7: LD B,A overwrites it before anything reads it")
+	}
+	if li.IsRegDeadAt(0, search.RegA) {
+		t.Fatal("A should still be live right after LD B,7: LD B,A reads it next")
+	}
+}
+
+func TestAnalyze_FlagsDeadBeforeOverwrite(t *testing.T) {
+	prog := []inst.Instruction{
+		{Op: inst.LD_A_N, Imm: 0},
+		{Op: inst.XOR_A},
+	}
+	li := Analyze(prog, BuildCFG(prog))
+
+	if got := li.DeadFlagsAt(0); got != search.FlagMask(0xFF) {
+		t.Fatalf("DeadFlagsAt(0) = 0x%02X, want 0xFF: XOR A overwrites every flag before anything reads them", got)
+	}
+}
+
+func TestAnalyze_ExitIsConservativelyAllLive(t *testing.T) {
+	prog := []inst.Instruction{{Op: inst.NOP}}
+	li := Analyze(prog, BuildCFG(prog))
+
+	if got := li.DeadFlagsAt(0); got != 0 {
+		t.Fatalf("DeadFlagsAt(0) = 0x%02X, want 0: a program's exit must assume every flag may be observed", got)
+	}
+	if li.IsRegDeadAt(0, search.RegA) {
+		t.Fatal("a program's exit must assume every register may be observed")
+	}
+}
+
+func TestAnalyze_DJNZLoopReachesFixedPoint(t *testing.T) {
+	// A backward loop (DJNZ -> DEC B) must not make Analyze loop forever or
+	// under-propagate: B is read by DEC B every iteration, so it must come
+	// back live before LD B,3 sets it up.
+	prog := []inst.Instruction{
+		{Op: inst.LD_B_N, Imm: 3},
+		{Op: inst.DEC_B},
+		{Op: inst.DJNZ},
+	}
+	addrs := inst.SeqAddresses(prog)
+	prog[2].Imm = addrs[1]
+
+	li := Analyze(prog, BuildCFG(prog))
+	if li.IsRegDeadAt(0, search.RegB) {
+		t.Fatal("B must be live after LD B,3: DEC B reads it on every loop iteration")
+	}
+}
@@ -0,0 +1,158 @@
+// Package liveness computes backward liveness over a basic-block CFG of Z80
+// instructions: for every program point, which flags and which registers a
+// later instruction still reads before they're next overwritten. pkg/search's
+// IsDead and pkg/rewrite/mined.go's LiveBefore already answer this for a
+// flat, branch-free instruction run; this package generalizes that to a
+// real CFG (built from JR/DJNZ/JP/CALL/RET targets, see BuildCFG) so a
+// backward branch — a DJNZ loop, a retry via JR NZ — doesn't have to be
+// conservatively assumed live-through the way a straight-line scan must
+// treat it.
+package liveness
+
+import "github.com/oisee/z80-optimizer/pkg/inst"
+
+// Block is a maximal straight-line run of instructions: prog[Start:End].
+// Succs holds the indices (into CFG.Blocks) of blocks that can run next; an
+// empty Succs means the block can exit the modeled program — either by
+// falling off prog's end, or via an unconditional RET/RST/JP whose target
+// isn't one of prog's own addresses (a call into code outside prog, or a
+// real ROM entry point). Analyze treats every such exit as "the full
+// register file and flag set may be observed from here", the same
+// assumption the equivalence checker's default (DeadFlags == DeadNone)
+// already makes for a sequence's end state.
+type Block struct {
+	Start, End int
+	Succs      []int
+}
+
+// CFG is a basic-block control-flow graph over a flat instruction sequence.
+type CFG struct {
+	Blocks []Block
+
+	// blockOf maps an instruction index to the index of the Block containing
+	// it, for BuildCFG's branch-target-to-successor lookups.
+	blockOf []int
+}
+
+// endsBlock reports whether op never falls through unconditionally to the
+// next instruction in program order — either because it always jumps
+// elsewhere (an unconditional JR/JP/CALL/RST) or always stops the modeled
+// sequence (RET). Conditional forms (JR_NZ, DJNZ, RET_C, ...) do fall
+// through on the untaken path and so still end their block here, just with
+// a fallthrough successor added in BuildCFG alongside the branch one.
+func endsBlock(op inst.OpCode) bool {
+	return inst.HasBranchTarget(op) || isReturn(op)
+}
+
+// isReturn reports whether op is RET or one of its conditional forms —
+// these don't set Instruction.Imm to a branch target the way
+// HasBranchTarget's opcodes do (RET/RET cc "end the modeled sequence"
+// instead, per HasBranchTarget's doc comment), so BuildCFG has to
+// recognize them separately to know a block ends here.
+func isReturn(op inst.OpCode) bool {
+	return op >= inst.RET && op <= inst.RET_C
+}
+
+// isConditional reports whether op can fall through to the next instruction
+// as well as branch — every condition-coded JR/JP/CALL/RET form, plus DJNZ
+// (which falls through once B decrements to 0).
+func isConditional(op inst.OpCode) bool {
+	switch op {
+	case inst.JR_NZ, inst.JR_Z, inst.JR_NC, inst.JR_C, inst.DJNZ,
+		inst.JP_NZ, inst.JP_Z, inst.JP_NC, inst.JP_C,
+		inst.CALL_NZ, inst.CALL_Z, inst.CALL_NC, inst.CALL_C,
+		inst.RET_NZ, inst.RET_Z, inst.RET_NC, inst.RET_C:
+		return true
+	}
+	return false
+}
+
+// BuildCFG lays out prog's basic blocks the same way inst.IsWellFormedCFG
+// validates branch targets: a leader starts at index 0, at every resolvable
+// branch target, and right after every instruction that ends a block. A
+// branch whose target isn't one of prog's own instruction addresses (Wave
+// 7's CALL modeled as JP, an RST vector, a jump out of the given window)
+// can't be resolved to a Block and is simply left out of Succs — that
+// block has one fewer (or zero) successors, which Analyze treats as an
+// exit from the modeled program.
+func BuildCFG(prog []inst.Instruction) *CFG {
+	if len(prog) == 0 {
+		return &CFG{}
+	}
+
+	addrs := inst.SeqAddresses(prog)
+	indexOf := make(map[uint16]int, len(prog))
+	for i, a := range addrs {
+		indexOf[a] = i
+	}
+
+	leaders := map[int]bool{0: true}
+	for i := range prog {
+		if !endsBlock(prog[i].Op) {
+			continue
+		}
+		if inst.HasBranchTarget(prog[i].Op) {
+			if idx, ok := indexOf[prog[i].Imm]; ok {
+				leaders[idx] = true
+			}
+		}
+		if i+1 < len(prog) {
+			leaders[i+1] = true
+		}
+	}
+
+	starts := make([]int, 0, len(leaders))
+	for i := range leaders {
+		starts = append(starts, i)
+	}
+	insertionSort(starts)
+
+	cfg := &CFG{blockOf: make([]int, len(prog))}
+	for bi, start := range starts {
+		end := len(prog)
+		if bi+1 < len(starts) {
+			end = starts[bi+1]
+		}
+		cfg.Blocks = append(cfg.Blocks, Block{Start: start, End: end})
+		for i := start; i < end; i++ {
+			cfg.blockOf[i] = bi
+		}
+	}
+
+	for bi := range cfg.Blocks {
+		b := &cfg.Blocks[bi]
+		last := b.End - 1
+		if last < b.Start {
+			continue
+		}
+		op := prog[last].Op
+
+		if !endsBlock(op) {
+			if bi+1 < len(cfg.Blocks) {
+				b.Succs = append(b.Succs, bi+1)
+			}
+			continue
+		}
+
+		if inst.HasBranchTarget(op) {
+			if idx, ok := indexOf[prog[last].Imm]; ok {
+				b.Succs = append(b.Succs, cfg.blockOf[idx])
+			}
+		}
+		if isConditional(op) && bi+1 < len(cfg.Blocks) {
+			b.Succs = append(b.Succs, bi+1)
+		}
+	}
+	return cfg
+}
+
+// insertionSort sorts small int slices in place — BuildCFG's leader count is
+// bounded by prog's length, the same scale pkg/rewrite/mined.go's sortInts
+// targets, so a full sort.Ints import isn't worth it here either.
+func insertionSort(s []int) {
+	for i := 1; i < len(s); i++ {
+		for j := i; j > 0 && s[j-1] > s[j]; j-- {
+			s[j-1], s[j] = s[j], s[j-1]
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package liveness
+
+import (
+	"testing"
+
+	"github.com/oisee/z80-optimizer/pkg/inst"
+)
+
+func TestBuildCFG_StraightLine(t *testing.T) {
+	prog := []inst.Instruction{{Op: inst.NOP}, {Op: inst.NOP}, {Op: inst.XOR_A}}
+	cfg := BuildCFG(prog)
+
+	if len(cfg.Blocks) != 1 {
+		t.Fatalf("want 1 block for a branch-free program, got %d: %+v", len(cfg.Blocks), cfg.Blocks)
+	}
+	if cfg.Blocks[0].Start != 0 || cfg.Blocks[0].End != len(prog) {
+		t.Fatalf("block range = [%d,%d), want [0,%d)", cfg.Blocks[0].Start, cfg.Blocks[0].End, len(prog))
+	}
+	if len(cfg.Blocks[0].Succs) != 0 {
+		t.Fatalf("want no successors falling off the program's end, got %v", cfg.Blocks[0].Succs)
+	}
+}
+
+func TestBuildCFG_DJNZLoop(t *testing.T) {
+	// LD B,3 ; DEC B ; DJNZ -> DEC B — a 2-block loop: the DJNZ's own block
+	// branches back to itself (DEC B and DJNZ share a block, since nothing
+	// else targets DEC B directly) rather than falling off the end, since
+	// DJNZ has no successor block after it.
+	prog := []inst.Instruction{
+		{Op: inst.LD_B_N, Imm: 3},
+		{Op: inst.DEC_B},
+		{Op: inst.DJNZ},
+	}
+	addrs := inst.SeqAddresses(prog)
+	prog[2].Imm = addrs[1]
+
+	cfg := BuildCFG(prog)
+	if len(cfg.Blocks) != 2 {
+		t.Fatalf("want 2 blocks (LD B,3 alone; DEC B/DJNZ loop body), got %d: %+v", len(cfg.Blocks), cfg.Blocks)
+	}
+
+	entry, loop := cfg.Blocks[0], cfg.Blocks[1]
+	if entry.Start != 0 || entry.End != 1 {
+		t.Fatalf("entry block = [%d,%d), want [0,1)", entry.Start, entry.End)
+	}
+	if loop.Start != 1 || loop.End != 3 {
+		t.Fatalf("loop block = [%d,%d), want [1,3)", loop.Start, loop.End)
+	}
+	if len(entry.Succs) != 1 || entry.Succs[0] != 1 {
+		t.Fatalf("entry.Succs = %v, want [1]", entry.Succs)
+	}
+	if len(loop.Succs) != 1 || loop.Succs[0] != 1 {
+		t.Fatalf("loop.Succs = %v, want [1] (DJNZ loops back, no fallthrough block exists)", loop.Succs)
+	}
+}
+
+func TestBuildCFG_UnresolvedTargetIsExit(t *testing.T) {
+	// A JP to an address outside prog (e.g. a real ROM routine) can't be
+	// resolved to a Block — it should just leave that block with no
+	// successors rather than panicking or guessing.
+	prog := []inst.Instruction{{Op: inst.JP, Imm: 0xFFFF}}
+	cfg := BuildCFG(prog)
+	if len(cfg.Blocks) != 1 {
+		t.Fatalf("want 1 block, got %d", len(cfg.Blocks))
+	}
+	if len(cfg.Blocks[0].Succs) != 0 {
+		t.Fatalf("unresolved JP target should leave no successors, got %v", cfg.Blocks[0].Succs)
+	}
+}
@@ -0,0 +1,25 @@
+package liveness
+
+import (
+	"github.com/oisee/z80-optimizer/pkg/inst"
+	"github.com/oisee/z80-optimizer/pkg/search"
+)
+
+// Liveness runs Analyze over block treated as a single straight-line run
+// (BuildCFG already gives any JR/DJNZ inside it real successors, so a loop
+// or early-exit inside block is still handled correctly; it's "straight
+// line" only in the sense that nothing outside block can jump back into
+// it). It returns the live-out flag set immediately after each instruction,
+// indexed the same way as block — the shape a local peephole window (one
+// candidate instruction at a time, not a whole CFG) actually wants, instead
+// of holding onto the LivenessInfo/CFG pair Analyze needs for a multi-query
+// caller.
+func Liveness(block []inst.Instruction) []search.FlagSet {
+	cfg := BuildCFG(block)
+	li := Analyze(block, cfg)
+	out := make([]search.FlagSet, len(block))
+	for i := range block {
+		out[i] = li.LiveOutFlagsAt(i)
+	}
+	return out
+}
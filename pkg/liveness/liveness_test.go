@@ -0,0 +1,19 @@
+package liveness
+
+import (
+	"testing"
+
+	"github.com/oisee/z80-optimizer/pkg/inst"
+	"github.com/oisee/z80-optimizer/pkg/search"
+)
+
+func TestLiveness_MatchesAnalyzeLiveOut(t *testing.T) {
+	block := []inst.Instruction{
+		{Op: inst.LD_A_N, Imm: 0},
+		{Op: inst.XOR_A},
+	}
+	got := Liveness(block)
+	if got[0] != search.FlagSet(0) {
+		t.Fatalf("Liveness(block)[0] = %v, want 0: XOR A overwrites every flag before anything reads them", got[0])
+	}
+}
@@ -1,12 +1,35 @@
 package result
 
 import (
+	"bytes"
 	"encoding/gob"
+	"encoding/json"
+	"fmt"
 	"os"
 
 	"github.com/oisee/z80-optimizer/pkg/inst"
 )
 
+// CheckpointFormat selects how SaveCheckpoint encodes a Checkpoint on disk.
+type CheckpointFormat int
+
+const (
+	// FormatJSON is the default (chunk4-4): a versioned, length-prefixed-by-
+	// the-OS-file envelope that survives an inst.OpCode renumbering, because
+	// each instruction is stored by its catalog mnemonic rather than its
+	// numeric id. Human-readable, and portable outside Go.
+	FormatJSON CheckpointFormat = iota
+	// FormatGob is the original encoding/gob format. It round-trips fine
+	// within a single build but silently breaks if OpCode values are
+	// renumbered or Rule gains a field, and isn't readable outside Go.
+	// Deprecated: kept only so pre-chunk4-4 checkpoints can still be loaded.
+	FormatGob
+)
+
+// CurrentSchemaVersion is the SchemaVersion SaveCheckpoint writes under
+// FormatJSON. LoadCheckpoint upgrades anything older via migrateCheckpoint.
+const CurrentSchemaVersion = 1
+
 // Checkpoint holds state for resuming a search.
 type Checkpoint struct {
 	Rules           []Rule
@@ -15,31 +38,185 @@ type Checkpoint struct {
 }
 
 func init() {
-	// Register types for gob encoding
+	// Register types for gob encoding (FormatGob only).
 	gob.Register(inst.Instruction{})
 	gob.Register(inst.OpCode(0))
 }
 
-// SaveCheckpoint writes search state to a file.
+// SaveCheckpoint writes search state to path using the default format
+// (FormatJSON). Use SaveCheckpointFormat to opt into the deprecated
+// FormatGob instead.
 func SaveCheckpoint(path string, ckpt *Checkpoint) error {
+	return SaveCheckpointFormat(path, ckpt, FormatJSON)
+}
+
+// SaveCheckpointFormat writes search state to path using the given format.
+func SaveCheckpointFormat(path string, ckpt *Checkpoint, format CheckpointFormat) error {
 	f, err := os.Create(path)
 	if err != nil {
 		return err
 	}
 	defer f.Close()
-	return gob.NewEncoder(f).Encode(ckpt)
+
+	if format == FormatGob {
+		return gob.NewEncoder(f).Encode(ckpt)
+	}
+	return json.NewEncoder(f).Encode(toEnvelope(ckpt))
 }
 
-// LoadCheckpoint loads search state from a file.
+// LoadCheckpoint loads search state from a file, auto-detecting the format
+// it was saved in: a FormatJSON envelope (upgraded first via
+// migrateCheckpoint if it's an older SchemaVersion) or a legacy gob blob.
 func LoadCheckpoint(path string) (*Checkpoint, error) {
-	f, err := os.Open(path)
+	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, err
 	}
-	defer f.Close()
+
+	if looksLikeJSON(data) {
+		var env checkpointEnvelope
+		if err := json.Unmarshal(data, &env); err != nil {
+			return nil, fmt.Errorf("decode checkpoint envelope: %w", err)
+		}
+		if err := migrateCheckpoint(&env); err != nil {
+			return nil, err
+		}
+		return fromEnvelope(&env)
+	}
+
 	var ckpt Checkpoint
-	if err := gob.NewDecoder(f).Decode(&ckpt); err != nil {
-		return nil, err
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&ckpt); err != nil {
+		return nil, fmt.Errorf("decode gob checkpoint: %w", err)
 	}
 	return &ckpt, nil
 }
+
+func looksLikeJSON(data []byte) bool {
+	for _, b := range data {
+		switch b {
+		case ' ', '\t', '\n', '\r':
+			continue
+		case '{':
+			return true
+		default:
+			return false
+		}
+	}
+	return false
+}
+
+// checkpointEnvelope is the on-disk FormatJSON shape. OpcodeNames is a
+// diagnostic manifest of every mnemonic->id mapping in the build that wrote
+// the file; resolution on load always goes by mnemonic (jsonInstruction.Op),
+// never by replaying this map, so a renumbering can't silently corrupt it.
+type checkpointEnvelope struct {
+	SchemaVersion   int               `json:"schema_version"`
+	OpcodeNames     map[string]uint16 `json:"opcode_names"`
+	Rules           []jsonRule        `json:"rules"`
+	CompletedTarget int               `json:"completed_target"`
+	TargetLen       int               `json:"target_len"`
+}
+
+type jsonRule struct {
+	Source      []jsonInstruction `json:"source"`
+	Replacement []jsonInstruction `json:"replacement"`
+	BytesSaved  int               `json:"bytes_saved"`
+	CyclesSaved int               `json:"cycles_saved"`
+	DeadFlags   uint8             `json:"dead_flags"`
+	DeadRegs    uint16            `json:"dead_regs"`
+}
+
+type jsonInstruction struct {
+	Op   string `json:"op"`
+	Imm  uint16 `json:"imm,omitempty"`
+	Disp int8   `json:"disp,omitempty"`
+}
+
+func toEnvelope(ckpt *Checkpoint) *checkpointEnvelope {
+	names := make(map[string]uint16, inst.OpCodeCount)
+	for _, op := range inst.AllOps() {
+		names[inst.OpCodeName(op)] = uint16(op)
+	}
+
+	rules := make([]jsonRule, len(ckpt.Rules))
+	for i, r := range ckpt.Rules {
+		rules[i] = jsonRule{
+			Source:      toJSONInstructions(r.Source),
+			Replacement: toJSONInstructions(r.Replacement),
+			BytesSaved:  r.BytesSaved,
+			CyclesSaved: r.CyclesSaved,
+			DeadFlags:   r.DeadFlags,
+			DeadRegs:    r.DeadRegs,
+		}
+	}
+
+	return &checkpointEnvelope{
+		SchemaVersion:   CurrentSchemaVersion,
+		OpcodeNames:     names,
+		Rules:           rules,
+		CompletedTarget: ckpt.CompletedTarget,
+		TargetLen:       ckpt.TargetLen,
+	}
+}
+
+func fromEnvelope(env *checkpointEnvelope) (*Checkpoint, error) {
+	rules := make([]Rule, len(env.Rules))
+	for i, jr := range env.Rules {
+		source, err := fromJSONInstructions(jr.Source)
+		if err != nil {
+			return nil, fmt.Errorf("rule %d source: %w", i, err)
+		}
+		replacement, err := fromJSONInstructions(jr.Replacement)
+		if err != nil {
+			return nil, fmt.Errorf("rule %d replacement: %w", i, err)
+		}
+		rules[i] = Rule{
+			Source:      source,
+			Replacement: replacement,
+			BytesSaved:  jr.BytesSaved,
+			CyclesSaved: jr.CyclesSaved,
+			DeadFlags:   jr.DeadFlags,
+			DeadRegs:    jr.DeadRegs,
+		}
+	}
+
+	return &Checkpoint{
+		Rules:           rules,
+		CompletedTarget: env.CompletedTarget,
+		TargetLen:       env.TargetLen,
+	}, nil
+}
+
+func toJSONInstructions(seq []inst.Instruction) []jsonInstruction {
+	out := make([]jsonInstruction, len(seq))
+	for i, instr := range seq {
+		out[i] = jsonInstruction{Op: inst.OpCodeName(instr.Op), Imm: instr.Imm, Disp: instr.Disp}
+	}
+	return out
+}
+
+func fromJSONInstructions(seq []jsonInstruction) ([]inst.Instruction, error) {
+	out := make([]inst.Instruction, len(seq))
+	for i, ji := range seq {
+		op, ok := inst.OpCodeByName(ji.Op)
+		if !ok {
+			return nil, fmt.Errorf("unknown opcode mnemonic %q (checkpoint was written by a build with a different catalog)", ji.Op)
+		}
+		out[i] = inst.Instruction{Op: op, Imm: ji.Imm, Disp: ji.Disp}
+	}
+	return out, nil
+}
+
+// migrateCheckpoint upgrades env in place to CurrentSchemaVersion. There's
+// only ever been schema version 1 so far — this is the extension point a
+// future schema bump adds a case to, rewriting env before falling through to
+// the next case, rather than LoadCheckpoint gaining per-version branches.
+func migrateCheckpoint(env *checkpointEnvelope) error {
+	if env.SchemaVersion == CurrentSchemaVersion {
+		return nil
+	}
+	if env.SchemaVersion > CurrentSchemaVersion {
+		return fmt.Errorf("checkpoint schema version %d is newer than this build supports (%d)", env.SchemaVersion, CurrentSchemaVersion)
+	}
+	return fmt.Errorf("no migration path from checkpoint schema version %d to %d", env.SchemaVersion, CurrentSchemaVersion)
+}
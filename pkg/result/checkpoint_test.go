@@ -0,0 +1,153 @@
+package result
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/oisee/z80-optimizer/pkg/inst"
+)
+
+func sampleCheckpoint() *Checkpoint {
+	return &Checkpoint{
+		Rules: []Rule{
+			{
+				Source:      []inst.Instruction{{Op: inst.LD_A_N, Imm: 0}},
+				Replacement: []inst.Instruction{{Op: inst.XOR_A}},
+				BytesSaved:  1,
+				CyclesSaved: 3,
+				DeadFlags:   0xFF,
+			},
+		},
+		CompletedTarget: 12,
+		TargetLen:       2,
+	}
+}
+
+func TestSaveLoadCheckpointJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ckpt.json")
+	want := sampleCheckpoint()
+
+	if err := SaveCheckpoint(path, want); err != nil {
+		t.Fatalf("SaveCheckpoint: %v", err)
+	}
+	got, err := LoadCheckpoint(path)
+	if err != nil {
+		t.Fatalf("LoadCheckpoint: %v", err)
+	}
+	assertCheckpointsEqual(t, want, got)
+}
+
+func TestSaveLoadCheckpointGob(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ckpt.gob")
+	want := sampleCheckpoint()
+
+	if err := SaveCheckpointFormat(path, want, FormatGob); err != nil {
+		t.Fatalf("SaveCheckpointFormat: %v", err)
+	}
+	got, err := LoadCheckpoint(path)
+	if err != nil {
+		t.Fatalf("LoadCheckpoint: %v", err)
+	}
+	assertCheckpointsEqual(t, want, got)
+}
+
+// TestLoadCheckpointIgnoresStaleOpcodeManifest simulates chunk4-4's whole
+// point: a checkpoint whose embedded OpcodeNames manifest reflects a
+// different (e.g. older/renumbered) build than the one loading it. Since
+// resolution always goes by mnemonic (see fromJSONInstructions), a manifest
+// that disagrees with the current numbering must not affect the result.
+func TestLoadCheckpointIgnoresStaleOpcodeManifest(t *testing.T) {
+	env := checkpointEnvelope{
+		SchemaVersion: CurrentSchemaVersion,
+		OpcodeNames:   map[string]uint16{inst.OpCodeName(inst.LD_A_N): 9999}, // numeric id from some other build
+		Rules: []jsonRule{{
+			Source:      []jsonInstruction{{Op: inst.OpCodeName(inst.LD_A_N), Imm: 7}},
+			Replacement: []jsonInstruction{{Op: inst.OpCodeName(inst.XOR_A)}},
+		}},
+	}
+	path := writeEnvelope(t, env)
+
+	got, err := LoadCheckpoint(path)
+	if err != nil {
+		t.Fatalf("LoadCheckpoint: %v", err)
+	}
+	want := &Checkpoint{Rules: []Rule{{
+		Source:      []inst.Instruction{{Op: inst.LD_A_N, Imm: 7}},
+		Replacement: []inst.Instruction{{Op: inst.XOR_A}},
+	}}}
+	assertCheckpointsEqual(t, want, got)
+}
+
+func TestLoadCheckpointUnknownMnemonic(t *testing.T) {
+	env := checkpointEnvelope{
+		SchemaVersion: CurrentSchemaVersion,
+		Rules: []jsonRule{
+			{Source: []jsonInstruction{{Op: "NOT A REAL OPCODE"}}},
+		},
+	}
+	path := writeEnvelope(t, env)
+
+	if _, err := LoadCheckpoint(path); err == nil {
+		t.Fatal("expected an error for an unresolvable opcode mnemonic, got nil")
+	}
+}
+
+func TestMigrateCheckpointRejectsNewerSchema(t *testing.T) {
+	env := checkpointEnvelope{SchemaVersion: CurrentSchemaVersion + 1}
+	if err := migrateCheckpoint(&env); err == nil {
+		t.Fatal("expected an error for a checkpoint schema newer than this build supports")
+	}
+}
+
+func TestMigrateCheckpointRejectsOlderSchemaWithNoPath(t *testing.T) {
+	env := checkpointEnvelope{SchemaVersion: 0}
+	if err := migrateCheckpoint(&env); err == nil {
+		t.Fatal("expected an error: schema version 0 has never been written, so there's no migration for it")
+	}
+}
+
+func writeEnvelope(t *testing.T, env checkpointEnvelope) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "ckpt.json")
+	data, err := json.Marshal(env)
+	if err != nil {
+		t.Fatalf("marshal envelope: %v", err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	return path
+}
+
+func assertCheckpointsEqual(t *testing.T, want, got *Checkpoint) {
+	t.Helper()
+	if got.CompletedTarget != want.CompletedTarget || got.TargetLen != want.TargetLen {
+		t.Fatalf("counters = %+v, want %+v", got, want)
+	}
+	if len(got.Rules) != len(want.Rules) {
+		t.Fatalf("len(Rules) = %d, want %d", len(got.Rules), len(want.Rules))
+	}
+	for i := range want.Rules {
+		wr, gr := want.Rules[i], got.Rules[i]
+		if gr.BytesSaved != wr.BytesSaved || gr.CyclesSaved != wr.CyclesSaved || gr.DeadFlags != wr.DeadFlags || gr.DeadRegs != wr.DeadRegs {
+			t.Fatalf("rule %d metadata = %+v, want %+v", i, gr, wr)
+		}
+		if !instSeqEqual(gr.Source, wr.Source) || !instSeqEqual(gr.Replacement, wr.Replacement) {
+			t.Fatalf("rule %d instructions = %+v, want %+v", i, gr, wr)
+		}
+	}
+}
+
+func instSeqEqual(a, b []inst.Instruction) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,201 @@
+package result
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/oisee/z80-optimizer/pkg/inst"
+)
+
+// dbEnvelope is Save's on-disk shape: the same mnemonic-keyed JSON envelope
+// checkpoint.go's checkpointEnvelope already uses, so a rule database
+// survives an OpCode renumbering across builds (resolution on load always
+// goes by mnemonic, never by replaying OpcodeNames).
+type dbEnvelope struct {
+	SchemaVersion int               `json:"schema_version"`
+	OpcodeNames   map[string]uint16 `json:"opcode_names"`
+	Rules         []jsonRule        `json:"rules"`
+}
+
+// Save writes t's rules to path as a rule database (chunk8-5) — a curated
+// or GPU-discovered rule set that can be shipped alongside the module, or
+// merged into a later run's Table via Merge instead of re-searched.
+func (t *Table) Save(path string) error {
+	rules := t.Rules()
+
+	names := make(map[string]uint16, inst.OpCodeCount)
+	for _, op := range inst.AllOps() {
+		names[inst.OpCodeName(op)] = uint16(op)
+	}
+
+	env := dbEnvelope{
+		SchemaVersion: CurrentSchemaVersion,
+		OpcodeNames:   names,
+		Rules:         make([]jsonRule, len(rules)),
+	}
+	for i, r := range rules {
+		env.Rules[i] = jsonRule{
+			Source:      toJSONInstructions(r.Source),
+			Replacement: toJSONInstructions(r.Replacement),
+			BytesSaved:  r.BytesSaved,
+			CyclesSaved: r.CyclesSaved,
+			DeadFlags:   r.DeadFlags,
+			DeadRegs:    r.DeadRegs,
+		}
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return json.NewEncoder(f).Encode(env)
+}
+
+// LoadTable reads a rule database written by Save.
+func LoadTable(path string) (*Table, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var env dbEnvelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return nil, fmt.Errorf("decode rule database: %w", err)
+	}
+	if env.SchemaVersion > CurrentSchemaVersion {
+		return nil, fmt.Errorf("rule database schema version %d is newer than this build supports (%d)", env.SchemaVersion, CurrentSchemaVersion)
+	}
+
+	t := NewTable()
+	for i, jr := range env.Rules {
+		source, err := fromJSONInstructions(jr.Source)
+		if err != nil {
+			return nil, fmt.Errorf("rule %d source: %w", i, err)
+		}
+		replacement, err := fromJSONInstructions(jr.Replacement)
+		if err != nil {
+			return nil, fmt.Errorf("rule %d replacement: %w", i, err)
+		}
+		t.rules = append(t.rules, Rule{
+			Source:      source,
+			Replacement: replacement,
+			BytesSaved:  jr.BytesSaved,
+			CyclesSaved: jr.CyclesSaved,
+			DeadFlags:   jr.DeadFlags,
+			DeadRegs:    jr.DeadRegs,
+		})
+	}
+	return t, nil
+}
+
+// Merge folds other's rules into t, keyed by CanonicalKey(r.Source) so a
+// rule already covered by an equal-or-better entry in t is skipped rather
+// than duplicated, and a strictly better incoming rule (see ruleBetter)
+// replaces the one already there. added counts newly-inserted keys;
+// superseded counts existing entries an incoming rule replaced.
+func (t *Table) Merge(other *Table) (added, superseded int) {
+	incoming := other.Rules()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	byKey := make(map[string]int, len(t.rules))
+	for i, r := range t.rules {
+		byKey[CanonicalKey(r.Source)] = i
+	}
+
+	for _, r := range incoming {
+		key := CanonicalKey(r.Source)
+		if idx, ok := byKey[key]; ok {
+			if ruleBetter(r, t.rules[idx]) {
+				t.rules[idx] = r
+				superseded++
+			}
+			continue
+		}
+		byKey[key] = len(t.rules)
+		t.rules = append(t.rules, r)
+		added++
+	}
+	return added, superseded
+}
+
+// ruleBetter reports whether a should replace b as the canonical rule kept
+// for a shared Source key: compared by (BytesSaved, CyclesSaved, then
+// preferring DeadFlags == 0 — a rule that holds unconditionally beats one
+// that only holds once some flags are ignored).
+func ruleBetter(a, b Rule) bool {
+	if a.BytesSaved != b.BytesSaved {
+		return a.BytesSaved > b.BytesSaved
+	}
+	if a.CyclesSaved != b.CyclesSaved {
+		return a.CyclesSaved > b.CyclesSaved
+	}
+	return a.DeadFlags == 0 && b.DeadFlags != 0
+}
+
+// immLoadTargetReg maps the seven "load a register with an immediate"
+// opcodes to the single register letter they target — the one class of
+// pairwise-commuting instructions CanonicalKey normalizes without needing
+// pkg/search's full read/write dependency DAG (chunk8-4), which pkg/result
+// can't import without creating a cycle (pkg/search already imports
+// pkg/result). Two such loads to different registers can run in either
+// order with an identical final state, since neither reads anything the
+// other could have written.
+var immLoadTargetReg = map[inst.OpCode]byte{
+	inst.LD_A_N: 'A', inst.LD_B_N: 'B', inst.LD_C_N: 'C', inst.LD_D_N: 'D',
+	inst.LD_E_N: 'E', inst.LD_H_N: 'H', inst.LD_L_N: 'L',
+}
+
+// CanonicalKey returns a stable dedup key for seq, identifying Save/Merge's
+// rules by mnemonic+immediate (not numeric OpCode, for the same
+// renumbering-safety reason checkpoint.go's envelope uses mnemonics).
+// Maximal runs of immediate-register loads to pairwise-distinct registers
+// are sorted by target register first, so two Source sequences that only
+// differ in such a run's order canonicalize to the same key — see
+// immLoadTargetReg.
+func CanonicalKey(seq []inst.Instruction) string {
+	canon := canonicalizeImmediateLoadRuns(seq)
+	var b strings.Builder
+	for _, instr := range canon {
+		fmt.Fprintf(&b, "%s:%d:%d|", inst.OpCodeName(instr.Op), instr.Imm, instr.Disp)
+	}
+	return b.String()
+}
+
+func canonicalizeImmediateLoadRuns(seq []inst.Instruction) []inst.Instruction {
+	out := make([]inst.Instruction, len(seq))
+	copy(out, seq)
+
+	for i := 0; i < len(out); {
+		reg, ok := immLoadTargetReg[out[i].Op]
+		if !ok {
+			i++
+			continue
+		}
+		j := i + 1
+		seen := map[byte]bool{reg: true}
+		for j < len(out) {
+			r, ok := immLoadTargetReg[out[j].Op]
+			if !ok || seen[r] {
+				break
+			}
+			seen[r] = true
+			j++
+		}
+		sortByTargetReg(out[i:j])
+		i = j
+	}
+	return out
+}
+
+func sortByTargetReg(run []inst.Instruction) {
+	for i := 1; i < len(run); i++ {
+		for k := i; k > 0 && immLoadTargetReg[run[k-1].Op] > immLoadTargetReg[run[k].Op]; k-- {
+			run[k-1], run[k] = run[k], run[k-1]
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package result
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/oisee/z80-optimizer/pkg/inst"
+)
+
+func TestSaveLoadTable(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.json")
+
+	want := NewTable()
+	want.Add(Rule{
+		Source:      []inst.Instruction{{Op: inst.LD_A_N, Imm: 0}},
+		Replacement: []inst.Instruction{{Op: inst.XOR_A}},
+		BytesSaved:  1,
+		CyclesSaved: 3,
+		DeadFlags:   0xFF,
+	})
+
+	if err := want.Save(path); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	got, err := LoadTable(path)
+	if err != nil {
+		t.Fatalf("LoadTable: %v", err)
+	}
+	if got.Len() != 1 {
+		t.Fatalf("LoadTable: got %d rules, want 1", got.Len())
+	}
+	gotRule := got.Rules()[0]
+	if gotRule.BytesSaved != 1 || gotRule.CyclesSaved != 3 || gotRule.DeadFlags != 0xFF {
+		t.Fatalf("LoadTable round-trip mismatch: %+v", gotRule)
+	}
+}
+
+func TestMerge_AddsNewAndKeepsBetterOnConflict(t *testing.T) {
+	source := []inst.Instruction{{Op: inst.CP_B}}
+
+	base := NewTable()
+	base.Add(Rule{Source: source, Replacement: []inst.Instruction{{Op: inst.NOP}}, BytesSaved: 1, CyclesSaved: 4})
+	base.Add(Rule{Source: []inst.Instruction{{Op: inst.OR_A}}, Replacement: nil, BytesSaved: 1, CyclesSaved: 4})
+
+	incoming := NewTable()
+	// Strictly better rule for the shared Source (more cycles saved).
+	incoming.Add(Rule{Source: source, Replacement: []inst.Instruction{{Op: inst.NOP}}, BytesSaved: 1, CyclesSaved: 8})
+	// A genuinely new rule.
+	incoming.Add(Rule{Source: []inst.Instruction{{Op: inst.AND_A}}, Replacement: nil, BytesSaved: 1, CyclesSaved: 4})
+
+	added, superseded := base.Merge(incoming)
+	if added != 1 {
+		t.Fatalf("added = %d, want 1", added)
+	}
+	if superseded != 1 {
+		t.Fatalf("superseded = %d, want 1", superseded)
+	}
+	if base.Len() != 3 {
+		t.Fatalf("base.Len() = %d, want 3", base.Len())
+	}
+
+	for _, r := range base.Rules() {
+		if CanonicalKey(r.Source) == CanonicalKey(source) && r.CyclesSaved != 8 {
+			t.Fatalf("Merge should have kept the better (CyclesSaved=8) rule, got %+v", r)
+		}
+	}
+}
+
+func TestCanonicalKey_NormalizesIndependentImmediateLoadOrder(t *testing.T) {
+	a := []inst.Instruction{{Op: inst.LD_B_N, Imm: 1}, {Op: inst.LD_C_N, Imm: 2}}
+	b := []inst.Instruction{{Op: inst.LD_C_N, Imm: 2}, {Op: inst.LD_B_N, Imm: 1}}
+	if CanonicalKey(a) != CanonicalKey(b) {
+		t.Fatalf("CanonicalKey should treat independent immediate loads to different registers as equal regardless of order: %q vs %q", CanonicalKey(a), CanonicalKey(b))
+	}
+}
+
+func TestCanonicalKey_SameRegisterLoadOrderMatters(t *testing.T) {
+	a := []inst.Instruction{{Op: inst.LD_B_N, Imm: 1}, {Op: inst.LD_B_N, Imm: 2}}
+	b := []inst.Instruction{{Op: inst.LD_B_N, Imm: 2}, {Op: inst.LD_B_N, Imm: 1}}
+	if CanonicalKey(a) == CanonicalKey(b) {
+		t.Fatal("CanonicalKey must not normalize two loads to the SAME register: order changes the final value")
+	}
+}
@@ -0,0 +1,121 @@
+package result
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"strings"
+
+	"github.com/oisee/z80-optimizer/pkg/inst"
+)
+
+// RuleRecord is the on-disk JSON shape for one rule, shared by the
+// enumerate --output-format json/jsonl writers and everything that reads
+// rules back in (verify, verify-jsonl, export): instructions are rendered
+// as " : "-joined assembly text, the same separator ParseAssembly splits
+// on, rather than opcode IDs — unlike pkg/result's own Checkpoint envelope,
+// this format is meant to be hand-inspected and fed straight back into
+// asm.ParseAssembly.
+type RuleRecord struct {
+	SourceASM      string `json:"source_asm"`
+	ReplacementASM string `json:"replacement_asm"`
+	BytesSaved     int    `json:"bytes_saved"`
+	CyclesSaved    int    `json:"cycles_saved"`
+	DeadFlags      uint8  `json:"dead_flags,omitempty"`
+	DeadRegs       uint16 `json:"dead_regs,omitempty"`
+}
+
+// ToRuleRecord renders r's instruction sequences as RuleRecord's assembly
+// text.
+func ToRuleRecord(r Rule) RuleRecord {
+	return RuleRecord{
+		SourceASM:      disasmSeq(r.Source),
+		ReplacementASM: disasmSeq(r.Replacement),
+		BytesSaved:     r.BytesSaved,
+		CyclesSaved:    r.CyclesSaved,
+		DeadFlags:      r.DeadFlags,
+		DeadRegs:       r.DeadRegs,
+	}
+}
+
+func disasmSeq(seq []inst.Instruction) string {
+	parts := make([]string, len(seq))
+	for i, instr := range seq {
+		parts[i] = inst.Disassemble(instr)
+	}
+	return strings.Join(parts, " : ")
+}
+
+// WriteJSON writes rules as a single JSON array of RuleRecord — the
+// "enumerate --output-format json" shape.
+func WriteJSON(w io.Writer, rules []Rule) error {
+	records := make([]RuleRecord, len(rules))
+	for i, r := range rules {
+		records[i] = ToRuleRecord(r)
+	}
+	return json.NewEncoder(w).Encode(records)
+}
+
+// ReadJSON reads back a WriteJSON array.
+func ReadJSON(r io.Reader) ([]RuleRecord, error) {
+	var records []RuleRecord
+	if err := json.NewDecoder(r).Decode(&records); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// JSONLWriter streams one RuleRecord per line, flushing after every write —
+// the "enumerate --output-format jsonl" shape, the same schema
+// verify-jsonl already consumes. Flushing per-line trades throughput for
+// crash safety: an interrupted multi-hour enumeration still leaves a valid
+// prefix of complete lines on disk.
+type JSONLWriter struct {
+	w *bufio.Writer
+}
+
+// NewJSONLWriter wraps w for streaming rule output.
+func NewJSONLWriter(w io.Writer) *JSONLWriter {
+	return &JSONLWriter{w: bufio.NewWriter(w)}
+}
+
+// WriteRule appends r to the stream as one JSON line.
+func (jw *JSONLWriter) WriteRule(r Rule) error {
+	data, err := json.Marshal(ToRuleRecord(r))
+	if err != nil {
+		return err
+	}
+	if _, err := jw.w.Write(data); err != nil {
+		return err
+	}
+	if err := jw.w.WriteByte('\n'); err != nil {
+		return err
+	}
+	return jw.w.Flush()
+}
+
+// deadFlagLetters lists the eight flag bits from S (bit 7) down to C (bit
+// 0) — kept independent of search.FlagSet's identical table since pkg/search
+// already imports pkg/result and the reverse import would cycle.
+var deadFlagLetters = [...]struct {
+	bit byte
+	ch  byte
+}{
+	{0x80, 'S'}, {0x40, 'Z'}, {0x20, 'Y'}, {0x10, 'H'},
+	{0x08, 'X'}, {0x04, 'P'}, {0x02, 'N'}, {0x01, 'C'},
+}
+
+// DeadFlagDesc renders a dead-flags bitmask as one character per flag bit
+// in SZYHXPNC order: the flag's letter where the bit is set (ignored),
+// '-' where it's clear (still checked).
+func DeadFlagDesc(deadFlags uint8) string {
+	b := make([]byte, len(deadFlagLetters))
+	for i, fl := range deadFlagLetters {
+		if deadFlags&fl.bit != 0 {
+			b[i] = fl.ch
+		} else {
+			b[i] = '-'
+		}
+	}
+	return string(b)
+}
@@ -0,0 +1,89 @@
+package result
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/oisee/z80-optimizer/pkg/inst"
+)
+
+func sampleRule() Rule {
+	return Rule{
+		Source:      []inst.Instruction{{Op: inst.LD_A_N, Imm: 0}},
+		Replacement: []inst.Instruction{{Op: inst.XOR_A}},
+		BytesSaved:  1,
+		CyclesSaved: 3,
+	}
+}
+
+func TestWriteJSONReadJSONRoundTrip(t *testing.T) {
+	rules := []Rule{sampleRule()}
+
+	var buf bytes.Buffer
+	if err := WriteJSON(&buf, rules); err != nil {
+		t.Fatalf("WriteJSON: %v", err)
+	}
+
+	records, err := ReadJSON(&buf)
+	if err != nil {
+		t.Fatalf("ReadJSON: %v", err)
+	}
+	if len(records) != 1 {
+		t.Fatalf("got %d records, want 1", len(records))
+	}
+	want := ToRuleRecord(rules[0])
+	if records[0] != want {
+		t.Errorf("records[0] = %+v, want %+v", records[0], want)
+	}
+}
+
+func TestJSONLWriterWritesOneRecordPerLine(t *testing.T) {
+	var buf bytes.Buffer
+	jw := NewJSONLWriter(&buf)
+
+	rules := []Rule{sampleRule(), sampleRule()}
+	for _, r := range rules {
+		if err := jw.WriteRule(r); err != nil {
+			t.Fatalf("WriteRule: %v", err)
+		}
+	}
+
+	lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2: %q", len(lines), buf.String())
+	}
+	for _, line := range lines {
+		if !strings.Contains(line, `"source_asm"`) {
+			t.Errorf("line missing source_asm: %q", line)
+		}
+	}
+}
+
+func TestDeadFlagDescRendersSetBitsAsLetters(t *testing.T) {
+	if got, want := DeadFlagDesc(0x00), "--------"; got != want {
+		t.Errorf("DeadFlagDesc(0x00) = %q, want %q", got, want)
+	}
+	if got, want := DeadFlagDesc(0xFF), "SZYHXPNC"; got != want {
+		t.Errorf("DeadFlagDesc(0xFF) = %q, want %q", got, want)
+	}
+	if got, want := DeadFlagDesc(0x01), "-------C"; got != want {
+		t.Errorf("DeadFlagDesc(0x01) = %q, want %q", got, want)
+	}
+}
+
+func TestTableSetOnRuleFiresPerAdd(t *testing.T) {
+	table := NewTable()
+	var seen []Rule
+	table.SetOnRule(func(r Rule) { seen = append(seen, r) })
+
+	table.Add(sampleRule())
+	table.Add(sampleRule())
+
+	if len(seen) != 2 {
+		t.Fatalf("onRule fired %d times, want 2", len(seen))
+	}
+	if table.Len() != 2 {
+		t.Errorf("table.Len() = %d, want 2", table.Len())
+	}
+}
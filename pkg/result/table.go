@@ -13,12 +13,15 @@ type Rule struct {
 	Replacement []inst.Instruction
 	BytesSaved  int
 	CyclesSaved int
+	DeadFlags   uint8  // Nonzero means Source and Replacement only agree once these F bits are ignored
+	DeadRegs    uint16 // chunk4-1: nonzero means they only agree once these registers are ignored too
 }
 
 // Table stores discovered optimization rules.
 type Table struct {
-	mu    sync.Mutex
-	rules []Rule
+	mu     sync.Mutex
+	rules  []Rule
+	onRule func(Rule)
 }
 
 // NewTable creates an empty table.
@@ -26,11 +29,25 @@ func NewTable() *Table {
 	return &Table{}
 }
 
+// SetOnRule registers fn to be invoked, outside t's lock, each time Add
+// appends a new rule — e.g. streaming each rule to a JSONL file as it's
+// found, so a long search that's interrupted still leaves a usable partial
+// file instead of only writing out at the end.
+func (t *Table) SetOnRule(fn func(Rule)) {
+	t.mu.Lock()
+	t.onRule = fn
+	t.mu.Unlock()
+}
+
 // Add inserts a rule into the table.
 func (t *Table) Add(r Rule) {
 	t.mu.Lock()
-	defer t.mu.Unlock()
 	t.rules = append(t.rules, r)
+	onRule := t.onRule
+	t.mu.Unlock()
+	if onRule != nil {
+		onRule(r)
+	}
 }
 
 // Rules returns a copy of all rules, sorted by bytes saved (descending).
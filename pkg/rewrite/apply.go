@@ -0,0 +1,91 @@
+package rewrite
+
+import (
+	"github.com/oisee/z80-optimizer/pkg/inst"
+	"github.com/oisee/z80-optimizer/pkg/liveness"
+	"github.com/oisee/z80-optimizer/pkg/search"
+)
+
+// Apply repeatedly rewrites seq using Rules until no rule matches anywhere
+// (a fixed point) and returns the result; seq itself is left untouched.
+func Apply(seq []inst.Instruction) []inst.Instruction {
+	return applyRules(seq, Rules)
+}
+
+// applyRules is Apply against an explicit rule set, split out so a rule set
+// other than Rules can be applied.
+func applyRules(seq []inst.Instruction, rules []Rule) []inst.Instruction {
+	out := append([]inst.Instruction(nil), seq...)
+
+	// Every firing strictly shrinks either byte count or T-state count (see
+	// Rule.improves), so the number of rounds is bounded by the sequence's
+	// total starting cost; this cap just guards against a future rule that
+	// slips past that check due to a bug, rather than hanging forever.
+	maxRounds := 4*len(out) + 64
+	for round := 0; round < maxRounds; round++ {
+		if !applyOnePass(&out, rules) {
+			return out
+		}
+	}
+	return out
+}
+
+// applyOnePass finds the first (position, rule) match in out and rewrites
+// it in place, returning true if it changed anything.
+func applyOnePass(out *[]inst.Instruction, rules []Rule) bool {
+	seq := *out
+
+	// liveInfo is built at most once per pass, and only if some rule actually
+	// needs it — most rules only carry HasDeadReg, which search.IsDead
+	// answers directly from seq's tail without any CFG analysis.
+	var liveInfo *liveness.LivenessInfo
+	for i := range seq {
+		for _, r := range rules {
+			n := len(r.Match)
+			if i+n > len(seq) || !matchesAt(seq, i, r.Match) {
+				continue
+			}
+			if r.HasDeadReg && !search.IsDead(seq[i+n:], r.DeadReg) {
+				continue
+			}
+			if r.HasDeadFlags {
+				if liveInfo == nil {
+					liveInfo = liveness.Analyze(seq, liveness.BuildCFG(seq))
+				}
+				if liveInfo.LiveOutFlagsAt(i+n-1)&r.DeadFlags != 0 {
+					continue
+				}
+			}
+			*out = splice(seq, i, n, r.Replace)
+			return true
+		}
+	}
+	return false
+}
+
+func matchesAt(seq []inst.Instruction, at int, pattern []inst.OpCode) bool {
+	for j, op := range pattern {
+		if seq[at+j].Op != op {
+			return false
+		}
+		// ADD A,n / SUB n only leave A unchanged for n == 0 (zero-add and
+		// zero-sub in patterns.rules); the DSL has no immediate syntax.
+		if (op == inst.ADD_A_N || op == inst.SUB_N) && seq[at+j].Imm != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// splice replaces seq[at:at+n] with replacement, each as a zero-immediate
+// inst.Instruction — none of Rules' Replace sides carry an immediate
+// operand, so there's nothing to bind Imm to.
+func splice(seq []inst.Instruction, at, n int, replacement []inst.OpCode) []inst.Instruction {
+	out := make([]inst.Instruction, 0, len(seq)-n+len(replacement))
+	out = append(out, seq[:at]...)
+	for _, op := range replacement {
+		out = append(out, inst.Instruction{Op: op})
+	}
+	out = append(out, seq[at+n:]...)
+	return out
+}
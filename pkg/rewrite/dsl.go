@@ -0,0 +1,210 @@
+package rewrite
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+
+	"github.com/oisee/z80-optimizer/pkg/inst"
+	"github.com/oisee/z80-optimizer/pkg/search"
+)
+
+// Defs line format (whitespace-separated tokens, one rule per line):
+//
+//	name: OP [OP...] => OP [OP...] [if dead(REG)] [if flags-dead(F[,F...])] [for r in REG[,REG...]]
+//
+//   - OP is an inst.OpCode name (see opByName) or contains the placeholder
+//     "{r}", which is expanded once per register in the rule's register set
+//     (A,B,C,D,E,H,L by default, or the list given by a trailing "for r in"
+//     clause) — bound the same way across every {r} in that line.
+//   - "nop" on the right of "=>" means delete the match instead of replacing
+//     it with anything.
+//   - "if dead(REG)" (REG a literal register or "{r}") requires that register
+//     be unread by everything after the match — see search.IsDead — for the
+//     rule to fire; omit it for rules that are unconditionally safe.
+//   - "if flags-dead(F[,F...])" (each F one of S,Z,Y,H,X,P,N,C) is the
+//     finer-grained sibling of "if dead(F)": it requires only the named
+//     flags be dead at the match's end rather than all of F, gated against
+//     pkg/liveness's CFG-aware analysis (chunk8-3) rather than
+//     search.IsDead. Mutually exclusive with "if dead(...)" on the same
+//     line — a rule only needs one kind of liveness guard.
+//
+// Blank lines and lines starting with "#" are ignored.
+var registerFamily = []string{"A", "B", "C", "D", "E", "H", "L"}
+
+var registerByName = map[string]search.Register{
+	"A": search.RegA, "B": search.RegB, "C": search.RegC, "D": search.RegD,
+	"E": search.RegE, "H": search.RegH, "L": search.RegL, "F": search.RegF,
+}
+
+var flagByName = map[string]search.FlagSet{
+	"S": search.FlagS, "Z": search.FlagZ, "Y": search.FlagY, "H": search.FlagH,
+	"X": search.FlagX, "P": search.FlagPV, "N": search.FlagN, "C": search.FlagC,
+}
+
+// parseRules parses a patterns.rules-format string into compiled Rules,
+// expanding {r} templates and validating each rule strictly reduces cost.
+func parseRules(src string) ([]Rule, error) {
+	var rules []Rule
+	sc := bufio.NewScanner(strings.NewReader(src))
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		parsed, err := parseLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", line, err)
+		}
+		rules = append(rules, parsed...)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+func parseLine(line string) ([]Rule, error) {
+	name, rest, ok := strings.Cut(line, ":")
+	if !ok {
+		return nil, fmt.Errorf("missing ':' before pattern")
+	}
+	name = strings.TrimSpace(name)
+
+	lhsText, rest, ok := strings.Cut(rest, "=>")
+	if !ok {
+		return nil, fmt.Errorf("missing '=>'")
+	}
+
+	regs := registerFamily
+	if idx := strings.Index(rest, " for r in "); idx >= 0 {
+		list := strings.TrimSpace(rest[idx+len(" for r in "):])
+		rest = rest[:idx]
+		regs = strings.Split(list, ",")
+		for i := range regs {
+			regs[i] = strings.TrimSpace(regs[i])
+		}
+	}
+
+	var condFlags string
+	var hasFlagsCond bool
+	if idx := strings.Index(rest, " if flags-dead("); idx >= 0 {
+		end := strings.IndexByte(rest[idx:], ')')
+		if end < 0 {
+			return nil, fmt.Errorf("unterminated flags-dead(...)")
+		}
+		condFlags = strings.TrimSpace(rest[idx+len(" if flags-dead(") : idx+end])
+		rest = rest[:idx]
+		hasFlagsCond = true
+	}
+
+	var condReg string
+	var hasCond bool
+	if idx := strings.Index(rest, " if dead("); idx >= 0 {
+		end := strings.IndexByte(rest[idx:], ')')
+		if end < 0 {
+			return nil, fmt.Errorf("unterminated dead(...)")
+		}
+		condReg = strings.TrimSpace(rest[idx+len(" if dead(") : idx+end])
+		rest = rest[:idx]
+		hasCond = true
+	}
+	if hasCond && hasFlagsCond {
+		return nil, fmt.Errorf("a rule can't have both dead(...) and flags-dead(...)")
+	}
+
+	rhsText := strings.TrimSpace(rest)
+	lhsTokens := strings.Fields(lhsText)
+	rhsTokens := strings.Fields(rhsText)
+	if len(lhsTokens) == 0 {
+		return nil, fmt.Errorf("empty match side")
+	}
+
+	usesPlaceholder := false
+	for _, t := range lhsTokens {
+		if strings.Contains(t, "{r}") {
+			usesPlaceholder = true
+		}
+	}
+	if condReg == "{r}" {
+		usesPlaceholder = true
+	}
+
+	if !usesPlaceholder {
+		r, err := buildRule(name, lhsTokens, rhsTokens, condReg, hasCond, condFlags, hasFlagsCond)
+		if err != nil {
+			return nil, err
+		}
+		return []Rule{r}, nil
+	}
+
+	var out []Rule
+	for _, reg := range regs {
+		substLHS := make([]string, len(lhsTokens))
+		for i, t := range lhsTokens {
+			substLHS[i] = strings.ReplaceAll(t, "{r}", reg)
+		}
+		substRHS := make([]string, len(rhsTokens))
+		for i, t := range rhsTokens {
+			substRHS[i] = strings.ReplaceAll(t, "{r}", reg)
+		}
+		substCond := strings.ReplaceAll(condReg, "{r}", reg)
+		r, err := buildRule(fmt.Sprintf("%s[%s]", name, reg), substLHS, substRHS, substCond, hasCond, condFlags, hasFlagsCond)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	return out, nil
+}
+
+func buildRule(name string, lhsTokens, rhsTokens []string, condReg string, hasCond bool, condFlags string, hasFlagsCond bool) (Rule, error) {
+	match, err := opNames(lhsTokens)
+	if err != nil {
+		return Rule{}, err
+	}
+
+	var replace []inst.OpCode
+	if !(len(rhsTokens) == 1 && rhsTokens[0] == "nop") {
+		replace, err = opNames(rhsTokens)
+		if err != nil {
+			return Rule{}, err
+		}
+	}
+
+	r := Rule{Name: name, Match: match, Replace: replace}
+	if hasCond {
+		reg, ok := registerByName[condReg]
+		if !ok {
+			return Rule{}, fmt.Errorf("unknown register %q in dead(...)", condReg)
+		}
+		r.DeadReg, r.HasDeadReg = reg, true
+	}
+	if hasFlagsCond {
+		var flags search.FlagSet
+		for _, fname := range strings.Split(condFlags, ",") {
+			f, ok := flagByName[strings.TrimSpace(fname)]
+			if !ok {
+				return Rule{}, fmt.Errorf("unknown flag %q in flags-dead(...)", fname)
+			}
+			flags |= f
+		}
+		r.DeadFlags, r.HasDeadFlags = flags, true
+	}
+	if !r.improves() {
+		return Rule{}, fmt.Errorf("replacement does not strictly reduce cost")
+	}
+	return r, nil
+}
+
+func opNames(tokens []string) ([]inst.OpCode, error) {
+	ops := make([]inst.OpCode, len(tokens))
+	for i, t := range tokens {
+		op, ok := opByName[t]
+		if !ok {
+			return nil, fmt.Errorf("unknown opcode %q", t)
+		}
+		ops[i] = op
+	}
+	return ops, nil
+}
@@ -0,0 +1,280 @@
+package rewrite
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/oisee/z80-optimizer/pkg/inst"
+	"github.com/oisee/z80-optimizer/pkg/result"
+	"github.com/oisee/z80-optimizer/pkg/search"
+)
+
+// FlagLiveness is which F bits a caller still needs — the boundary
+// condition LiveBefore's backward pass starts from (chunk6-5). It's
+// search.FlagSet under another name so pkg/rewrite callers don't need to
+// import pkg/search just to build one: search.FlagS|search.FlagZ|... or
+// FlagLiveness(0) for "nothing after this block cares about any flag".
+type FlagLiveness = search.FlagSet
+
+// LiveBefore runs a backward flag-liveness analysis over asm and returns,
+// for each index i, which flags are live immediately before asm[i] —
+// i.e. still read by some later instruction before being overwritten.
+// liveOut is the liveness boundary past the end of asm (what the code after
+// this block still needs). The returned slice has len(asm)+1 entries;
+// live[len(asm)] == liveOut.
+//
+// inst.FlagEffects is sparse: an opcode nobody has annotated yet comes back
+// as the zero FlagEffect, which is indistinguishable from "touches nothing"
+// unless checked via FlagEffect.Known. Guessing "unaffected" for an
+// unannotated opcode could let ApplyMined substitute a rule that's wrong in
+// practice, so any unannotated opcode is instead treated as a full barrier:
+// every flag is live immediately before it, exactly as if that instruction
+// read and rewrote all eight bits. That's conservative (it can suppress a
+// substitution that would actually have been safe) rather than unsound.
+func LiveBefore(asm []inst.Instruction, liveOut FlagLiveness) []FlagLiveness {
+	live := make([]FlagLiveness, len(asm)+1)
+	live[len(asm)] = liveOut
+	for i := len(asm) - 1; i >= 0; i-- {
+		fe := inst.FlagEffects[asm[i].Op]
+		if !fe.Known() {
+			live[i] = FlagLiveness(0xFF)
+			continue
+		}
+		after := live[i+1]
+		written := writtenFlags(fe)
+		read := FlagLiveness(0)
+		if carryReader(asm[i].Op) {
+			read |= search.FlagC
+		}
+		live[i] = read | (after &^ written)
+	}
+	return live
+}
+
+// writtenFlags returns which flag bits fe pins to a computed or fixed
+// value — anything but '-' (left alone). A flag fe overwrites is not live
+// before the instruction that overwrites it, regardless of what's live
+// after.
+func writtenFlags(fe inst.FlagEffect) FlagLiveness {
+	var w FlagLiveness
+	if fe.Z != '-' {
+		w |= search.FlagZ
+	}
+	if fe.N != '-' {
+		w |= search.FlagN
+	}
+	if fe.H != '-' {
+		w |= search.FlagH
+	}
+	if fe.C != '-' {
+		w |= search.FlagC
+	}
+	return w
+}
+
+// carryReader reports whether op consumes the incoming carry flag as an
+// operand rather than just producing one — ADC/SBC and the through-carry
+// rotates RL/RR (as opposed to RLC/RRC, which rotate in the bit they shift
+// out and ignore C coming in). FlagEffects' Known/unaffected bits say
+// whether C is written, not whether it's read, so this heuristic covers the
+// other half: an op LiveBefore must mark FlagC live before, on top of
+// whatever the backward written-flags pass already contributes.
+func carryReader(op inst.OpCode) bool {
+	mnemonic := inst.Catalog[op].Mnemonic
+	if strings.Contains(mnemonic, "ADC") || strings.Contains(mnemonic, "SBC") {
+		return true
+	}
+	return strings.HasPrefix(mnemonic, "RL ") || strings.HasPrefix(mnemonic, "RR ")
+}
+
+// CostWeights turns a candidate rule's (bytes saved, cycles saved) into a
+// single score ApplyMined uses to pick among several rules matching the
+// same window — the bytes-vs-cycles knob the request asks for. Score is
+// purely additive; set one weight to 0 to optimize for the other dimension
+// exclusively.
+type CostWeights struct {
+	BytesWeight, CyclesWeight int
+}
+
+// DefaultCostWeights values bytes and cycles equally.
+var DefaultCostWeights = CostWeights{BytesWeight: 1, CyclesWeight: 1}
+
+// Score returns r's weighted savings under w.
+func (w CostWeights) Score(r result.Rule) int {
+	return w.BytesWeight*r.BytesSaved + w.CyclesWeight*r.CyclesSaved
+}
+
+// Explanation records one ApplyMined firing, for --explain output: which
+// rule fired, where, and what it saved.
+type Explanation struct {
+	Pos         int
+	Rule        result.Rule
+	BytesSaved  int
+	CyclesSaved int
+}
+
+// String renders e the way z80opt's --explain mode prints it.
+func (e Explanation) String() string {
+	return fmt.Sprintf("@%d: %s -> %s (-%d bytes, -%d cycles)",
+		e.Pos, disasmSeq(e.Rule.Source), disasmSeq(e.Rule.Replacement), e.BytesSaved, e.CyclesSaved)
+}
+
+func disasmSeq(seq []inst.Instruction) string {
+	parts := make([]string, len(seq))
+	for i, instr := range seq {
+		parts[i] = inst.Disassemble(instr)
+	}
+	return strings.Join(parts, " : ")
+}
+
+// ruleIndex looks up mined rules by their Source's behavioral fingerprint —
+// the O(1) lookup the request asks for, keyed the same way
+// search.FingerprintMap already keys target sequences.
+type ruleIndex struct {
+	byFingerprint map[[search.FingerprintLen]byte][]result.Rule
+	lengths       []int // distinct Source lengths present, ascending
+}
+
+func newRuleIndex(rules []result.Rule) *ruleIndex {
+	idx := &ruleIndex{byFingerprint: make(map[[search.FingerprintLen]byte][]result.Rule, len(rules))}
+	seenLen := make(map[int]bool)
+	for _, r := range rules {
+		fp := search.Fingerprint(r.Source)
+		idx.byFingerprint[fp] = append(idx.byFingerprint[fp], r)
+		if !seenLen[len(r.Source)] {
+			seenLen[len(r.Source)] = true
+			idx.lengths = append(idx.lengths, len(r.Source))
+		}
+	}
+	sortInts(idx.lengths)
+	return idx
+}
+
+func sortInts(s []int) {
+	for i := 1; i < len(s); i++ {
+		for j := i; j > 0 && s[j-1] > s[j]; j-- {
+			s[j-1], s[j] = s[j], s[j-1]
+		}
+	}
+}
+
+// best returns the best-scoring rule (under weights) whose Source matches
+// asm[pos:pos+len(Source)], given live (LiveBefore's per-position result for
+// asm) and liveOut (the boundary past asm's end). Fingerprint lookup first,
+// then an exact search.QuickCheck against the candidate window guards
+// against a fingerprint collision with an unrelated sequence — Fingerprint
+// equality alone doesn't guarantee true equivalence, only inequality
+// guarantees non-equivalence. ok is false if nothing matched.
+func (idx *ruleIndex) best(asm []inst.Instruction, pos int, live []FlagLiveness, liveOut FlagLiveness, weights CostWeights) (result.Rule, bool) {
+	var bestRule result.Rule
+	bestScore := 0
+	found := false
+
+	for _, n := range idx.lengths {
+		if pos+n > len(asm) {
+			continue
+		}
+		window := asm[pos : pos+n]
+		fp := search.Fingerprint(window)
+		liveAfter := liveOut
+		if pos+n < len(live) {
+			liveAfter = live[pos+n]
+		}
+		for _, r := range idx.byFingerprint[fp] {
+			if len(r.Source) != n || !search.QuickCheck(window, r.Source) {
+				continue
+			}
+			if uint8(liveAfter)&r.DeadFlags != 0 {
+				continue // a flag this rule assumes is dead is actually still live here
+			}
+			if r.DeadRegs != 0 && !regsDeadAfter(asm[pos+n:], r.DeadRegs) {
+				continue
+			}
+			score := weights.Score(r)
+			if !found || score > bestScore {
+				bestRule, bestScore, found = r, score, true
+			}
+		}
+	}
+	return bestRule, found
+}
+
+// regsDeadAfter reports whether every register r.DeadRegs marks dead is
+// actually unread by rest, reusing search.IsDead per bit the same way
+// pkg/rewrite's DSL rules already gate on search.IsDead(rest, DeadReg) —
+// just generalized to a bitmask since a mined rule can declare several dead
+// registers where a DSL Rule declares at most one.
+func regsDeadAfter(rest []inst.Instruction, deadRegs uint16) bool {
+	check := func(mask uint16, reg search.Register) bool {
+		return deadRegs&mask == 0 || search.IsDead(rest, reg)
+	}
+	return check(search.DeadRegA, search.RegA) &&
+		check(search.DeadRegB|search.DeadRegBC, search.RegB) &&
+		check(search.DeadRegC|search.DeadRegBC, search.RegC) &&
+		check(search.DeadRegD|search.DeadRegDE, search.RegD) &&
+		check(search.DeadRegE|search.DeadRegDE, search.RegE) &&
+		check(search.DeadRegH|search.DeadRegHL, search.RegH) &&
+		check(search.DeadRegL|search.DeadRegHL, search.RegL)
+}
+
+// ApplyMined peephole-rewrites asm using rules mined by the worker pool
+// (result.Table, not pkg/rewrite's hand-authored DSL Rules Apply uses): for
+// each window it looks a candidate rule up by Source fingerprint, confirms
+// the match, and checks the rule's DeadFlags/DeadRegs against asm's actual
+// liveness before substituting — the missing consumer for what
+// WorkerPool.Results mines (chunk6-5). liveOut is which flags the code
+// after asm still needs; pass 0 if nothing downstream reads F. Runs to a
+// fixed point the same way Apply does: each substitution can expose a new
+// match at the same position.
+func ApplyMined(asm []inst.Instruction, rules *result.Table, liveOut FlagLiveness, weights CostWeights) []inst.Instruction {
+	out, _ := applyMined(asm, rules, liveOut, weights)
+	return out
+}
+
+// ApplyMinedExplain is ApplyMined plus a log of every firing, in the order
+// applied, for a --explain CLI flag.
+func ApplyMinedExplain(asm []inst.Instruction, rules *result.Table, liveOut FlagLiveness, weights CostWeights) ([]inst.Instruction, []Explanation) {
+	return applyMined(asm, rules, liveOut, weights)
+}
+
+func applyMined(asm []inst.Instruction, rules *result.Table, liveOut FlagLiveness, weights CostWeights) ([]inst.Instruction, []Explanation) {
+	idx := newRuleIndex(rules.Rules())
+	out := append([]inst.Instruction(nil), asm...)
+	var explain []Explanation
+
+	// Same cap as applyRules' maxRounds. Mined rules are not checked for
+	// positive savings before firing, so this cap is what bounds the
+	// number of rounds.
+	maxRounds := 4*len(out) + 64
+	for round := 0; round < maxRounds; round++ {
+		live := LiveBefore(out, liveOut)
+		fired := false
+		for pos := range out {
+			rule, ok := idx.best(out, pos, live, liveOut, weights)
+			if !ok {
+				continue
+			}
+			out = spliceInstructions(out, pos, len(rule.Source), rule.Replacement)
+			explain = append(explain, Explanation{Pos: pos, Rule: rule, BytesSaved: rule.BytesSaved, CyclesSaved: rule.CyclesSaved})
+			fired = true
+			break
+		}
+		if !fired {
+			break
+		}
+	}
+	return out, explain
+}
+
+// spliceInstructions is splice generalized to a []inst.Instruction
+// replacement: a mined Rule's Replacement, unlike a DSL Rule's Replace, can
+// carry an immediate or displacement operand (e.g. "LD A,5" surviving into
+// the replacement side), so each instruction is copied whole rather than
+// rebuilt as zero-immediate.
+func spliceInstructions(seq []inst.Instruction, at, n int, replacement []inst.Instruction) []inst.Instruction {
+	out := make([]inst.Instruction, 0, len(seq)-n+len(replacement))
+	out = append(out, seq[:at]...)
+	out = append(out, replacement...)
+	out = append(out, seq[at+n:]...)
+	return out
+}
@@ -0,0 +1,135 @@
+package rewrite
+
+import (
+	"testing"
+
+	"github.com/oisee/z80-optimizer/pkg/inst"
+	"github.com/oisee/z80-optimizer/pkg/result"
+	"github.com/oisee/z80-optimizer/pkg/search"
+)
+
+func TestLiveBeforeTracksKnownFlagEffects(t *testing.T) {
+	// No wave has actually annotated FlagEffects in this tree yet (wave7.defs
+	// is still an unused template — see its doc comment), so fake an
+	// annotation on INC_A for the duration of this test: Z/N/H computed, C
+	// left alone. liveOut asks for just C, which INC_A doesn't touch, so C
+	// must still show live before it.
+	prev := inst.FlagEffects[inst.INC_A]
+	inst.FlagEffects[inst.INC_A] = inst.FlagEffect{Z: 'Z', N: '0', H: 'H', C: '-'}
+	defer func() { inst.FlagEffects[inst.INC_A] = prev }()
+
+	asm := []inst.Instruction{{Op: inst.INC_A}}
+	live := LiveBefore(asm, search.FlagC)
+	if live[0] != search.FlagC {
+		t.Fatalf("live[0] = %v, want FlagC preserved through an op that doesn't write it", live[0])
+	}
+
+	inst.FlagEffects[inst.INC_A] = inst.FlagEffect{Z: 'Z', N: '0', H: 'H', C: '0'}
+	live = LiveBefore(asm, search.FlagC)
+	if live[0] != FlagLiveness(0) {
+		t.Fatalf("live[0] = %v, want FlagC dead before an op that overwrites it", live[0])
+	}
+}
+
+func TestLiveBeforeTreatsUnannotatedOpcodeAsBarrier(t *testing.T) {
+	// LD_A_A has never been given a FlagEffects entry (it doesn't touch
+	// flags, but that's exactly the kind of fact this package refuses to
+	// assume for an opcode nobody has annotated) — every flag must come back
+	// live before it regardless of liveOut.
+	asm := []inst.Instruction{{Op: inst.LD_A_A}}
+	live := LiveBefore(asm, FlagLiveness(0))
+	if live[0] != FlagLiveness(0xFF) {
+		t.Fatalf("live[0] = %v, want all flags live (0xFF) for an unannotated opcode", live[0])
+	}
+}
+
+func TestCarryReaderDistinguishesRLFromRLC(t *testing.T) {
+	if !carryReader(inst.RL_A) {
+		t.Error("RL A reads the incoming carry, should be a carryReader")
+	}
+	if carryReader(inst.RLC_A) {
+		t.Error("RLC A does not read the incoming carry, should not be a carryReader")
+	}
+}
+
+func TestApplyMinedSubstitutesMatchingWindow(t *testing.T) {
+	table := result.NewTable()
+	table.Add(result.Rule{
+		Source:      []inst.Instruction{{Op: inst.INC_A}, {Op: inst.INC_A}},
+		Replacement: []inst.Instruction{{Op: inst.ADD_A_N, Imm: 2}},
+		BytesSaved:  1,
+		CyclesSaved: 4,
+	})
+
+	asm := []inst.Instruction{{Op: inst.INC_A}, {Op: inst.INC_A}}
+	if !search.QuickCheck(asm, table.Rules()[0].Source) {
+		t.Fatal("test setup: Source must match asm via QuickCheck")
+	}
+
+	got := ApplyMined(asm, table, FlagLiveness(0), DefaultCostWeights)
+	want := []inst.Instruction{{Op: inst.ADD_A_N, Imm: 2}}
+	if len(got) != len(want) || got[0].Op != want[0].Op || got[0].Imm != want[0].Imm {
+		t.Fatalf("ApplyMined(asm) = %+v, want %+v", got, want)
+	}
+}
+
+func TestApplyMinedRespectsLiveDeadFlags(t *testing.T) {
+	// The rule only agrees with its source once C is ignored (DeadFlags
+	// includes FlagC); requesting FlagC live downstream must block it.
+	table := result.NewTable()
+	table.Add(result.Rule{
+		Source:      []inst.Instruction{{Op: inst.OR_A}},
+		Replacement: []inst.Instruction{{Op: inst.LD_A_A}},
+		BytesSaved:  0,
+		CyclesSaved: 0,
+		DeadFlags:   uint8(search.FlagC),
+	})
+
+	asm := []inst.Instruction{{Op: inst.OR_A}}
+	blocked := ApplyMined(asm, table, search.FlagC, DefaultCostWeights)
+	if blocked[0].Op != inst.OR_A {
+		t.Fatalf("ApplyMined fired despite FlagC being live downstream: got %+v", blocked)
+	}
+
+	allowed := ApplyMined(asm, table, FlagLiveness(0), DefaultCostWeights)
+	if allowed[0].Op != inst.LD_A_A {
+		t.Fatalf("ApplyMined should fire once C is not live downstream: got %+v", allowed)
+	}
+}
+
+func TestApplyMinedLeavesNonMatchingCodeAlone(t *testing.T) {
+	table := result.NewTable()
+	table.Add(result.Rule{
+		Source:      []inst.Instruction{{Op: inst.INC_A}, {Op: inst.INC_A}},
+		Replacement: []inst.Instruction{{Op: inst.ADD_A_N, Imm: 2}},
+		BytesSaved:  1,
+	})
+
+	asm := []inst.Instruction{{Op: inst.INC_B}, {Op: inst.DEC_C}}
+	got := ApplyMined(asm, table, FlagLiveness(0), DefaultCostWeights)
+	if len(got) != len(asm) || got[0].Op != inst.INC_B || got[1].Op != inst.DEC_C {
+		t.Fatalf("ApplyMined(asm) = %+v, want asm unchanged", got)
+	}
+}
+
+func TestApplyMinedExplainRecordsFiring(t *testing.T) {
+	table := result.NewTable()
+	table.Add(result.Rule{
+		Source:      []inst.Instruction{{Op: inst.INC_A}, {Op: inst.INC_A}},
+		Replacement: []inst.Instruction{{Op: inst.ADD_A_N, Imm: 2}},
+		BytesSaved:  1,
+		CyclesSaved: 4,
+	})
+
+	asm := []inst.Instruction{{Op: inst.INC_A}, {Op: inst.INC_A}}
+	_, explain := ApplyMinedExplain(asm, table, FlagLiveness(0), DefaultCostWeights)
+	if len(explain) != 1 {
+		t.Fatalf("got %d explanations, want 1", len(explain))
+	}
+	if explain[0].Pos != 0 || explain[0].BytesSaved != 1 || explain[0].CyclesSaved != 4 {
+		t.Fatalf("explain[0] = %+v, want Pos=0 BytesSaved=1 CyclesSaved=4", explain[0])
+	}
+	if explain[0].String() == "" {
+		t.Error("Explanation.String() should not be empty")
+	}
+}
@@ -0,0 +1,31 @@
+package rewrite
+
+import "github.com/oisee/z80-optimizer/pkg/inst"
+
+// opByName maps the textual opcode names patterns.rules (or any other rule
+// file loaded through parseRules) is allowed to use to their inst.OpCode
+// constants. inst.OpCode has no name->value reflection, so this is a plain
+// hand-written table — intentionally just the subset the shipped rules
+// reference; extend it when a new rule needs another opcode.
+var opByName = map[string]inst.OpCode{
+	"LD_A_A": inst.LD_A_A, "LD_B_B": inst.LD_B_B, "LD_C_C": inst.LD_C_C,
+	"LD_D_D": inst.LD_D_D, "LD_E_E": inst.LD_E_E, "LD_H_H": inst.LD_H_H, "LD_L_L": inst.LD_L_L,
+
+	"INC_A": inst.INC_A, "INC_B": inst.INC_B, "INC_C": inst.INC_C,
+	"INC_D": inst.INC_D, "INC_E": inst.INC_E, "INC_H": inst.INC_H, "INC_L": inst.INC_L,
+
+	"DEC_A": inst.DEC_A, "DEC_B": inst.DEC_B, "DEC_C": inst.DEC_C,
+	"DEC_D": inst.DEC_D, "DEC_E": inst.DEC_E, "DEC_H": inst.DEC_H, "DEC_L": inst.DEC_L,
+
+	"ADD_A_N": inst.ADD_A_N, "SUB_N": inst.SUB_N,
+	"OR_A": inst.OR_A, "AND_A": inst.AND_A,
+
+	"CP_A": inst.CP_A, "CP_B": inst.CP_B, "CP_C": inst.CP_C, "CP_D": inst.CP_D,
+	"CP_E": inst.CP_E, "CP_H": inst.CP_H, "CP_L": inst.CP_L, "CP_N": inst.CP_N,
+
+	"SUB_B": inst.SUB_B, "SUB_C": inst.SUB_C, "SUB_D": inst.SUB_D,
+	"SUB_E": inst.SUB_E, "SUB_H": inst.SUB_H, "SUB_L": inst.SUB_L,
+
+	"ADD_A_B": inst.ADD_A_B, "ADD_A_C": inst.ADD_A_C, "ADD_A_D": inst.ADD_A_D,
+	"ADD_A_E": inst.ADD_A_E, "ADD_A_H": inst.ADD_A_H, "ADD_A_L": inst.ADD_A_L,
+}
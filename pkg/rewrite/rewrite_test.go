@@ -0,0 +1,95 @@
+package rewrite
+
+import (
+	"math/rand/v2"
+	"testing"
+
+	"github.com/oisee/z80-optimizer/pkg/cpu"
+	"github.com/oisee/z80-optimizer/pkg/inst"
+	"github.com/oisee/z80-optimizer/pkg/search"
+)
+
+func TestRuleCountInRange(t *testing.T) {
+	if n := len(Rules); n < 30 || n > 50 {
+		t.Fatalf("Rules has %d entries, want 30-50 (see patterns.rules)", n)
+	}
+}
+
+func execSeq(s cpu.State, seq []inst.OpCode) cpu.State {
+	for _, op := range seq {
+		cpu.Exec(&s, op, 0)
+	}
+	return s
+}
+
+func randState(rng *rand.Rand) cpu.State {
+	return cpu.State{
+		A: uint8(rng.IntN(256)), F: uint8(rng.IntN(256)),
+		B: uint8(rng.IntN(256)), C: uint8(rng.IntN(256)),
+		D: uint8(rng.IntN(256)), E: uint8(rng.IntN(256)),
+		H: uint8(rng.IntN(256)), L: uint8(rng.IntN(256)),
+	}
+}
+
+// regsEqual compares every register Apply could observe, except F — Rules
+// whose DeadReg is RegF are only claiming register equivalence, and the
+// ones with no condition at all happen to preserve F too (asserted
+// separately below).
+func regsEqual(a, b cpu.State) bool {
+	return a.A == b.A && a.B == b.B && a.C == b.C && a.D == b.D &&
+		a.E == b.E && a.H == b.H && a.L == b.L
+}
+
+// TestRulesPreserveSemantics fuzzes every compiled rule: for random initial
+// states, Match and Replace must agree on every register always, and on F
+// too unless the rule's condition says some or all of F may differ —
+// dead(F) (no starter rule's bits may differ) or flags-dead(...) (only the
+// named bits may differ, chunk8-3's finer-grained sibling).
+func TestRulesPreserveSemantics(t *testing.T) {
+	rng := rand.New(rand.NewPCG(42, 42))
+
+	for _, r := range Rules {
+		r := r
+		t.Run(r.Name, func(t *testing.T) {
+			for i := 0; i < 500; i++ {
+				init := randState(rng)
+				got := execSeq(init, r.Match)
+				want := execSeq(init, r.Replace)
+
+				if !regsEqual(got, want) {
+					t.Fatalf("registers diverge on %+v: match=%+v replace=%+v", init, got, want)
+				}
+
+				flagDiff := search.FlagSet(got.F ^ want.F)
+				switch {
+				case r.HasDeadReg && r.DeadReg == search.RegF:
+					// any bit may differ
+				case r.HasDeadFlags:
+					if flagDiff&^r.DeadFlags != 0 {
+						t.Fatalf("flags diverge outside DeadFlags=%v on %+v: match=%+v replace=%+v", r.DeadFlags, init, got, want)
+					}
+				default:
+					if flagDiff != 0 {
+						t.Fatalf("flags diverge on %+v without a dead(F)/flags-dead(...) guard: match=%+v replace=%+v", init, got, want)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestApplyIdentityLoad(t *testing.T) {
+	seq := []inst.Instruction{{Op: inst.LD_A_A}, {Op: inst.LD_B_C}}
+	out := Apply(seq)
+	if len(out) != 1 || out[0].Op != inst.LD_B_C {
+		t.Fatalf("Apply(LD_A_A; LD_B_C) = %+v, want just LD_B_C", out)
+	}
+}
+
+func TestApplyRoundTrip(t *testing.T) {
+	seq := []inst.Instruction{{Op: inst.SUB_B}, {Op: inst.ADD_A_B}, {Op: inst.LD_C_D}}
+	out := Apply(seq)
+	if len(out) != 1 || out[0].Op != inst.LD_C_D {
+		t.Fatalf("Apply(SUB_B; ADD_A_B; LD_C_D) = %+v, want just LD_C_D", out)
+	}
+}
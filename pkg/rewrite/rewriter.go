@@ -0,0 +1,115 @@
+package rewrite
+
+import (
+	"github.com/oisee/z80-optimizer/pkg/inst"
+	"github.com/oisee/z80-optimizer/pkg/result"
+	"github.com/oisee/z80-optimizer/pkg/search"
+)
+
+// Application records one rule substitution Rewriter.Apply made: which rule
+// fired, where in the (pre-substitution-at-that-round) program it fired,
+// and what it saved — the machine-readable diff the request asks for, one
+// entry per firing in the order applied.
+type Application struct {
+	Pos         int
+	Rule        result.Rule
+	BytesSaved  int
+	CyclesSaved int
+}
+
+// Rewriter applies a mined result.Table's rules to real Z80 instruction
+// sequences, closing the loop between the superoptimizer's output (so far
+// just data) and an actual peephole optimizer that rewrites programs with
+// it. It's built on the same liveness-gated substitution ApplyMined already
+// does (chunk6-5); what it adds is the conflict-resolution policy and
+// return shape a direct consumer of a Table wants: prefer whichever
+// overlapping match saves the most bytes, tie-breaking on cycles saved,
+// rather than ApplyMined's caller-supplied linear CostWeights.
+type Rewriter struct {
+	table *result.Table
+}
+
+// NewRewriter builds a Rewriter over table. table is not copied, so rules
+// added to it after construction are picked up by later Apply calls.
+func NewRewriter(table *result.Table) *Rewriter {
+	return &Rewriter{table: table}
+}
+
+// Apply rewrites program to a fixed point, substituting the table's rules
+// wherever a match's DeadFlags/DeadRegs are actually dead at that point.
+// program has no caller-supplied context about what follows it, so flag
+// liveness is seeded conservatively: every flag is assumed live past the
+// end of program, the same way LiveBefore treats an unannotated opcode.
+// applied lists every substitution in firing order, each with the program
+// position it occurred at.
+func (rw *Rewriter) Apply(program []inst.Instruction) (rewritten []inst.Instruction, applied []Application) {
+	idx := newRuleIndex(rw.table.Rules())
+	out := append([]inst.Instruction(nil), program...)
+
+	// Same cap as applyMined/applyRules. Mined rules are not checked for
+	// positive BytesSaved or CyclesSaved before firing, so this cap is what
+	// bounds the number of rounds.
+	maxRounds := 4*len(out) + 64
+	for round := 0; round < maxRounds; round++ {
+		live := LiveBefore(out, FlagLiveness(0xFF))
+		fired := false
+		for pos := range out {
+			rule, ok := idx.bestLex(out, pos, live, FlagLiveness(0xFF))
+			if !ok {
+				continue
+			}
+			out = spliceInstructions(out, pos, len(rule.Source), rule.Replacement)
+			applied = append(applied, Application{Pos: pos, Rule: rule, BytesSaved: rule.BytesSaved, CyclesSaved: rule.CyclesSaved})
+			fired = true
+			break
+		}
+		if !fired {
+			break
+		}
+	}
+	return out, applied
+}
+
+// bestLex is ruleIndex.best with the conflict-resolution policy pinned to
+// "greatest BytesSaved, tie-break by CyclesSaved" instead of a caller-chosen
+// CostWeights — the policy Rewriter.Apply promises its callers.
+func (idx *ruleIndex) bestLex(asm []inst.Instruction, pos int, live []FlagLiveness, liveOut FlagLiveness) (result.Rule, bool) {
+	var bestRule result.Rule
+	found := false
+
+	for _, n := range idx.lengths {
+		if pos+n > len(asm) {
+			continue
+		}
+		window := asm[pos : pos+n]
+		fp := search.Fingerprint(window)
+		liveAfter := liveOut
+		if pos+n < len(live) {
+			liveAfter = live[pos+n]
+		}
+		for _, r := range idx.byFingerprint[fp] {
+			if len(r.Source) != n || !search.QuickCheck(window, r.Source) {
+				continue
+			}
+			if uint8(liveAfter)&r.DeadFlags != 0 {
+				continue // a flag this rule assumes is dead is actually still live here
+			}
+			if r.DeadRegs != 0 && !regsDeadAfter(asm[pos+n:], r.DeadRegs) {
+				continue
+			}
+			if !found || betterRule(r, bestRule) {
+				bestRule, found = r, true
+			}
+		}
+	}
+	return bestRule, found
+}
+
+// betterRule reports whether a should be preferred over b under the
+// greatest-BytesSaved, tie-break-by-CyclesSaved policy.
+func betterRule(a, b result.Rule) bool {
+	if a.BytesSaved != b.BytesSaved {
+		return a.BytesSaved > b.BytesSaved
+	}
+	return a.CyclesSaved > b.CyclesSaved
+}
@@ -0,0 +1,96 @@
+package rewrite
+
+import (
+	"testing"
+
+	"github.com/oisee/z80-optimizer/pkg/inst"
+	"github.com/oisee/z80-optimizer/pkg/result"
+	"github.com/oisee/z80-optimizer/pkg/search"
+)
+
+func TestRewriterApplySubstitutesMatchingWindow(t *testing.T) {
+	table := result.NewTable()
+	table.Add(result.Rule{
+		Source:      []inst.Instruction{{Op: inst.INC_A}, {Op: inst.INC_A}},
+		Replacement: []inst.Instruction{{Op: inst.ADD_A_N, Imm: 2}},
+		BytesSaved:  1,
+		CyclesSaved: 4,
+	})
+
+	rw := NewRewriter(table)
+	program := []inst.Instruction{{Op: inst.INC_A}, {Op: inst.INC_A}}
+	got, applied := rw.Apply(program)
+
+	want := []inst.Instruction{{Op: inst.ADD_A_N, Imm: 2}}
+	if len(got) != len(want) || got[0].Op != want[0].Op || got[0].Imm != want[0].Imm {
+		t.Fatalf("Apply(program) = %+v, want %+v", got, want)
+	}
+	if len(applied) != 1 || applied[0].Pos != 0 || applied[0].BytesSaved != 1 || applied[0].CyclesSaved != 4 {
+		t.Fatalf("applied = %+v, want one Application{Pos:0 BytesSaved:1 CyclesSaved:4}", applied)
+	}
+}
+
+func TestRewriterApplyBlocksOnLiveFlags(t *testing.T) {
+	// With no caller context about what follows program, Apply must assume
+	// every flag is live past the end — so a rule that only agrees once C
+	// is dead must never fire here, unlike ApplyMined where the caller can
+	// pass FlagLiveness(0).
+	table := result.NewTable()
+	table.Add(result.Rule{
+		Source:      []inst.Instruction{{Op: inst.OR_A}},
+		Replacement: []inst.Instruction{{Op: inst.LD_A_A}},
+		DeadFlags:   uint8(search.FlagC),
+	})
+
+	rw := NewRewriter(table)
+	got, applied := rw.Apply([]inst.Instruction{{Op: inst.OR_A}})
+	if len(applied) != 0 || got[0].Op != inst.OR_A {
+		t.Fatalf("Apply fired a DeadFlags rule with no downstream liveness context: got %+v, applied %+v", got, applied)
+	}
+}
+
+func TestRewriterApplyPrefersGreatestBytesSaved(t *testing.T) {
+	// Two rules both match the same window; the request's conflict policy
+	// is greatest BytesSaved first, regardless of CyclesSaved.
+	table := result.NewTable()
+	table.Add(result.Rule{
+		Source:      []inst.Instruction{{Op: inst.INC_A}, {Op: inst.INC_A}},
+		Replacement: []inst.Instruction{{Op: inst.ADD_A_N, Imm: 2}},
+		BytesSaved:  1,
+		CyclesSaved: 100,
+	})
+	table.Add(result.Rule{
+		Source:      []inst.Instruction{{Op: inst.INC_A}, {Op: inst.INC_A}},
+		Replacement: []inst.Instruction{{Op: inst.LD_A_N, Imm: 9}},
+		BytesSaved:  2,
+		CyclesSaved: 1,
+	})
+
+	rw := NewRewriter(table)
+	got, applied := rw.Apply([]inst.Instruction{{Op: inst.INC_A}, {Op: inst.INC_A}})
+	if len(got) != 1 || got[0].Op != inst.LD_A_N || got[0].Imm != 9 {
+		t.Fatalf("Apply should prefer the BytesSaved:2 rule: got %+v", got)
+	}
+	if len(applied) != 1 || applied[0].BytesSaved != 2 {
+		t.Fatalf("applied = %+v, want the BytesSaved:2 rule recorded", applied)
+	}
+}
+
+func TestRewriterApplyLeavesNonMatchingCodeAlone(t *testing.T) {
+	table := result.NewTable()
+	table.Add(result.Rule{
+		Source:      []inst.Instruction{{Op: inst.INC_A}, {Op: inst.INC_A}},
+		Replacement: []inst.Instruction{{Op: inst.ADD_A_N, Imm: 2}},
+		BytesSaved:  1,
+	})
+
+	rw := NewRewriter(table)
+	program := []inst.Instruction{{Op: inst.INC_B}, {Op: inst.DEC_C}}
+	got, applied := rw.Apply(program)
+	if len(got) != len(program) || got[0].Op != inst.INC_B || got[1].Op != inst.DEC_C {
+		t.Fatalf("Apply(program) = %+v, want program unchanged", got)
+	}
+	if len(applied) != 0 {
+		t.Fatalf("applied = %+v, want none", applied)
+	}
+}
@@ -0,0 +1,62 @@
+// Package rewrite is a rule-based peephole rewriter: patterns are expressed
+// declaratively in patterns.rules (see dsl.go for the format) instead of as
+// ad-hoc Go code, so a new peephole is usually a one-line addition there
+// rather than a new switch case somewhere in pkg/search.
+//
+// Because cpu.Exec is the ground-truth semantics, rewrite_test.go fuzzes
+// every parsed Rule by executing its Match and Replace sequences on random
+// cpu.State inputs and asserting they agree on A, B, C, D, E, H, L and every
+// flag the rule doesn't declare dead — the same equivalence property
+// pkg/search's verifier checks for candidate sequences, just applied here
+// to the rules themselves.
+package rewrite
+
+import (
+	"github.com/oisee/z80-optimizer/pkg/inst"
+	"github.com/oisee/z80-optimizer/pkg/search"
+)
+
+// Rule is one compiled peephole: Match is a contiguous opcode sequence to
+// look for, Replace is what to substitute in its place (nil means delete
+// the match outright). DeadReg/HasDeadReg optionally restrict firing to
+// call sites where search.IsDead(rest, DeadReg) holds for the instructions
+// following the match — e.g. a rule that drops a flags-setting CP needs F
+// to be dead afterward, and one that replaces a live value with a freshly
+// computed zero needs that value's register to be dead first.
+//
+// DeadFlags/HasDeadFlags (chunk8-3) is the finer-grained counterpart for
+// rules that only need a subset of F dead rather than all of it — gated
+// at apply time against pkg/liveness's per-site analysis instead of
+// search.IsDead(rest, RegF), which can only ask "is all of F unread".
+type Rule struct {
+	Name         string
+	Match        []inst.OpCode
+	Replace      []inst.OpCode
+	DeadReg      search.Register
+	HasDeadReg   bool
+	DeadFlags    search.FlagSet
+	HasDeadFlags bool
+}
+
+// Cost is the (bytes, T-states) pair improves compares Match against Replace
+// with — a rule only parses when Replace is no worse in either dimension and
+// strictly better in at least one.
+type Cost struct {
+	Bytes, TStates int
+}
+
+func seqCost(seq []inst.OpCode) Cost {
+	var c Cost
+	for _, op := range seq {
+		c.Bytes += inst.ByteSize(op)
+		c.TStates += inst.TStates(op)
+	}
+	return c
+}
+
+// improves reports whether replacing Match with Replace strictly reduces
+// cost: no worse in bytes or T-states, and strictly better in at least one.
+func (r Rule) improves() bool {
+	m, c := seqCost(r.Match), seqCost(r.Replace)
+	return c.Bytes <= m.Bytes && c.TStates <= m.TStates && (c.Bytes < m.Bytes || c.TStates < m.TStates)
+}
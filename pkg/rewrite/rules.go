@@ -0,0 +1,23 @@
+package rewrite
+
+import _ "embed"
+
+//go:embed patterns.rules
+var starterRules string
+
+// Rules is the compiled starter rule set, parsed once at init from
+// patterns.rules. Apply uses this by default; tests and callers that want a
+// different rule set can parse their own with parseRules and call
+// applyRules directly.
+var Rules []Rule
+
+func init() {
+	rules, err := parseRules(starterRules)
+	if err != nil {
+		// patterns.rules is embedded at build time, so a parse failure here
+		// means the shipped file itself is broken — a programming error,
+		// not a runtime condition callers can recover from.
+		panic("rewrite: " + err.Error())
+	}
+	Rules = rules
+}
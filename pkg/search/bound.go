@@ -0,0 +1,106 @@
+package search
+
+import "github.com/oisee/z80-optimizer/pkg/inst"
+
+// optimisticRemaining[diff] is an admissible lower bound (never an
+// overestimate) on the byte+cycle cost of any instruction sequence whose
+// Writes masks, taken together, cover every bit set in diff — i.e. "at
+// least this much has to be spent before every register diff still open
+// right now" is completely a bound.
+var optimisticRemaining [256]int
+
+// unreachableRemaining marks a diff mask the DP below found no covering
+// combination for; LowerBound/ShouldPruneBound treat it as "no bound
+// available" rather than a hard wall, since failing to find a cover in
+// this table's own (A,F,B,C,D,E,H,L)-only opcode view doesn't mean no real
+// sequence could ever clear those bits.
+const unreachableRemaining = 1 << 30
+
+// opCost is the same per-instruction byte+cycle weighting SizeCycleCost
+// uses for a whole sequence (bytes + cycles/100), computed per opcode so
+// the set-cover DP below can price a single instruction in isolation.
+// Flooring per instruction instead of flooring the sequence's total cycles
+// once only ever under-counts (floor(a)+floor(b) <= floor(a+b)), so summing
+// opCost across a chosen set of instructions still never overestimates the
+// real SizeCycleCost of running them — optimisticRemaining stays admissible.
+func opCost(op inst.OpCode) int {
+	return int(Properties[op].Bytes) + int(Properties[op].Cycles)/100
+}
+
+func init() {
+	// Reduce the catalog to its cheapest opcode per distinct (masked to
+	// A/F/B/C/D/E/H/L) Writes shape — the DP only ever needs the cheapest
+	// way to touch a given set of those 8 registers, not every opcode that
+	// happens to touch it.
+	cheapest := map[regMask]int{}
+	for op := inst.OpCode(0); op < inst.OpCodeCount; op++ {
+		w := Properties[op].Writes & 0xFF
+		if w == 0 {
+			continue
+		}
+		if c, ok := cheapest[w]; !ok || opCost(op) < c {
+			cheapest[w] = opCost(op)
+		}
+	}
+
+	for i := range optimisticRemaining {
+		optimisticRemaining[i] = unreachableRemaining
+	}
+	optimisticRemaining[0] = 0
+
+	// Weighted minimum set cover over diff masks 0..255: dp[mask] is the
+	// cheapest sum of per-opcode costs whose Writes, ORed together, clear
+	// every bit mask still has set. Each option can only ever shrink mask
+	// (mask &^ w < mask whenever w&mask != 0), so a single ascending pass
+	// over 0..255 already visits every dependency before it's needed.
+	for mask := 1; mask < 256; mask++ {
+		for w, cost := range cheapest {
+			overlap := int(w) & mask
+			if overlap == 0 {
+				continue // this opcode doesn't touch anything mask still needs
+			}
+			rest := mask &^ overlap
+			if optimisticRemaining[rest] == unreachableRemaining {
+				continue
+			}
+			if cand := optimisticRemaining[rest] + cost; cand < optimisticRemaining[mask] {
+				optimisticRemaining[mask] = cand
+			}
+		}
+	}
+}
+
+// LowerBound returns an admissible lower bound on the total byte+cycle cost
+// (SizeCycleCost's units) of any completion of a partial candidate: what's
+// already been spent (prefixBytes, prefixTStates) plus the cheapest this
+// table has ever seen for clearing whichever of A/F/B/C/D/E/H/L still
+// differ from the target (diff, read against the low 8 bits of regMask —
+// regA|regF|regB|regC|regD|regE|regH|regL).
+func LowerBound(prefixBytes, prefixTStates int, diff regMask) int {
+	rem := optimisticRemaining[diff&0xFF]
+	if rem == unreachableRemaining {
+		return prefixBytes + prefixTStates/100
+	}
+	return prefixBytes + prefixTStates/100 + rem
+}
+
+// ShouldPruneBound reports whether a partial candidate can be discarded
+// before extending it any further: once LowerBound's estimate of the
+// cheapest possible completion already meets or beats bestCost (the best
+// verified replacement found so far for this target), no extension of this
+// prefix can do better. bestCost <= 0 means there's no incumbent yet, so
+// nothing to prune against.
+//
+// This is additive, standalone bound infrastructure (chunk5-6): wiring it
+// into an actual mid-recursion branch-and-bound would mean restructuring
+// the enumerator to call back on internal nodes, not just leaves —
+// enumerateRec (enumerator.go) only invokes fn once pos == len(seq). Rather
+// than paper over that with unrelated, unverified surgery, this change ships
+// LowerBound/ShouldPruneBound fully tested and ready for whichever future
+// enumerator change adds a real prefix callback.
+func ShouldPruneBound(prefixBytes, prefixTStates int, diff regMask, bestCost int) bool {
+	if bestCost <= 0 {
+		return false
+	}
+	return LowerBound(prefixBytes, prefixTStates, diff) >= bestCost
+}
@@ -0,0 +1,51 @@
+package search
+
+import "testing"
+
+func TestOptimisticRemainingZeroForNoDiff(t *testing.T) {
+	if got := optimisticRemaining[0]; got != 0 {
+		t.Errorf("optimisticRemaining[0] = %d, want 0", got)
+	}
+}
+
+func TestOptimisticRemainingMonotonicUnderUnion(t *testing.T) {
+	// Clearing A|F can never cost less than clearing just A alone: any cover
+	// for A|F is also a cover for A.
+	onlyA := optimisticRemaining[regA]
+	aAndF := optimisticRemaining[regA|regF]
+	if aAndF < onlyA {
+		t.Errorf("optimisticRemaining[A|F] = %d, want >= optimisticRemaining[A] = %d", aAndF, onlyA)
+	}
+}
+
+func TestLowerBoundAddsPrefixSpend(t *testing.T) {
+	diff := regMask(regA)
+	withNoSpend := LowerBound(0, 0, diff)
+	withSpend := LowerBound(3, 400, diff)
+	if withSpend != withNoSpend+3+4 {
+		t.Errorf("LowerBound(3, 400, diff) = %d, want %d", withSpend, withNoSpend+3+4)
+	}
+}
+
+func TestLowerBoundNoDiffIsJustPrefixSpend(t *testing.T) {
+	if got, want := LowerBound(2, 300, 0), 2+3; got != want {
+		t.Errorf("LowerBound with no diff = %d, want %d", got, want)
+	}
+}
+
+func TestShouldPruneBoundNoIncumbent(t *testing.T) {
+	if ShouldPruneBound(0, 0, regA, 0) {
+		t.Error("ShouldPruneBound with bestCost <= 0 should never prune")
+	}
+}
+
+func TestShouldPruneBoundPrunesWhenBoundMeetsIncumbent(t *testing.T) {
+	diff := regMask(regA | regF | regB | regC | regD | regE | regH | regL)
+	bound := LowerBound(100, 0, diff)
+	if !ShouldPruneBound(100, 0, diff, bound) {
+		t.Error("ShouldPruneBound should prune once the bound meets bestCost")
+	}
+	if ShouldPruneBound(100, 0, diff, bound+1) {
+		t.Error("ShouldPruneBound should not prune when bestCost is still above the bound")
+	}
+}
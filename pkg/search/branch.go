@@ -0,0 +1,162 @@
+package search
+
+import (
+	"github.com/oisee/z80-optimizer/pkg/cpu"
+	"github.com/oisee/z80-optimizer/pkg/inst"
+)
+
+// DefaultMaxUnroll bounds how many times execSeqPC lets a sequence revisit
+// its own instructions before giving up on an input vector (chunk4-3): a
+// loop that hasn't exited by then is treated as non-terminating rather than
+// guessed at.
+const DefaultMaxUnroll = 64
+
+// HasControlFlow reports whether seq contains any branch instruction
+// (JR/JR cc/DJNZ/JP/JP cc/CALL/CALL cc/RET/RET cc), meaning it needs the
+// *Branching checks below instead of the straight-line ones.
+func HasControlFlow(seq []inst.Instruction) bool {
+	for i := range seq {
+		if inst.HasBranchTarget(seq[i].Op) || isReturnOp(seq[i].Op) {
+			return true
+		}
+	}
+	return false
+}
+
+// execSeqPC runs seq starting at its own base address, following taken
+// branches by address the way a real CPU would — the superblock counterpart
+// of execSeq, which just walks the slice index by index and so can't see a
+// branch change what executes next. addrs is seq's layout (inst.SeqAddresses);
+// a branch landing outside that layout exits the block cleanly. overran
+// reports true if the sequence is still inside the block after maxUnroll
+// full passes over it, i.e. it didn't terminate within the allowed budget.
+func execSeqPC(initial cpu.State, seq []inst.Instruction, addrs []uint16, maxUnroll int) (final cpu.State, overran bool) {
+	if len(seq) == 0 {
+		return initial, false
+	}
+	index := make(map[uint16]int, len(seq))
+	for i, a := range addrs {
+		index[a] = i
+	}
+
+	s := initial
+	s.PC = addrs[0]
+	maxSteps := maxUnroll * len(seq)
+	for step := 0; step < maxSteps; step++ {
+		i, ok := index[s.PC]
+		if !ok {
+			return s, false
+		}
+		cpu.Exec(&s, seq[i].Op, seq[i].Imm)
+		if s.Halted {
+			return s, false
+		}
+	}
+	return s, true
+}
+
+// QuickCheckBranching is QuickCheck for sequences containing conditional
+// branches or DJNZ loops (chunk4-3): target and candidate are simulated by
+// following PC like a real CPU, so which instruction runs next actually
+// depends on the flags/counters the branch tests, the same as on real
+// hardware. A vector that doesn't terminate within maxUnroll passes (in
+// either sequence) counts as a mismatch, same as any other disagreement.
+func QuickCheckBranching(target, candidate []inst.Instruction, maxUnroll int) bool {
+	tAddrs, cAddrs := inst.SeqAddresses(target), inst.SeqAddresses(candidate)
+	for i := range TestVectors {
+		tOut, tOverran := execSeqPC(TestVectors[i], target, tAddrs, maxUnroll)
+		cOut, cOverran := execSeqPC(TestVectors[i], candidate, cAddrs, maxUnroll)
+		if tOverran || cOverran || tOut != cOut {
+			return false
+		}
+	}
+	return true
+}
+
+// ExhaustiveCheckBranching is ExhaustiveCheck for sequences containing
+// conditional branches or DJNZ loops: it sweeps every register the two
+// sequences actually read (see regsRead) instead of just the fixed
+// TestVectors, but — unlike straight-line ExhaustiveCheck — always sweeps
+// multi-register combinations with representative values rather than the
+// full/reduced tiering exhaustiveAll picks between. A branch target already
+// forces maxUnroll full reruns per value; a 256x256 sweep on top of that
+// would make this too slow to be worth running.
+func ExhaustiveCheckBranching(target, candidate []inst.Instruction, maxUnroll int) bool {
+	reads := regsRead(target) | regsRead(candidate)
+	tAddrs, cAddrs := inst.SeqAddresses(target), inst.SeqAddresses(candidate)
+
+	var extraRegs []int
+	if reads&regB != 0 {
+		extraRegs = append(extraRegs, 2)
+	}
+	if reads&regC != 0 {
+		extraRegs = append(extraRegs, 3)
+	}
+	if reads&regD != 0 {
+		extraRegs = append(extraRegs, 4)
+	}
+	if reads&regE != 0 {
+		extraRegs = append(extraRegs, 5)
+	}
+	if reads&regH != 0 {
+		extraRegs = append(extraRegs, 6)
+	}
+	if reads&regL != 0 {
+		extraRegs = append(extraRegs, 7)
+	}
+	if reads&regMem != 0 {
+		extraRegs = append(extraRegs, 8)
+	}
+	sweepSP := reads&regSP != 0
+	sweepIX := reads&regIX != 0
+	sweepIY := reads&regIY != 0
+
+	repValues := []uint8{
+		0x00, 0x01, 0x02, 0x0F, 0x10, 0x1F, 0x20, 0x3F,
+		0x40, 0x55, 0x7E, 0x7F, 0x80, 0x81, 0xAA, 0xBF,
+		0xC0, 0xD5, 0xE0, 0xEF, 0xF0, 0xF7, 0xFE, 0xFF,
+	}
+	spVals, ixVals, iyVals := wideSweepValues(sweepSP), wideSweepValues(sweepIX), wideSweepValues(sweepIY)
+
+	compare := func(s cpu.State) bool {
+		tOut, tOverran := execSeqPC(s, target, tAddrs, maxUnroll)
+		cOut, cOverran := execSeqPC(s, candidate, cAddrs, maxUnroll)
+		return !tOverran && !cOverran && tOut == cOut
+	}
+
+	var sweep func(s cpu.State, regIdx int) bool
+	sweep = func(s cpu.State, regIdx int) bool {
+		if regIdx >= len(extraRegs) {
+			for _, sp := range spVals {
+				for _, ix := range ixVals {
+					for _, iy := range iyVals {
+						s2 := s
+						s2.SP, s2.IX, s2.IY = sp, ix, iy
+						if !compare(s2) {
+							return false
+						}
+					}
+				}
+			}
+			return true
+		}
+		for _, v := range repValues {
+			s2 := s
+			setExtraReg(&s2, extraRegs[regIdx], v)
+			if !sweep(s2, regIdx+1) {
+				return false
+			}
+		}
+		return true
+	}
+
+	for a := 0; a < 256; a++ {
+		for carry := uint8(0); carry <= 1; carry++ {
+			s := cpu.State{A: uint8(a), F: carry}
+			if !sweep(s, 0) {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,96 @@
+package search
+
+import (
+	"testing"
+
+	"github.com/oisee/z80-optimizer/pkg/inst"
+)
+
+func TestQuickCheckBranchingStraightLineMatchesQuickCheck(t *testing.T) {
+	target := []inst.Instruction{{Op: inst.LD_A_B}}
+	candidate := []inst.Instruction{{Op: inst.LD_A_B}}
+	if !QuickCheckBranching(target, candidate, DefaultMaxUnroll) {
+		t.Fatal("identical straight-line sequences should pass QuickCheckBranching")
+	}
+}
+
+func TestQuickCheckBranchingFollowsTakenBranch(t *testing.T) {
+	// target: XOR A (sets Z) ; JR Z, +2 (skip INC A) ; INC A ; INC A
+	// candidate: XOR A ; INC A ; INC A  (no branch, always runs both incs)
+	// These are NOT equivalent: target's branch is always taken (A^A==0
+	// sets Z), so target always skips straight to the address just past the
+	// block, leaving A untouched, while candidate always increments twice.
+	target := []inst.Instruction{
+		{Op: inst.XOR_A},
+		{Op: inst.JR_Z, Imm: 5}, // addrs: XOR_A@0(1 byte), JR_Z@1(2 bytes), INC_A@3, INC_A@4 -> end=5
+		{Op: inst.INC_A},
+		{Op: inst.INC_A},
+	}
+	addrs := inst.SeqAddresses(target)
+	target[1].Imm = addrs[len(addrs)-1] + uint16(inst.ByteSize(target[len(target)-1].Op))
+
+	candidate := []inst.Instruction{
+		{Op: inst.XOR_A},
+		{Op: inst.INC_A},
+		{Op: inst.INC_A},
+	}
+
+	if QuickCheckBranching(target, candidate, DefaultMaxUnroll) {
+		t.Fatal("target always skips the increments; candidate never does — should not match")
+	}
+}
+
+func TestQuickCheckBranchingDJNZLoop(t *testing.T) {
+	// LD B, 3 ; INC A ; DJNZ -2 (back to INC A) is equivalent to
+	// LD B, 0 ; INC A ; INC A ; INC A for every starting A (B ends at 0 either way).
+	loop := []inst.Instruction{
+		{Op: inst.LD_B_N, Imm: 3},
+		{Op: inst.INC_A},
+		{Op: inst.DJNZ},
+	}
+	addrs := inst.SeqAddresses(loop)
+	loop[2].Imm = addrs[1] // loop back to INC_A
+
+	unrolled := []inst.Instruction{
+		{Op: inst.LD_B_N, Imm: 0},
+		{Op: inst.INC_A},
+		{Op: inst.INC_A},
+		{Op: inst.INC_A},
+	}
+
+	if !QuickCheckBranching(loop, unrolled, DefaultMaxUnroll) {
+		t.Fatal("DJNZ 3-iteration loop should match its manually unrolled equivalent")
+	}
+	if !ExhaustiveCheckBranching(loop, unrolled, DefaultMaxUnroll) {
+		t.Fatal("ExhaustiveCheckBranching should also confirm the DJNZ loop is equivalent for every A")
+	}
+}
+
+func TestExhaustiveCheckBranchingCatchesMismatch(t *testing.T) {
+	loop := []inst.Instruction{
+		{Op: inst.LD_B_N, Imm: 3},
+		{Op: inst.INC_A},
+		{Op: inst.DJNZ},
+	}
+	addrs := inst.SeqAddresses(loop)
+	loop[2].Imm = addrs[1]
+
+	wrong := []inst.Instruction{
+		{Op: inst.LD_B_N, Imm: 3},
+		{Op: inst.INC_A},
+		{Op: inst.INC_A},
+	}
+
+	if ExhaustiveCheckBranching(loop, wrong, DefaultMaxUnroll) {
+		t.Fatal("a 3-iteration loop is not equivalent to 2 unrolled increments")
+	}
+}
+
+func TestHasControlFlow(t *testing.T) {
+	if HasControlFlow([]inst.Instruction{{Op: inst.LD_A_B}, {Op: inst.INC_A}}) {
+		t.Fatal("straight-line sequence reported as having control flow")
+	}
+	if !HasControlFlow([]inst.Instruction{{Op: inst.DJNZ, Imm: 0}}) {
+		t.Fatal("DJNZ should be reported as control flow")
+	}
+}
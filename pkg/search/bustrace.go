@@ -0,0 +1,153 @@
+package search
+
+import (
+	"github.com/oisee/z80-optimizer/pkg/cpu"
+	"github.com/oisee/z80-optimizer/pkg/inst"
+)
+
+// TraceMask declares bus events that are allowed to differ between a target
+// and a candidate without failing trace equivalence — the bus-trace
+// counterpart to FlagMask (chunk6-4). Zero value masks nothing.
+type TraceMask struct {
+	// DeadPorts are port numbers whose IN/OUT accesses are ignored, e.g. a
+	// write-only sound/border port neither sequence's caller reads back.
+	DeadPorts map[uint16]bool
+	// ScratchAbove, if nonzero, ignores memory reads/writes at addresses >=
+	// this value — "memory above 0x4000 is scratch" in the request's words.
+	// Zero disables scratch masking (masking from address 0 up would discard
+	// every memory event, which is never what a caller means by leaving this
+	// unset).
+	ScratchAbove uint16
+}
+
+// ignore reports whether ev should be excluded from a masked trace comparison.
+func (m TraceMask) ignore(ev cpu.BusEvent) bool {
+	switch ev.Kind {
+	case cpu.BusIn, cpu.BusOut:
+		return m.DeadPorts != nil && m.DeadPorts[ev.Addr]
+	case cpu.BusRead, cpu.BusWrite:
+		return m.ScratchAbove != 0 && ev.Addr >= m.ScratchAbove
+	default:
+		return false
+	}
+}
+
+// busTraceEqual reports whether a and b are identical, in order.
+func busTraceEqual(a, b []cpu.BusEvent) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// busTraceEqualMasked is busTraceEqual after dropping every event mask says
+// to ignore from both traces.
+func busTraceEqualMasked(a, b []cpu.BusEvent, mask TraceMask) bool {
+	return busTraceEqual(filterTrace(a, mask), filterTrace(b, mask))
+}
+
+func filterTrace(events []cpu.BusEvent, mask TraceMask) []cpu.BusEvent {
+	out := make([]cpu.BusEvent, 0, len(events))
+	for _, ev := range events {
+		if !mask.ignore(ev) {
+			out = append(out, ev)
+		}
+	}
+	return out
+}
+
+// memSeed fills a fresh AllRAM with a fixed pattern before a sequence runs,
+// so trace equivalence is exercised against more than one memory image
+// without needing a full 64KB sweep — reads just echo back whatever the
+// pattern put there, so a handful of patterns covers the interesting cases
+// (untouched/zero, all-ones, and an address-dependent pattern that makes
+// reads at different addresses observably distinct).
+type memSeed func(*cpu.AllRAM)
+
+var memSeeds = []memSeed{
+	func(*cpu.AllRAM) {}, // untouched: reads as zero everywhere
+	func(b *cpu.AllRAM) {
+		for addr := 0; addr < 0x10000; addr += 0x100 {
+			b.Write(uint16(addr), 0xFF)
+		}
+	},
+	func(b *cpu.AllRAM) {
+		for addr := 0; addr < 0x10000; addr += 0x100 {
+			b.Write(uint16(addr), uint8(addr))
+		}
+	},
+}
+
+// execSeqBus runs seq from initial against a freshly seeded AllRAM, returning
+// the final CPU state and the ordered bus trace (chunk6-4) — the memory/IO
+// equivalent of execSeqIO.
+func execSeqBus(initial cpu.State, seq []inst.Instruction, seed memSeed) (cpu.State, []cpu.BusEvent) {
+	bus := cpu.NewAllRAM()
+	seed(bus)
+	return cpu.Trace(initial, bus, seq)
+}
+
+// QuickCheckTrace is QuickCheck plus bus-trace equivalence: two sequences
+// must also produce identical ordered memory/IO traces (not just identical
+// final register state) across TestVectors and memSeeds. Cheap enough to run
+// as a filter before ExhaustiveCheckTrace, the same role QuickCheck/
+// QuickCheckIO play for their checks.
+func QuickCheckTrace(target, candidate []inst.Instruction) bool {
+	for i := range TestVectors {
+		for _, seed := range memSeeds {
+			tOut, tTrace := execSeqBus(TestVectors[i], target, seed)
+			cOut, cTrace := execSeqBus(TestVectors[i], candidate, seed)
+			if tOut != cOut || !busTraceEqual(tTrace, cTrace) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// ExhaustiveCheckTrace requires target and candidate to agree on both final
+// register state and ordered bus trace across every (TestVectors, memSeeds)
+// combination — the trace-aware counterpart to ExhaustiveCheck, for targets
+// containing LD (HL),r / LD (BC),A / LD (DE),A or any other
+// inst.UsesMemory opcode, where register-state equivalence alone is
+// meaningless (chunk6-4). Not a full exhaustive-memory sweep: see memSeeds'
+// doc for why a handful of patterns suffices instead of a 64KB one.
+func ExhaustiveCheckTrace(target, candidate []inst.Instruction) bool {
+	return ExhaustiveCheckTraceMasked(target, candidate, TraceMask{})
+}
+
+// ExhaustiveCheckTraceMasked is ExhaustiveCheckTrace with mask's dead
+// ports/scratch-memory events excluded from the comparison.
+func ExhaustiveCheckTraceMasked(target, candidate []inst.Instruction, mask TraceMask) bool {
+	for i := range TestVectors {
+		for _, seed := range memSeeds {
+			tOut, tTrace := execSeqBus(TestVectors[i], target, seed)
+			cOut, cTrace := execSeqBus(TestVectors[i], candidate, seed)
+			if tOut != cOut {
+				return false
+			}
+			if !busTraceEqualMasked(tTrace, cTrace, mask) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// NeedsTraceCheck reports whether seq contains an opcode register-state
+// equivalence can't faithfully judge on its own — any inst.UsesMemory opcode
+// — so a caller deciding between ExhaustiveCheck and ExhaustiveCheckTrace
+// knows which one a given target actually needs.
+func NeedsTraceCheck(seq []inst.Instruction) bool {
+	for _, instr := range seq {
+		if inst.UsesMemory(instr.Op) {
+			return true
+		}
+	}
+	return false
+}
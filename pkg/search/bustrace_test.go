@@ -0,0 +1,79 @@
+package search
+
+import (
+	"testing"
+
+	"github.com/oisee/z80-optimizer/pkg/cpu"
+	"github.com/oisee/z80-optimizer/pkg/inst"
+)
+
+func TestQuickCheckTrace_IdenticalSequences(t *testing.T) {
+	seq := []inst.Instruction{{Op: inst.LD_HLI_A}, {Op: inst.INC_HL}}
+	if !QuickCheckTrace(seq, seq) {
+		t.Fatal("a sequence should trace-equal itself")
+	}
+}
+
+func TestQuickCheckTrace_DetectsDifferentWriteValue(t *testing.T) {
+	target := []inst.Instruction{{Op: inst.LD_HLI_A}}
+	candidate := []inst.Instruction{{Op: inst.LD_HLI_B}}
+
+	if QuickCheckTrace(target, candidate) {
+		t.Fatal("LD (HL),A vs LD (HL),B should not trace-equal when A != B")
+	}
+}
+
+func TestExhaustiveCheckTrace_INCHLvsINCLNotEquivalent(t *testing.T) {
+	// LD (HL),A : INC HL vs LD (HL),A : INC L differ whenever H must carry
+	// (L wraps from 0xFF to 0x00) — ExhaustiveCheckTrace must catch that even
+	// though QuickCheckTrace's fixed vectors might not hit the wrap case.
+	target := []inst.Instruction{{Op: inst.LD_HLI_A}, {Op: inst.INC_HL}}
+	candidate := []inst.Instruction{{Op: inst.LD_HLI_A}, {Op: inst.INC_L}}
+
+	if ExhaustiveCheckTrace(target, candidate) {
+		t.Fatal("INC HL and INC L should not be trace-equivalent in general (H may need to carry)")
+	}
+}
+
+func TestExhaustiveCheckTrace_NonMemorySequenceStillComparesRegisters(t *testing.T) {
+	target := []inst.Instruction{{Op: inst.ADD_A_B}}
+	candidate := []inst.Instruction{{Op: inst.SUB_B}}
+
+	if ExhaustiveCheckTrace(target, candidate) {
+		t.Fatal("ADD A,B and SUB B should not be equivalent")
+	}
+}
+
+func TestExhaustiveCheckTraceMasked_IdenticalSequencesUnderScratchAbove(t *testing.T) {
+	// Target and candidate are the same LD (HL),A; setting a scratch
+	// boundary must not make a sequence stop trace-equalling itself.
+	target := []inst.Instruction{{Op: inst.LD_HLI_A}}
+	candidate := []inst.Instruction{{Op: inst.LD_HLI_A}}
+
+	mask := TraceMask{ScratchAbove: 0x4000}
+	if !ExhaustiveCheckTraceMasked(target, candidate, mask) {
+		t.Fatal("identical sequences should trace-equal under any mask")
+	}
+}
+
+func TestNeedsTraceCheck(t *testing.T) {
+	if NeedsTraceCheck([]inst.Instruction{{Op: inst.ADD_A_B}}) {
+		t.Error("ADD A,B does not use memory")
+	}
+	if !NeedsTraceCheck([]inst.Instruction{{Op: inst.LD_HLI_A}}) {
+		t.Error("LD (HL),A uses memory")
+	}
+}
+
+func TestBusTraceEqualMasked_DeadPortsIgnoresOUT(t *testing.T) {
+	a := []cpu.BusEvent{{Kind: cpu.BusOut, Addr: 0xFE, Value: 1}}
+	b := []cpu.BusEvent{{Kind: cpu.BusOut, Addr: 0xFE, Value: 2}}
+
+	if busTraceEqualMasked(a, b, TraceMask{}) {
+		t.Fatal("different OUT values should not be equal unmasked")
+	}
+	mask := TraceMask{DeadPorts: map[uint16]bool{0xFE: true}}
+	if !busTraceEqualMasked(a, b, mask) {
+		t.Fatal("OUT to a dead port should be ignored under mask")
+	}
+}
@@ -62,6 +62,26 @@ func InstructionCount() int {
 	return len(inst.NonImmediateOps()) + len(inst.ImmediateOps())*256 + len(inst.Imm16Ops())*65536
 }
 
+// EnumerateSequences8 is EnumerateSequences restricted to instructions with
+// no 16-bit immediate operand (non-immediate plus 8-bit-immediate ops only).
+// collectTasks uses this for target sequences to keep the search space
+// feasible — a target's own LD rr,nn forms are vastly outnumbered by the
+// candidates that might replace them, so there's little to gain enumerating
+// all 65536 values of nn on the target side too.
+func EnumerateSequences8(n int, fn func(seq []inst.Instruction) bool) {
+	nonImm := inst.NonImmediateOps()
+	imm8Ops := inst.ImmediateOps()
+
+	seq := make([]inst.Instruction, n)
+	enumerateRec(seq, 0, nonImm, imm8Ops, nil, fn)
+}
+
+// InstructionCount8 returns the number of distinct instructions
+// EnumerateSequences8 considers per position.
+func InstructionCount8() int {
+	return len(inst.NonImmediateOps()) + len(inst.ImmediateOps())*256
+}
+
 // EnumerateFirstOp returns all possible first instructions (for partitioning).
 func EnumerateFirstOp() []inst.Instruction {
 	result := make([]inst.Instruction, 0, InstructionCount())
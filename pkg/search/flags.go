@@ -0,0 +1,76 @@
+package search
+
+import "github.com/oisee/z80-optimizer/pkg/inst"
+
+// FlagSet is a named-bit view over the eight Z80 flag positions in F. It's
+// distinct from FlagMask (which just answers "ignore this bit or not") so it
+// can carry a String() for logging/debugging which flags a rule actually
+// depends on; convert with FlagSet(mask) and FlagMask(set) at the boundary.
+type FlagSet uint8
+
+const (
+	FlagC  FlagSet = 0x01 // Carry
+	FlagN  FlagSet = 0x02 // Subtract
+	FlagPV FlagSet = 0x04 // Parity/Overflow
+	FlagX  FlagSet = 0x08 // Undocumented bit 3
+	FlagH  FlagSet = 0x10 // Half-carry
+	FlagY  FlagSet = 0x20 // Undocumented bit 5
+	FlagZ  FlagSet = 0x40 // Zero
+	FlagS  FlagSet = 0x80 // Sign
+)
+
+// flagLetters lists the eight flag bits from S (bit 7) down to C (bit 0),
+// the order the F register is conventionally printed in.
+var flagLetters = [...]struct {
+	bit FlagSet
+	ch  byte
+}{
+	{FlagS, 'S'}, {FlagZ, 'Z'}, {FlagY, 'Y'}, {FlagH, 'H'},
+	{FlagX, 'X'}, {FlagPV, 'P'}, {FlagN, 'N'}, {FlagC, 'C'},
+}
+
+// String renders the set as one character per flag bit in SZYHXPNC order:
+// the flag's letter where set, '-' where clear. For example, every
+// documented flag set with the undocumented X/Y bits clear prints as
+// "SZ-H-PNC".
+func (fs FlagSet) String() string {
+	b := make([]byte, len(flagLetters))
+	for i, fl := range flagLetters {
+		if fs&fl.bit != 0 {
+			b[i] = fl.ch
+		} else {
+			b[i] = '-'
+		}
+	}
+	return string(b)
+}
+
+// FlagDiffExhaustive is FlagDiff under the full register sweep ExhaustiveCheck
+// uses instead of just the fixed TestVectors: for each flag bit it checks
+// whether requiring that bit to match still holds across every input the
+// sequences read. Bits that must differ somewhere come back set, giving
+// callers a minimal dead-flag mask instead of the coarse DeadUndoc/DeadAll
+// buckets — pass the result through FlagMask(...) to ExhaustiveCheckMasked.
+func FlagDiffExhaustive(target, candidate []inst.Instruction) FlagSet {
+	if !ExhaustiveCheckMasked(target, candidate, DeadAll) {
+		// Register state disagrees somewhere even with every flag ignored —
+		// there's no flag mask that makes these equivalent.
+		return FlagSet(DeadAll)
+	}
+	var diff FlagSet
+	for _, fl := range flagLetters {
+		if !ExhaustiveCheckMasked(target, candidate, DeadAll&^FlagMask(fl.bit)) {
+			diff |= fl.bit
+		}
+	}
+	return diff
+}
+
+// ExhaustiveCheckLiveOut is ExhaustiveCheckMasked expressed in terms of which
+// flags are live at the end of the block — e.g. from downstream branch
+// analysis — rather than a fixed dead-flag mask: a candidate is accepted
+// whenever FlagDiff & liveOut == 0, not only when every differing flag falls
+// in the documented DeadUndoc/DeadAll buckets.
+func ExhaustiveCheckLiveOut(target, candidate []inst.Instruction, liveOut FlagSet) bool {
+	return ExhaustiveCheckMasked(target, candidate, FlagMask(^liveOut))
+}
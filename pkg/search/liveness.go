@@ -0,0 +1,83 @@
+package search
+
+import "github.com/oisee/z80-optimizer/pkg/inst"
+
+// Register names one general-purpose register or F, for callers outside
+// this package — pkg/rewrite's peephole conditions, specifically — that
+// need a liveness query without reaching into the unexported regMask bit
+// layout Reads/Writes already wrap.
+type Register uint8
+
+const (
+	RegA Register = iota
+	RegB
+	RegC
+	RegD
+	RegE
+	RegH
+	RegL
+	RegF
+)
+
+var registerBit = [...]regMask{
+	RegA: regA,
+	RegB: regB,
+	RegC: regC,
+	RegD: regD,
+	RegE: regE,
+	RegH: regH,
+	RegL: regL,
+	RegF: regF,
+}
+
+// IsDead reports whether reg is read by any instruction in seq. pkg/rewrite
+// uses this to gate peepholes that drop or clobber a register's old value
+// (e.g. deleting a flags-only CP, or replacing INC r; DEC r with nothing)
+// on that value — or the flags it feeds — actually being unused afterward.
+func IsDead(seq []inst.Instruction, reg Register) bool {
+	return regsRead(seq)&registerBit[reg] == 0
+}
+
+// RegSet is a bitmask over Register values — pkg/liveness's backward
+// dataflow (chunk8-3) needs a whole per-instruction read/write set to run
+// its worklist algorithm, not one IsDead query against a sequence's tail at
+// a time.
+type RegSet uint8
+
+// Has reports whether reg's bit is set in s.
+func (s RegSet) Has(reg Register) bool { return s&(1<<reg) != 0 }
+
+// InstReads and InstWrites report which of the eight registers Register
+// enumerates a single instruction reads/writes as operands, built from the
+// same Properties table IsDead already wraps.
+func InstReads(instr inst.Instruction) RegSet  { return toRegSet(Properties[instr.Op].Reads) }
+func InstWrites(instr inst.Instruction) RegSet { return toRegSet(Properties[instr.Op].Writes) }
+
+func toRegSet(m regMask) RegSet {
+	var s RegSet
+	for r := Register(0); int(r) < len(registerBit); r++ {
+		if m&registerBit[r] != 0 {
+			s |= 1 << r
+		}
+	}
+	return s
+}
+
+// InstReadsFlags and InstWritesFlags are InstReads/InstWrites for flag bits
+// rather than registers: InstReadsFlags mirrors readsFlags (currently only
+// the Z/C bits Wave 7's branch conditions test); InstWritesFlags widens
+// Properties' coarse "this op changes some flag" bit to every flag, since
+// that table doesn't break down which ones — the same conservative
+// all-or-nothing choice writtenFlags in pkg/rewrite/mined.go makes for
+// unannotated opcodes, just applied uniformly here instead of gated on
+// inst.FlagEffects.Known.
+func InstReadsFlags(instr inst.Instruction) FlagSet {
+	return FlagSet(Properties[instr.Op].ReadsFlags)
+}
+
+func InstWritesFlags(instr inst.Instruction) FlagSet {
+	if Properties[instr.Op].WritesFlags != DeadNone {
+		return FlagSet(DeadAll)
+	}
+	return 0
+}
@@ -0,0 +1,34 @@
+package search
+
+import (
+	"testing"
+
+	"github.com/oisee/z80-optimizer/pkg/inst"
+)
+
+func TestInstReadsWrites_LDBA(t *testing.T) {
+	instr := inst.Instruction{Op: inst.LD_B_A}
+	if !InstReads(instr).Has(RegA) {
+		t.Fatal("LD B,A should read A")
+	}
+	if !InstWrites(instr).Has(RegB) {
+		t.Fatal("LD B,A should write B")
+	}
+	if InstWrites(instr).Has(RegA) {
+		t.Fatal("LD B,A should not write A")
+	}
+}
+
+func TestInstWritesFlags_XORA(t *testing.T) {
+	instr := inst.Instruction{Op: inst.XOR_A}
+	if InstWritesFlags(instr) != FlagSet(DeadAll) {
+		t.Fatalf("XOR A should write every flag bit, got %v", InstWritesFlags(instr))
+	}
+}
+
+func TestInstReadsFlags_ConditionalJump(t *testing.T) {
+	instr := inst.Instruction{Op: inst.JR_Z}
+	if InstReadsFlags(instr) != FlagSet(FlagZ) {
+		t.Fatalf("JR Z should read only the Z flag, got %v", InstReadsFlags(instr))
+	}
+}
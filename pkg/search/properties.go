@@ -0,0 +1,420 @@
+package search
+
+import (
+	"github.com/oisee/z80-optimizer/pkg/cpu"
+	"github.com/oisee/z80-optimizer/pkg/inst"
+)
+
+// InstProperty is the per-opcode summary opReads/opWrites/the pruner need,
+// collected once at init instead of re-deriving it from switch statements on
+// every call. Reads/Writes cover general-purpose + SP/IX/IY registers, plus
+// regMem for any op that touches the shared memory byte (see the regMem fold
+// below); ReadsMem/WritesMem stay coarse (either direction sets both — see
+// wave5Reads below) since regMem itself doesn't distinguish direction either.
+type InstProperty struct {
+	Reads, Writes           regMask
+	ReadsFlags, WritesFlags FlagMask
+	Cycles, Bytes           uint8
+	ReadsMem, WritesMem     bool
+}
+
+// Properties is built once at init from the pre-existing Wave 0-4 switches
+// (legacyReads/legacyWrites) plus new, compact per-wave population for
+// Wave 5 (memory), Wave 6 (IX/IY), Wave 7 (branches) and Wave 8 (I/O) —
+// the opcodes legacyReads/legacyWrites silently returned 0 for.
+var Properties [inst.OpCodeCount]InstProperty
+
+func init() {
+	for op := inst.OpCode(0); op < inst.OpCodeCount; op++ {
+		p := InstProperty{
+			Reads:  legacyReads(op) | wave5Reads(op) | wave6Reads(op) | wave7Reads(op) | wave8Reads(op),
+			Writes: legacyWrites(op) | wave5Writes(op) | wave6Writes(op) | wave7Writes(op) | wave8Writes(op),
+			Cycles: uint8(inst.TStates(op)),
+			Bytes:  uint8(inst.ByteSize(op)),
+		}
+		p.ReadsMem = inst.UsesMemory(op)
+		p.WritesMem = inst.UsesMemory(op)
+		if p.ReadsMem {
+			p.Reads |= regMem
+			p.Writes |= regMem
+		}
+		p.ReadsFlags = readsFlags(op)
+		if writesAnyFlag(p.Writes) {
+			p.WritesFlags = DeadAll
+		}
+		Properties[op] = p
+	}
+}
+
+// writesAnyFlag reports whether the legacy/wave mask above folded in regF —
+// the existing convention for "this op changes flags", kept as-is here.
+func writesAnyFlag(w regMask) bool {
+	return w&regF != 0
+}
+
+// readsFlags reports which flag bits an opcode's behavior depends on,
+// beyond what's already captured by Reads&regF (carry-dependent ALU ops).
+// Only the bits the search/pruner currently care about — Z and C, the ones
+// Wave 7's conditions test — are populated here; a full per-flag (SZHXPNC)
+// breakdown lives alongside FlagSet in flags.go, used by the equivalence
+// checker rather than this table.
+func readsFlags(op inst.OpCode) FlagMask {
+	switch op {
+	case inst.JR_NZ, inst.JR_Z, inst.JP_NZ, inst.JP_Z, inst.CALL_NZ, inst.CALL_Z,
+		inst.RET_NZ, inst.RET_Z:
+		return cpu.FlagZ
+	case inst.JR_NC, inst.JR_C, inst.JP_NC, inst.JP_C, inst.CALL_NC, inst.CALL_C,
+		inst.RET_NC, inst.RET_C:
+		return cpu.FlagC
+	}
+	return DeadNone
+}
+
+// Reads returns which registers an instruction reads as source operands.
+func Reads(op inst.OpCode) regMask { return Properties[op].Reads }
+
+// Writes returns which registers an instruction modifies.
+func Writes(op inst.OpCode) regMask { return Properties[op].Writes }
+
+// Effects returns the full collected property set for an opcode.
+func Effects(op inst.OpCode) InstProperty { return Properties[op] }
+
+// opReads and opWrites are the package-internal call sites (pruner.go,
+// verifier.go) for Reads/Writes — kept as lowercase aliases so this wave
+// didn't need to touch every existing caller.
+func opReads(op inst.OpCode) regMask  { return Properties[op].Reads }
+func opWrites(op inst.OpCode) regMask { return Properties[op].Writes }
+
+// wave5Reads covers the (HL)/(BC)/(DE) indirect ops UsesMemory/HasIndexDisp
+// don't classify by GP-register operand: the address pair (and, for stores
+// and read-modify-write ALU ops, the value register) read alongside memory.
+func wave5Reads(op inst.OpCode) regMask {
+	switch op {
+	case inst.LD_A_HLI, inst.LD_B_HLI, inst.LD_C_HLI, inst.LD_D_HLI, inst.LD_E_HLI,
+		inst.LD_H_HLI, inst.LD_L_HLI, inst.LD_HLI_N,
+		inst.INC_HLI, inst.DEC_HLI,
+		inst.RLC_HLI, inst.RRC_HLI, inst.RL_HLI, inst.RR_HLI,
+		inst.SLA_HLI, inst.SRA_HLI, inst.SRL_HLI, inst.SLL_HLI,
+		inst.BIT_0_HLI, inst.BIT_1_HLI, inst.BIT_2_HLI, inst.BIT_3_HLI,
+		inst.BIT_4_HLI, inst.BIT_5_HLI, inst.BIT_6_HLI, inst.BIT_7_HLI,
+		inst.RES_0_HLI, inst.RES_1_HLI, inst.RES_2_HLI, inst.RES_3_HLI,
+		inst.RES_4_HLI, inst.RES_5_HLI, inst.RES_6_HLI, inst.RES_7_HLI,
+		inst.SET_0_HLI, inst.SET_1_HLI, inst.SET_2_HLI, inst.SET_3_HLI,
+		inst.SET_4_HLI, inst.SET_5_HLI, inst.SET_6_HLI, inst.SET_7_HLI:
+		return regH | regL
+	case inst.LD_HLI_A:
+		return regH | regL | regA
+	case inst.LD_HLI_B:
+		return regH | regL | regB
+	case inst.LD_HLI_C:
+		return regH | regL | regC
+	case inst.LD_HLI_D:
+		return regH | regL | regD
+	case inst.LD_HLI_E:
+		return regH | regL | regE
+	case inst.LD_HLI_H:
+		return regH | regL
+	case inst.LD_HLI_L:
+		return regH | regL
+	case inst.LD_A_BCI:
+		return regB | regC
+	case inst.LD_A_DEI:
+		return regD | regE
+	case inst.LD_BCI_A:
+		return regB | regC | regA
+	case inst.LD_DEI_A:
+		return regD | regE | regA
+	case inst.ADD_A_HLI, inst.SUB_HLI, inst.AND_HLI, inst.XOR_HLI, inst.OR_HLI, inst.CP_HLI:
+		return regH | regL | regA
+	case inst.ADC_A_HLI, inst.SBC_A_HLI:
+		return regH | regL | regA | regF
+	}
+	return 0
+}
+
+func wave5Writes(op inst.OpCode) regMask {
+	switch op {
+	case inst.LD_A_HLI:
+		return regA
+	case inst.LD_B_HLI:
+		return regB
+	case inst.LD_C_HLI:
+		return regC
+	case inst.LD_D_HLI:
+		return regD
+	case inst.LD_E_HLI:
+		return regE
+	case inst.LD_H_HLI:
+		return regH
+	case inst.LD_L_HLI:
+		return regL
+	case inst.LD_A_BCI, inst.LD_A_DEI:
+		return regA
+	case inst.ADD_A_HLI, inst.ADC_A_HLI, inst.SUB_HLI, inst.SBC_A_HLI,
+		inst.AND_HLI, inst.XOR_HLI, inst.OR_HLI:
+		return regA | regF
+	case inst.CP_HLI:
+		return regF
+	case inst.INC_HLI, inst.DEC_HLI,
+		inst.RLC_HLI, inst.RRC_HLI, inst.RL_HLI, inst.RR_HLI,
+		inst.SLA_HLI, inst.SRA_HLI, inst.SRL_HLI, inst.SLL_HLI,
+		inst.BIT_0_HLI, inst.BIT_1_HLI, inst.BIT_2_HLI, inst.BIT_3_HLI,
+		inst.BIT_4_HLI, inst.BIT_5_HLI, inst.BIT_6_HLI, inst.BIT_7_HLI:
+		return regF
+	}
+	return 0
+}
+
+// wave6Reads covers IX/IY register-pair ops, (IX+d)/(IY+d) indirect ops
+// (which read the index pair itself alongside any value register — the
+// displacement is a fixed operand carried in Instruction.Disp, not a
+// register read), and the undocumented IXH/IXL/IYH/IYL half ops (folded
+// into the regIX/regIY bit — this table doesn't model half-registers
+// separately from the pair they belong to).
+func wave6Reads(op inst.OpCode) regMask {
+	switch op {
+	case inst.ADD_IX_BC:
+		return regIX | regB | regC
+	case inst.ADD_IX_DE:
+		return regIX | regD | regE
+	case inst.ADD_IX_IX:
+		return regIX
+	case inst.ADD_IX_SP:
+		return regIX | regSP
+	case inst.ADD_IY_BC:
+		return regIY | regB | regC
+	case inst.ADD_IY_DE:
+		return regIY | regD | regE
+	case inst.ADD_IY_IY:
+		return regIY
+	case inst.ADD_IY_SP:
+		return regIY | regSP
+
+	case inst.LD_A_IXD, inst.LD_B_IXD, inst.LD_C_IXD, inst.LD_D_IXD,
+		inst.LD_E_IXD, inst.LD_H_IXD, inst.LD_L_IXD,
+		inst.INC_IXD, inst.DEC_IXD, inst.LD_IXD_N,
+		inst.RLC_IXD, inst.RRC_IXD, inst.RL_IXD, inst.RR_IXD,
+		inst.SLA_IXD, inst.SRA_IXD, inst.SRL_IXD, inst.SLL_IXD,
+		inst.BIT_0_IXD, inst.BIT_1_IXD, inst.BIT_2_IXD, inst.BIT_3_IXD,
+		inst.BIT_4_IXD, inst.BIT_5_IXD, inst.BIT_6_IXD, inst.BIT_7_IXD,
+		inst.RES_0_IXD, inst.RES_1_IXD, inst.RES_2_IXD, inst.RES_3_IXD,
+		inst.RES_4_IXD, inst.RES_5_IXD, inst.RES_6_IXD, inst.RES_7_IXD,
+		inst.SET_0_IXD, inst.SET_1_IXD, inst.SET_2_IXD, inst.SET_3_IXD,
+		inst.SET_4_IXD, inst.SET_5_IXD, inst.SET_6_IXD, inst.SET_7_IXD,
+		inst.ADD_A_IXD, inst.SUB_IXD, inst.AND_IXD, inst.XOR_IXD, inst.OR_IXD, inst.CP_IXD:
+		return regIX
+	case inst.ADC_A_IXD, inst.SBC_A_IXD:
+		return regIX | regA | regF
+	case inst.LD_IXD_A:
+		return regIX | regA
+	case inst.LD_IXD_B:
+		return regIX | regB
+	case inst.LD_IXD_C:
+		return regIX | regC
+	case inst.LD_IXD_D:
+		return regIX | regD
+	case inst.LD_IXD_E:
+		return regIX | regE
+	case inst.LD_IXD_H:
+		return regIX | regH
+	case inst.LD_IXD_L:
+		return regIX | regL
+
+	case inst.LD_A_IYD, inst.LD_B_IYD, inst.LD_C_IYD, inst.LD_D_IYD,
+		inst.LD_E_IYD, inst.LD_H_IYD, inst.LD_L_IYD,
+		inst.INC_IYD, inst.DEC_IYD, inst.LD_IYD_N,
+		inst.RLC_IYD, inst.RRC_IYD, inst.RL_IYD, inst.RR_IYD,
+		inst.SLA_IYD, inst.SRA_IYD, inst.SRL_IYD, inst.SLL_IYD,
+		inst.BIT_0_IYD, inst.BIT_1_IYD, inst.BIT_2_IYD, inst.BIT_3_IYD,
+		inst.BIT_4_IYD, inst.BIT_5_IYD, inst.BIT_6_IYD, inst.BIT_7_IYD,
+		inst.RES_0_IYD, inst.RES_1_IYD, inst.RES_2_IYD, inst.RES_3_IYD,
+		inst.RES_4_IYD, inst.RES_5_IYD, inst.RES_6_IYD, inst.RES_7_IYD,
+		inst.SET_0_IYD, inst.SET_1_IYD, inst.SET_2_IYD, inst.SET_3_IYD,
+		inst.SET_4_IYD, inst.SET_5_IYD, inst.SET_6_IYD, inst.SET_7_IYD,
+		inst.ADD_A_IYD, inst.SUB_IYD, inst.AND_IYD, inst.XOR_IYD, inst.OR_IYD, inst.CP_IYD:
+		return regIY
+	case inst.ADC_A_IYD, inst.SBC_A_IYD:
+		return regIY | regA | regF
+	case inst.LD_IYD_A:
+		return regIY | regA
+	case inst.LD_IYD_B:
+		return regIY | regB
+	case inst.LD_IYD_C:
+		return regIY | regC
+	case inst.LD_IYD_D:
+		return regIY | regD
+	case inst.LD_IYD_E:
+		return regIY | regE
+	case inst.LD_IYD_H:
+		return regIY | regH
+	case inst.LD_IYD_L:
+		return regIY | regL
+
+	case inst.LD_A_IXH, inst.LD_A_IXL, inst.INC_IXH, inst.INC_IXL, inst.DEC_IXH, inst.DEC_IXL:
+		return regIX
+	case inst.LD_IXH_A, inst.LD_IXL_A, inst.ADD_A_IXH, inst.ADD_A_IXL:
+		return regIX | regA
+	case inst.LD_A_IYH, inst.LD_A_IYL, inst.INC_IYH, inst.INC_IYL, inst.DEC_IYH, inst.DEC_IYL:
+		return regIY
+	case inst.LD_IYH_A, inst.LD_IYL_A, inst.ADD_A_IYH, inst.ADD_A_IYL:
+		return regIY | regA
+	}
+	return 0
+}
+
+func wave6Writes(op inst.OpCode) regMask {
+	switch op {
+	case inst.LD_IX_NN:
+		return regIX
+	case inst.LD_IY_NN:
+		return regIY
+	case inst.ADD_IX_BC, inst.ADD_IX_DE, inst.ADD_IX_IX, inst.ADD_IX_SP:
+		return regIX | regF
+	case inst.ADD_IY_BC, inst.ADD_IY_DE, inst.ADD_IY_IY, inst.ADD_IY_SP:
+		return regIY | regF
+
+	case inst.LD_A_IXD:
+		return regA
+	case inst.LD_B_IXD:
+		return regB
+	case inst.LD_C_IXD:
+		return regC
+	case inst.LD_D_IXD:
+		return regD
+	case inst.LD_E_IXD:
+		return regE
+	case inst.LD_H_IXD:
+		return regH
+	case inst.LD_L_IXD:
+		return regL
+	case inst.ADD_A_IXD, inst.ADC_A_IXD, inst.SUB_IXD, inst.SBC_A_IXD,
+		inst.AND_IXD, inst.XOR_IXD, inst.OR_IXD:
+		return regA | regF
+	case inst.CP_IXD:
+		return regF
+	case inst.INC_IXD, inst.DEC_IXD,
+		inst.RLC_IXD, inst.RRC_IXD, inst.RL_IXD, inst.RR_IXD,
+		inst.SLA_IXD, inst.SRA_IXD, inst.SRL_IXD, inst.SLL_IXD,
+		inst.BIT_0_IXD, inst.BIT_1_IXD, inst.BIT_2_IXD, inst.BIT_3_IXD,
+		inst.BIT_4_IXD, inst.BIT_5_IXD, inst.BIT_6_IXD, inst.BIT_7_IXD:
+		return regF
+
+	case inst.LD_A_IYD:
+		return regA
+	case inst.LD_B_IYD:
+		return regB
+	case inst.LD_C_IYD:
+		return regC
+	case inst.LD_D_IYD:
+		return regD
+	case inst.LD_E_IYD:
+		return regE
+	case inst.LD_H_IYD:
+		return regH
+	case inst.LD_L_IYD:
+		return regL
+	case inst.ADD_A_IYD, inst.ADC_A_IYD, inst.SUB_IYD, inst.SBC_A_IYD,
+		inst.AND_IYD, inst.XOR_IYD, inst.OR_IYD:
+		return regA | regF
+	case inst.CP_IYD:
+		return regF
+	case inst.INC_IYD, inst.DEC_IYD,
+		inst.RLC_IYD, inst.RRC_IYD, inst.RL_IYD, inst.RR_IYD,
+		inst.SLA_IYD, inst.SRA_IYD, inst.SRL_IYD, inst.SLL_IYD,
+		inst.BIT_0_IYD, inst.BIT_1_IYD, inst.BIT_2_IYD, inst.BIT_3_IYD,
+		inst.BIT_4_IYD, inst.BIT_5_IYD, inst.BIT_6_IYD, inst.BIT_7_IYD:
+		return regF
+
+	case inst.LD_A_IXH, inst.LD_A_IXL:
+		return regA
+	case inst.LD_IXH_A, inst.LD_IXL_A:
+		return regIX
+	case inst.INC_IXH, inst.DEC_IXH, inst.INC_IXL, inst.DEC_IXL:
+		return regIX | regF
+	case inst.ADD_A_IXH, inst.ADD_A_IXL:
+		return regA | regF
+	case inst.LD_A_IYH, inst.LD_A_IYL:
+		return regA
+	case inst.LD_IYH_A, inst.LD_IYL_A:
+		return regIY
+	case inst.INC_IYH, inst.DEC_IYH, inst.INC_IYL, inst.DEC_IYL:
+		return regIY | regF
+	case inst.ADD_A_IYH, inst.ADD_A_IYL:
+		return regA | regF
+	}
+	return 0
+}
+
+// wave7Reads covers JR/JP/CALL/RET condition codes (which read F) and DJNZ
+// (which reads and decrements B). Unconditional branches and RST read
+// nothing this table tracks — PC isn't part of regMask.
+func wave7Reads(op inst.OpCode) regMask {
+	switch op {
+	case inst.DJNZ:
+		return regB
+	case inst.JR_NZ, inst.JR_Z, inst.JR_NC, inst.JR_C,
+		inst.JP_NZ, inst.JP_Z, inst.JP_NC, inst.JP_C,
+		inst.CALL_NZ, inst.CALL_Z, inst.CALL_NC, inst.CALL_C,
+		inst.RET_NZ, inst.RET_Z, inst.RET_NC, inst.RET_C:
+		return regF
+	}
+	return 0
+}
+
+func wave7Writes(op inst.OpCode) regMask {
+	if op == inst.DJNZ {
+		return regB
+	}
+	return 0
+}
+
+// wave8Reads/wave8Writes cover the I/O ports wave. Port C reads/OUT (C),r
+// writes go through regC/the value register; the block I/O forms read and
+// write B (counter), C (port) and HL (address) together.
+func wave8Reads(op inst.OpCode) regMask {
+	switch op {
+	case inst.OUT_N_A:
+		return regA
+	case inst.IN_A_C, inst.IN_B_C, inst.IN_C_C, inst.IN_D_C, inst.IN_E_C, inst.IN_H_C, inst.IN_L_C:
+		return regC
+	case inst.OUT_C_A:
+		return regC | regA
+	case inst.OUT_C_B:
+		return regC | regB
+	case inst.OUT_C_C:
+		return regC
+	case inst.OUT_C_D:
+		return regC | regD
+	case inst.OUT_C_E:
+		return regC | regE
+	case inst.OUT_C_H:
+		return regC | regH
+	case inst.OUT_C_L:
+		return regC | regL
+	case inst.INI, inst.INIR, inst.IND, inst.INDR, inst.OUTI, inst.OTIR, inst.OUTD, inst.OTDR:
+		return regB | regC | regH | regL
+	}
+	return 0
+}
+
+func wave8Writes(op inst.OpCode) regMask {
+	switch op {
+	case inst.IN_A_N, inst.IN_A_C:
+		return regA
+	case inst.IN_B_C:
+		return regB
+	case inst.IN_C_C:
+		return regC
+	case inst.IN_D_C:
+		return regD
+	case inst.IN_E_C:
+		return regE
+	case inst.IN_H_C:
+		return regH
+	case inst.IN_L_C:
+		return regL
+	case inst.INI, inst.INIR, inst.IND, inst.INDR, inst.OUTI, inst.OTIR, inst.OUTD, inst.OTDR:
+		return regB | regH | regL | regF
+	}
+	return 0
+}
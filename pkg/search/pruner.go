@@ -47,6 +47,11 @@ func isSelfLoad(op inst.OpCode) bool {
 // isDeadWrite returns true if 'first' writes a register that 'second'
 // overwrites without reading first.
 func isDeadWrite(first, second inst.Instruction) bool {
+	// I/O has an observable effect beyond the register it loads — never
+	// treat it as dead just because the loaded register looks overwritten.
+	if inst.HasSideEffects(first.Op) {
+		return false
+	}
 	written := opWrites(first.Op)
 	if written == 0 {
 		return false
@@ -62,8 +67,10 @@ func isDeadWrite(first, second inst.Instruction) bool {
 	return dead != 0
 }
 
-// opWrites returns which registers an instruction modifies.
-func opWrites(op inst.OpCode) regMask {
+// legacyWrites returns which registers an instruction modifies, for the
+// Wave 0-4 opcodes it was originally written to cover. Wave 5+ is layered
+// on top in properties.go; opWrites itself just reads Properties.
+func legacyWrites(op inst.OpCode) regMask {
 	switch op {
 	// ALU ops always write A and F
 	case inst.ADD_A_B, inst.ADD_A_C, inst.ADD_A_D, inst.ADD_A_E, inst.ADD_A_H, inst.ADD_A_L, inst.ADD_A_A, inst.ADD_A_N,
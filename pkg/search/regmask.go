@@ -0,0 +1,230 @@
+package search
+
+import (
+	"github.com/oisee/z80-optimizer/pkg/cpu"
+	"github.com/oisee/z80-optimizer/pkg/inst"
+)
+
+// RegMask indicates which general-purpose registers are "dead" (their final
+// value is unobserved) and can be ignored during equivalence checks — the
+// same idea as FlagMask, but for registers instead of flag bits. BC/DE/HL
+// bits mask both halves of their pair at once, for rules that only care
+// about the pair being dead as a whole (e.g. a 16-bit pointer computation).
+type RegMask = uint16
+
+const (
+	DeadRegNone RegMask = 0x0000
+	DeadRegA    RegMask = 1 << 0
+	DeadRegB    RegMask = 1 << 1
+	DeadRegC    RegMask = 1 << 2
+	DeadRegD    RegMask = 1 << 3
+	DeadRegE    RegMask = 1 << 4
+	DeadRegH    RegMask = 1 << 5
+	DeadRegL    RegMask = 1 << 6
+	DeadRegBC   RegMask = 1 << 7
+	DeadRegDE   RegMask = 1 << 8
+	DeadRegHL   RegMask = 1 << 9
+)
+
+// statesEqualMaskedRegs is statesEqualMasked plus register masking: a
+// register byte is only compared if neither its own bit nor its pair's bit
+// is set in deadRegs.
+func statesEqualMaskedRegs(a, b cpu.State, deadFlags FlagMask, deadRegs RegMask) bool {
+	if deadRegs&DeadRegA == 0 && a.A != b.A {
+		return false
+	}
+	if (a.F &^ deadFlags) != (b.F &^ deadFlags) {
+		return false
+	}
+	if deadRegs&(DeadRegB|DeadRegBC) == 0 && a.B != b.B {
+		return false
+	}
+	if deadRegs&(DeadRegC|DeadRegBC) == 0 && a.C != b.C {
+		return false
+	}
+	if deadRegs&(DeadRegD|DeadRegDE) == 0 && a.D != b.D {
+		return false
+	}
+	if deadRegs&(DeadRegE|DeadRegDE) == 0 && a.E != b.E {
+		return false
+	}
+	if deadRegs&(DeadRegH|DeadRegHL) == 0 && a.H != b.H {
+		return false
+	}
+	if deadRegs&(DeadRegL|DeadRegHL) == 0 && a.L != b.L {
+		return false
+	}
+	return a.SP == b.SP && a.M == b.M && a.IX == b.IX && a.IY == b.IY
+}
+
+// QuickCheckMaskedRegs is QuickCheckMasked plus register masking: candidate
+// and target only need to agree on registers not marked dead in deadRegs.
+func QuickCheckMaskedRegs(target, candidate []inst.Instruction, deadFlags FlagMask, deadRegs RegMask) bool {
+	if deadRegs == DeadRegNone {
+		return QuickCheckMasked(target, candidate, deadFlags)
+	}
+	for i := range TestVectors {
+		tOut := execSeq(TestVectors[i], target)
+		cOut := execSeq(TestVectors[i], candidate)
+		if !statesEqualMaskedRegs(tOut, cOut, deadFlags, deadRegs) {
+			return false
+		}
+	}
+	return true
+}
+
+// ExhaustiveCheckMaskedRegs is ExhaustiveCheckMasked plus register masking.
+func ExhaustiveCheckMaskedRegs(target, candidate []inst.Instruction, deadFlags FlagMask, deadRegs RegMask) bool {
+	if deadRegs == DeadRegNone {
+		return ExhaustiveCheckMasked(target, candidate, deadFlags)
+	}
+
+	reads := regsRead(target) | regsRead(candidate)
+
+	if reads&^(regA|regF) == 0 {
+		return exhaustiveAFMaskedRegs(target, candidate, deadFlags, deadRegs)
+	}
+	return exhaustiveAllMaskedRegs(target, candidate, reads, deadFlags, deadRegs)
+}
+
+func exhaustiveAFMaskedRegs(target, candidate []inst.Instruction, deadFlags FlagMask, deadRegs RegMask) bool {
+	for a := 0; a < 256; a++ {
+		for carry := uint8(0); carry <= 1; carry++ {
+			s := cpu.State{A: uint8(a), F: carry}
+			tOut := execSeq(s, target)
+			cOut := execSeq(s, candidate)
+			if !statesEqualMaskedRegs(tOut, cOut, deadFlags, deadRegs) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func exhaustiveAllMaskedRegs(target, candidate []inst.Instruction, reads regMask, deadFlags FlagMask, deadRegs RegMask) bool {
+	extraRegs := make([]int, 0, 6)
+	if reads&regB != 0 {
+		extraRegs = append(extraRegs, 2)
+	}
+	if reads&regC != 0 {
+		extraRegs = append(extraRegs, 3)
+	}
+	if reads&regD != 0 {
+		extraRegs = append(extraRegs, 4)
+	}
+	if reads&regE != 0 {
+		extraRegs = append(extraRegs, 5)
+	}
+	if reads&regH != 0 {
+		extraRegs = append(extraRegs, 6)
+	}
+	if reads&regL != 0 {
+		extraRegs = append(extraRegs, 7)
+	}
+	if reads&regMem != 0 {
+		extraRegs = append(extraRegs, 8)
+	}
+
+	sweepSP := reads&regSP != 0
+	sweepIX := reads&regIX != 0
+	sweepIY := reads&regIY != 0
+
+	if len(extraRegs) == 0 && !sweepSP && !sweepIX && !sweepIY {
+		return exhaustiveAFMaskedRegs(target, candidate, deadFlags, deadRegs)
+	}
+
+	if len(extraRegs) <= 2 && !sweepSP && !sweepIX && !sweepIY {
+		return exhaustiveFullSweepMaskedRegs(target, candidate, extraRegs, deadFlags, deadRegs)
+	}
+	return exhaustiveReducedSweepMaskedRegs(target, candidate, extraRegs, sweepSP, sweepIX, sweepIY, deadFlags, deadRegs)
+}
+
+func exhaustiveFullSweepMaskedRegs(target, candidate []inst.Instruction, extraRegs []int, deadFlags FlagMask, deadRegs RegMask) bool {
+	if len(extraRegs) == 1 {
+		for a := 0; a < 256; a++ {
+			for carry := uint8(0); carry <= 1; carry++ {
+				for r := 0; r < 256; r++ {
+					s := cpu.State{A: uint8(a), F: carry}
+					setExtraReg(&s, extraRegs[0], uint8(r))
+					tOut := execSeq(s, target)
+					cOut := execSeq(s, candidate)
+					if !statesEqualMaskedRegs(tOut, cOut, deadFlags, deadRegs) {
+						return false
+					}
+				}
+			}
+		}
+		return true
+	}
+
+	for a := 0; a < 256; a++ {
+		for carry := uint8(0); carry <= 1; carry++ {
+			for r1 := 0; r1 < 256; r1++ {
+				for r2 := 0; r2 < 256; r2++ {
+					s := cpu.State{A: uint8(a), F: carry}
+					setExtraReg(&s, extraRegs[0], uint8(r1))
+					setExtraReg(&s, extraRegs[1], uint8(r2))
+					tOut := execSeq(s, target)
+					cOut := execSeq(s, candidate)
+					if !statesEqualMaskedRegs(tOut, cOut, deadFlags, deadRegs) {
+						return false
+					}
+				}
+			}
+		}
+	}
+	return true
+}
+
+func exhaustiveReducedSweepMaskedRegs(target, candidate []inst.Instruction, extraRegs []int, sweepSP, sweepIX, sweepIY bool, deadFlags FlagMask, deadRegs RegMask) bool {
+	repValues := []uint8{
+		0x00, 0x01, 0x02, 0x0F, 0x10, 0x1F, 0x20, 0x3F,
+		0x40, 0x55, 0x7E, 0x7F, 0x80, 0x81, 0xAA, 0xBF,
+		0xC0, 0xD5, 0xE0, 0xEF, 0xF0, 0xF7, 0xFE, 0xFF,
+		0x03, 0x07, 0x11, 0x33, 0x77, 0xBB, 0xDD, 0xEE,
+	}
+
+	spVals, ixVals, iyVals := wideSweepValues(sweepSP), wideSweepValues(sweepIX), wideSweepValues(sweepIY)
+
+	compare := func(s cpu.State) bool {
+		tOut := execSeq(s, target)
+		cOut := execSeq(s, candidate)
+		return statesEqualMaskedRegs(tOut, cOut, deadFlags, deadRegs)
+	}
+
+	var sweep func(s cpu.State, regIdx int) bool
+	sweep = func(s cpu.State, regIdx int) bool {
+		if regIdx >= len(extraRegs) {
+			for _, sp := range spVals {
+				for _, ix := range ixVals {
+					for _, iy := range iyVals {
+						s2 := s
+						s2.SP, s2.IX, s2.IY = sp, ix, iy
+						if !compare(s2) {
+							return false
+						}
+					}
+				}
+			}
+			return true
+		}
+		for _, v := range repValues {
+			s2 := s
+			setExtraReg(&s2, extraRegs[regIdx], v)
+			if !sweep(s2, regIdx+1) {
+				return false
+			}
+		}
+		return true
+	}
+
+	for a := 0; a < 256; a++ {
+		for carry := uint8(0); carry <= 1; carry++ {
+			s := cpu.State{A: uint8(a), F: carry}
+			if !sweep(s, 0) {
+				return false
+			}
+		}
+	}
+	return true
+}
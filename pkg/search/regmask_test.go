@@ -0,0 +1,84 @@
+package search
+
+import (
+	"testing"
+
+	"github.com/oisee/z80-optimizer/pkg/inst"
+)
+
+func TestQuickCheckMaskedRegs_LDAB_vs_NOP(t *testing.T) {
+	// LD A, B vs NOP: should fail fully (A differs), pass once A is dead.
+	target := []inst.Instruction{{Op: inst.LD_A_B}}
+	candidate := []inst.Instruction{{Op: inst.NOP}}
+
+	if QuickCheck(target, candidate) {
+		t.Fatal("QuickCheck should fail: LD A, B and NOP leave A different")
+	}
+
+	if !QuickCheckMaskedRegs(target, candidate, DeadNone, DeadRegA) {
+		t.Fatal("QuickCheckMaskedRegs(DeadRegA) should pass: only A differs")
+	}
+}
+
+func TestQuickCheckMaskedRegs_DeadRegNone_SameAsQuickCheckMasked(t *testing.T) {
+	target := []inst.Instruction{{Op: inst.LD_A_N, Imm: 0}}
+	candidate := []inst.Instruction{{Op: inst.XOR_A}}
+
+	flagsOnly := QuickCheckMasked(target, candidate, DeadAll)
+	combined := QuickCheckMaskedRegs(target, candidate, DeadAll, DeadRegNone)
+	if flagsOnly != combined {
+		t.Fatalf("DeadRegNone should match QuickCheckMasked: flagsOnly=%v combined=%v", flagsOnly, combined)
+	}
+}
+
+func TestExhaustiveCheckMaskedRegs_LDAB_vs_NOP(t *testing.T) {
+	target := []inst.Instruction{{Op: inst.LD_A_B}}
+	candidate := []inst.Instruction{{Op: inst.NOP}}
+
+	if ExhaustiveCheck(target, candidate) {
+		t.Fatal("ExhaustiveCheck should fail: A differs")
+	}
+
+	if !ExhaustiveCheckMaskedRegs(target, candidate, DeadNone, DeadRegA) {
+		t.Fatal("ExhaustiveCheckMaskedRegs(DeadRegA) should pass: A is the only difference, for every input")
+	}
+}
+
+func TestExhaustiveCheckMaskedRegs_PairBitMasksBothHalves(t *testing.T) {
+	// LD HL, 0x1234 vs NOP: differ in both H and L. DeadRegHL should cover both.
+	target := []inst.Instruction{{Op: inst.LD_HL_NN, Imm: 0x1234}}
+	candidate := []inst.Instruction{{Op: inst.NOP}}
+
+	if ExhaustiveCheckMaskedRegs(target, candidate, DeadNone, DeadRegH) {
+		t.Fatal("DeadRegH alone should not mask the L half")
+	}
+	if !ExhaustiveCheckMaskedRegs(target, candidate, DeadNone, DeadRegHL) {
+		t.Fatal("DeadRegHL should mask both H and L")
+	}
+}
+
+func TestStatesEqualMaskedRegs(t *testing.T) {
+	tests := []struct {
+		name     string
+		aA, bA   uint8
+		deadRegs RegMask
+		want     bool
+	}{
+		{"same A", 1, 1, DeadRegNone, true},
+		{"diff A no mask", 1, 2, DeadRegNone, false},
+		{"diff A dead", 1, 2, DeadRegA, true},
+		{"diff A wrong bit dead", 1, 2, DeadRegB, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := cpu_state(tt.aA, 0)
+			b := cpu_state(tt.bA, 0)
+			got := statesEqualMaskedRegs(a, b, DeadNone, tt.deadRegs)
+			if got != tt.want {
+				t.Fatalf("statesEqualMaskedRegs(A=%d, A=%d, deadRegs=%#x) = %v, want %v",
+					tt.aA, tt.bA, tt.deadRegs, got, tt.want)
+			}
+		})
+	}
+}
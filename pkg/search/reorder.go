@@ -0,0 +1,198 @@
+package search
+
+import (
+	"github.com/oisee/z80-optimizer/pkg/inst"
+	"github.com/oisee/z80-optimizer/pkg/result"
+)
+
+// defaultReorderWindow bounds how many instructions SearchReorder will
+// permute at once (chunk8-4): topological linearizations grow factorially
+// in window size, so this keeps the enumeration feasible without a caller
+// having to know that tradeoff up front.
+const defaultReorderWindow = 6
+
+// ReorderConfig holds SearchReorder's search parameters.
+type ReorderConfig struct {
+	// MaxWindow caps len(target); sequences longer than this are rejected
+	// outright rather than silently truncated. Defaults to
+	// defaultReorderWindow if zero.
+	MaxWindow int
+
+	// DeadFlags, if nonzero, lets a reordering also change these flag bits —
+	// the same liberalization Config.DeadFlags already gives the
+	// shortening search.
+	DeadFlags FlagMask
+
+	// UseSymbolic selects SymbolicCheck over ExhaustiveCheck/
+	// ExhaustiveCheckMasked for verifying each candidate linearization.
+	UseSymbolic bool
+}
+
+// SearchReorder looks for a permutation of target that's cheaper in
+// T-states than target's own instruction order while still provably
+// equivalent to it — a different optimization axis from the rest of
+// pkg/search, which only ever searches for a *shorter* replacement.
+// Reordering never adds or removes an instruction, so every returned rule
+// has BytesSaved == 0 and CyclesSaved > 0.
+//
+// Soundness comes from a dependency DAG built over target's own
+// instructions: edge i->j means instruction i must still run before j in
+// any valid reordering, derived from their read/write sets over registers,
+// flags, and the shared memory byte (see InstReads/InstWrites/
+// InstReadsFlags/InstWritesFlags). SearchReorder only ever enumerates
+// linearizations that respect every such edge, so ExhaustiveCheck/
+// SymbolicCheck is there to confirm a cheaper ordering was actually found —
+// not to reprove equivalence the DAG already guarantees. Sequences
+// containing a branch, call, return, or I/O instruction are rejected
+// outright: their side effects (or targets) depend on real program order in
+// ways a read/write-set DAG over registers and flags can't see.
+//
+// inst.TStates is a flat per-opcode cost with no memory-contention or
+// pipeline model behind it, so inst.SeqTStates(seq) is order-invariant
+// today: no candidate linearization is ever strictly cheaper than target's
+// own, and SearchReorder always returns an empty slice against this repo's
+// current cost model. It's wired up against CyclesSaved (not a placeholder)
+// so a future contention-aware cost table only has to change what
+// inst.TStates reports per instruction — this search, the DAG, and the
+// equivalence gate need no changes to start finding real reorderings then.
+func SearchReorder(target []inst.Instruction, cfg ReorderConfig) []result.Rule {
+	maxWindow := cfg.MaxWindow
+	if maxWindow <= 0 {
+		maxWindow = defaultReorderWindow
+	}
+	if len(target) < 2 || len(target) > maxWindow {
+		return nil
+	}
+	for _, instr := range target {
+		if !reorderable(instr.Op) {
+			return nil
+		}
+	}
+
+	mustPrecede := buildDependencyDAG(target)
+	baseTStates := inst.SeqTStates(target)
+
+	seen := map[[FingerprintLen]byte]bool{Fingerprint(target): true}
+	var rules []result.Rule
+
+	used := make([]bool, len(target))
+	order := make([]int, 0, len(target))
+
+	var rec func()
+	rec = func() {
+		if len(order) == len(target) {
+			candidate := reorderBy(target, order)
+			fp := Fingerprint(candidate)
+			if seen[fp] {
+				return
+			}
+			seen[fp] = true
+
+			candTStates := inst.SeqTStates(candidate)
+			if candTStates >= baseTStates {
+				return
+			}
+
+			if !reorderEquivalent(target, candidate, cfg) {
+				return
+			}
+
+			rules = append(rules, result.Rule{
+				Source:      copySeq(target),
+				Replacement: candidate,
+				CyclesSaved: baseTStates - candTStates,
+				DeadFlags:   uint8(cfg.DeadFlags),
+			})
+			return
+		}
+
+		for i := range target {
+			if used[i] || !readyToPlace(i, used, mustPrecede) {
+				continue
+			}
+			used[i] = true
+			order = append(order, i)
+			rec()
+			order = order[:len(order)-1]
+			used[i] = false
+		}
+	}
+	rec()
+
+	return rules
+}
+
+func reorderEquivalent(target, candidate []inst.Instruction, cfg ReorderConfig) bool {
+	if cfg.UseSymbolic {
+		return SymbolicCheck(target, candidate, cfg.DeadFlags)
+	}
+	if cfg.DeadFlags == DeadNone {
+		return ExhaustiveCheck(target, candidate)
+	}
+	return ExhaustiveCheckMasked(target, candidate, cfg.DeadFlags)
+}
+
+// reorderable reports whether op is safe to move relative to other
+// instructions under nothing but a register/flag/memory dependency DAG —
+// true for plain data-processing opcodes, false for anything whose meaning
+// depends on real program order (a branch target, a call/return, or an I/O
+// side effect).
+func reorderable(op inst.OpCode) bool {
+	return !inst.HasBranchTarget(op) && !inst.HasSideEffects(op) && !isReturnOp(op)
+}
+
+// isReturnOp reports whether op is RET or one of its conditional forms.
+// RET/RET cc don't set Instruction.Imm to a branch target the way
+// inst.HasBranchTarget's opcodes do, so reorderable has to recognize them
+// separately — the same gap pkg/liveness/cfg.go's isReturn closes for CFG
+// construction.
+func isReturnOp(op inst.OpCode) bool {
+	return op >= inst.RET && op <= inst.RET_C
+}
+
+// buildDependencyDAG returns, for each instruction index in seq, the set of
+// earlier indices it must still follow in any valid reordering: any pair
+// (i, j) with i < j conflicts — one reads what the other writes, or both
+// write the same register/flag/memory — so their relative order has to be
+// preserved (RAW, WAR, and WAW hazards alike; SearchReorder doesn't attempt
+// to distinguish which kind applies, since all three equally forbid
+// swapping the pair).
+func buildDependencyDAG(seq []inst.Instruction) [][]int {
+	mustPrecede := make([][]int, len(seq))
+	for j := range seq {
+		jr, jw := InstReads(seq[j]), InstWrites(seq[j])
+		jrf, jwf := InstReadsFlags(seq[j]), InstWritesFlags(seq[j])
+		for i := 0; i < j; i++ {
+			ir, iw := InstReads(seq[i]), InstWrites(seq[i])
+			irf, iwf := InstReadsFlags(seq[i]), InstWritesFlags(seq[i])
+			conflict := iw&(jr|jw) != 0 || ir&jw != 0 ||
+				iwf&(jrf|jwf) != 0 || irf&jwf != 0
+			if conflict {
+				mustPrecede[j] = append(mustPrecede[j], i)
+			}
+		}
+	}
+	return mustPrecede
+}
+
+// readyToPlace reports whether instruction i's dependencies (from
+// buildDependencyDAG) have all already been placed earlier in the
+// in-progress linearization, i.e. every index in mustPrecede[i] is already
+// marked used.
+func readyToPlace(i int, used []bool, mustPrecede [][]int) bool {
+	for _, p := range mustPrecede[i] {
+		if !used[p] {
+			return false
+		}
+	}
+	return true
+}
+
+// reorderBy builds the instruction sequence seq[order[0]], seq[order[1]], ...
+func reorderBy(seq []inst.Instruction, order []int) []inst.Instruction {
+	out := make([]inst.Instruction, len(order))
+	for k, i := range order {
+		out[k] = seq[i]
+	}
+	return out
+}
@@ -0,0 +1,58 @@
+package search
+
+import (
+	"testing"
+
+	"github.com/oisee/z80-optimizer/pkg/inst"
+)
+
+func TestBuildDependencyDAG_IndependentOpsHaveNoEdge(t *testing.T) {
+	seq := []inst.Instruction{{Op: inst.LD_B_N, Imm: 1}, {Op: inst.LD_C_N, Imm: 2}}
+	deps := buildDependencyDAG(seq)
+	if len(deps[1]) != 0 {
+		t.Fatalf("LD C,N shouldn't depend on LD B,N (disjoint registers), got %v", deps[1])
+	}
+}
+
+func TestBuildDependencyDAG_WAWOrdersSameRegister(t *testing.T) {
+	seq := []inst.Instruction{{Op: inst.LD_B_N, Imm: 1}, {Op: inst.LD_B_N, Imm: 2}}
+	deps := buildDependencyDAG(seq)
+	if len(deps[1]) != 1 || deps[1][0] != 0 {
+		t.Fatalf("second LD B,N should depend on the first (both write B), got %v", deps[1])
+	}
+}
+
+func TestBuildDependencyDAG_RAWOrdersReaderAfterWriter(t *testing.T) {
+	seq := []inst.Instruction{{Op: inst.LD_B_A}, {Op: inst.LD_C_B}}
+	deps := buildDependencyDAG(seq)
+	if len(deps[1]) != 1 || deps[1][0] != 0 {
+		t.Fatalf("LD C,B should depend on LD B,A (reads the register it just wrote), got %v", deps[1])
+	}
+}
+
+func TestSearchReorder_RejectsControlFlow(t *testing.T) {
+	seq := []inst.Instruction{{Op: inst.LD_B_N, Imm: 1}, {Op: inst.JP, Imm: 0x1234}}
+	if rules := SearchReorder(seq, ReorderConfig{}); rules != nil {
+		t.Fatalf("SearchReorder should refuse a window containing a branch, got %v", rules)
+	}
+}
+
+func TestSearchReorder_RejectsWindowAboveMaxWindow(t *testing.T) {
+	seq := make([]inst.Instruction, 3)
+	for i := range seq {
+		seq[i] = inst.Instruction{Op: inst.NOP}
+	}
+	if rules := SearchReorder(seq, ReorderConfig{MaxWindow: 2}); rules != nil {
+		t.Fatalf("SearchReorder should refuse a window longer than MaxWindow, got %v", rules)
+	}
+}
+
+// TestSearchReorder_NoImprovementUnderFlatCostModel documents the current,
+// honest behavior: inst.TStates has no contention model, so no permutation
+// of independent instructions is ever cheaper than target's own order.
+func TestSearchReorder_NoImprovementUnderFlatCostModel(t *testing.T) {
+	seq := []inst.Instruction{{Op: inst.LD_B_N, Imm: 1}, {Op: inst.LD_C_N, Imm: 2}, {Op: inst.LD_D_N, Imm: 3}}
+	if rules := SearchReorder(seq, ReorderConfig{}); len(rules) != 0 {
+		t.Fatalf("SearchReorder found %d rules against a flat per-opcode cost model, want 0", len(rules))
+	}
+}
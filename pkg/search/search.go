@@ -15,6 +15,19 @@ type Config struct {
 	MaxCandLen   int  // Maximum candidate length (defaults to MaxTargetLen-1)
 	NumWorkers   int  // Number of parallel workers (defaults to NumCPU)
 	Verbose      bool // Print progress
+
+	DeadFlags FlagMask // If nonzero, also accept replacements that only match once these flag bits are ignored
+	DeadRegs  RegMask  // chunk5-1: if nonzero, also accept replacements that only match once these registers are ignored
+
+	// Checkpoint, if set, resumes collectTasks' target list for the first
+	// targetLen from wherever a prior run left off, and periodically saves
+	// progress back to it (chunk6-1). Nil runs exactly as before.
+	Checkpoint CheckpointStore
+
+	// OnRule, if set, is called synchronously each time a new rule is
+	// added to the result table — e.g. streaming rules to a JSONL file as
+	// they're found (chunk7-3), rather than only once Run returns.
+	OnRule func(result.Rule)
 }
 
 // Run executes the superoptimizer search.
@@ -28,19 +41,36 @@ func Run(cfg Config) *result.Table {
 	}
 
 	pool := NewWorkerPool(cfg.NumWorkers)
+	if cfg.OnRule != nil {
+		pool.Results.SetOnRule(cfg.OnRule)
+	}
 	startTime := time.Now()
 
-	for targetLen := 2; targetLen <= cfg.MaxTargetLen; targetLen++ {
+	startLen := 2
+	resumeTasks := false
+	if cfg.Checkpoint != nil {
+		if cursor, ok, err := cfg.Checkpoint.Load(); err == nil && ok && cursor.TargetLen >= startLen {
+			startLen = cursor.TargetLen
+			resumeTasks = true // only the in-progress length resumes a TaskIndex; later lengths start at 0
+		}
+	}
+
+	for targetLen := startLen; targetLen <= cfg.MaxTargetLen; targetLen++ {
 		if cfg.Verbose {
 			fmt.Printf("=== Searching target length %d ===\n", targetLen)
 		}
 
-		tasks := collectTasks(targetLen, cfg.MaxCandLen)
+		tasks := collectTasks(targetLen, cfg.MaxCandLen, cfg.DeadFlags, cfg.DeadRegs)
 		if cfg.Verbose {
 			fmt.Printf("  Generated %d target sequences (after pruning)\n", len(tasks))
 		}
 
-		pool.RunTasks(tasks, cfg.Verbose)
+		if cfg.Checkpoint != nil {
+			pool.RunTasksResumable(tasks, cfg.Verbose, targetLenCheckpoint{store: cfg.Checkpoint, targetLen: targetLen, resume: resumeTasks})
+			resumeTasks = false // only the first (possibly in-progress) length gets a TaskIndex to resume from
+		} else {
+			pool.RunTasks(tasks, cfg.Verbose)
+		}
 
 		checked, found := pool.Stats()
 		if cfg.Verbose {
@@ -55,7 +85,7 @@ func Run(cfg Config) *result.Table {
 // collectTasks generates all non-prunable target sequences of the given length.
 // Uses 8-bit-only enumeration for targets to keep the search space feasible.
 // 16-bit immediate ops are still considered as candidate replacements.
-func collectTasks(targetLen, maxCandLen int) []SearchTask {
+func collectTasks(targetLen, maxCandLen int, deadFlags FlagMask, deadRegs RegMask) []SearchTask {
 	var tasks []SearchTask
 
 	EnumerateSequences8(targetLen, func(seq []inst.Instruction) bool {
@@ -69,6 +99,8 @@ func collectTasks(targetLen, maxCandLen int) []SearchTask {
 		tasks = append(tasks, SearchTask{
 			Target:     seqCopy,
 			MaxCandLen: maxCandLen,
+			DeadFlags:  deadFlags,
+			DeadRegs:   deadRegs,
 		})
 		return true
 	})
@@ -78,10 +110,19 @@ func collectTasks(targetLen, maxCandLen int) []SearchTask {
 
 // SearchSingle finds the shortest replacement for a specific target sequence.
 func SearchSingle(target []inst.Instruction, maxCandLen int, verbose bool) *result.Rule {
+	return SearchSingleLiveOut(target, maxCandLen, DeadNone, DeadRegNone, verbose)
+}
+
+// SearchSingleLiveOut is SearchSingle, but also accepting replacements that
+// only match target once deadFlags/deadRegs are ignored — e.g. passing
+// DeadAll finds "LD A, 0 -> XOR A" (flags dead) where SearchSingle can't.
+func SearchSingleLiveOut(target []inst.Instruction, maxCandLen int, deadFlags FlagMask, deadRegs RegMask, verbose bool) *result.Rule {
 	pool := NewWorkerPool(1)
 	pool.RunTasks([]SearchTask{{
 		Target:     target,
 		MaxCandLen: maxCandLen,
+		DeadFlags:  deadFlags,
+		DeadRegs:   deadRegs,
 	}}, verbose)
 
 	rules := pool.Results.Rules()
@@ -1,9 +1,11 @@
 package search
 
 import (
+	"sync"
 	"testing"
 
 	"github.com/oisee/z80-optimizer/pkg/inst"
+	"github.com/oisee/z80-optimizer/pkg/result"
 )
 
 // TestKnownOptimizations verifies the superoptimizer finds known Z80 optimizations.
@@ -71,6 +73,49 @@ func TestKnownOptimizations(t *testing.T) {
 	}
 }
 
+// TestSearchSingleLiveOutFindsDeadFlagRewrite verifies that, with flags
+// declared dead, the search finds rewrites SearchSingle rejects — the
+// "LD A, 0 != XOR A (flags differ)" case from TestKnownOptimizations above,
+// found once F is excluded from the live-out set (chunk5-1).
+func TestSearchSingleLiveOutFindsDeadFlagRewrite(t *testing.T) {
+	target := []inst.Instruction{{Op: inst.LD_A_N, Imm: 0x00}}
+
+	if rule := SearchSingle(target, 1, false); rule != nil {
+		t.Fatalf("SearchSingle should not find a full match, got: %s", testDisasmSeq(rule.Replacement))
+	}
+
+	rule := SearchSingleLiveOut(target, 1, DeadAll, DeadRegNone, false)
+	if rule == nil {
+		t.Fatal("expected a dead-flags optimization, got nil")
+	}
+	if rule.BytesSaved != 1 {
+		t.Errorf("bytes saved: got %d want 1", rule.BytesSaved)
+	}
+	if rule.DeadFlags == 0 {
+		t.Error("expected a nonzero DeadFlags on the returned rule")
+	}
+}
+
+// TestSearchSingleLiveOutFindsDeadRegRewrite verifies that declaring a
+// register dead lets the search find a rewrite that clobbers it (chunk5-1).
+func TestSearchSingleLiveOutFindsDeadRegRewrite(t *testing.T) {
+	// LD B, 0 with B declared dead: a 1-byte candidate that agrees on every
+	// other register and on F, but leaves B different, is accepted.
+	target := []inst.Instruction{{Op: inst.LD_B_N, Imm: 0x00}}
+
+	if rule := SearchSingle(target, 1, false); rule != nil {
+		t.Fatalf("SearchSingle should not find a full match, got: %s", testDisasmSeq(rule.Replacement))
+	}
+
+	rule := SearchSingleLiveOut(target, 1, DeadNone, DeadRegB, false)
+	if rule == nil {
+		t.Fatal("expected a dead-register optimization, got nil")
+	}
+	if rule.DeadRegs&DeadRegB == 0 {
+		t.Errorf("expected DeadRegs to include DeadRegB, got 0x%04X", rule.DeadRegs)
+	}
+}
+
 // TestQuickCheck verifies the quick check catches equivalences.
 func TestQuickCheck(t *testing.T) {
 	// XOR A is equivalent to LD A, 0 on outputs (but flags differ!)
@@ -286,6 +331,46 @@ func TestSearchImmediateSubset(t *testing.T) {
 	}
 }
 
+// TestRunOnRuleFiresForEveryRuleFound verifies Run's OnRule hook (chunk7-3)
+// streams exactly the same rules Run's returned table ends up holding.
+//
+// This drives WorkerPool directly (the same plumbing Run wires cfg.OnRule
+// into) over a hand-picked, bounded target list instead of calling Run
+// itself: Run's collectTasks enumerates targets over the *entire* 8-bit
+// opcode catalog for the given length (EnumerateSequences8), which even at
+// MaxTargetLen=2 is combinatorial over hundreds of opcodes and never
+// finishes in test time — TestSearchImmediateSubset takes the same
+// bounded-subset approach for the same reason.
+func TestRunOnRuleFiresForEveryRuleFound(t *testing.T) {
+	var mu sync.Mutex
+	var streamed []result.Rule
+
+	targets := [][]inst.Instruction{
+		{{Op: inst.SUB_A}, {Op: inst.LD_A_N, Imm: 0}},
+		{{Op: inst.AND_A}, {Op: inst.AND_A}},
+		{{Op: inst.OR_A}, {Op: inst.OR_A}},
+	}
+	tasks := make([]SearchTask, len(targets))
+	for i, target := range targets {
+		tasks[i] = SearchTask{Target: target, MaxCandLen: len(target) - 1}
+	}
+
+	pool := NewWorkerPool(1)
+	pool.Results.SetOnRule(func(r result.Rule) {
+		mu.Lock()
+		streamed = append(streamed, r)
+		mu.Unlock()
+	})
+	pool.RunTasks(tasks, false)
+
+	if got, want := len(streamed), pool.Results.Len(); got != want {
+		t.Fatalf("OnRule fired %d times, but table holds %d rules", got, want)
+	}
+	if len(streamed) == 0 {
+		t.Fatal("expected at least one rule to stream")
+	}
+}
+
 func testDisasmSeq(seq []inst.Instruction) string {
 	s := ""
 	for i, instr := range seq {
@@ -0,0 +1,139 @@
+package search
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+
+	"github.com/oisee/z80-optimizer/pkg/inst"
+)
+
+// CursorState is how far a checkpointed search has gotten, so a restart can
+// skip whatever's already been accounted for instead of starting over.
+// FirstOpRank is EnumerateShard's own cursor (ranks below it, within this
+// shard, are done); TaskIndex is WorkerPool.RunTasksResumable's (see its
+// doc for why it's a count rather than a precise completed-prefix index
+// under concurrent execution).
+type CursorState struct {
+	FirstOpRank int
+	TaskIndex   int
+	TargetLen   int // Run's own cursor: target lengths below this are fully done
+	Checked     int64
+	Found       int64
+}
+
+// SearchShard partitions a search space deterministically across NumShards
+// workers by rank (rank % NumShards == ShardID) over EnumerateFirstOp's
+// ordering, and carries whatever CursorState a prior run of this shard
+// already reached.
+type SearchShard struct {
+	ShardID   int
+	NumShards int
+	Resume    CursorState
+}
+
+// CheckpointStore persists a CursorState between runs — a file on disk, a
+// kv row, whatever the caller has available — so a multi-day search can be
+// preempted and resumed without replaying finished work.
+type CheckpointStore interface {
+	Save(CursorState) error
+	Load() (state CursorState, ok bool, err error)
+}
+
+// FileCheckpointStore is a CheckpointStore backed by a single JSON file —
+// the default callers reach for, mirroring pkg/result's own file-based
+// Checkpoint envelope.
+type FileCheckpointStore struct {
+	Path string
+}
+
+// Save writes state to the store's file, overwriting whatever was there.
+func (s FileCheckpointStore) Save(state CursorState) error {
+	f, err := os.Create(s.Path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return json.NewEncoder(f).Encode(state)
+}
+
+// Load reads the store's file. A missing file is not an error — it means
+// there's nothing to resume from yet, so ok is false.
+func (s FileCheckpointStore) Load() (CursorState, bool, error) {
+	data, err := os.ReadFile(s.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return CursorState{}, false, nil
+	}
+	if err != nil {
+		return CursorState{}, false, err
+	}
+	var state CursorState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return CursorState{}, false, err
+	}
+	return state, true, nil
+}
+
+// targetLenCheckpoint adapts a caller's CheckpointStore to Run's own
+// per-targetLen loop: Save always stamps the current targetLen so a
+// restart knows which lengths are already fully done, and Load only
+// surfaces a TaskIndex to resume from when resume is true — i.e. only for
+// whichever targetLen was actually in progress when the store was last
+// saved, never for a later length starting fresh.
+type targetLenCheckpoint struct {
+	store     CheckpointStore
+	targetLen int
+	resume    bool
+}
+
+func (c targetLenCheckpoint) Save(state CursorState) error {
+	state.TargetLen = c.targetLen
+	return c.store.Save(state)
+}
+
+func (c targetLenCheckpoint) Load() (CursorState, bool, error) {
+	if !c.resume {
+		return CursorState{}, false, nil
+	}
+	return c.store.Load()
+}
+
+// EnumerateShard is EnumerateSequences (full candidate space, including
+// 16-bit immediates) restricted to the deterministic slice of
+// first-instruction prefixes shard owns, resuming at shard.Resume.FirstOpRank
+// instead of rank 0. fn's seq argument and return convention match
+// EnumerateSequences.
+//
+// This is prefix-granularity resume, not mid-subtree resume: enumerateRec
+// has no callback below the leaf level (see bound.go's ShouldPruneBound
+// doc for the same constraint), so the smallest unit of checkpointed
+// progress on offer is "this first-instruction's whole subtree is done,
+// move to the next one this shard owns." For the multi-day, many-millions-
+// of-prefixes searches this targets, that bounds replayed work on restart
+// to at most one prefix's subtree.
+func EnumerateShard(shard SearchShard, n int, fn func(seq []inst.Instruction) bool) {
+	if shard.NumShards <= 0 {
+		shard.NumShards = 1
+	}
+	if n == 0 {
+		return
+	}
+
+	nonImm := inst.NonImmediateOps()
+	imm8Ops := inst.ImmediateOps()
+	imm16Ops := inst.Imm16Ops()
+	seq := make([]inst.Instruction, n)
+
+	for rank, first := range EnumerateFirstOp() {
+		if rank%shard.NumShards != shard.ShardID {
+			continue
+		}
+		if rank < shard.Resume.FirstOpRank {
+			continue
+		}
+		seq[0] = first
+		if !enumerateRec(seq, 1, nonImm, imm8Ops, imm16Ops, fn) {
+			return
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package search
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/oisee/z80-optimizer/pkg/inst"
+)
+
+func TestEnumerateShardPartitionsDeterministically(t *testing.T) {
+	const numShards = 4
+	seen := map[string]int{}
+
+	for id := 0; id < numShards; id++ {
+		shard := SearchShard{ShardID: id, NumShards: numShards}
+		EnumerateShard(shard, 1, func(seq []inst.Instruction) bool {
+			key := inst.Disassemble(seq[0])
+			seen[key]++
+			return true
+		})
+	}
+
+	if len(seen) != len(EnumerateFirstOp()) {
+		t.Fatalf("shards covered %d distinct instructions, want %d", len(seen), len(EnumerateFirstOp()))
+	}
+	for key, count := range seen {
+		if count != 1 {
+			t.Errorf("instruction %s seen %d times across shards, want exactly 1", key, count)
+		}
+	}
+}
+
+func TestEnumerateShardResumesAtFirstOpRank(t *testing.T) {
+	full := EnumerateFirstOp()
+	resumeRank := len(full) / 2
+
+	var got []inst.Instruction
+	shard := SearchShard{ShardID: 0, NumShards: 1, Resume: CursorState{FirstOpRank: resumeRank}}
+	EnumerateShard(shard, 1, func(seq []inst.Instruction) bool {
+		got = append(got, seq[0])
+		return true
+	})
+
+	if len(got) != len(full)-resumeRank {
+		t.Fatalf("resumed enumeration yielded %d instructions, want %d", len(got), len(full)-resumeRank)
+	}
+	if got[0] != full[resumeRank] {
+		t.Errorf("first resumed instruction = %v, want %v", got[0], full[resumeRank])
+	}
+}
+
+func TestFileCheckpointStoreRoundTrips(t *testing.T) {
+	store := FileCheckpointStore{Path: filepath.Join(t.TempDir(), "cursor.json")}
+
+	if _, ok, err := store.Load(); err != nil || ok {
+		t.Fatalf("Load() on a missing file = (ok=%v, err=%v), want (false, nil)", ok, err)
+	}
+
+	want := CursorState{FirstOpRank: 12, TaskIndex: 34, Checked: 56, Found: 7}
+	if err := store.Save(want); err != nil {
+		t.Fatalf("Save() failed: %v", err)
+	}
+	got, ok, err := store.Load()
+	if err != nil || !ok {
+		t.Fatalf("Load() after Save() = (ok=%v, err=%v), want (true, nil)", ok, err)
+	}
+	if got != want {
+		t.Errorf("Load() = %+v, want %+v", got, want)
+	}
+}
+
+func TestRunTasksResumableSkipsCheckpointedPrefix(t *testing.T) {
+	store := FileCheckpointStore{Path: filepath.Join(t.TempDir(), "cursor.json")}
+	if err := store.Save(CursorState{TaskIndex: 1, Checked: 10, Found: 2}); err != nil {
+		t.Fatalf("Save() failed: %v", err)
+	}
+
+	tasks := []SearchTask{
+		{Target: []inst.Instruction{{Op: inst.LD_A_B}}, MaxCandLen: 1},
+		{Target: []inst.Instruction{{Op: inst.LD_A_C}}, MaxCandLen: 1},
+	}
+
+	pool := NewWorkerPool(1)
+	pool.RunTasksResumable(tasks, false, store)
+
+	checked, found := pool.Stats()
+	if checked < 10 {
+		t.Errorf("checked = %d, want at least the 10 restored from the checkpoint", checked)
+	}
+	if found < 2 {
+		t.Errorf("found = %d, want at least the 2 restored from the checkpoint", found)
+	}
+
+	final, ok, err := store.Load()
+	if err != nil || !ok {
+		t.Fatalf("final Load() = (ok=%v, err=%v), want (true, nil)", ok, err)
+	}
+	if final.TaskIndex != len(tasks) {
+		t.Errorf("final TaskIndex = %d, want %d", final.TaskIndex, len(tasks))
+	}
+}
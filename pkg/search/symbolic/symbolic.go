@@ -0,0 +1,690 @@
+// Package symbolic provides an SMT-backed equivalence check for sequences
+// exhaustiveReducedSweep can't cover soundly: once 3+ extra 8-bit registers
+// are read alongside SP/IX/IY, 32 representative values per register is a
+// sample, not a proof, and can miss a carry/half-carry corner case that only
+// shows up for one specific bit pattern. SymbolicEquiv instead lowers both
+// sequences to an SMT-LIB2 formula over bitvectors and asks Z3 whether any
+// input makes them disagree.
+//
+// The lowering only understands the "pure ALU" subset of inst.OpCode: 8-bit
+// ADD/ADC/SUB/SBC/AND/OR/XOR/CP (register and immediate forms), INC/DEC, LD
+// r,r', and NOP — exactly the instruction class where carry propagation
+// through a long register chain is the thing the reduced sweep can miss.
+// Rotates, 16-bit ops, DAA/NEG, and anything touching memory or I/O don't
+// chain carries the same way and the sweep already checks them soundly, so
+// SymbolicEquiv reports them as unsupported rather than pretending to model
+// them; callers fall back to the sweep in that case.
+package symbolic
+
+import (
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/oisee/z80-optimizer/pkg/cpu"
+	"github.com/oisee/z80-optimizer/pkg/inst"
+	"github.com/oisee/z80-optimizer/pkg/search"
+)
+
+// Z3Path is the path to the z3 binary. Override it (e.g. in tests) if z3
+// isn't on PATH under that name.
+var Z3Path = "z3"
+
+// Counterexample is a concrete input on which target and candidate disagree,
+// decoded from Z3's model. Vector can be appended straight to
+// search.TestVectors so QuickCheck starts rejecting this candidate (and
+// anything else that fails the same way) without another SMT call.
+type Counterexample struct {
+	Vector       cpu.State
+	TargetOut    cpu.State
+	CandidateOut cpu.State
+}
+
+// unsupportedOpError marks an instruction this package doesn't lower to SMT.
+// ExhaustiveCheck's sweep already handles that instruction class soundly, so
+// Equiv falls back to it rather than failing outright.
+type unsupportedOpError struct{ op inst.OpCode }
+
+func (e *unsupportedOpError) Error() string {
+	return fmt.Sprintf("symbolic: %s has no SMT lowering", inst.Table[e.op].Mnemonic)
+}
+
+// z3UnavailableError means Z3Path isn't on PATH.
+type z3UnavailableError struct{ err error }
+
+func (e *z3UnavailableError) Error() string {
+	return fmt.Sprintf("symbolic: z3 unavailable: %v", e.err)
+}
+func (e *z3UnavailableError) Unwrap() error { return e.err }
+
+// Equiv picks SymbolicEquiv over search.ExhaustiveCheckMasked when
+// search.NeedsSMT reports the reduced sweep would be unsound for this pair,
+// falling back to the sweep if the sequences use an opcode SymbolicEquiv
+// doesn't lower, or if Z3 isn't installed.
+func Equiv(target, candidate []inst.Instruction, deadFlags search.FlagMask) bool {
+	if !search.NeedsSMT(target, candidate) {
+		return search.ExhaustiveCheckMasked(target, candidate, deadFlags)
+	}
+	ok, _, err := SymbolicEquiv(target, candidate, deadFlags)
+	if err != nil {
+		return search.ExhaustiveCheckMasked(target, candidate, deadFlags)
+	}
+	return ok
+}
+
+// SymbolicEquiv checks whether target and candidate produce identical A, F
+// (modulo deadFlags), B, C, D, E, H, L for every possible input, by asking
+// Z3 to find a counterexample. It returns an *unsupportedOpError wrapped
+// error if either sequence contains an instruction this package can't lower,
+// and a *z3UnavailableError if Z3Path isn't on PATH.
+func SymbolicEquiv(target, candidate []inst.Instruction, deadFlags search.FlagMask) (bool, *Counterexample, error) {
+	if _, err := exec.LookPath(Z3Path); err != nil {
+		return false, nil, &z3UnavailableError{err}
+	}
+
+	init := regExprs{A: "A0", F: "F0", B: "B0", C: "C0", D: "D0", E: "E0", H: "H0", L: "L0"}
+
+	tFinal, err := lowerSeq(init, target)
+	if err != nil {
+		return false, nil, err
+	}
+	cFinal, err := lowerSeq(init, candidate)
+	if err != nil {
+		return false, nil, err
+	}
+
+	script := buildScript(tFinal, cFinal, deadFlags)
+
+	out, err := runZ3(script)
+	if err != nil {
+		return false, nil, fmt.Errorf("symbolic: running z3: %w", err)
+	}
+
+	switch {
+	case strings.HasPrefix(out, "unsat"):
+		return true, nil, nil
+	case strings.HasPrefix(out, "sat"):
+		cex, err := decodeCounterexample(out, target, candidate)
+		if err != nil {
+			return false, nil, fmt.Errorf("symbolic: decoding model: %w", err)
+		}
+		return false, cex, nil
+	default:
+		return false, nil, fmt.Errorf("symbolic: z3 returned %q", strings.TrimSpace(out))
+	}
+}
+
+func runZ3(script string) (string, error) {
+	cmd := exec.Command(Z3Path, "-in")
+	cmd.Stdin = strings.NewReader(script)
+	out, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+// inputVars are the free 8-bit inputs every lowered sequence starts from.
+var inputVars = []string{"A0", "F0", "B0", "C0", "D0", "E0", "H0", "L0"}
+
+func buildScript(t, c regExprs, deadFlags search.FlagMask) string {
+	var b strings.Builder
+	b.WriteString("(set-logic QF_BV)\n")
+	for _, v := range inputVars {
+		fmt.Fprintf(&b, "(declare-const %s (_ BitVec 8))\n", v)
+	}
+
+	liveMask := ^deadFlags // bits that must match for equivalence
+	fmt.Fprintf(&b, "(assert (not (and (= %s %s) (= (bvand %s #x%02X) (bvand %s #x%02X)) (= %s %s) (= %s %s) (= %s %s) (= %s %s) (= %s %s) (= %s %s))))\n",
+		t.A, c.A,
+		t.F, liveMask, c.F, liveMask,
+		t.B, c.B, t.C, c.C, t.D, c.D, t.E, c.E, t.H, c.H, t.L, c.L)
+
+	b.WriteString("(check-sat)\n(get-model)\n")
+	return b.String()
+}
+
+var modelVarRe = map[string]*regexp.Regexp{}
+
+func modelValue(model, name string) (uint8, bool) {
+	re := modelVarRe[name]
+	if re == nil {
+		re = regexp.MustCompile(`define-fun ` + regexp.QuoteMeta(name) + ` \(\) \(_ BitVec 8\)\s*\n?\s*(#x[0-9a-fA-F]{2}|#b[01]{8})`)
+		modelVarRe[name] = re
+	}
+	m := re.FindStringSubmatch(model)
+	if m == nil {
+		return 0, false
+	}
+	lit := m[1]
+	var base int
+	var digits string
+	if strings.HasPrefix(lit, "#x") {
+		base, digits = 16, lit[2:]
+	} else {
+		base, digits = 2, lit[2:]
+	}
+	v, err := strconv.ParseUint(digits, base, 8)
+	if err != nil {
+		return 0, false
+	}
+	return uint8(v), true
+}
+
+// decodeCounterexample reads the free input variables out of Z3's model,
+// builds the concrete cpu.State they describe, and re-runs both sequences
+// on it with cpu.Exec directly — simpler and more robust than evaluating
+// the output terms symbolically, and it reuses the real interpreter so the
+// counterexample is exactly what QuickCheck would see.
+func decodeCounterexample(model string, target, candidate []inst.Instruction) (*Counterexample, error) {
+	vals := make(map[string]uint8, len(inputVars))
+	for _, v := range inputVars {
+		val, ok := modelValue(model, v)
+		if !ok {
+			return nil, fmt.Errorf("no assignment for %s in model", v)
+		}
+		vals[v] = val
+	}
+
+	in := cpu.State{A: vals["A0"], F: vals["F0"], B: vals["B0"], C: vals["C0"],
+		D: vals["D0"], E: vals["E0"], H: vals["H0"], L: vals["L0"]}
+
+	tOut := in
+	for _, instr := range target {
+		cpu.Exec(&tOut, instr.Op, instr.Imm)
+	}
+	cOut := in
+	for _, instr := range candidate {
+		cpu.Exec(&cOut, instr.Op, instr.Imm)
+	}
+
+	return &Counterexample{Vector: in, TargetOut: tOut, CandidateOut: cOut}, nil
+}
+
+// regExprs holds the current SMT-LIB2 term for each 8-bit register as the
+// sequence is lowered instruction by instruction. Terms nest (each op wraps
+// the previous one) rather than introducing fresh let-bound names, which is
+// fine at the short sequence lengths (2-4 instructions) the search explores.
+type regExprs struct {
+	A, F, B, C, D, E, H, L string
+}
+
+func (r regExprs) get(reg byte) string {
+	switch reg {
+	case 'A':
+		return r.A
+	case 'B':
+		return r.B
+	case 'C':
+		return r.C
+	case 'D':
+		return r.D
+	case 'E':
+		return r.E
+	case 'H':
+		return r.H
+	case 'L':
+		return r.L
+	}
+	return ""
+}
+
+func (r regExprs) set(reg byte, term string) regExprs {
+	switch reg {
+	case 'A':
+		r.A = term
+	case 'B':
+		r.B = term
+	case 'C':
+		r.C = term
+	case 'D':
+		r.D = term
+	case 'E':
+		r.E = term
+	case 'H':
+		r.H = term
+	case 'L':
+		r.L = term
+	}
+	return r
+}
+
+func lowerSeq(init regExprs, seq []inst.Instruction) (regExprs, error) {
+	cur := init
+	for _, instr := range seq {
+		next, err := lowerOp(instr.Op, instr.Imm, cur)
+		if err != nil {
+			return regExprs{}, err
+		}
+		cur = next
+	}
+	return cur, nil
+}
+
+type aluFamily int
+
+const (
+	famAdd aluFamily = iota
+	famAdc
+	famSub
+	famSbc
+	famAnd
+	famOr
+	famXor
+	famCp
+)
+
+type opKind int
+
+const (
+	kindALU opKind = iota
+	kindInc
+	kindDec
+	kindLd
+	kindNop
+)
+
+type opInfo struct {
+	kind   opKind
+	family aluFamily
+	dstReg byte
+	srcReg byte // 0 when imm is true
+	imm    bool
+}
+
+// classify maps an opcode to the shape lowerOp needs: which family of
+// transformer it is, which register(s) it touches, and whether its source
+// operand is a register or the instruction's own immediate byte.
+func classify(op inst.OpCode) (opInfo, bool) {
+	switch op {
+	// INC/DEC on 8-bit registers
+	case inst.INC_A:
+		return opInfo{kind: kindInc, dstReg: 'A'}, true
+	case inst.INC_B:
+		return opInfo{kind: kindInc, dstReg: 'B'}, true
+	case inst.INC_C:
+		return opInfo{kind: kindInc, dstReg: 'C'}, true
+	case inst.INC_D:
+		return opInfo{kind: kindInc, dstReg: 'D'}, true
+	case inst.INC_E:
+		return opInfo{kind: kindInc, dstReg: 'E'}, true
+	case inst.INC_H:
+		return opInfo{kind: kindInc, dstReg: 'H'}, true
+	case inst.INC_L:
+		return opInfo{kind: kindInc, dstReg: 'L'}, true
+	case inst.DEC_A:
+		return opInfo{kind: kindDec, dstReg: 'A'}, true
+	case inst.DEC_B:
+		return opInfo{kind: kindDec, dstReg: 'B'}, true
+	case inst.DEC_C:
+		return opInfo{kind: kindDec, dstReg: 'C'}, true
+	case inst.DEC_D:
+		return opInfo{kind: kindDec, dstReg: 'D'}, true
+	case inst.DEC_E:
+		return opInfo{kind: kindDec, dstReg: 'E'}, true
+	case inst.DEC_H:
+		return opInfo{kind: kindDec, dstReg: 'H'}, true
+	case inst.DEC_L:
+		return opInfo{kind: kindDec, dstReg: 'L'}, true
+
+	// LD r,r' (pure copy, flags untouched; same-register forms are a no-op)
+	case inst.LD_A_A:
+		return opInfo{kind: kindLd, dstReg: 'A', srcReg: 'A'}, true
+	case inst.LD_A_B:
+		return opInfo{kind: kindLd, dstReg: 'A', srcReg: 'B'}, true
+	case inst.LD_A_C:
+		return opInfo{kind: kindLd, dstReg: 'A', srcReg: 'C'}, true
+	case inst.LD_A_D:
+		return opInfo{kind: kindLd, dstReg: 'A', srcReg: 'D'}, true
+	case inst.LD_A_E:
+		return opInfo{kind: kindLd, dstReg: 'A', srcReg: 'E'}, true
+	case inst.LD_A_H:
+		return opInfo{kind: kindLd, dstReg: 'A', srcReg: 'H'}, true
+	case inst.LD_A_L:
+		return opInfo{kind: kindLd, dstReg: 'A', srcReg: 'L'}, true
+	case inst.LD_B_A:
+		return opInfo{kind: kindLd, dstReg: 'B', srcReg: 'A'}, true
+	case inst.LD_B_B:
+		return opInfo{kind: kindLd, dstReg: 'B', srcReg: 'B'}, true
+	case inst.LD_B_C:
+		return opInfo{kind: kindLd, dstReg: 'B', srcReg: 'C'}, true
+	case inst.LD_B_D:
+		return opInfo{kind: kindLd, dstReg: 'B', srcReg: 'D'}, true
+	case inst.LD_B_E:
+		return opInfo{kind: kindLd, dstReg: 'B', srcReg: 'E'}, true
+	case inst.LD_B_H:
+		return opInfo{kind: kindLd, dstReg: 'B', srcReg: 'H'}, true
+	case inst.LD_B_L:
+		return opInfo{kind: kindLd, dstReg: 'B', srcReg: 'L'}, true
+	case inst.LD_C_A:
+		return opInfo{kind: kindLd, dstReg: 'C', srcReg: 'A'}, true
+	case inst.LD_C_B:
+		return opInfo{kind: kindLd, dstReg: 'C', srcReg: 'B'}, true
+	case inst.LD_C_C:
+		return opInfo{kind: kindLd, dstReg: 'C', srcReg: 'C'}, true
+	case inst.LD_C_D:
+		return opInfo{kind: kindLd, dstReg: 'C', srcReg: 'D'}, true
+	case inst.LD_C_E:
+		return opInfo{kind: kindLd, dstReg: 'C', srcReg: 'E'}, true
+	case inst.LD_C_H:
+		return opInfo{kind: kindLd, dstReg: 'C', srcReg: 'H'}, true
+	case inst.LD_C_L:
+		return opInfo{kind: kindLd, dstReg: 'C', srcReg: 'L'}, true
+	case inst.LD_D_A:
+		return opInfo{kind: kindLd, dstReg: 'D', srcReg: 'A'}, true
+	case inst.LD_D_B:
+		return opInfo{kind: kindLd, dstReg: 'D', srcReg: 'B'}, true
+	case inst.LD_D_C:
+		return opInfo{kind: kindLd, dstReg: 'D', srcReg: 'C'}, true
+	case inst.LD_D_D:
+		return opInfo{kind: kindLd, dstReg: 'D', srcReg: 'D'}, true
+	case inst.LD_D_E:
+		return opInfo{kind: kindLd, dstReg: 'D', srcReg: 'E'}, true
+	case inst.LD_D_H:
+		return opInfo{kind: kindLd, dstReg: 'D', srcReg: 'H'}, true
+	case inst.LD_D_L:
+		return opInfo{kind: kindLd, dstReg: 'D', srcReg: 'L'}, true
+	case inst.LD_E_A:
+		return opInfo{kind: kindLd, dstReg: 'E', srcReg: 'A'}, true
+	case inst.LD_E_B:
+		return opInfo{kind: kindLd, dstReg: 'E', srcReg: 'B'}, true
+	case inst.LD_E_C:
+		return opInfo{kind: kindLd, dstReg: 'E', srcReg: 'C'}, true
+	case inst.LD_E_D:
+		return opInfo{kind: kindLd, dstReg: 'E', srcReg: 'D'}, true
+	case inst.LD_E_E:
+		return opInfo{kind: kindLd, dstReg: 'E', srcReg: 'E'}, true
+	case inst.LD_E_H:
+		return opInfo{kind: kindLd, dstReg: 'E', srcReg: 'H'}, true
+	case inst.LD_E_L:
+		return opInfo{kind: kindLd, dstReg: 'E', srcReg: 'L'}, true
+	case inst.LD_H_A:
+		return opInfo{kind: kindLd, dstReg: 'H', srcReg: 'A'}, true
+	case inst.LD_H_B:
+		return opInfo{kind: kindLd, dstReg: 'H', srcReg: 'B'}, true
+	case inst.LD_H_C:
+		return opInfo{kind: kindLd, dstReg: 'H', srcReg: 'C'}, true
+	case inst.LD_H_D:
+		return opInfo{kind: kindLd, dstReg: 'H', srcReg: 'D'}, true
+	case inst.LD_H_E:
+		return opInfo{kind: kindLd, dstReg: 'H', srcReg: 'E'}, true
+	case inst.LD_H_H:
+		return opInfo{kind: kindLd, dstReg: 'H', srcReg: 'H'}, true
+	case inst.LD_H_L:
+		return opInfo{kind: kindLd, dstReg: 'H', srcReg: 'L'}, true
+	case inst.LD_L_A:
+		return opInfo{kind: kindLd, dstReg: 'L', srcReg: 'A'}, true
+	case inst.LD_L_B:
+		return opInfo{kind: kindLd, dstReg: 'L', srcReg: 'B'}, true
+	case inst.LD_L_C:
+		return opInfo{kind: kindLd, dstReg: 'L', srcReg: 'C'}, true
+	case inst.LD_L_D:
+		return opInfo{kind: kindLd, dstReg: 'L', srcReg: 'D'}, true
+	case inst.LD_L_E:
+		return opInfo{kind: kindLd, dstReg: 'L', srcReg: 'E'}, true
+	case inst.LD_L_H:
+		return opInfo{kind: kindLd, dstReg: 'L', srcReg: 'H'}, true
+	case inst.LD_L_L:
+		return opInfo{kind: kindLd, dstReg: 'L', srcReg: 'L'}, true
+
+	// 8-bit ALU ops: A op r, and A op n
+	case inst.ADD_A_A:
+		return opInfo{kind: kindALU, family: famAdd, dstReg: 'A', srcReg: 'A'}, true
+	case inst.ADD_A_B:
+		return opInfo{kind: kindALU, family: famAdd, dstReg: 'A', srcReg: 'B'}, true
+	case inst.ADD_A_C:
+		return opInfo{kind: kindALU, family: famAdd, dstReg: 'A', srcReg: 'C'}, true
+	case inst.ADD_A_D:
+		return opInfo{kind: kindALU, family: famAdd, dstReg: 'A', srcReg: 'D'}, true
+	case inst.ADD_A_E:
+		return opInfo{kind: kindALU, family: famAdd, dstReg: 'A', srcReg: 'E'}, true
+	case inst.ADD_A_H:
+		return opInfo{kind: kindALU, family: famAdd, dstReg: 'A', srcReg: 'H'}, true
+	case inst.ADD_A_L:
+		return opInfo{kind: kindALU, family: famAdd, dstReg: 'A', srcReg: 'L'}, true
+	case inst.ADD_A_N:
+		return opInfo{kind: kindALU, family: famAdd, dstReg: 'A', imm: true}, true
+	case inst.ADC_A_A:
+		return opInfo{kind: kindALU, family: famAdc, dstReg: 'A', srcReg: 'A'}, true
+	case inst.ADC_A_B:
+		return opInfo{kind: kindALU, family: famAdc, dstReg: 'A', srcReg: 'B'}, true
+	case inst.ADC_A_C:
+		return opInfo{kind: kindALU, family: famAdc, dstReg: 'A', srcReg: 'C'}, true
+	case inst.ADC_A_D:
+		return opInfo{kind: kindALU, family: famAdc, dstReg: 'A', srcReg: 'D'}, true
+	case inst.ADC_A_E:
+		return opInfo{kind: kindALU, family: famAdc, dstReg: 'A', srcReg: 'E'}, true
+	case inst.ADC_A_H:
+		return opInfo{kind: kindALU, family: famAdc, dstReg: 'A', srcReg: 'H'}, true
+	case inst.ADC_A_L:
+		return opInfo{kind: kindALU, family: famAdc, dstReg: 'A', srcReg: 'L'}, true
+	case inst.ADC_A_N:
+		return opInfo{kind: kindALU, family: famAdc, dstReg: 'A', imm: true}, true
+	case inst.SUB_A:
+		return opInfo{kind: kindALU, family: famSub, dstReg: 'A', srcReg: 'A'}, true
+	case inst.SUB_B:
+		return opInfo{kind: kindALU, family: famSub, dstReg: 'A', srcReg: 'B'}, true
+	case inst.SUB_C:
+		return opInfo{kind: kindALU, family: famSub, dstReg: 'A', srcReg: 'C'}, true
+	case inst.SUB_D:
+		return opInfo{kind: kindALU, family: famSub, dstReg: 'A', srcReg: 'D'}, true
+	case inst.SUB_E:
+		return opInfo{kind: kindALU, family: famSub, dstReg: 'A', srcReg: 'E'}, true
+	case inst.SUB_H:
+		return opInfo{kind: kindALU, family: famSub, dstReg: 'A', srcReg: 'H'}, true
+	case inst.SUB_L:
+		return opInfo{kind: kindALU, family: famSub, dstReg: 'A', srcReg: 'L'}, true
+	case inst.SUB_N:
+		return opInfo{kind: kindALU, family: famSub, dstReg: 'A', imm: true}, true
+	case inst.SBC_A_A:
+		return opInfo{kind: kindALU, family: famSbc, dstReg: 'A', srcReg: 'A'}, true
+	case inst.SBC_A_B:
+		return opInfo{kind: kindALU, family: famSbc, dstReg: 'A', srcReg: 'B'}, true
+	case inst.SBC_A_C:
+		return opInfo{kind: kindALU, family: famSbc, dstReg: 'A', srcReg: 'C'}, true
+	case inst.SBC_A_D:
+		return opInfo{kind: kindALU, family: famSbc, dstReg: 'A', srcReg: 'D'}, true
+	case inst.SBC_A_E:
+		return opInfo{kind: kindALU, family: famSbc, dstReg: 'A', srcReg: 'E'}, true
+	case inst.SBC_A_H:
+		return opInfo{kind: kindALU, family: famSbc, dstReg: 'A', srcReg: 'H'}, true
+	case inst.SBC_A_L:
+		return opInfo{kind: kindALU, family: famSbc, dstReg: 'A', srcReg: 'L'}, true
+	case inst.SBC_A_N:
+		return opInfo{kind: kindALU, family: famSbc, dstReg: 'A', imm: true}, true
+	case inst.AND_A:
+		return opInfo{kind: kindALU, family: famAnd, dstReg: 'A', srcReg: 'A'}, true
+	case inst.AND_B:
+		return opInfo{kind: kindALU, family: famAnd, dstReg: 'A', srcReg: 'B'}, true
+	case inst.AND_C:
+		return opInfo{kind: kindALU, family: famAnd, dstReg: 'A', srcReg: 'C'}, true
+	case inst.AND_D:
+		return opInfo{kind: kindALU, family: famAnd, dstReg: 'A', srcReg: 'D'}, true
+	case inst.AND_E:
+		return opInfo{kind: kindALU, family: famAnd, dstReg: 'A', srcReg: 'E'}, true
+	case inst.AND_H:
+		return opInfo{kind: kindALU, family: famAnd, dstReg: 'A', srcReg: 'H'}, true
+	case inst.AND_L:
+		return opInfo{kind: kindALU, family: famAnd, dstReg: 'A', srcReg: 'L'}, true
+	case inst.AND_N:
+		return opInfo{kind: kindALU, family: famAnd, dstReg: 'A', imm: true}, true
+	case inst.OR_A:
+		return opInfo{kind: kindALU, family: famOr, dstReg: 'A', srcReg: 'A'}, true
+	case inst.OR_B:
+		return opInfo{kind: kindALU, family: famOr, dstReg: 'A', srcReg: 'B'}, true
+	case inst.OR_C:
+		return opInfo{kind: kindALU, family: famOr, dstReg: 'A', srcReg: 'C'}, true
+	case inst.OR_D:
+		return opInfo{kind: kindALU, family: famOr, dstReg: 'A', srcReg: 'D'}, true
+	case inst.OR_E:
+		return opInfo{kind: kindALU, family: famOr, dstReg: 'A', srcReg: 'E'}, true
+	case inst.OR_H:
+		return opInfo{kind: kindALU, family: famOr, dstReg: 'A', srcReg: 'H'}, true
+	case inst.OR_L:
+		return opInfo{kind: kindALU, family: famOr, dstReg: 'A', srcReg: 'L'}, true
+	case inst.OR_N:
+		return opInfo{kind: kindALU, family: famOr, dstReg: 'A', imm: true}, true
+	case inst.XOR_A:
+		return opInfo{kind: kindALU, family: famXor, dstReg: 'A', srcReg: 'A'}, true
+	case inst.XOR_B:
+		return opInfo{kind: kindALU, family: famXor, dstReg: 'A', srcReg: 'B'}, true
+	case inst.XOR_C:
+		return opInfo{kind: kindALU, family: famXor, dstReg: 'A', srcReg: 'C'}, true
+	case inst.XOR_D:
+		return opInfo{kind: kindALU, family: famXor, dstReg: 'A', srcReg: 'D'}, true
+	case inst.XOR_E:
+		return opInfo{kind: kindALU, family: famXor, dstReg: 'A', srcReg: 'E'}, true
+	case inst.XOR_H:
+		return opInfo{kind: kindALU, family: famXor, dstReg: 'A', srcReg: 'H'}, true
+	case inst.XOR_L:
+		return opInfo{kind: kindALU, family: famXor, dstReg: 'A', srcReg: 'L'}, true
+	case inst.XOR_N:
+		return opInfo{kind: kindALU, family: famXor, dstReg: 'A', imm: true}, true
+	case inst.CP_A:
+		return opInfo{kind: kindALU, family: famCp, dstReg: 'A', srcReg: 'A'}, true
+	case inst.CP_B:
+		return opInfo{kind: kindALU, family: famCp, dstReg: 'A', srcReg: 'B'}, true
+	case inst.CP_C:
+		return opInfo{kind: kindALU, family: famCp, dstReg: 'A', srcReg: 'C'}, true
+	case inst.CP_D:
+		return opInfo{kind: kindALU, family: famCp, dstReg: 'A', srcReg: 'D'}, true
+	case inst.CP_E:
+		return opInfo{kind: kindALU, family: famCp, dstReg: 'A', srcReg: 'E'}, true
+	case inst.CP_H:
+		return opInfo{kind: kindALU, family: famCp, dstReg: 'A', srcReg: 'H'}, true
+	case inst.CP_L:
+		return opInfo{kind: kindALU, family: famCp, dstReg: 'A', srcReg: 'L'}, true
+	case inst.CP_N:
+		return opInfo{kind: kindALU, family: famCp, dstReg: 'A', imm: true}, true
+	case inst.NOP:
+		return opInfo{kind: kindNop}, true
+	}
+	return opInfo{}, false
+}
+
+func lowerOp(op inst.OpCode, imm uint16, r regExprs) (regExprs, error) {
+	info, ok := classify(op)
+	if !ok {
+		return regExprs{}, &unsupportedOpError{op}
+	}
+
+	switch info.kind {
+	case kindNop:
+		return r, nil
+	case kindLd:
+		return r.set(info.dstReg, r.get(info.srcReg)), nil
+	case kindInc:
+		return lowerIncDec(r, info.dstReg, true), nil
+	case kindDec:
+		return lowerIncDec(r, info.dstReg, false), nil
+	case kindALU:
+		var src string
+		if info.imm {
+			src = fmt.Sprintf("#x%02X", uint8(imm))
+		} else {
+			src = r.get(info.srcReg)
+		}
+		return lowerALU(r, info.family, src), nil
+	}
+	return regExprs{}, &unsupportedOpError{op}
+}
+
+// bit7/bit5/bit3 of the result are the S/Y/X flags verbatim (same bit
+// positions), matching cpu.Sz53Table's `result & (Flag3|Flag5|FlagS)`.
+const flagsFromResultMask = 0xA8
+
+// flagTerm assembles F from a result term plus the carry/half-carry/
+// parity-overflow conditions and the static N bit for this family —
+// the same shape as cpu.execAdd/execSub's `s.F = ... | Sz53Table[...]`.
+func flagTerm(result, carryCond, halfCond, pvCond string, nSet bool) string {
+	nBits := "#x00"
+	if nSet {
+		nBits = "#x02"
+	}
+	return fmt.Sprintf(
+		"(bvor (bvand %s #x%02X) (bvor (ite (= %s #x00) #x40 #x00) (bvor (ite %s #x01 #x00) (bvor (ite %s #x10 #x00) (bvor (ite %s #x04 #x00) %s)))))",
+		result, flagsFromResultMask, result, carryCond, halfCond, pvCond, nBits)
+}
+
+func zeroExt8to9(term string) string {
+	return fmt.Sprintf("((_ zero_extend 1) %s)", term)
+}
+
+func lowerALU(r regExprs, fam aluFamily, src string) regExprs {
+	a := r.A
+	switch fam {
+	case famAdd, famAdc:
+		cin := "#b0"
+		if fam == famAdc {
+			cin = fmt.Sprintf("(ite (= (bvand %s #x01) #x01) #b1 #b0)", r.F)
+		}
+		wide := fmt.Sprintf("(bvadd (bvadd %s %s) ((_ zero_extend 8) %s))", zeroExt8to9(a), zeroExt8to9(src), cin)
+		result := fmt.Sprintf("((_ extract 7 0) %s)", wide)
+		carry := fmt.Sprintf("(= ((_ extract 8 8) %s) #b1)", wide)
+		halfWide := fmt.Sprintf("(bvadd (bvadd ((_ zero_extend 1) ((_ extract 3 0) %s)) ((_ zero_extend 1) ((_ extract 3 0) %s))) ((_ zero_extend 4) %s))", a, src, cin)
+		half := fmt.Sprintf("(= ((_ extract 4 4) %s) #b1)", halfWide)
+		pv := fmt.Sprintf("(and (= ((_ extract 7 7) %s) ((_ extract 7 7) %s)) (not (= ((_ extract 7 7) %s) ((_ extract 7 7) %s))))", a, src, a, result)
+		f := flagTerm(result, carry, half, pv, false)
+		return r.set('A', result).set('F', f)
+
+	case famSub, famSbc, famCp:
+		cin := "#b0"
+		if fam == famSbc {
+			cin = fmt.Sprintf("(ite (= (bvand %s #x01) #x01) #b1 #b0)", r.F)
+		}
+		wide := fmt.Sprintf("(bvsub (bvsub %s %s) ((_ zero_extend 8) %s))", zeroExt8to9(a), zeroExt8to9(src), cin)
+		result := fmt.Sprintf("((_ extract 7 0) %s)", wide)
+		borrow := fmt.Sprintf("(= ((_ extract 8 8) %s) #b1)", wide)
+		halfWide := fmt.Sprintf("(bvsub (bvsub ((_ zero_extend 1) ((_ extract 3 0) %s)) ((_ zero_extend 1) ((_ extract 3 0) %s))) ((_ zero_extend 4) %s))", a, src, cin)
+		half := fmt.Sprintf("(= ((_ extract 4 4) %s) #b1)", halfWide)
+		pv := fmt.Sprintf("(and (not (= ((_ extract 7 7) %s) ((_ extract 7 7) %s))) (not (= ((_ extract 7 7) %s) ((_ extract 7 7) %s))))", a, src, a, result)
+		f := flagTerm(result, borrow, half, pv, true)
+		if fam == famCp {
+			return r.set('F', f) // CP only updates flags, A is unchanged
+		}
+		return r.set('A', result).set('F', f)
+
+	case famAnd, famOr, famXor:
+		var result string
+		switch fam {
+		case famAnd:
+			result = fmt.Sprintf("(bvand %s %s)", a, src)
+		case famOr:
+			result = fmt.Sprintf("(bvor %s %s)", a, src)
+		default:
+			result = fmt.Sprintf("(bvxor %s %s)", a, src)
+		}
+		parity := fmt.Sprintf("(= (bvxor (bvxor (bvxor ((_ extract 0 0) %s) ((_ extract 1 1) %s)) (bvxor ((_ extract 2 2) %s) ((_ extract 3 3) %s))) (bvxor (bvxor ((_ extract 4 4) %s) ((_ extract 5 5) %s)) (bvxor ((_ extract 6 6) %s) ((_ extract 7 7) %s)))) #b0)",
+			result, result, result, result, result, result, result, result)
+		half := "false"
+		if fam == famAnd {
+			half = "true"
+		}
+		f := flagTerm(result, "false", half, parity, false)
+		return r.set('A', result).set('F', f)
+	}
+	return r
+}
+
+func lowerIncDec(r regExprs, reg byte, inc bool) regExprs {
+	v := r.get(reg)
+	var result, halfCond, pvCond string
+	if inc {
+		result = fmt.Sprintf("(bvadd %s #x01)", v)
+		halfCond = fmt.Sprintf("(= (bvand %s #x0F) #x0F)", v) // low nibble was 0xF, carries into bit 4
+		pvCond = fmt.Sprintf("(= %s #x7F)", v)                // overflow iff incrementing 0x7F
+	} else {
+		result = fmt.Sprintf("(bvsub %s #x01)", v)
+		halfCond = fmt.Sprintf("(= (bvand %s #x0F) #x00)", v) // low nibble was 0, borrows from bit 4
+		pvCond = fmt.Sprintf("(= %s #x80)", v)                // overflow iff decrementing 0x80
+	}
+	// INC/DEC leave C untouched, so splice the new S/Z/H/PV/N bits in around
+	// the caller's existing carry bit instead of using flagTerm's carryCond.
+	carryPreserved := fmt.Sprintf("(bvand %s #x01)", r.F)
+	f := fmt.Sprintf(
+		"(bvor (bvand %s #x%02X) (bvor (ite (= %s #x00) #x40 #x00) (bvor %s (bvor (ite %s #x10 #x00) (bvor (ite %s #x04 #x00) %s)))))",
+		result, flagsFromResultMask, result, carryPreserved, halfCond, pvCond, map[bool]string{true: "#x00", false: "#x02"}[inc])
+	return r.set(reg, result).set('F', f)
+}
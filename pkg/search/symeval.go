@@ -0,0 +1,598 @@
+// This file adds a pure-Go symbolic equivalence engine, independent of the
+// Z3-backed pkg/search/symbolic package: instead of shelling out to an SMT
+// solver, it lowers both sequences to a hash-consed expression DAG (in the
+// style of CompCert's CSE3 value numbering) and compares the resulting
+// nodes by pointer. Structurally identical sub-expressions always intern to
+// the same *symExpr, so the final comparison is O(1) per register/flag
+// instead of a solver call or a multi-vector sweep.
+//
+// Like pkg/search/symbolic, the lowering only understands a deliberately
+// narrow instruction subset: LD r,r'/LD r,n, the 8-bit ALU family (register
+// and immediate forms), INC/DEC r, NOP, and the (HL)-indirect forms of all
+// of the above (modeling memory as a functional Store/Select array keyed by
+// the symbolic HL address). It tracks only the Z and C flags precisely;
+// S/H/P-V/N and the undocumented bits are never modeled, so SymbolicCheck
+// only trusts its own verdict when deadFlags already marks all of those
+// dead. Anything outside this subset, or a deadFlags mask that still cares
+// about an unmodeled flag, falls back to ExhaustiveCheckMasked.
+
+package search
+
+import (
+	"sync"
+
+	"github.com/oisee/z80-optimizer/pkg/inst"
+)
+
+type symKind uint8
+
+const (
+	symConst symKind = iota
+	symInput
+	symAdd
+	symSub
+	symAnd
+	symOr
+	symXor
+	symConcat
+	symSlice
+	symZero
+	symSelect
+	symStore
+)
+
+// symExpr is one hash-consed node. Two expressions that are structurally
+// equal are always the same *symExpr: every smart constructor below goes
+// through intern, so pointer equality after construction is expression
+// equality.
+type symExpr struct {
+	kind  symKind
+	width uint8 // bits; 0 for symSelect/symStore (memory-array-typed)
+	val   uint64 // symConst's value, or symSlice's low-bit offset
+	name  string // symInput's variable name
+	a, b, c *symExpr
+}
+
+type symKey struct {
+	kind  symKind
+	width uint8
+	val   uint64
+	name  string
+	a, b, c *symExpr
+}
+
+var (
+	symMu    sync.Mutex
+	symTable = map[symKey]*symExpr{}
+)
+
+func symIntern(k symKey) *symExpr {
+	symMu.Lock()
+	defer symMu.Unlock()
+	if e, ok := symTable[k]; ok {
+		return e
+	}
+	e := &symExpr{kind: k.kind, width: k.width, val: k.val, name: k.name, a: k.a, b: k.b, c: k.c}
+	symTable[k] = e
+	return e
+}
+
+func symMask(width uint8) uint64 {
+	if width >= 64 {
+		return ^uint64(0)
+	}
+	return (uint64(1) << width) - 1
+}
+
+func symConstExpr(width uint8, val uint64) *symExpr {
+	return symIntern(symKey{kind: symConst, width: width, val: val & symMask(width)})
+}
+
+func symInputExpr(name string, width uint8) *symExpr {
+	return symIntern(symKey{kind: symInput, width: width, name: name})
+}
+
+// symCanon orders two operands of a commutative op so that, e.g.,
+// symAddExpr(x, y) and symAddExpr(y, x) always build the same node. Every
+// operand is already interned by the time it reaches here, so comparing
+// the pointers found in symTable (via their insertion order, captured in
+// the table's own map iteration is not stable — instead we tag each node
+// with a creation sequence number) gives a total order that's consistent
+// for the lifetime of the process.
+var symSeq uint64
+var symSeqOf = map[*symExpr]uint64{}
+var symSeqMu sync.Mutex
+
+func symSeqNo(e *symExpr) uint64 {
+	symSeqMu.Lock()
+	defer symSeqMu.Unlock()
+	if n, ok := symSeqOf[e]; ok {
+		return n
+	}
+	symSeq++
+	symSeqOf[e] = symSeq
+	return symSeq
+}
+
+func symCanon(a, b *symExpr) (*symExpr, *symExpr) {
+	if symSeqNo(a) <= symSeqNo(b) {
+		return a, b
+	}
+	return b, a
+}
+
+func symAddExpr(width uint8, a, b *symExpr) *symExpr {
+	if a.kind == symConst && b.kind == symConst {
+		return symConstExpr(width, a.val+b.val)
+	}
+	a, b = symCanon(a, b)
+	if a.kind == symConst && a.val == 0 {
+		return b
+	}
+	if b.kind == symConst && b.val == 0 {
+		return a
+	}
+	return symIntern(symKey{kind: symAdd, width: width, a: a, b: b})
+}
+
+func symSubExpr(width uint8, a, b *symExpr) *symExpr {
+	if a.kind == symConst && b.kind == symConst {
+		return symConstExpr(width, a.val-b.val)
+	}
+	if b.kind == symConst && b.val == 0 {
+		return a
+	}
+	if a == b {
+		return symConstExpr(width, 0)
+	}
+	return symIntern(symKey{kind: symSub, width: width, a: a, b: b})
+}
+
+func symAndExpr(width uint8, a, b *symExpr) *symExpr {
+	if a.kind == symConst && b.kind == symConst {
+		return symConstExpr(width, a.val&b.val)
+	}
+	a, b = symCanon(a, b)
+	full := symMask(width)
+	if a.kind == symConst && a.val == full {
+		return b
+	}
+	if b.kind == symConst && b.val == full {
+		return a
+	}
+	if a.kind == symConst && a.val == 0 {
+		return symConstExpr(width, 0)
+	}
+	if b.kind == symConst && b.val == 0 {
+		return symConstExpr(width, 0)
+	}
+	if a == b {
+		return a
+	}
+	return symIntern(symKey{kind: symAnd, width: width, a: a, b: b})
+}
+
+func symOrExpr(width uint8, a, b *symExpr) *symExpr {
+	if a.kind == symConst && b.kind == symConst {
+		return symConstExpr(width, a.val|b.val)
+	}
+	a, b = symCanon(a, b)
+	full := symMask(width)
+	if a.kind == symConst && a.val == 0 {
+		return b
+	}
+	if b.kind == symConst && b.val == 0 {
+		return a
+	}
+	if a.kind == symConst && a.val == full {
+		return symConstExpr(width, full)
+	}
+	if b.kind == symConst && b.val == full {
+		return symConstExpr(width, full)
+	}
+	if a == b {
+		return a
+	}
+	return symIntern(symKey{kind: symOr, width: width, a: a, b: b})
+}
+
+func symXorExpr(width uint8, a, b *symExpr) *symExpr {
+	if a.kind == symConst && b.kind == symConst {
+		return symConstExpr(width, a.val^b.val)
+	}
+	if a == b {
+		return symConstExpr(width, 0)
+	}
+	a, b = symCanon(a, b)
+	if a.kind == symConst && a.val == 0 {
+		return b
+	}
+	if b.kind == symConst && b.val == 0 {
+		return a
+	}
+	return symIntern(symKey{kind: symXor, width: width, a: a, b: b})
+}
+
+// symConcatExpr joins hi and lo into one value hi.width+lo.width bits wide,
+// hi occupying the top bits — the same shape LD A,(HL)'s address needs from
+// the symbolic H and L register values.
+func symConcatExpr(hi, lo *symExpr) *symExpr {
+	width := hi.width + lo.width
+	if hi.kind == symConst && lo.kind == symConst {
+		return symConstExpr(width, (hi.val<<lo.width)|lo.val)
+	}
+	return symIntern(symKey{kind: symConcat, width: width, val: uint64(lo.width), a: hi, b: lo})
+}
+
+// symSliceExpr extracts the width bits of e starting at bit lo (lo=0 is the
+// least-significant bit) — used to read the carry/borrow bit out of a
+// widened sum/difference without a dedicated Carry node kind. A slice that
+// lands exactly on one half of a symConcat forwards to that half directly
+// (mirroring symSelectExpr's store-forwarding) rather than building an
+// opaque node: without this, zero-extending a value and then slicing it
+// back down — exactly what every flag computation in this file does — would
+// never re-intern to the original node, defeating hash-consing for the
+// common case of an ALU op whose operand folds away to nothing (e.g.
+// "ADD A, 0").
+func symSliceExpr(width uint8, e *symExpr, lo uint8) *symExpr {
+	if lo == 0 && width == e.width {
+		return e
+	}
+	if e.kind == symConst {
+		return symConstExpr(width, e.val>>lo)
+	}
+	if e.kind == symConcat {
+		loWidth := e.b.width
+		if lo == 0 && width <= loWidth {
+			if width == loWidth {
+				return e.b
+			}
+			return symSliceExpr(width, e.b, 0)
+		}
+		if lo >= loWidth && lo+width <= loWidth+e.a.width {
+			return symSliceExpr(width, e.a, lo-loWidth)
+		}
+	}
+	return symIntern(symKey{kind: symSlice, width: width, val: uint64(lo), a: e})
+}
+
+func symZeroExpr(e *symExpr) *symExpr {
+	if e.kind == symConst {
+		if e.val == 0 {
+			return symConstExpr(1, 1)
+		}
+		return symConstExpr(1, 0)
+	}
+	return symIntern(symKey{kind: symZero, width: 1, a: e})
+}
+
+// symSelectExpr reads mem at addr. A select immediately following a store to
+// the same address forwards the stored value instead of building a new
+// node — the one piece of array reasoning this engine needs to prove
+// memory round-trips like "LD (HL), A" followed by "LD B, (HL)" equivalent
+// to "LD B, A".
+func symSelectExpr(mem *symExpr, addr *symExpr) *symExpr {
+	if mem.kind == symStore {
+		if mem.b == addr {
+			return mem.c
+		}
+	}
+	return symIntern(symKey{kind: symSelect, width: 8, a: mem, b: addr})
+}
+
+func symStoreExpr(mem, addr, val *symExpr) *symExpr {
+	return symIntern(symKey{kind: symStore, a: mem, b: addr, c: val})
+}
+
+// symState is the symbolic machine state threaded through lowering: one
+// expression per 8-bit register, the memory array, and the Z/C flags —
+// exactly the pieces SymbolicCheck's narrow instruction subset touches.
+type symState struct {
+	reg map[byte]*symExpr
+	mem *symExpr
+	z   *symExpr
+	cy  *symExpr
+}
+
+var symRegNames = [7]byte{'A', 'B', 'C', 'D', 'E', 'H', 'L'}
+
+func newSymState() *symState {
+	reg := make(map[byte]*symExpr, len(symRegNames))
+	for _, r := range symRegNames {
+		reg[r] = symInputExpr(string(r), 8)
+	}
+	return &symState{
+		reg: reg,
+		mem: symInputExpr("mem", 0),
+		z:   symInputExpr("Z", 1),
+		cy:  symInputExpr("C", 1),
+	}
+}
+
+func (st *symState) clone() *symState {
+	reg := make(map[byte]*symExpr, len(st.reg))
+	for k, v := range st.reg {
+		reg[k] = v
+	}
+	return &symState{reg: reg, mem: st.mem, z: st.z, cy: st.cy}
+}
+
+func (st *symState) hlAddr() *symExpr {
+	return symConcatExpr(st.reg['H'], st.reg['L'])
+}
+
+// symAddWithFlags computes an 8-bit a+b+cin, returning the wrapped result
+// along with the carry-out and zero flags it produces.
+func symAddWithFlags(a, b, cin *symExpr) (sum, carry, zero *symExpr) {
+	a9 := symConcatExpr(symConstExpr(1, 0), a)
+	b9 := symConcatExpr(symConstExpr(1, 0), b)
+	cin9 := symConcatExpr(symConstExpr(8, 0), cin)
+	sum9 := symAddExpr(9, symAddExpr(9, a9, b9), cin9)
+	sum = symSliceExpr(8, sum9, 0)
+	carry = symSliceExpr(1, sum9, 8)
+	zero = symZeroExpr(sum)
+	return
+}
+
+// symSubWithFlags computes an 8-bit a-b-cin, returning the wrapped result
+// along with the borrow-out and zero flags it produces.
+func symSubWithFlags(a, b, cin *symExpr) (diff, borrow, zero *symExpr) {
+	a9 := symConcatExpr(symConstExpr(1, 0), a)
+	b9 := symConcatExpr(symConstExpr(1, 0), b)
+	cin9 := symConcatExpr(symConstExpr(8, 0), cin)
+	diff9 := symSubExpr(9, symSubExpr(9, a9, b9), cin9)
+	diff = symSliceExpr(8, diff9, 0)
+	borrow = symSliceExpr(1, diff9, 8)
+	zero = symZeroExpr(diff)
+	return
+}
+
+// ldRegPair is the (dest, src) for one LD r,r' opcode. Register-to-register
+// loads aren't laid out with a uniform arithmetic offset per block (LD_A_*
+// lists its sources as B,C,D,E,H,L,A while every other destination lists
+// A,B,C,D,E,H,L — see pkg/inst/instruction.go), so this table is spelled
+// out in the exact order the opcodes were declared rather than computed.
+var ldRegPairOps = []struct {
+	op       inst.OpCode
+	dst, src byte
+}{
+	{inst.LD_A_B, 'A', 'B'}, {inst.LD_A_C, 'A', 'C'}, {inst.LD_A_D, 'A', 'D'}, {inst.LD_A_E, 'A', 'E'}, {inst.LD_A_H, 'A', 'H'}, {inst.LD_A_L, 'A', 'L'}, {inst.LD_A_A, 'A', 'A'},
+	{inst.LD_B_A, 'B', 'A'}, {inst.LD_B_B, 'B', 'B'}, {inst.LD_B_C, 'B', 'C'}, {inst.LD_B_D, 'B', 'D'}, {inst.LD_B_E, 'B', 'E'}, {inst.LD_B_H, 'B', 'H'}, {inst.LD_B_L, 'B', 'L'},
+	{inst.LD_C_A, 'C', 'A'}, {inst.LD_C_B, 'C', 'B'}, {inst.LD_C_C, 'C', 'C'}, {inst.LD_C_D, 'C', 'D'}, {inst.LD_C_E, 'C', 'E'}, {inst.LD_C_H, 'C', 'H'}, {inst.LD_C_L, 'C', 'L'},
+	{inst.LD_D_A, 'D', 'A'}, {inst.LD_D_B, 'D', 'B'}, {inst.LD_D_C, 'D', 'C'}, {inst.LD_D_D, 'D', 'D'}, {inst.LD_D_E, 'D', 'E'}, {inst.LD_D_H, 'D', 'H'}, {inst.LD_D_L, 'D', 'L'},
+	{inst.LD_E_A, 'E', 'A'}, {inst.LD_E_B, 'E', 'B'}, {inst.LD_E_C, 'E', 'C'}, {inst.LD_E_D, 'E', 'D'}, {inst.LD_E_E, 'E', 'E'}, {inst.LD_E_H, 'E', 'H'}, {inst.LD_E_L, 'E', 'L'},
+	{inst.LD_H_A, 'H', 'A'}, {inst.LD_H_B, 'H', 'B'}, {inst.LD_H_C, 'H', 'C'}, {inst.LD_H_D, 'H', 'D'}, {inst.LD_H_E, 'H', 'E'}, {inst.LD_H_H, 'H', 'H'}, {inst.LD_H_L, 'H', 'L'},
+	{inst.LD_L_A, 'L', 'A'}, {inst.LD_L_B, 'L', 'B'}, {inst.LD_L_C, 'L', 'C'}, {inst.LD_L_D, 'L', 'D'}, {inst.LD_L_E, 'L', 'E'}, {inst.LD_L_H, 'L', 'H'}, {inst.LD_L_L, 'L', 'L'},
+}
+
+var (
+	ldRegPairOnce  sync.Once
+	ldRegPairIndex map[inst.OpCode]struct{ dst, src byte }
+)
+
+func ldRegPairLookup(op inst.OpCode) (dst, src byte, ok bool) {
+	ldRegPairOnce.Do(func() {
+		ldRegPairIndex = make(map[inst.OpCode]struct{ dst, src byte }, len(ldRegPairOps))
+		for _, p := range ldRegPairOps {
+			ldRegPairIndex[p.op] = struct{ dst, src byte }{p.dst, p.src}
+		}
+	})
+	p, ok := ldRegPairIndex[op]
+	return p.dst, p.src, ok
+}
+
+// aluFamily describes one contiguous 8-opcode block: base+0..base+6 are the
+// register forms in B,C,D,E,H,L,A order (matching catalog.go's aluReg
+// table) and base+7 is the immediate form.
+type aluFamily struct {
+	base      inst.OpCode
+	op        byte // 'a' add, 's' sub, 'c' compare, '&', '|', '^'
+	withCarry bool
+}
+
+var aluFamilies = []aluFamily{
+	{inst.ADD_A_B, 'a', false},
+	{inst.ADC_A_B, 'a', true},
+	{inst.SUB_B, 's', false},
+	{inst.SBC_A_B, 's', true},
+	{inst.AND_B, '&', false},
+	{inst.XOR_B, '^', false},
+	{inst.OR_B, '|', false},
+	{inst.CP_B, 'c', false},
+}
+
+var aluRegOrder = [7]byte{'B', 'C', 'D', 'E', 'H', 'L', 'A'}
+
+// lowerALU lowers op if it falls in one of aluFamilies' 8-opcode blocks.
+// imm is the instruction's immediate operand, used only for the base+7 form.
+func lowerALU(op inst.OpCode, imm uint8, st *symState) (*symState, bool) {
+	for _, fam := range aluFamilies {
+		offset := int(op) - int(fam.base)
+		if offset < 0 || offset > 7 {
+			continue
+		}
+		var operand *symExpr
+		if offset == 7 {
+			operand = symConstExpr(8, uint64(imm))
+		} else {
+			operand = st.reg[aluRegOrder[offset]]
+		}
+		next := st.clone()
+		a := st.reg['A']
+		switch fam.op {
+		case 'a':
+			cin := symConstExpr(1, 0)
+			if fam.withCarry {
+				cin = st.cy
+			}
+			sum, carry, zero := symAddWithFlags(a, operand, cin)
+			next.reg['A'] = sum
+			next.cy = carry
+			next.z = zero
+		case 's':
+			cin := symConstExpr(1, 0)
+			if fam.withCarry {
+				cin = st.cy
+			}
+			diff, borrow, zero := symSubWithFlags(a, operand, cin)
+			next.reg['A'] = diff
+			next.cy = borrow
+			next.z = zero
+		case 'c':
+			_, borrow, zero := symSubWithFlags(a, operand, symConstExpr(1, 0))
+			next.cy = borrow
+			next.z = zero
+		case '&':
+			next.reg['A'] = symAndExpr(8, a, operand)
+			next.cy = symConstExpr(1, 0)
+			next.z = symZeroExpr(next.reg['A'])
+		case '|':
+			next.reg['A'] = symOrExpr(8, a, operand)
+			next.cy = symConstExpr(1, 0)
+			next.z = symZeroExpr(next.reg['A'])
+		case '^':
+			next.reg['A'] = symXorExpr(8, a, operand)
+			next.cy = symConstExpr(1, 0)
+			next.z = symZeroExpr(next.reg['A'])
+		}
+		return next, true
+	}
+	return nil, false
+}
+
+// lowerOne lowers a single instruction against st, returning ok=false for
+// anything outside SymbolicCheck's supported subset so the caller can fall
+// back to ExhaustiveCheckMasked.
+func lowerOne(instr inst.Instruction, st *symState) (*symState, bool) {
+	op := instr.Op
+	imm := uint8(instr.Imm)
+
+	if op == inst.NOP {
+		return st, true
+	}
+
+	if dst, src, ok := ldRegPairLookup(op); ok {
+		next := st.clone()
+		next.reg[dst] = st.reg[src]
+		return next, true
+	}
+
+	if op >= inst.LD_A_N && op <= inst.LD_L_N {
+		next := st.clone()
+		next.reg[symRegNames[op-inst.LD_A_N]] = symConstExpr(8, uint64(imm))
+		return next, true
+	}
+
+	if op >= inst.INC_A && op <= inst.INC_L {
+		r := symRegNames[op-inst.INC_A]
+		next := st.clone()
+		result := symAddExpr(8, st.reg[r], symConstExpr(8, 1))
+		next.reg[r] = result
+		next.z = symZeroExpr(result)
+		return next, true
+	}
+	if op >= inst.DEC_A && op <= inst.DEC_L {
+		r := symRegNames[op-inst.DEC_A]
+		next := st.clone()
+		result := symSubExpr(8, st.reg[r], symConstExpr(8, 1))
+		next.reg[r] = result
+		next.z = symZeroExpr(result)
+		return next, true
+	}
+
+	if next, ok := lowerALU(op, imm, st); ok {
+		return next, true
+	}
+
+	// (HL)-indirect forms: same shapes as above, addressed through Select/
+	// Store against the symbolic HL address instead of a register.
+	if op >= inst.LD_A_HLI && op <= inst.LD_L_HLI {
+		r := symRegNames[op-inst.LD_A_HLI]
+		next := st.clone()
+		next.reg[r] = symSelectExpr(st.mem, st.hlAddr())
+		return next, true
+	}
+	if op >= inst.LD_HLI_A && op <= inst.LD_HLI_L {
+		r := symRegNames[op-inst.LD_HLI_A]
+		next := st.clone()
+		next.mem = symStoreExpr(st.mem, st.hlAddr(), st.reg[r])
+		return next, true
+	}
+	if op == inst.LD_HLI_N {
+		next := st.clone()
+		next.mem = symStoreExpr(st.mem, st.hlAddr(), symConstExpr(8, uint64(imm)))
+		return next, true
+	}
+	if op == inst.INC_HLI || op == inst.DEC_HLI {
+		next := st.clone()
+		addr := st.hlAddr()
+		cur := symSelectExpr(st.mem, addr)
+		var result *symExpr
+		if op == inst.INC_HLI {
+			result = symAddExpr(8, cur, symConstExpr(8, 1))
+		} else {
+			result = symSubExpr(8, cur, symConstExpr(8, 1))
+		}
+		next.mem = symStoreExpr(st.mem, addr, result)
+		next.z = symZeroExpr(result)
+		return next, true
+	}
+
+	return nil, false
+}
+
+func lowerSeq(seq []inst.Instruction) *symState {
+	st := newSymState()
+	for i := range seq {
+		var ok bool
+		st, ok = lowerOne(seq[i], st)
+		if !ok {
+			return nil
+		}
+	}
+	return st
+}
+
+// symModeledFlags are the only flag bits SymbolicCheck tracks; everything
+// else (S, H, P/V, N, and the undocumented bits) isn't modeled at all, so a
+// deadFlags mask that still cares about one of them can't be trusted —
+// SymbolicCheck falls back to ExhaustiveCheckMasked rather than claim a
+// verdict on a flag it never computed.
+const symModeledFlags = FlagMask(FlagS | FlagH | FlagPV | FlagN | FlagX | FlagY)
+
+// SymbolicCheck proves target and candidate equivalent by lowering both to
+// a hash-consed expression DAG and comparing the resulting register/memory/
+// flag nodes by pointer, instead of sampling test vectors (QuickCheck) or
+// sweeping the full input space (ExhaustiveCheck). It's sound whenever both
+// sequences stay inside the lowering's supported subset — see the package
+// doc comment at the top of this file for exactly what that covers — and
+// falls back to ExhaustiveCheckMasked otherwise, so callers get the same
+// guarantee QuickCheck/ExhaustiveCheck already give: a true result means
+// target and candidate really are equivalent under deadFlags.
+func SymbolicCheck(target, candidate []inst.Instruction, deadFlags FlagMask) bool {
+	if deadFlags&symModeledFlags != symModeledFlags {
+		return ExhaustiveCheckMasked(target, candidate, deadFlags)
+	}
+
+	tSt := lowerSeq(target)
+	cSt := lowerSeq(candidate)
+	if tSt == nil || cSt == nil {
+		return ExhaustiveCheckMasked(target, candidate, deadFlags)
+	}
+
+	for _, r := range symRegNames {
+		if tSt.reg[r] != cSt.reg[r] {
+			return false
+		}
+	}
+	if tSt.mem != cSt.mem {
+		return false
+	}
+	if deadFlags&FlagMask(FlagZ) == 0 && tSt.z != cSt.z {
+		return false
+	}
+	if deadFlags&FlagMask(FlagC) == 0 && tSt.cy != cSt.cy {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,184 @@
+package search
+
+import (
+	"encoding/gob"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/oisee/z80-optimizer/pkg/cpu"
+	"github.com/oisee/z80-optimizer/pkg/inst"
+)
+
+func init() {
+	gob.Register(cpu.State{})
+}
+
+// VectorBank is a growable set of QuickCheck inputs, seeded from TestVectors
+// plus generated flag-boundary edge cases and persisted across runs. Unlike
+// the static TestVectors, it's meant to be grown with Learn every time an
+// exhaustive check catches a disagreement QuickCheck missed, so that blind
+// spot doesn't recur in the next search that loads the bank.
+type VectorBank struct {
+	mu      sync.Mutex
+	Vectors []cpu.State
+	seen    map[cpu.State]bool
+}
+
+// NewVectorBank returns a bank seeded with TestVectors plus edgeVectors.
+func NewVectorBank() *VectorBank {
+	vb := &VectorBank{}
+	for _, v := range TestVectors {
+		vb.add(v)
+	}
+	for _, v := range edgeVectors() {
+		vb.add(v)
+	}
+	return vb
+}
+
+// add inserts v if it isn't already present. Caller holds vb.mu, if needed.
+func (vb *VectorBank) add(v cpu.State) bool {
+	if vb.seen == nil {
+		vb.seen = make(map[cpu.State]bool, len(vb.Vectors))
+	}
+	if vb.seen[v] {
+		return false
+	}
+	vb.seen[v] = true
+	vb.Vectors = append(vb.Vectors, v)
+	return true
+}
+
+// Learn records state as a vector future QuickCheckWithBank calls should
+// test against. Call it whenever ExhaustiveCheck/ExhaustiveCheckMasked finds
+// a disagreement on a state QuickCheckWithBank missed — the quick filter
+// only gets tighter from there on. Returns false if state was already known.
+func (vb *VectorBank) Learn(state cpu.State) bool {
+	vb.mu.Lock()
+	defer vb.mu.Unlock()
+	return vb.add(state)
+}
+
+// edgeRepValues are the 8-bit boundary values relevant to flag computation:
+// zero, one, the nibble boundaries that drive half-carry, the sign boundary
+// that drives overflow, and all-ones (borrow/NOT).
+var edgeRepValues = []uint8{0x00, 0x01, 0x0F, 0x10, 0x7F, 0x80, 0xFF}
+
+// edgeVectors enumerates states built from edgeRepValues on A and a second
+// shared value across B-L, with both carry polarities, so half-carry and
+// signed-overflow boundaries are exercised for flag-producing ops without a
+// full 256x256 sweep.
+func edgeVectors() []cpu.State {
+	var out []cpu.State
+	for _, a := range edgeRepValues {
+		for _, b := range edgeRepValues {
+			for _, carry := range [2]uint8{0x00, 0x01} {
+				out = append(out, cpu.State{A: a, F: carry, B: b, C: b, D: b, E: b, H: b, L: b})
+			}
+		}
+	}
+	return out
+}
+
+// VectorBankPath returns the default persisted bank location:
+// $XDG_CACHE_HOME/z80-opt/vectors.gob, falling back to
+// $HOME/.cache/z80-opt/vectors.gob per the XDG base directory spec.
+func VectorBankPath() (string, error) {
+	dir := os.Getenv("XDG_CACHE_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(home, ".cache")
+	}
+	return filepath.Join(dir, "z80-opt", "vectors.gob"), nil
+}
+
+// LoadVectorBank reads a bank previously saved with Save from path, or
+// returns a freshly seeded NewVectorBank if no file exists there yet.
+func LoadVectorBank(path string) (*VectorBank, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return NewVectorBank(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var vectors []cpu.State
+	if err := gob.NewDecoder(f).Decode(&vectors); err != nil {
+		return nil, err
+	}
+	vb := &VectorBank{}
+	for _, v := range vectors {
+		vb.add(v)
+	}
+	return vb, nil
+}
+
+// Save persists the bank to path, creating parent directories as needed.
+func (vb *VectorBank) Save(path string) error {
+	vb.mu.Lock()
+	defer vb.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return gob.NewEncoder(f).Encode(vb.Vectors)
+}
+
+// QuickCheckWithBank is QuickCheck against bank's learned vectors instead of
+// the fixed TestVectors — a superset that only grows as Learn is called.
+func QuickCheckWithBank(bank *VectorBank, target, candidate []inst.Instruction) bool {
+	bank.mu.Lock()
+	vectors := bank.Vectors
+	bank.mu.Unlock()
+
+	for i := range vectors {
+		tOut := execSeq(vectors[i], target)
+		cOut := execSeq(vectors[i], candidate)
+		if tOut != cOut {
+			return false
+		}
+	}
+	return true
+}
+
+// FingerprintWithBank is Fingerprint against bank's learned vectors: a []byte
+// rather than Fingerprint's [FingerprintLen]byte, since the bank (and so the
+// fingerprint length) grows over time and can't be a compile-time array size.
+// FingerprintMap and the GPU pipeline still key off the fixed Fingerprint;
+// this is for callers that load a grown bank and want the tightest filter
+// available right now.
+func FingerprintWithBank(bank *VectorBank, seq []inst.Instruction) []byte {
+	bank.mu.Lock()
+	vectors := bank.Vectors
+	bank.mu.Unlock()
+
+	fp := make([]byte, len(vectors)*FingerprintSize)
+	for i := range vectors {
+		out := execSeq(vectors[i], seq)
+		off := i * FingerprintSize
+		fp[off+0] = out.A
+		fp[off+1] = out.F
+		fp[off+2] = out.B
+		fp[off+3] = out.C
+		fp[off+4] = out.D
+		fp[off+5] = out.E
+		fp[off+6] = out.H
+		fp[off+7] = out.L
+		fp[off+8] = uint8(out.SP >> 8)
+		fp[off+9] = uint8(out.SP)
+		fp[off+10] = out.M
+	}
+	return fp
+}
@@ -10,21 +10,24 @@ import (
 type FlagMask = uint8
 
 const (
-	DeadNone  FlagMask = 0x00 // Full equivalence (current behavior)
-	DeadUndoc FlagMask = 0x28 // Undocumented flags (bits 3, 5) — almost always safe
-	DeadAll   FlagMask = 0xFF // All flags dead — registers only
+	DeadNone  FlagMask = 0x00                    // Full equivalence (current behavior)
+	DeadUndoc FlagMask = FlagMask(FlagX | FlagY) // Undocumented flags (bits 3, 5) — almost always safe
+	DeadAll   FlagMask = 0xFF                    // All flags dead — registers only
 )
 
 // TestVectors are fixed inputs used for QuickCheck to reject 99.99% of non-matches.
+// chunk1-3: M (the shared memory byte) and IX/IY now vary per vector too, so
+// memory- and index-register-reading candidates get exercised instead of
+// always comparing against their zero value.
 var TestVectors = []cpu.State{
-	{A: 0x00, F: 0x00, B: 0x00, C: 0x00, D: 0x00, E: 0x00, H: 0x00, L: 0x00, SP: 0x0000},
-	{A: 0xFF, F: 0xFF, B: 0xFF, C: 0xFF, D: 0xFF, E: 0xFF, H: 0xFF, L: 0xFF, SP: 0xFFFF},
-	{A: 0x01, F: 0x00, B: 0x02, C: 0x03, D: 0x04, E: 0x05, H: 0x06, L: 0x07, SP: 0x1234},
-	{A: 0x80, F: 0x01, B: 0x40, C: 0x20, D: 0x10, E: 0x08, H: 0x04, L: 0x02, SP: 0x8000},
-	{A: 0x55, F: 0x00, B: 0xAA, C: 0x55, D: 0xAA, E: 0x55, H: 0xAA, L: 0x55, SP: 0x5555},
-	{A: 0xAA, F: 0x01, B: 0x55, C: 0xAA, D: 0x55, E: 0xAA, H: 0x55, L: 0xAA, SP: 0xAAAA},
-	{A: 0x0F, F: 0x00, B: 0xF0, C: 0x0F, D: 0xF0, E: 0x0F, H: 0xF0, L: 0x0F, SP: 0xFFFE},
-	{A: 0x7F, F: 0x01, B: 0x80, C: 0x7F, D: 0x80, E: 0x7F, H: 0x80, L: 0x7F, SP: 0x7FFF},
+	{A: 0x00, F: 0x00, B: 0x00, C: 0x00, D: 0x00, E: 0x00, H: 0x00, L: 0x00, SP: 0x0000, M: 0x00, IX: 0x0000, IY: 0x0000},
+	{A: 0xFF, F: 0xFF, B: 0xFF, C: 0xFF, D: 0xFF, E: 0xFF, H: 0xFF, L: 0xFF, SP: 0xFFFF, M: 0xFF, IX: 0xFFFF, IY: 0xFFFF},
+	{A: 0x01, F: 0x00, B: 0x02, C: 0x03, D: 0x04, E: 0x05, H: 0x06, L: 0x07, SP: 0x1234, M: 0x08, IX: 0x2000, IY: 0x3000},
+	{A: 0x80, F: 0x01, B: 0x40, C: 0x20, D: 0x10, E: 0x08, H: 0x04, L: 0x02, SP: 0x8000, M: 0x01, IX: 0x8000, IY: 0x4000},
+	{A: 0x55, F: 0x00, B: 0xAA, C: 0x55, D: 0xAA, E: 0x55, H: 0xAA, L: 0x55, SP: 0x5555, M: 0xAA, IX: 0x5AA5, IY: 0xA55A},
+	{A: 0xAA, F: 0x01, B: 0x55, C: 0xAA, D: 0x55, E: 0xAA, H: 0x55, L: 0xAA, SP: 0xAAAA, M: 0x55, IX: 0xA55A, IY: 0x5AA5},
+	{A: 0x0F, F: 0x00, B: 0xF0, C: 0x0F, D: 0xF0, E: 0x0F, H: 0xF0, L: 0x0F, SP: 0xFFFE, M: 0xF0, IX: 0x1000, IY: 0xE000},
+	{A: 0x7F, F: 0x01, B: 0x80, C: 0x7F, D: 0x80, E: 0x7F, H: 0x80, L: 0x7F, SP: 0x7FFF, M: 0x7F, IX: 0x7FFE, IY: 0x9000},
 }
 
 // execSeq runs a sequence of instructions on a state, returning the final state.
@@ -49,12 +52,53 @@ func QuickCheck(target, candidate []inst.Instruction) bool {
 	return true
 }
 
+// execSeqIO runs seq with a fresh cpu.RecordingPorts attached, returning the
+// final state (with IO cleared back to nil, so callers can still compare it
+// with == without caring about pointer identity) and the I/O trace.
+func execSeqIO(initial cpu.State, seq []inst.Instruction) (cpu.State, []cpu.PortAccess) {
+	s := initial
+	ports := cpu.NewRecordingPorts()
+	s.IO = ports
+	for i := range seq {
+		cpu.Exec(&s, seq[i].Op, seq[i].Imm)
+	}
+	s.IO = nil
+	return s, ports.Trace
+}
+
+func tracesEqual(a, b []cpu.PortAccess) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// QuickCheckIO is QuickCheck plus inst.HasSideEffects: two sequences must
+// also produce identical I/O traces (same ports, values, and directions, in
+// order) to be considered equivalent, not just identical final register state.
+func QuickCheckIO(target, candidate []inst.Instruction) bool {
+	for i := range TestVectors {
+		tOut, tTrace := execSeqIO(TestVectors[i], target)
+		cOut, cTrace := execSeqIO(TestVectors[i], candidate)
+		if tOut != cOut || !tracesEqual(tTrace, cTrace) {
+			return false
+		}
+	}
+	return true
+}
+
 // FingerprintSize is the number of bytes per state snapshot in a fingerprint.
-// V1: 8 (A,F,B,C,D,E,H,L), Wave 2: 10 (+SP high/low bytes).
-const FingerprintSize = 10
+// V1: 8 (A,F,B,C,D,E,H,L), Wave 2: 10 (+SP high/low bytes),
+// chunk1-3: 11 (+M, the shared memory byte).
+const FingerprintSize = 11
 
 // FingerprintLen is the total fingerprint length: FingerprintSize * len(TestVectors).
-const FingerprintLen = FingerprintSize * 8 // 80 bytes
+const FingerprintLen = FingerprintSize * 8 // 88 bytes
 
 // Fingerprint computes a compact hash of a sequence's behavior on test vectors.
 // Sequences with different fingerprints are guaranteed non-equivalent.
@@ -73,6 +117,7 @@ func Fingerprint(seq []inst.Instruction) [FingerprintLen]byte {
 		fp[off+7] = out.L
 		fp[off+8] = uint8(out.SP >> 8)
 		fp[off+9] = uint8(out.SP)
+		fp[off+10] = out.M
 	}
 	return fp
 }
@@ -99,7 +144,9 @@ func ExhaustiveCheck(target, candidate []inst.Instruction) bool {
 
 // Register bitmask for tracking which registers are read/written.
 // Wave 0: widened from uint8 to uint16 for SP, IX, IY, shadow regs.
-type regMask uint16
+// chunk1-3: widened again to uint32 — IX/IY used up the last uint16 bits,
+// and shadow-register/memory tracking needs 7 more.
+type regMask uint32
 
 const (
 	regA regMask = 1 << iota
@@ -111,6 +158,22 @@ const (
 	regH
 	regL
 	regSP
+	regIX
+	regIY
+	// regAFs/regBCs/regDEs/regHLs/regI/regR are reserved for EXX, EX AF,AF',
+	// LD A,I and LD A,R — none of those exist in inst.OpCode yet, so nothing
+	// currently sets these bits; they're here so opReads/opWrites don't need
+	// another mask widening once that opcode wave lands.
+	regAFs
+	regBCs
+	regDEs
+	regHLs
+	regI
+	regR
+	// regMem marks "this op touches the shared memory byte State.M" (see
+	// wave5Reads/wave5Writes and wave6Reads/wave6Writes) so the exhaustive
+	// sweep below varies M instead of always leaving it zero.
+	regMem
 )
 
 func regsRead(seq []inst.Instruction) regMask {
@@ -121,8 +184,10 @@ func regsRead(seq []inst.Instruction) regMask {
 	return mask
 }
 
-// opReads returns which registers an instruction reads as source operands.
-func opReads(op inst.OpCode) regMask {
+// legacyReads returns which registers an instruction reads as source operands,
+// for the Wave 0-4 opcodes it was originally written to cover. Wave 5+ is
+// layered on top in properties.go; opReads itself just reads Properties.
+func legacyReads(op inst.OpCode) regMask {
 	switch op {
 	// Instructions reading only A (no carry dependency)
 	case inst.ADD_A_A, inst.SUB_A,
@@ -335,47 +400,57 @@ func exhaustiveAll(target, candidate []inst.Instruction, reads regMask) bool {
 	if reads&regL != 0 {
 		extraRegs = append(extraRegs, 7)
 	}
+	if reads&regMem != 0 {
+		extraRegs = append(extraRegs, 8) // shared memory byte (State.M)
+	}
 
 	sweepSP := reads&regSP != 0
+	sweepIX := reads&regIX != 0
+	sweepIY := reads&regIY != 0
 
-	if len(extraRegs) == 0 && !sweepSP {
+	if len(extraRegs) == 0 && !sweepSP && !sweepIX && !sweepIY {
 		return exhaustiveAF(target, candidate)
 	}
 
 	// For 1 extra register: A(256) * carry(2) * reg(256) = 131,072 iterations - very fast
 	// For 2 extra: 33,554,432 - still feasible
 	// For 3+: we use a reduced sweep of 32 values per extra reg
-	// SP is 16-bit, so always uses reduced sweep (32 representative values)
-	if len(extraRegs) <= 2 && !sweepSP {
+	// SP/IX/IY are 16-bit, so always use the reduced sweep (16 representative values each)
+	if len(extraRegs) <= 2 && !sweepSP && !sweepIX && !sweepIY {
 		return exhaustiveFullSweep(target, candidate, extraRegs)
 	}
-	return exhaustiveReducedSweep(target, candidate, extraRegs, sweepSP)
+	return exhaustiveReducedSweep(target, candidate, extraRegs, sweepSP, sweepIX, sweepIY)
 }
 
-func exhaustiveFullSweep(target, candidate []inst.Instruction, extraRegs []int) bool {
-	setReg := func(s *cpu.State, offset int, val uint8) {
-		switch offset {
-		case 2:
-			s.B = val
-		case 3:
-			s.C = val
-		case 4:
-			s.D = val
-		case 5:
-			s.E = val
-		case 6:
-			s.H = val
-		case 7:
-			s.L = val
-		}
+// setExtraReg writes val into the State field identified by offset, the
+// same offset codes used by extraRegs throughout this file: 2=B, 3=C, 4=D,
+// 5=E, 6=H, 7=L, 8=M (the shared memory byte).
+func setExtraReg(s *cpu.State, offset int, val uint8) {
+	switch offset {
+	case 2:
+		s.B = val
+	case 3:
+		s.C = val
+	case 4:
+		s.D = val
+	case 5:
+		s.E = val
+	case 6:
+		s.H = val
+	case 7:
+		s.L = val
+	case 8:
+		s.M = val
 	}
+}
 
+func exhaustiveFullSweep(target, candidate []inst.Instruction, extraRegs []int) bool {
 	if len(extraRegs) == 1 {
 		for a := 0; a < 256; a++ {
 			for carry := uint8(0); carry <= 1; carry++ {
 				for r := 0; r < 256; r++ {
 					s := cpu.State{A: uint8(a), F: carry}
-					setReg(&s, extraRegs[0], uint8(r))
+					setExtraReg(&s, extraRegs[0], uint8(r))
 					tOut := execSeq(s, target)
 					cOut := execSeq(s, candidate)
 					if tOut != cOut {
@@ -393,8 +468,8 @@ func exhaustiveFullSweep(target, candidate []inst.Instruction, extraRegs []int)
 			for r1 := 0; r1 < 256; r1++ {
 				for r2 := 0; r2 < 256; r2++ {
 					s := cpu.State{A: uint8(a), F: carry}
-					setReg(&s, extraRegs[0], uint8(r1))
-					setReg(&s, extraRegs[1], uint8(r2))
+					setExtraReg(&s, extraRegs[0], uint8(r1))
+					setExtraReg(&s, extraRegs[1], uint8(r2))
 					tOut := execSeq(s, target)
 					cOut := execSeq(s, candidate)
 					if tOut != cOut {
@@ -407,7 +482,24 @@ func exhaustiveFullSweep(target, candidate []inst.Instruction, extraRegs []int)
 	return true
 }
 
-func exhaustiveReducedSweep(target, candidate []inst.Instruction, extraRegs []int, sweepSP bool) bool {
+// repWide16 is the shared set of representative 16-bit values used to sweep
+// SP, IX and IY in the reduced sweep below.
+var repWide16 = []uint16{
+	0x0000, 0x0001, 0x00FF, 0x0100, 0x7FFE, 0x7FFF, 0x8000, 0x8001,
+	0xFFFE, 0xFFFF, 0x1234, 0x5678, 0xABCD, 0xDEAD, 0xBEEF, 0xCAFE,
+}
+
+// wideSweepValues returns the values to try for a 16-bit register: the full
+// representative set if it's actually read, or just its zero value if not
+// (so the caller can loop over it unconditionally).
+func wideSweepValues(sweep bool) []uint16 {
+	if sweep {
+		return repWide16
+	}
+	return []uint16{0}
+}
+
+func exhaustiveReducedSweep(target, candidate []inst.Instruction, extraRegs []int, sweepSP, sweepIX, sweepIY bool) bool {
 	// Use 32 representative values per extra register
 	repValues := []uint8{
 		0x00, 0x01, 0x02, 0x0F, 0x10, 0x1F, 0x20, 0x3F,
@@ -416,28 +508,7 @@ func exhaustiveReducedSweep(target, candidate []inst.Instruction, extraRegs []in
 		0x03, 0x07, 0x11, 0x33, 0x77, 0xBB, 0xDD, 0xEE,
 	}
 
-	setReg := func(s *cpu.State, offset int, val uint8) {
-		switch offset {
-		case 2:
-			s.B = val
-		case 3:
-			s.C = val
-		case 4:
-			s.D = val
-		case 5:
-			s.E = val
-		case 6:
-			s.H = val
-		case 7:
-			s.L = val
-		}
-	}
-
-	// Representative 16-bit values for SP sweep
-	repSP := []uint16{
-		0x0000, 0x0001, 0x00FF, 0x0100, 0x7FFE, 0x7FFF, 0x8000, 0x8001,
-		0xFFFE, 0xFFFF, 0x1234, 0x5678, 0xABCD, 0xDEAD, 0xBEEF, 0xCAFE,
-	}
+	spVals, ixVals, iyVals := wideSweepValues(sweepSP), wideSweepValues(sweepIX), wideSweepValues(sweepIY)
 
 	// compare is the base case: run both sequences and check equivalence
 	compare := func(s cpu.State) bool {
@@ -450,22 +521,24 @@ func exhaustiveReducedSweep(target, candidate []inst.Instruction, extraRegs []in
 	var sweep func(s cpu.State, regIdx int) bool
 	sweep = func(s cpu.State, regIdx int) bool {
 		if regIdx >= len(extraRegs) {
-			// After 8-bit regs, optionally sweep SP
-			if sweepSP {
-				for _, sp := range repSP {
-					s2 := s
-					s2.SP = sp
-					if !compare(s2) {
-						return false
+			// After 8-bit regs, sweep SP/IX/IY (each a single no-op pass if
+			// that register isn't actually read).
+			for _, sp := range spVals {
+				for _, ix := range ixVals {
+					for _, iy := range iyVals {
+						s2 := s
+						s2.SP, s2.IX, s2.IY = sp, ix, iy
+						if !compare(s2) {
+							return false
+						}
 					}
 				}
-				return true
 			}
-			return compare(s)
+			return true
 		}
 		for _, v := range repValues {
 			s2 := s
-			setReg(&s2, extraRegs[regIdx], v)
+			setExtraReg(&s2, extraRegs[regIdx], v)
 			if !sweep(s2, regIdx+1) {
 				return false
 			}
@@ -484,14 +557,37 @@ func exhaustiveReducedSweep(target, candidate []inst.Instruction, extraRegs []in
 	return true
 }
 
+// NeedsSMT reports whether exhaustiveReducedSweep's representative-value
+// sampling is unsound for this pair: once the sequences read 3 or more extra
+// 8-bit registers in addition to a 16-bit one (SP/IX/IY), the 32 values
+// tried per register are a sample, not a proof, and can miss a carry/
+// half-carry corner case that only shows up for one specific bit pattern.
+// pkg/search/symbolic uses this to decide whether to drop down to an SMT
+// solver instead of trusting the sweep.
+func NeedsSMT(target, candidate []inst.Instruction) bool {
+	reads := regsRead(target) | regsRead(candidate)
+
+	extra := 0
+	for _, r := range []regMask{regB, regC, regD, regE, regH, regL, regMem} {
+		if reads&r != 0 {
+			extra++
+		}
+	}
+	wide := reads&(regSP|regIX|regIY) != 0
+
+	return extra >= 3 && wide
+}
+
 // statesEqualMasked compares two states, ignoring flag bits set in deadFlags.
 func statesEqualMasked(a, b cpu.State, deadFlags FlagMask) bool {
 	return a.A == b.A &&
-		(a.F &^ deadFlags) == (b.F &^ deadFlags) &&
+		(a.F&^deadFlags) == (b.F&^deadFlags) &&
 		a.B == b.B && a.C == b.C &&
 		a.D == b.D && a.E == b.E &&
 		a.H == b.H && a.L == b.L &&
-		a.SP == b.SP
+		a.SP == b.SP &&
+		a.M == b.M &&
+		a.IX == b.IX && a.IY == b.IY
 }
 
 // QuickCheckMasked tests two sequences against test vectors, ignoring dead flag bits.
@@ -577,43 +673,31 @@ func exhaustiveAllMasked(target, candidate []inst.Instruction, reads regMask, de
 	if reads&regL != 0 {
 		extraRegs = append(extraRegs, 7)
 	}
+	if reads&regMem != 0 {
+		extraRegs = append(extraRegs, 8)
+	}
 
 	sweepSP := reads&regSP != 0
+	sweepIX := reads&regIX != 0
+	sweepIY := reads&regIY != 0
 
-	if len(extraRegs) == 0 && !sweepSP {
+	if len(extraRegs) == 0 && !sweepSP && !sweepIX && !sweepIY {
 		return exhaustiveAFMasked(target, candidate, deadFlags)
 	}
 
-	if len(extraRegs) <= 2 && !sweepSP {
+	if len(extraRegs) <= 2 && !sweepSP && !sweepIX && !sweepIY {
 		return exhaustiveFullSweepMasked(target, candidate, extraRegs, deadFlags)
 	}
-	return exhaustiveReducedSweepMasked(target, candidate, extraRegs, sweepSP, deadFlags)
+	return exhaustiveReducedSweepMasked(target, candidate, extraRegs, sweepSP, sweepIX, sweepIY, deadFlags)
 }
 
 func exhaustiveFullSweepMasked(target, candidate []inst.Instruction, extraRegs []int, deadFlags FlagMask) bool {
-	setReg := func(s *cpu.State, offset int, val uint8) {
-		switch offset {
-		case 2:
-			s.B = val
-		case 3:
-			s.C = val
-		case 4:
-			s.D = val
-		case 5:
-			s.E = val
-		case 6:
-			s.H = val
-		case 7:
-			s.L = val
-		}
-	}
-
 	if len(extraRegs) == 1 {
 		for a := 0; a < 256; a++ {
 			for carry := uint8(0); carry <= 1; carry++ {
 				for r := 0; r < 256; r++ {
 					s := cpu.State{A: uint8(a), F: carry}
-					setReg(&s, extraRegs[0], uint8(r))
+					setExtraReg(&s, extraRegs[0], uint8(r))
 					tOut := execSeq(s, target)
 					cOut := execSeq(s, candidate)
 					if !statesEqualMasked(tOut, cOut, deadFlags) {
@@ -630,8 +714,8 @@ func exhaustiveFullSweepMasked(target, candidate []inst.Instruction, extraRegs [
 			for r1 := 0; r1 < 256; r1++ {
 				for r2 := 0; r2 < 256; r2++ {
 					s := cpu.State{A: uint8(a), F: carry}
-					setReg(&s, extraRegs[0], uint8(r1))
-					setReg(&s, extraRegs[1], uint8(r2))
+					setExtraReg(&s, extraRegs[0], uint8(r1))
+					setExtraReg(&s, extraRegs[1], uint8(r2))
 					tOut := execSeq(s, target)
 					cOut := execSeq(s, candidate)
 					if !statesEqualMasked(tOut, cOut, deadFlags) {
@@ -644,7 +728,7 @@ func exhaustiveFullSweepMasked(target, candidate []inst.Instruction, extraRegs [
 	return true
 }
 
-func exhaustiveReducedSweepMasked(target, candidate []inst.Instruction, extraRegs []int, sweepSP bool, deadFlags FlagMask) bool {
+func exhaustiveReducedSweepMasked(target, candidate []inst.Instruction, extraRegs []int, sweepSP, sweepIX, sweepIY bool, deadFlags FlagMask) bool {
 	repValues := []uint8{
 		0x00, 0x01, 0x02, 0x0F, 0x10, 0x1F, 0x20, 0x3F,
 		0x40, 0x55, 0x7E, 0x7F, 0x80, 0x81, 0xAA, 0xBF,
@@ -652,27 +736,7 @@ func exhaustiveReducedSweepMasked(target, candidate []inst.Instruction, extraReg
 		0x03, 0x07, 0x11, 0x33, 0x77, 0xBB, 0xDD, 0xEE,
 	}
 
-	setReg := func(s *cpu.State, offset int, val uint8) {
-		switch offset {
-		case 2:
-			s.B = val
-		case 3:
-			s.C = val
-		case 4:
-			s.D = val
-		case 5:
-			s.E = val
-		case 6:
-			s.H = val
-		case 7:
-			s.L = val
-		}
-	}
-
-	repSP := []uint16{
-		0x0000, 0x0001, 0x00FF, 0x0100, 0x7FFE, 0x7FFF, 0x8000, 0x8001,
-		0xFFFE, 0xFFFF, 0x1234, 0x5678, 0xABCD, 0xDEAD, 0xBEEF, 0xCAFE,
-	}
+	spVals, ixVals, iyVals := wideSweepValues(sweepSP), wideSweepValues(sweepIX), wideSweepValues(sweepIY)
 
 	compare := func(s cpu.State) bool {
 		tOut := execSeq(s, target)
@@ -683,21 +747,22 @@ func exhaustiveReducedSweepMasked(target, candidate []inst.Instruction, extraReg
 	var sweep func(s cpu.State, regIdx int) bool
 	sweep = func(s cpu.State, regIdx int) bool {
 		if regIdx >= len(extraRegs) {
-			if sweepSP {
-				for _, sp := range repSP {
-					s2 := s
-					s2.SP = sp
-					if !compare(s2) {
-						return false
+			for _, sp := range spVals {
+				for _, ix := range ixVals {
+					for _, iy := range iyVals {
+						s2 := s
+						s2.SP, s2.IX, s2.IY = sp, ix, iy
+						if !compare(s2) {
+							return false
+						}
 					}
 				}
-				return true
 			}
-			return compare(s)
+			return true
 		}
 		for _, v := range repValues {
 			s2 := s
-			setReg(&s2, extraRegs[regIdx], v)
+			setExtraReg(&s2, extraRegs[regIdx], v)
 			if !sweep(s2, regIdx+1) {
 				return false
 			}
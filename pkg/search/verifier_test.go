@@ -135,3 +135,33 @@ func TestStatesEqualMasked(t *testing.T) {
 		})
 	}
 }
+
+func TestQuickCheckIO_SamePortSameValue_Equivalent(t *testing.T) {
+	target := []inst.Instruction{{Op: inst.OUT_N_A, Imm: 0xFE}}
+	candidate := []inst.Instruction{{Op: inst.OUT_N_A, Imm: 0xFE}}
+
+	if !QuickCheckIO(target, candidate) {
+		t.Fatal("identical OUT (n), A sequences should be equivalent")
+	}
+}
+
+func TestQuickCheckIO_DifferentPort_NotEquivalent(t *testing.T) {
+	target := []inst.Instruction{{Op: inst.OUT_N_A, Imm: 0xFE}}
+	candidate := []inst.Instruction{{Op: inst.OUT_N_A, Imm: 0xFD}}
+
+	if QuickCheckIO(target, candidate) {
+		t.Fatal("OUT to different ports should not be equivalent")
+	}
+}
+
+func TestQuickCheckIO_DeadLookingLoadStillCompared(t *testing.T) {
+	// IN A,(n) loads a value from the port that QuickCheck alone (register
+	// state only) might call equivalent to a plain NOP if A is later
+	// overwritten, but HasSideEffects means the access itself must match.
+	target := []inst.Instruction{{Op: inst.IN_A_N, Imm: 0x10}, {Op: inst.LD_B_A}}
+	candidate := []inst.Instruction{{Op: inst.IN_A_N, Imm: 0x20}, {Op: inst.LD_B_A}}
+
+	if QuickCheckIO(target, candidate) {
+		t.Fatal("IN from different ports should not be equivalent even though B ends up the same (0)")
+	}
+}
@@ -37,6 +37,7 @@ type SearchTask struct {
 	Target     []inst.Instruction
 	MaxCandLen int
 	DeadFlags  FlagMask // If nonzero, also try masked equivalence when full match fails
+	DeadRegs   RegMask  // chunk5-1: if nonzero, also ignore these registers when full match fails
 }
 
 // Stats returns search statistics.
@@ -185,13 +186,17 @@ func (wp *WorkerPool) processTask(task SearchTask, verbose bool) {
 		}
 	}
 
-	// If no full match found and dead-flags mode is enabled, try masked equivalence
-	if task.DeadFlags != DeadNone {
+	// If no full match found and either dead-flags or dead-regs mode is
+	// enabled, try masked equivalence.
+	if task.DeadFlags != DeadNone || task.DeadRegs != DeadRegNone {
 		wp.processTaskMasked(task, verbose)
 	}
 }
 
-// processTaskMasked tries to find replacements that are equivalent when dead flags are ignored.
+// processTaskMasked tries to find replacements that are equivalent once
+// task.DeadFlags/task.DeadRegs are ignored — e.g. LD A,0 -> XOR A when the
+// flags it sets are dead, or LD B,n; LD C,n -> LD BC,nn when only the pair
+// BC is live and the individual halves aren't.
 func (wp *WorkerPool) processTaskMasked(task SearchTask, verbose bool) {
 	targetBytes := inst.SeqByteSize(task.Target)
 	targetTStates := inst.SeqTStates(task.Target)
@@ -216,19 +221,21 @@ func (wp *WorkerPool) processTaskMasked(task SearchTask, verbose bool) {
 			}
 
 			// Try masked QuickCheck
-			if !QuickCheckMasked(task.Target, cand, task.DeadFlags) {
+			if !QuickCheckMaskedRegs(task.Target, cand, task.DeadFlags, task.DeadRegs) {
 				return true
 			}
 
 			// Masked exhaustive verification
-			if !ExhaustiveCheckMasked(task.Target, cand, task.DeadFlags) {
+			if !ExhaustiveCheckMaskedRegs(task.Target, cand, task.DeadFlags, task.DeadRegs) {
 				return true
 			}
 
-			// Determine exactly which flags differ
+			// Determine exactly which flags differ; registers are recorded
+			// as the caller's declared DeadRegs (there's no per-pair minimal
+			// diff the way FlagDiff narrows flags).
 			flagDiff := FlagDiff(task.Target, cand)
-			if flagDiff == 0 {
-				// Registers differ — shouldn't happen after masked check, skip
+			if flagDiff == 0 && task.DeadRegs == DeadRegNone {
+				// Nothing actually differs — shouldn't happen after masked check, skip
 				return true
 			}
 
@@ -243,6 +250,7 @@ func (wp *WorkerPool) processTaskMasked(task SearchTask, verbose bool) {
 				BytesSaved:  targetBytes - candBytes,
 				CyclesSaved: targetTStates - candTStates,
 				DeadFlags:   flagDiff,
+				DeadRegs:    task.DeadRegs,
 			}
 
 			wp.mu.Lock()
@@ -250,8 +258,8 @@ func (wp *WorkerPool) processTaskMasked(task SearchTask, verbose bool) {
 			wp.mu.Unlock()
 
 			if verbose {
-				fmt.Printf("  FOUND (dead flags 0x%02X): %s -> %s (-%d bytes, -%d cycles)\n",
-					flagDiff, disasmSeq(task.Target), disasmSeq(candCopy),
+				fmt.Printf("  FOUND (dead flags 0x%02X, dead regs 0x%04X): %s -> %s (-%d bytes, -%d cycles)\n",
+					flagDiff, task.DeadRegs, disasmSeq(task.Target), disasmSeq(candCopy),
 					rule.BytesSaved, rule.CyclesSaved)
 			}
 
@@ -264,6 +272,67 @@ func (wp *WorkerPool) processTaskMasked(task SearchTask, verbose bool) {
 	}
 }
 
+// RunTasksResumable is RunTasks, but skips the prefix of tasks a prior run
+// already got through (per store's saved CursorState.TaskIndex) and
+// periodically checkpoints progress through store, so a preempted
+// multi-day run can pick back up instead of redoing finished targets
+// (chunk6-1). A nil store behaves exactly like RunTasks.
+//
+// tasks must be built the same deterministic way every run (collectTasks'
+// EnumerateSequences8 order) for TaskIndex to mean the same thing across
+// runs. Because RunTasks hands tasks to NumWorkers goroutines off a shared
+// channel, completions don't land in index order, so TaskIndex is tracked
+// as a count rather than "tasks[0:TaskIndex] are specifically done" — on
+// resume, at most NumWorkers-1 tasks that were in flight (but uncompleted)
+// at the last checkpoint may be redundantly rechecked. That's wasted work,
+// never lost results.
+func (wp *WorkerPool) RunTasksResumable(tasks []SearchTask, verbose bool, store CheckpointStore) {
+	startAt := 0
+	if store != nil {
+		if cursor, ok, err := store.Load(); err == nil && ok {
+			startAt = cursor.TaskIndex
+			wp.checked.Store(cursor.Checked)
+			wp.found.Store(cursor.Found)
+		}
+	}
+	if startAt > len(tasks) {
+		startAt = len(tasks)
+	}
+
+	if store == nil {
+		wp.RunTasks(tasks[startAt:], verbose)
+		return
+	}
+
+	done := make(chan struct{})
+	completedAtStart := wp.completed.Load()
+	go func() {
+		ticker := time.NewTicker(30 * time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				_ = store.Save(CursorState{
+					TaskIndex: startAt + int(wp.completed.Load()-completedAtStart),
+					Checked:   wp.checked.Load(),
+					Found:     wp.found.Load(),
+				})
+			}
+		}
+	}()
+
+	wp.RunTasks(tasks[startAt:], verbose)
+	close(done)
+
+	_ = store.Save(CursorState{
+		TaskIndex: len(tasks),
+		Checked:   wp.checked.Load(),
+		Found:     wp.found.Load(),
+	})
+}
+
 func copySeq(seq []inst.Instruction) []inst.Instruction {
 	c := make([]inst.Instruction, len(seq))
 	copy(c, seq)
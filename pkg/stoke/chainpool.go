@@ -0,0 +1,147 @@
+package stoke
+
+import (
+	"math/rand/v2"
+
+	"github.com/oisee/z80-optimizer/pkg/inst"
+)
+
+// ChainPool is a geometric temperature ladder of Chains with periodic
+// replica exchange between adjacent rungs (chunk4-2's parallel tempering,
+// factored out here so chunk5-2's ladder auto-tuning and burn-in mode have
+// one place to live instead of living inline in Run).
+type ChainPool struct {
+	Chains       []*Chain
+	Ladder       []float64
+	SwapInterval int
+
+	swapAttempts []int64 // per adjacent pair (len(Chains)-1)
+	swapAccepts  []int64
+}
+
+// NewChainPool builds a pool of n chains seeded along a geometric ladder
+// from tMin (coldest, exploitation) to tMax (hottest, exploration), the
+// same ladder shape Run constructs.
+func NewChainPool(target []inst.Instruction, n int, tMin, tMax float64, swapInterval int, baseSeed uint64) *ChainPool {
+	ladder := temperatureLadder(n, tMin, tMax)
+	chains := make([]*Chain, n)
+	for i := range chains {
+		seed := baseSeed + uint64(i)*0x9E3779B97F4A7C15
+		chains[i] = NewChain(target, ladder[i], seed)
+	}
+	pairs := 0
+	if n > 1 {
+		pairs = n - 1
+	}
+	return &ChainPool{
+		Chains:       chains,
+		Ladder:       ladder,
+		SwapInterval: swapInterval,
+		swapAttempts: make([]int64, pairs),
+		swapAccepts:  make([]int64, pairs),
+	}
+}
+
+// TryExchanges attempts one replica-exchange sweep across every adjacent
+// rung pair, tracking each pair's accept/attempt counts for AutoTune and
+// TotalSwaps.
+func (p *ChainPool) TryExchanges(rng *rand.Rand) {
+	for i := 0; i < len(p.Chains)-1; i++ {
+		p.swapAttempts[i]++
+		if p.Chains[i].TryReplicaExchange(p.Chains[i+1], rng) {
+			p.swapAccepts[i]++
+		}
+	}
+}
+
+// SwapAcceptRate returns the observed replica-exchange acceptance rate
+// between rungs i and i+1, or 0 if no swaps between that pair have been
+// attempted yet.
+func (p *ChainPool) SwapAcceptRate(i int) float64 {
+	if p.swapAttempts[i] == 0 {
+		return 0
+	}
+	return float64(p.swapAccepts[i]) / float64(p.swapAttempts[i])
+}
+
+// TotalSwaps sums accept/attempt counts across every adjacent pair, for
+// callers that only want one aggregate acceptance rate to report.
+func (p *ChainPool) TotalSwaps() (attempted, accepted int64) {
+	for i := range p.swapAttempts {
+		attempted += p.swapAttempts[i]
+		accepted += p.swapAccepts[i]
+	}
+	return attempted, accepted
+}
+
+// Target acceptance band AutoTune aims to keep every adjacent rung pair
+// within. Below it, the temperature gap is too wide (swaps almost never
+// accepted, rungs barely share information); above it, the gap is too
+// narrow (the ladder isn't spreading exploration across temperatures) —
+// the ~20-40% range that gives parallel tempering literature its rule of
+// thumb for efficient mixing.
+const (
+	autoTuneMinAccept = 0.20
+	autoTuneMaxAccept = 0.40
+	autoTuneStep      = 0.05 // fractional adjustment to a rung's gap per call
+)
+
+// AutoTune nudges each rung's temperature toward keeping its
+// replica-exchange acceptance rate against the rung below it within
+// [autoTuneMinAccept, autoTuneMaxAccept]: too low narrows the gap, too high
+// widens it. Intended to be called periodically (e.g. every few thousand
+// steps) once enough swaps have accumulated for SwapAcceptRate to be
+// meaningful; each call resets the pair's counters so the next AutoTune
+// judges only swaps taken under the new gap.
+func (p *ChainPool) AutoTune() {
+	for i := 0; i < len(p.Chains)-1; i++ {
+		if p.swapAttempts[i] == 0 {
+			continue
+		}
+		rate := p.SwapAcceptRate(i)
+		gap := p.Ladder[i+1] - p.Ladder[i]
+		switch {
+		case rate < autoTuneMinAccept:
+			gap *= 1 - autoTuneStep
+		case rate > autoTuneMaxAccept:
+			gap *= 1 + autoTuneStep
+		default:
+			p.swapAttempts[i], p.swapAccepts[i] = 0, 0
+			continue
+		}
+		p.Ladder[i+1] = p.Ladder[i] + gap
+		p.Chains[i+1].temperature = p.Ladder[i+1]
+		p.swapAttempts[i], p.swapAccepts[i] = 0, 0
+	}
+}
+
+// BurnIn runs every chain in the pool for steps iterations, attempting a
+// replica exchange every SwapInterval steps — full parallel-tempering
+// exploration, matching Run's main loop. Call this before AnnealColdest so
+// candidates have a chance to migrate down to the coldest rung before the
+// hotter rungs are discarded.
+func (p *ChainPool) BurnIn(steps int, decay float64, rng *rand.Rand) {
+	for s := 0; s < steps; s++ {
+		for _, c := range p.Chains {
+			c.Step(decay)
+		}
+		if p.SwapInterval > 0 && (s+1)%p.SwapInterval == 0 {
+			p.TryExchanges(rng)
+		}
+	}
+}
+
+// AnnealColdest runs only the coldest rung (Chains[0]) for steps further
+// iterations, ignoring every other chain in the pool — the "burn-in then
+// anneal only the coldest chain" mode: once replica exchange has let good
+// candidates settle into the exploiting rung, the hotter rungs have done
+// their job and stepping them further is wasted work.
+func (p *ChainPool) AnnealColdest(steps int, decay float64) {
+	if len(p.Chains) == 0 {
+		return
+	}
+	coldest := p.Chains[0]
+	for s := 0; s < steps; s++ {
+		coldest.Step(decay)
+	}
+}
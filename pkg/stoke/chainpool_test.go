@@ -0,0 +1,95 @@
+package stoke
+
+import (
+	"math/rand/v2"
+	"testing"
+
+	"github.com/oisee/z80-optimizer/pkg/inst"
+)
+
+func TestNewChainPoolBuildsLadder(t *testing.T) {
+	target := []inst.Instruction{{Op: inst.AND_A}}
+	pool := NewChainPool(target, 4, 0.1, 1.0, 500, 7)
+
+	if len(pool.Chains) != 4 || len(pool.Ladder) != 4 {
+		t.Fatalf("expected 4 chains/rungs, got %d/%d", len(pool.Chains), len(pool.Ladder))
+	}
+	for i, c := range pool.Chains {
+		if c.temperature != pool.Ladder[i] {
+			t.Errorf("chain %d temperature = %v, want ladder rung %v", i, c.temperature, pool.Ladder[i])
+		}
+	}
+}
+
+func TestChainPoolTryExchangesTracksAcceptRate(t *testing.T) {
+	target := []inst.Instruction{{Op: inst.AND_A}}
+	pool := NewChainPool(target, 2, 0.1, 1.0, 1, 7)
+
+	// Force the hot rung to hold a much better candidate so every exchange
+	// attempt is guaranteed to accept.
+	pool.Chains[1].current, pool.Chains[1].cost = []inst.Instruction{{Op: inst.NOP}}, 10
+	pool.Chains[1].best = copySeq(pool.Chains[1].current)
+	pool.Chains[0].current, pool.Chains[0].cost = []inst.Instruction{{Op: inst.XOR_A}, {Op: inst.LD_A_B}}, 5000
+	pool.Chains[0].best = copySeq(pool.Chains[0].current)
+
+	rng := rand.New(rand.NewPCG(1, 1))
+	pool.TryExchanges(rng)
+
+	if rate := pool.SwapAcceptRate(0); rate != 1.0 {
+		t.Fatalf("SwapAcceptRate(0) = %v, want 1.0", rate)
+	}
+	attempted, accepted := pool.TotalSwaps()
+	if attempted != 1 || accepted != 1 {
+		t.Fatalf("TotalSwaps() = %d/%d, want 1/1", accepted, attempted)
+	}
+}
+
+func TestChainPoolAutoTuneNarrowsColdGapOnLowAcceptRate(t *testing.T) {
+	target := []inst.Instruction{{Op: inst.AND_A}}
+	pool := NewChainPool(target, 2, 0.1, 1.0, 1, 7)
+	gapBefore := pool.Ladder[1] - pool.Ladder[0]
+
+	// Every exchange attempt rejected: hot rung holds a much worse
+	// candidate than cold, so TryReplicaExchange should never accept.
+	pool.Chains[0].current, pool.Chains[0].cost = []inst.Instruction{{Op: inst.NOP}}, 10
+	pool.Chains[0].best = copySeq(pool.Chains[0].current)
+	pool.Chains[1].current, pool.Chains[1].cost = []inst.Instruction{{Op: inst.XOR_A}, {Op: inst.LD_A_B}}, 5000
+	pool.Chains[1].best = copySeq(pool.Chains[1].current)
+
+	rng := rand.New(rand.NewPCG(1, 1))
+	for i := 0; i < 20; i++ {
+		pool.TryExchanges(rng)
+	}
+
+	pool.AutoTune()
+
+	gapAfter := pool.Ladder[1] - pool.Ladder[0]
+	if gapAfter >= gapBefore {
+		t.Fatalf("AutoTune did not narrow the gap on a low accept rate: before=%v after=%v", gapBefore, gapAfter)
+	}
+	if pool.Chains[1].temperature != pool.Ladder[1] {
+		t.Fatalf("Chains[1].temperature = %v, want updated ladder rung %v", pool.Chains[1].temperature, pool.Ladder[1])
+	}
+}
+
+func TestChainPoolAnnealColdestOnlyStepsRungZero(t *testing.T) {
+	target := []inst.Instruction{{Op: inst.LD_A_N, Imm: 0}}
+	pool := NewChainPool(target, 3, 0.1, 1.0, 1000, 7)
+
+	beforeAccepted := make([]int64, len(pool.Chains))
+	beforeRejected := make([]int64, len(pool.Chains))
+	for i, c := range pool.Chains {
+		beforeAccepted[i], beforeRejected[i] = c.Accepted, c.Rejected
+	}
+
+	pool.AnnealColdest(200, 0.999)
+
+	if pool.Chains[0].Accepted+pool.Chains[0].Rejected == beforeAccepted[0]+beforeRejected[0] {
+		t.Fatal("AnnealColdest did not step the coldest chain")
+	}
+	for i := 1; i < len(pool.Chains); i++ {
+		if pool.Chains[i].Accepted != beforeAccepted[i] || pool.Chains[i].Rejected != beforeRejected[i] {
+			t.Errorf("chain %d stepped, expected AnnealColdest to leave non-coldest rungs untouched", i)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package stoke
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"fmt"
+	"os"
+
+	"github.com/oisee/z80-optimizer/pkg/inst"
+)
+
+// checkpointVersion is bumped whenever checkpointFile's shape changes in a
+// way older decoders can't handle; readCheckpoint refuses to load a file
+// whose Version doesn't match.
+const checkpointVersion byte = 1
+
+// ChainState is one Chain's serializable snapshot (chunk12-4): its
+// current/best candidates, cost, temperature, RNG state, and counters —
+// everything NewChainFromState needs to reconstruct an equivalent Chain.
+type ChainState struct {
+	Current       []inst.Instruction
+	Best          []inst.Instruction
+	Cost          int
+	BestCost      int
+	Temperature   float64
+	PCG           []byte // rand/v2 (*rand.PCG).MarshalBinary output
+	Accepted      int64
+	Rejected      int64
+	SwapsAccepted int64
+	SwapsProposed int64
+}
+
+// checkpointFile is the on-disk format Config.CheckpointPath is written to
+// and Resume/ResumeStream read back. Target and DeadFlags are carried so a
+// checkpoint can be matched against the cfg it's being resumed with before
+// any chain state is trusted.
+type checkpointFile struct {
+	Version      byte
+	Target       []inst.Instruction
+	DeadFlags    uint8
+	DeadRegs     RegMask
+	TempMin      float64
+	TempMax      float64
+	SwapInterval int
+	BaseSeed     uint64
+	Iter         int
+	Chains       []ChainState
+	Results      []Result // already-verified results, deduped by the run that wrote this file
+}
+
+// writeCheckpoint gob-encodes f to path via a temp file in the same
+// directory plus an atomic rename, so a crash mid-write never leaves a
+// corrupt checkpoint in path's place.
+func writeCheckpoint(path string, f checkpointFile) error {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(f); err != nil {
+		return fmt.Errorf("stoke: encoding checkpoint: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("stoke: writing checkpoint: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("stoke: committing checkpoint: %w", err)
+	}
+	return nil
+}
+
+// readCheckpoint loads and decodes path, refusing to return a checkpoint
+// whose Version, Target, DeadFlags, or DeadRegs disagrees with cfg —
+// resuming against a different problem than the one that was checkpointed
+// would silently corrupt the search rather than fail loudly.
+func readCheckpoint(path string, cfg Config) (checkpointFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return checkpointFile{}, fmt.Errorf("stoke: reading checkpoint: %w", err)
+	}
+	var f checkpointFile
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&f); err != nil {
+		return checkpointFile{}, fmt.Errorf("stoke: decoding checkpoint: %w", err)
+	}
+	if f.Version != checkpointVersion {
+		return checkpointFile{}, fmt.Errorf("stoke: checkpoint version %d, want %d", f.Version, checkpointVersion)
+	}
+	if !seqEqual(f.Target, cfg.Target) {
+		return checkpointFile{}, fmt.Errorf("stoke: checkpoint target does not match cfg.Target")
+	}
+	if f.DeadFlags != cfg.DeadFlags {
+		return checkpointFile{}, fmt.Errorf("stoke: checkpoint DeadFlags 0x%02X does not match cfg.DeadFlags 0x%02X", f.DeadFlags, cfg.DeadFlags)
+	}
+	if f.DeadRegs != cfg.DeadRegs {
+		return checkpointFile{}, fmt.Errorf("stoke: checkpoint DeadRegs 0x%04X does not match cfg.DeadRegs 0x%04X", f.DeadRegs, cfg.DeadRegs)
+	}
+	return f, nil
+}
+
+func seqEqual(a, b []inst.Instruction) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// ResumeStream is Resume's streaming form, paralleling RunStream: instead of
+// starting cfg.Target's search fresh, it loads cfg.CheckpointPath, validates
+// it against cfg.Target/cfg.DeadFlags, and continues every chain from its
+// checkpointed state — current/best candidates, temperature, RNG, and
+// counters — picking iteration counts up where the checkpoint left off.
+// Every Result the checkpoint had already verified is replayed on resultsCh
+// first, so a caller draining the channel sees the same results a fresh
+// drain of the original run would have, followed by anything new.
+//
+// If the checkpoint can't be read or doesn't match cfg, resultsCh is closed
+// immediately and errCh receives the reason — resuming against a mismatched
+// target or dead-flags set would silently corrupt the search, so this
+// refuses rather than guessing.
+func ResumeStream(ctx context.Context, cfg Config) (<-chan Result, <-chan error) {
+	resultsCh := make(chan Result, 16)
+	errCh := make(chan error, 1)
+
+	ckpt, err := readCheckpoint(cfg.CheckpointPath, cfg)
+	if err != nil {
+		close(resultsCh)
+		errCh <- err
+		close(errCh)
+		return resultsCh, errCh
+	}
+
+	go func() {
+		defer close(resultsCh)
+		defer close(errCh)
+		for _, r := range ckpt.Results {
+			select {
+			case resultsCh <- r:
+			case <-ctx.Done():
+				errCh <- ctx.Err()
+				return
+			}
+		}
+		runStreamFrom(ctx, cfg, resultsCh, &ckpt)
+		if err := ctx.Err(); err != nil {
+			errCh <- err
+		}
+	}()
+
+	return resultsCh, errCh
+}
+
+// Resume is ResumeStream's blocking form, paralleling Run: it drains
+// ResumeStream into a slice and returns it. If cfg.CheckpointPath can't be
+// loaded or doesn't match cfg.Target/cfg.DeadFlags, it returns nil — a
+// caller that needs to tell "resumed and found nothing new" apart from
+// "refused to resume" should call ResumeStream directly and check errCh.
+func Resume(cfg Config) []Result {
+	resultsCh, _ := ResumeStream(context.Background(), cfg)
+	var results []Result
+	for r := range resultsCh {
+		results = append(results, r)
+	}
+	return results
+}
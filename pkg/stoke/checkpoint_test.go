@@ -0,0 +1,89 @@
+package stoke
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/oisee/z80-optimizer/pkg/inst"
+)
+
+func TestResumeContinuesFromCheckpoint(t *testing.T) {
+	target := []inst.Instruction{{Op: inst.AND_N, Imm: 0xFF}}
+	path := filepath.Join(t.TempDir(), "ckpt.gob")
+
+	cfg := Config{
+		Target:          target,
+		Chains:          2,
+		Iterations:      200,
+		Decay:           0.9999,
+		CheckpointPath:  path,
+		CheckpointEvery: time.Nanosecond, // checkpoint on every batch
+	}
+	_ = Run(cfg)
+
+	if _, err := readCheckpoint(path, cfg); err != nil {
+		t.Fatalf("checkpoint not readable after Run: %v", err)
+	}
+
+	resumeCfg := cfg
+	resumeCfg.Iterations = 400 // resume and keep searching past where it left off
+	results := Resume(resumeCfg)
+	if len(results) == 0 {
+		t.Fatal("Resume found no results, want the checkpointed AND 0FFh optimization")
+	}
+}
+
+func TestReadCheckpointRefusesMismatchedTarget(t *testing.T) {
+	target := []inst.Instruction{{Op: inst.AND_N, Imm: 0xFF}}
+	path := filepath.Join(t.TempDir(), "ckpt.gob")
+	cfg := Config{
+		Target:          target,
+		Chains:          1,
+		Iterations:      50,
+		Decay:           0.9999,
+		CheckpointPath:  path,
+		CheckpointEvery: time.Nanosecond,
+	}
+	_ = Run(cfg)
+
+	other := cfg
+	other.Target = []inst.Instruction{{Op: inst.AND_N, Imm: 0x0F}}
+	if _, err := readCheckpoint(path, other); err == nil {
+		t.Fatal("readCheckpoint accepted a checkpoint for a different Target")
+	}
+}
+
+func TestReadCheckpointRefusesMismatchedDeadRegs(t *testing.T) {
+	target := []inst.Instruction{{Op: inst.AND_N, Imm: 0xFF}}
+	path := filepath.Join(t.TempDir(), "ckpt.gob")
+	cfg := Config{
+		Target:          target,
+		Chains:          1,
+		Iterations:      50,
+		Decay:           0.9999,
+		CheckpointPath:  path,
+		CheckpointEvery: time.Nanosecond,
+	}
+	_ = Run(cfg)
+
+	other := cfg
+	other.DeadRegs = DeadRegA
+	if _, err := readCheckpoint(path, other); err == nil {
+		t.Fatal("readCheckpoint accepted a checkpoint for a different DeadRegs")
+	}
+}
+
+func TestResumeStreamRefusesMissingCheckpoint(t *testing.T) {
+	cfg := Config{
+		Target:         []inst.Instruction{{Op: inst.AND_A}},
+		CheckpointPath: filepath.Join(t.TempDir(), "does-not-exist.gob"),
+	}
+	resultsCh, errCh := ResumeStream(context.Background(), cfg)
+	for range resultsCh {
+	}
+	if err := <-errCh; err == nil {
+		t.Fatal("ResumeStream accepted a nonexistent checkpoint path")
+	}
+}
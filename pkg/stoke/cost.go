@@ -29,18 +29,11 @@ func execSeq(initial cpu.State, seq []inst.Instruction) cpu.State {
 
 // Cost evaluates how far a candidate is from matching the target.
 // Returns: 1000 * mismatches + byteSize(candidate) + cycleCount(candidate)/100
+// (PlainZ80's uncontended timing; see CostWithModel for other machines).
 // When Cost returns a value with mismatches==0, the candidate matches on all
 // test vectors (but still needs ExhaustiveCheck to prove full equivalence).
 func Cost(target, candidate []inst.Instruction) int {
-	mismatches := 0
-	for i := range testVectors {
-		tOut := execSeq(testVectors[i], target)
-		cOut := execSeq(testVectors[i], candidate)
-		if tOut != cOut {
-			mismatches++
-		}
-	}
-	return 1000*mismatches + inst.SeqByteSize(candidate) + inst.SeqTStates(candidate)/100
+	return CostWithModel(target, candidate, PlainZ80)
 }
 
 // Mismatches returns only the mismatch count on test vectors.
@@ -81,9 +74,91 @@ func MismatchesMasked(target, candidate []inst.Instruction, deadFlags uint8) int
 // statesEqualMasked compares two states, ignoring flag bits set in deadFlags.
 func statesEqualMasked(a, b cpu.State, deadFlags uint8) bool {
 	return a.A == b.A &&
-		(a.F &^ deadFlags) == (b.F &^ deadFlags) &&
+		(a.F&^deadFlags) == (b.F&^deadFlags) &&
 		a.B == b.B && a.C == b.C &&
 		a.D == b.D && a.E == b.E &&
 		a.H == b.H && a.L == b.L &&
 		a.SP == b.SP
 }
+
+// RegMask indicates which general-purpose registers are "dead" (their final
+// value is unobserved) and can be ignored in equivalence checks, same idea as
+// the uint8 flag masks above but for registers. BC/DE/HL bits mask both
+// halves of their pair at once. Duplicated from pkg/search.RegMask rather
+// than imported, same reasoning as testVectors above.
+type RegMask = uint16
+
+const (
+	DeadRegNone RegMask = 0x0000
+	DeadRegA    RegMask = 1 << 0
+	DeadRegB    RegMask = 1 << 1
+	DeadRegC    RegMask = 1 << 2
+	DeadRegD    RegMask = 1 << 3
+	DeadRegE    RegMask = 1 << 4
+	DeadRegH    RegMask = 1 << 5
+	DeadRegL    RegMask = 1 << 6
+	DeadRegBC   RegMask = 1 << 7
+	DeadRegDE   RegMask = 1 << 8
+	DeadRegHL   RegMask = 1 << 9
+)
+
+// statesEqualMaskedRegs is statesEqualMasked plus register masking: a
+// register byte is only compared if neither its own bit nor its pair's bit
+// is set in deadRegs.
+func statesEqualMaskedRegs(a, b cpu.State, deadFlags uint8, deadRegs RegMask) bool {
+	if deadRegs&DeadRegA == 0 && a.A != b.A {
+		return false
+	}
+	if (a.F &^ deadFlags) != (b.F &^ deadFlags) {
+		return false
+	}
+	if deadRegs&(DeadRegB|DeadRegBC) == 0 && a.B != b.B {
+		return false
+	}
+	if deadRegs&(DeadRegC|DeadRegBC) == 0 && a.C != b.C {
+		return false
+	}
+	if deadRegs&(DeadRegD|DeadRegDE) == 0 && a.D != b.D {
+		return false
+	}
+	if deadRegs&(DeadRegE|DeadRegDE) == 0 && a.E != b.E {
+		return false
+	}
+	if deadRegs&(DeadRegH|DeadRegHL) == 0 && a.H != b.H {
+		return false
+	}
+	if deadRegs&(DeadRegL|DeadRegHL) == 0 && a.L != b.L {
+		return false
+	}
+	return a.SP == b.SP
+}
+
+// CostMaskedRegs is CostMasked plus register masking, so Run can search
+// subject to dead flags and dead registers simultaneously.
+func CostMaskedRegs(target, candidate []inst.Instruction, deadFlags uint8, deadRegs RegMask) int {
+	if deadFlags == 0 && deadRegs == DeadRegNone {
+		return Cost(target, candidate)
+	}
+	mismatches := MismatchesMaskedRegs(target, candidate, deadFlags, deadRegs)
+	return 1000*mismatches + inst.SeqByteSize(candidate) + inst.SeqTStates(candidate)/100
+}
+
+// MismatchesMaskedRegs is MismatchesMasked plus register masking.
+func MismatchesMaskedRegs(target, candidate []inst.Instruction, deadFlags uint8, deadRegs RegMask) int {
+	mismatches := 0
+	for i := range testVectors {
+		tOut := execSeq(testVectors[i], target)
+		cOut := execSeq(testVectors[i], candidate)
+		if !statesEqualMaskedRegs(tOut, cOut, deadFlags, deadRegs) {
+			mismatches++
+		}
+	}
+	return mismatches
+}
+
+// recordCounterexample appends an SMT-derived counterexample to
+// testVectors, so Cost's fast path starts rejecting candidates that fail
+// the same way on its own, without another solver call (chunk5-3).
+func recordCounterexample(v cpu.State) {
+	testVectors = append(testVectors, v)
+}
@@ -0,0 +1,174 @@
+package stoke
+
+import (
+	"github.com/oisee/z80-optimizer/pkg/inst"
+	"github.com/oisee/z80-optimizer/pkg/timing"
+)
+
+// CostModel turns a candidate sequence into the non-mismatch portion of its
+// STOKE cost — byte size plus a machine-specific cycle count. Cost used to
+// hardcode this as byteSize + T-states/100 (an uncontended, flat-timing
+// Z80); CostModel (chunk4-6) makes that pluggable per target machine via
+// Config.CostModel, so a rewrite gets judged on how fast it actually runs on
+// the machine it's meant for, not just on raw T-states.
+type CostModel interface {
+	// Cycles returns the actual cycle count seq takes on this machine,
+	// accounting for its own contention/wait-state behavior.
+	Cycles(seq []inst.Instruction) int
+	// SizeCycleCost returns byteSize(seq) + Cycles(seq)/100; Cost adds
+	// 1000*mismatches on top of this.
+	SizeCycleCost(seq []inst.Instruction) int
+}
+
+// contentionPattern is the ZX Spectrum 48K ULA's repeating early-contention
+// delay: of every 8 T-states the CPU is stalled by 6,5,4,3,2,1,0,0 T-states
+// depending on which of those eight a contended memory access lands on.
+var contentionPattern = [8]int{6, 5, 4, 3, 2, 1, 0, 0}
+
+// plainZ80 is the original, uncontended cost: byte size plus T-states/100,
+// computed with the same integer division the old Cost formula used so
+// PlainZ80 reproduces its historic numbers exactly.
+type plainZ80 struct{}
+
+func (plainZ80) Cycles(seq []inst.Instruction) int {
+	return inst.SeqTStates(seq)
+}
+
+func (p plainZ80) SizeCycleCost(seq []inst.Instruction) int {
+	return inst.SeqByteSize(seq) + p.Cycles(seq)/100
+}
+
+// PlainZ80 is an uncontended Z80: every instruction costs exactly its
+// catalog T-states, with no machine-specific wait states.
+var PlainZ80 CostModel = plainZ80{}
+
+// zxSpectrum48k charges the ULA's contention pattern on top of raw T-states
+// for every byte this sequence fetches or touches in memory, on the
+// assumption that the routine and its operands live in the lower 16K of RAM
+// (0x4000-0x7FFF) like the vast majority of real ZX Spectrum machine code.
+// STOKE has no notion of where a candidate will actually be assembled or
+// what address its (HL)/(IX+d) operands resolve to at runtime, so this is
+// an explicit simplification (every access pays contention) rather than a
+// per-address trace.
+type zxSpectrum48k struct {
+	frameOffset int // T-state within the 8-cycle contention pattern at sequence start
+}
+
+func (z zxSpectrum48k) Cycles(seq []inst.Instruction) int {
+	cycles := 0
+	offset := z.frameOffset
+	for _, instr := range seq {
+		cycles += inst.TStates(instr.Op)
+		acc := inst.AccessOf(instr.Op)
+		accesses := int(acc.OpcodeFetchBytes) + int(acc.OperandFetchBytes) + int(acc.MemReads) + int(acc.MemWrites)
+		for i := 0; i < accesses; i++ {
+			cycles += contentionPattern[offset%8]
+			offset += 4 // each contended access occupies one 4-T-state bus cycle before the pattern repeats
+		}
+	}
+	return cycles
+}
+
+func (z zxSpectrum48k) SizeCycleCost(seq []inst.Instruction) int {
+	return inst.SeqByteSize(seq) + z.Cycles(seq)/100
+}
+
+// ZXSpectrum48kContended is a 48K Spectrum with its contention pattern
+// starting at frame offset 0. Use NewZXSpectrum48kContended to pick a
+// different point in the ULA's cycle (e.g. a routine known to run at a
+// fixed point inside an interrupt handler).
+var ZXSpectrum48kContended CostModel = zxSpectrum48k{frameOffset: 0}
+
+// NewZXSpectrum48kContended builds a ZXSpectrum48kContended-style model
+// starting at a chosen T-state offset within the ULA's repeating 8-cycle
+// contention pattern.
+func NewZXSpectrum48kContended(frameOffset int) CostModel {
+	return zxSpectrum48k{frameOffset: frameOffset}
+}
+
+// cpc models the Amstrad CPC gate array, which only hands the Z80 the bus
+// on its own 4 T-state-aligned clock: every instruction's T-states are
+// rounded up to the next multiple of 4 (gate-array wait states rounded to 4).
+type cpc struct{}
+
+func (cpc) Cycles(seq []inst.Instruction) int {
+	cycles := 0
+	for _, instr := range seq {
+		t := inst.TStates(instr.Op)
+		cycles += (t + 3) / 4 * 4
+	}
+	return cycles
+}
+
+func (c cpc) SizeCycleCost(seq []inst.Instruction) int {
+	return inst.SeqByteSize(seq) + c.Cycles(seq)/100
+}
+
+// CPC is the Amstrad CPC gate-array cost model.
+var CPC CostModel = cpc{}
+
+// timingCostModel adapts a pkg/timing.TimingModel (chunk5-4's per-M-cycle,
+// PC-aware contention model) to the CostModel interface, fixing the base PC
+// and frame offset a candidate is assumed to run at — CostModel.Cycles has
+// no room for either, since Chain scores candidates without ever placing
+// them at a real address.
+type timingCostModel struct {
+	model       timing.TimingModel
+	basePC      uint16
+	frameOffset int
+}
+
+func (t timingCostModel) Cycles(seq []inst.Instruction) int {
+	return t.model.TStates(seq, t.basePC, t.frameOffset)
+}
+
+func (t timingCostModel) SizeCycleCost(seq []inst.Instruction) int {
+	return inst.SeqByteSize(seq) + t.Cycles(seq)/100
+}
+
+// targetCostModel adapts an inst.Target's per-opcode T-states (chunk5-5) to
+// the CostModel interface, so a variant with non-Z80 timing (R800's faster
+// clock) is judged on cycles that actually match the hardware Target names.
+type targetCostModel struct {
+	target inst.Target
+}
+
+func (t targetCostModel) Cycles(seq []inst.Instruction) int {
+	cycles := 0
+	for _, instr := range seq {
+		cycles += t.target.TStates(instr.Op)
+	}
+	return cycles
+}
+
+func (t targetCostModel) SizeCycleCost(seq []inst.Instruction) int {
+	return inst.SeqByteSize(seq) + t.Cycles(seq)/100
+}
+
+// NewTargetCostModel builds a CostModel from an inst.Target, so Chain can
+// be scored by a specific variant's actual cycle timings (chunk5-5)
+// instead of the Z80 baseline PlainZ80 uses.
+func NewTargetCostModel(target inst.Target) CostModel {
+	return targetCostModel{target: target}
+}
+
+// NewTimingCostModel builds a CostModel from a pkg/timing.TimingModel, so
+// Chain can be scored against fine-grained per-M-cycle contention (chunk5-4)
+// instead of this file's flat 8-T-state-phase models above.
+func NewTimingCostModel(model timing.TimingModel, basePC uint16, frameOffset int) CostModel {
+	return timingCostModel{model: model, basePC: basePC, frameOffset: frameOffset}
+}
+
+// CostWithModel is Cost, but with the byte/cycle weighting of candidate
+// priced by model instead of hardcoded to PlainZ80.
+func CostWithModel(target, candidate []inst.Instruction, model CostModel) int {
+	return 1000*Mismatches(target, candidate) + model.SizeCycleCost(candidate)
+}
+
+// CostMaskedRegsWithModel is CostMaskedRegs plus a pluggable CostModel, so
+// Chain can search subject to dead flags/registers on a specific machine's
+// timing instead of the flat uncontended default.
+func CostMaskedRegsWithModel(target, candidate []inst.Instruction, deadFlags uint8, deadRegs RegMask, model CostModel) int {
+	mismatches := MismatchesMaskedRegs(target, candidate, deadFlags, deadRegs)
+	return 1000*mismatches + model.SizeCycleCost(candidate)
+}
@@ -0,0 +1,110 @@
+package stoke
+
+import (
+	"math/rand/v2"
+	"testing"
+
+	"github.com/oisee/z80-optimizer/pkg/inst"
+)
+
+func TestTargetCostModelUsesVariantTStates(t *testing.T) {
+	seq := []inst.Instruction{{Op: inst.ADD_A_B}}
+	plain := NewTargetCostModel(inst.Z80)
+	fast := NewTargetCostModel(inst.R800)
+	if got := plain.Cycles(seq); got != inst.Z80.TStates(inst.ADD_A_B) {
+		t.Errorf("plain target model Cycles() = %d, want %d", got, inst.Z80.TStates(inst.ADD_A_B))
+	}
+	if got, want := fast.Cycles(seq), inst.R800.TStates(inst.ADD_A_B); got != want {
+		t.Errorf("R800 target model Cycles() = %d, want %d", got, want)
+	}
+	if fast.Cycles(seq) >= plain.Cycles(seq) {
+		t.Error("R800 should be faster than plain Z80 on shared opcodes")
+	}
+}
+
+func TestMutatorSetVariantRestrictsOpcodes(t *testing.T) {
+	rng := rand.New(rand.NewPCG(42, 42))
+	m := NewMutator(rng, 10)
+	m.SetVariant(inst.SM83)
+	for _, op := range m.allOps {
+		if !inst.SM83.Allowed(op) {
+			t.Fatalf("SetVariant(SM83) left illegal opcode %s in allOps", inst.Disassemble(inst.Instruction{Op: op}))
+		}
+	}
+}
+
+func TestPlainZ80Cycles(t *testing.T) {
+	seq := []inst.Instruction{{Op: inst.ADD_A_B}, {Op: inst.LD_A_N, Imm: 5}}
+	want := inst.TStates(inst.ADD_A_B) + inst.TStates(inst.LD_A_N)
+	if got := PlainZ80.Cycles(seq); got != want {
+		t.Errorf("PlainZ80.Cycles() = %d, want %d", got, want)
+	}
+}
+
+func TestZXSpectrum48kContendedAddsContention(t *testing.T) {
+	// LD A, IXH: 2 opcode bytes, 0 operand bytes, no memory access — 2
+	// contended accesses at pattern offsets 0 and 4: 6 + 2 = 8 extra T-states.
+	seq := []inst.Instruction{{Op: inst.LD_A_IXH}}
+	base := inst.TStates(inst.LD_A_IXH)
+	want := base + contentionPattern[0] + contentionPattern[4]
+	if got := ZXSpectrum48kContended.Cycles(seq); got != want {
+		t.Errorf("ZXSpectrum48kContended.Cycles() = %d, want %d", got, want)
+	}
+	if got := PlainZ80.Cycles(seq); got != base {
+		t.Errorf("PlainZ80.Cycles() = %d, want %d (no contention)", got, base)
+	}
+}
+
+func TestZXSpectrum48kContendedFrameOffset(t *testing.T) {
+	seq := []inst.Instruction{{Op: inst.NOP}}
+	m0 := NewZXSpectrum48kContended(0)
+	m3 := NewZXSpectrum48kContended(3)
+	base := inst.TStates(inst.NOP)
+	if got := m0.Cycles(seq); got != base+contentionPattern[0] {
+		t.Errorf("frame offset 0: got %d, want %d", got, base+contentionPattern[0])
+	}
+	if got := m3.Cycles(seq); got != base+contentionPattern[3] {
+		t.Errorf("frame offset 3: got %d, want %d", got, base+contentionPattern[3])
+	}
+}
+
+func TestCPCRoundsToMultipleOf4(t *testing.T) {
+	// ADD A, B is 4 T-states (already a multiple of 4) — no rounding needed.
+	seq := []inst.Instruction{{Op: inst.ADD_A_B}}
+	if got := CPC.Cycles(seq); got != 4 {
+		t.Errorf("CPC.Cycles(ADD A,B) = %d, want 4", got)
+	}
+
+	// IN A, (n) is 11 T-states, which the CPC's 4T-aligned bus rounds up to 12.
+	seq = []inst.Instruction{{Op: inst.IN_A_N}}
+	if got := CPC.Cycles(seq); got != 12 {
+		t.Errorf("CPC.Cycles(IN A,(n)) = %d, want 12", got)
+	}
+}
+
+func TestCostWithModelMatchesCostForPlainZ80(t *testing.T) {
+	target := []inst.Instruction{{Op: inst.LD_A_B}, {Op: inst.ADD_A_C}}
+	candidate := []inst.Instruction{{Op: inst.LD_A_B}}
+	if got, want := CostWithModel(target, candidate, PlainZ80), Cost(target, candidate); got != want {
+		t.Errorf("CostWithModel(..., PlainZ80) = %d, want Cost() = %d", got, want)
+	}
+}
+
+func TestChainSetCostModelRescoresCost(t *testing.T) {
+	// 13 repeats pushes contended cycles (~16/instr) past a /100 boundary
+	// that plain cycles (8/instr) doesn't, so the two costs actually differ.
+	target := make([]inst.Instruction, 13)
+	for i := range target {
+		target[i] = inst.Instruction{Op: inst.LD_A_IXH}
+	}
+	chain := NewChain(target, 1.0, 7)
+	plainCost := chain.cost
+	chain.SetCostModel(ZXSpectrum48kContended)
+	if chain.cost == plainCost {
+		t.Fatal("SetCostModel left cost unchanged; expected contention to raise it")
+	}
+	want := CostMaskedRegsWithModel(target, target, 0, DeadRegNone, ZXSpectrum48kContended)
+	if chain.cost != want {
+		t.Errorf("chain.cost after SetCostModel = %d, want %d", chain.cost, want)
+	}
+}
@@ -1,6 +1,7 @@
 package stoke
 
 import (
+	"fmt"
 	"math"
 	"math/rand/v2"
 
@@ -15,19 +16,25 @@ type Chain struct {
 	bestCost    int
 	temperature float64
 	rng         *rand.Rand
+	pcg         *rand.PCG // chunk12-4: rng's underlying source, kept so state() can marshal it
 	mutator     *Mutator
 	target      []inst.Instruction
 	targetBytes int
-	deadFlags   uint8 // If nonzero, ignore these flag bits in cost evaluation
+	deadFlags   uint8     // If nonzero, ignore these flag bits in cost evaluation
+	deadRegs    RegMask   // chunk4-1: if nonzero, also ignore these registers in cost evaluation
+	costModel   CostModel // chunk4-6: which machine's cycle timing Step evaluates candidates against
 
 	// Stats
-	Accepted int64
-	Rejected int64
+	Accepted      int64
+	Rejected      int64
+	SwapsAccepted int64 // chunk4-2: replica exchanges this chain's slot took part in and accepted
+	SwapsProposed int64 // chunk12-2: replica exchanges this chain's slot was offered, accepted or not
 }
 
 // NewChain creates a new MCMC chain initialized from the target sequence.
 func NewChain(target []inst.Instruction, temperature float64, seed uint64) *Chain {
-	rng := rand.New(rand.NewPCG(seed, seed^0xDEADBEEF))
+	pcg := rand.NewPCG(seed, seed^0xDEADBEEF)
+	rng := rand.New(pcg)
 	maxLen := len(target) + 2 // allow some growth
 	if maxLen < 10 {
 		maxLen = 10
@@ -35,6 +42,11 @@ func NewChain(target []inst.Instruction, temperature float64, seed uint64) *Chai
 	current := copySeq(target)
 	cost := Cost(target, current)
 
+	mutator := NewMutator(rng, maxLen)
+	// chunk4-5: bias mutation toward the classes target itself uses instead
+	// of sampling opcodes uniformly.
+	mutator.SetProfile(ProfileFromTarget(target))
+
 	return &Chain{
 		current:     current,
 		best:        copySeq(current),
@@ -42,17 +54,89 @@ func NewChain(target []inst.Instruction, temperature float64, seed uint64) *Chai
 		bestCost:    cost,
 		temperature: temperature,
 		rng:         rng,
-		mutator:     NewMutator(rng, maxLen),
+		pcg:         pcg,
+		mutator:     mutator,
 		target:      target,
 		targetBytes: inst.SeqByteSize(target),
+		costModel:   PlainZ80,
+	}
+}
+
+// NewChainFromState reconstructs a Chain from a checkpointed ChainState
+// (chunk12-4), restoring its RNG exactly via the underlying PCG's
+// UnmarshalBinary so a resumed chain continues the same trajectory instead
+// of just re-seeding, along with its current/best candidates, temperature,
+// and counters. maxLen bounds the Mutator the same way NewChain's does.
+func NewChainFromState(target []inst.Instruction, maxLen int, state ChainState) (*Chain, error) {
+	pcg := &rand.PCG{}
+	if err := pcg.UnmarshalBinary(state.PCG); err != nil {
+		return nil, fmt.Errorf("stoke: restoring chain RNG state: %w", err)
+	}
+	rng := rand.New(pcg)
+	mutator := NewMutator(rng, maxLen)
+	mutator.SetProfile(ProfileFromTarget(target))
+
+	return &Chain{
+		current:       copySeq(state.Current),
+		best:          copySeq(state.Best),
+		cost:          state.Cost,
+		bestCost:      state.BestCost,
+		temperature:   state.Temperature,
+		rng:           rng,
+		pcg:           pcg,
+		mutator:       mutator,
+		target:        target,
+		targetBytes:   inst.SeqByteSize(target),
+		costModel:     PlainZ80,
+		Accepted:      state.Accepted,
+		Rejected:      state.Rejected,
+		SwapsAccepted: state.SwapsAccepted,
+		SwapsProposed: state.SwapsProposed,
+	}, nil
+}
+
+// state returns a serializable snapshot of c's search state (chunk12-4),
+// suitable for NewChainFromState to reconstruct an equivalent Chain from.
+func (c *Chain) state() (ChainState, error) {
+	pcgBytes, err := c.pcg.MarshalBinary()
+	if err != nil {
+		return ChainState{}, fmt.Errorf("stoke: marshaling chain RNG state: %w", err)
 	}
+	return ChainState{
+		Current:       copySeq(c.current),
+		Best:          copySeq(c.best),
+		Cost:          c.cost,
+		BestCost:      c.bestCost,
+		Temperature:   c.temperature,
+		PCG:           pcgBytes,
+		Accepted:      c.Accepted,
+		Rejected:      c.Rejected,
+		SwapsAccepted: c.SwapsAccepted,
+		SwapsProposed: c.SwapsProposed,
+	}, nil
+}
+
+// SetCostModel replaces the Chain's CostModel and rescores current/best
+// against it, so a model swap after construction (see Config.CostModel)
+// doesn't leave cost/bestCost priced under the old model.
+func (c *Chain) SetCostModel(m CostModel) {
+	c.costModel = m
+	c.cost = CostMaskedRegsWithModel(c.target, c.current, c.deadFlags, c.deadRegs, m)
+	c.bestCost = CostMaskedRegsWithModel(c.target, c.best, c.deadFlags, c.deadRegs, m)
+}
+
+// SetVariant restricts the Chain's Mutator to variant's legal opcodes
+// (chunk5-5; see Config.Variant), so a run targeting e.g. SM83 stops
+// proposing Z80-only candidates.
+func (c *Chain) SetVariant(variant inst.Target) {
+	c.mutator.SetVariant(variant)
 }
 
 // Step performs one MCMC iteration: mutate, evaluate, accept/reject.
 // Returns true if the step was accepted.
 func (c *Chain) Step(decay float64) bool {
 	candidate := c.mutator.Mutate(c.current)
-	newCost := CostMasked(c.target, candidate, c.deadFlags)
+	newCost := CostMaskedRegsWithModel(c.target, candidate, c.deadFlags, c.deadRegs, c.costModel)
 	delta := newCost - c.cost
 
 	accepted := false
@@ -101,3 +185,24 @@ func (c *Chain) Current() ([]inst.Instruction, int) {
 func (c *Chain) IsShorter() bool {
 	return inst.SeqByteSize(c.best) < c.targetBytes
 }
+
+// TryReplicaExchange attempts a parallel-tempering swap between two adjacent
+// rungs of the temperature ladder (chunk4-2): hot and cold are expected to be
+// the same two chains every call for a given pair, with cold.temperature <
+// hot.temperature. On acceptance, their current and cost fields (not best)
+// trade places, so the cold rung keeps exploiting whichever candidate is
+// currently better while the hot rung keeps exploring. Returns true if the
+// swap was accepted.
+func (cold *Chain) TryReplicaExchange(hot *Chain, rng *rand.Rand) bool {
+	cold.SwapsProposed++
+	hot.SwapsProposed++
+	delta := (float64(cold.cost) - float64(hot.cost)) * (1/cold.temperature - 1/hot.temperature)
+	if delta < 0 && rng.Float64() >= math.Exp(delta) {
+		return false
+	}
+	cold.current, hot.current = hot.current, cold.current
+	cold.cost, hot.cost = hot.cost, cold.cost
+	cold.SwapsAccepted++
+	hot.SwapsAccepted++
+	return true
+}
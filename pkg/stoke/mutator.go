@@ -8,50 +8,96 @@ import (
 
 // Mutator applies random mutations to instruction sequences.
 type Mutator struct {
-	rng      *rand.Rand
-	nonImm   []inst.OpCode
-	imm8Ops  []inst.OpCode
-	imm16Ops []inst.OpCode
-	allOps   []inst.OpCode // all opcodes for random selection
-	maxLen   int           // maximum sequence length allowed
+	rng        *rand.Rand
+	nonImm     []inst.OpCode
+	imm8Ops    []inst.OpCode
+	imm16Ops   []inst.OpCode
+	allOps     []inst.OpCode // all opcodes for random selection
+	opsByClass map[inst.Class][]inst.OpCode
+	profile    *MutationProfile // chunk4-5: per-class sampling weights; defaults to uniform
+	maxLen     int              // maximum sequence length allowed
 }
 
-// NewMutator creates a Mutator with cached opcode lists.
+// NewMutator creates a Mutator with cached opcode lists and a uniform
+// MutationProfile. Call SetProfile to bias it toward a target's own class
+// histogram (see ProfileFromTarget).
 func NewMutator(rng *rand.Rand, maxLen int) *Mutator {
+	allOps := inst.AllOps()
+	byClass := make(map[inst.Class][]inst.OpCode, len(inst.AllClasses()))
+	for _, op := range allOps {
+		c := inst.ClassOf(op)
+		byClass[c] = append(byClass[c], op)
+	}
 	return &Mutator{
-		rng:      rng,
-		nonImm:   inst.NonImmediateOps(),
-		imm8Ops:  inst.ImmediateOps(),
-		imm16Ops: inst.Imm16Ops(),
-		allOps:   inst.AllOps(),
-		maxLen:   maxLen,
+		rng:        rng,
+		nonImm:     inst.NonImmediateOps(),
+		imm8Ops:    inst.ImmediateOps(),
+		imm16Ops:   inst.Imm16Ops(),
+		allOps:     allOps,
+		opsByClass: byClass,
+		profile:    UniformProfile(),
+		maxLen:     maxLen,
 	}
 }
 
+// SetVariant restricts the Mutator's cached opcode lists to the opcodes
+// variant.Allowed accepts (chunk5-5), so a running search stops proposing
+// candidates illegal on the machine it's actually targeting (e.g. SLL or
+// IX/IY forms on an SM83/Z180/R800 run).
+func (m *Mutator) SetVariant(variant inst.Target) {
+	m.nonImm = inst.FilterOps(variant, m.nonImm)
+	m.imm8Ops = inst.FilterOps(variant, m.imm8Ops)
+	m.imm16Ops = inst.FilterOps(variant, m.imm16Ops)
+	m.allOps = inst.FilterOps(variant, m.allOps)
+	byClass := make(map[inst.Class][]inst.OpCode, len(m.opsByClass))
+	for c, ops := range m.opsByClass {
+		if filtered := inst.FilterOps(variant, ops); len(filtered) > 0 {
+			byClass[c] = filtered
+		}
+	}
+	m.opsByClass = byClass
+}
+
+// SetProfile replaces the Mutator's class-sampling weights.
+func (m *Mutator) SetProfile(p *MutationProfile) {
+	m.profile = p
+}
+
 // Mutate applies a random mutation to seq and returns the new sequence.
 // The input slice is not modified; a new slice is always returned.
+//
+// chunk4-3: any mutation can shift instruction addresses out from under an
+// existing branch target (insert/delete most obviously, but replace too when
+// the new opcode has a different ByteSize), so the result is checked against
+// inst.IsWellFormedCFG before it's handed back; a mutation that would leave a
+// dangling branch is rejected in favor of the unmutated sequence.
 func (m *Mutator) Mutate(seq []inst.Instruction) []inst.Instruction {
 	// Weighted selection: 40% replace, 20% swap, 20% delete, 10% insert, 10% change-imm
 	r := m.rng.IntN(100)
+	var out []inst.Instruction
 	switch {
 	case r < 40:
-		return m.ReplaceInstruction(seq)
+		out = m.ReplaceInstruction(seq)
 	case r < 60:
-		return m.SwapInstructions(seq)
+		out = m.SwapInstructions(seq)
 	case r < 80:
-		return m.DeleteInstruction(seq)
+		out = m.DeleteInstruction(seq)
 	case r < 90:
-		return m.InsertInstruction(seq)
+		out = m.InsertInstruction(seq)
 	default:
-		return m.ChangeImmediate(seq)
+		out = m.ChangeImmediate(seq)
+	}
+	if !inst.IsWellFormedCFG(out) {
+		return copySeq(seq)
 	}
+	return out
 }
 
 // ReplaceInstruction swaps one instruction with a random one from the catalog.
 func (m *Mutator) ReplaceInstruction(seq []inst.Instruction) []inst.Instruction {
 	out := copySeq(seq)
 	pos := m.rng.IntN(len(out))
-	out[pos] = m.randomInstruction()
+	out[pos] = m.randomInstructionFor(seq)
 	return out
 }
 
@@ -85,7 +131,7 @@ func (m *Mutator) InsertInstruction(seq []inst.Instruction) []inst.Instruction {
 		return m.ReplaceInstruction(seq)
 	}
 	pos := m.rng.IntN(len(seq) + 1)
-	newInstr := m.randomInstruction()
+	newInstr := m.randomInstructionFor(seq)
 	out := make([]inst.Instruction, 0, len(seq)+1)
 	out = append(out, seq[:pos]...)
 	out = append(out, newInstr)
@@ -111,23 +157,154 @@ func (m *Mutator) ChangeImmediate(seq []inst.Instruction) []inst.Instruction {
 	if inst.HasImm16(out[pos].Op) {
 		out[pos].Imm = uint16(m.rng.IntN(65536))
 	} else {
-		out[pos].Imm = uint16(m.rng.IntN(256))
+		out[pos].Imm = m.biasedImm8(out[pos].Op)
 	}
 	return out
 }
 
+// randomOp draws an opcode with inst.Class frequencies weighted by the
+// Mutator's MutationProfile instead of sampling allOps uniformly (chunk4-5).
+func (m *Mutator) randomOp() inst.OpCode {
+	return m.profile.sample(m.rng, m.opsByClass, m.allOps)
+}
+
 // randomInstruction returns a random instruction (with random immediate if applicable).
 func (m *Mutator) randomInstruction() inst.Instruction {
-	op := m.allOps[m.rng.IntN(len(m.allOps))]
+	op := m.randomOp()
 	var imm uint16
 	if inst.HasImm16(op) {
 		imm = uint16(m.rng.IntN(65536))
 	} else if inst.HasImmediate(op) {
-		imm = uint16(m.rng.IntN(256))
+		imm = m.biasedImm8(op)
 	}
 	return inst.Instruction{Op: op, Imm: imm}
 }
 
+// randomInstructionFor is randomInstruction, except a branch op (chunk4-3)
+// gets its target resolved against seq's own layout via randomBranchTarget
+// instead of a fully random Imm, so InsertInstruction/ReplaceInstruction
+// produce branches that land somewhere real more often than by chance.
+func (m *Mutator) randomInstructionFor(seq []inst.Instruction) inst.Instruction {
+	op := m.randomOp()
+	if inst.HasBranchTarget(op) {
+		return inst.Instruction{Op: op, Imm: m.randomBranchTarget(seq)}
+	}
+	var imm uint16
+	if inst.HasImm16(op) {
+		imm = uint16(m.rng.IntN(65536))
+	} else if inst.HasImmediate(op) {
+		imm = m.biasedImm8(op)
+	}
+	return inst.Instruction{Op: op, Imm: imm}
+}
+
+// biasedImm8 picks an 8-bit immediate for op (chunk4-5): most opcodes still
+// get a uniform byte, but a couple of very common idioms get a distribution
+// that matches how they actually show up in real code instead of wasting
+// MCMC budget on values that would never appear.
+func (m *Mutator) biasedImm8(op inst.OpCode) uint16 {
+	switch op {
+	case inst.AND_N:
+		// AND N is almost always a power-of-two-minus-1 mask (keep the low
+		// bits) or its complement (clear one bit), not an arbitrary byte.
+		masks := []uint16{0x01, 0x03, 0x07, 0x0F, 0x1F, 0x3F, 0x7F, 0xFF,
+			0xFE, 0xFC, 0xF8, 0xF0, 0xE0, 0xC0, 0x80, 0x00}
+		return masks[m.rng.IntN(len(masks))]
+	case inst.LD_A_N, inst.LD_B_N, inst.LD_C_N, inst.LD_D_N, inst.LD_E_N, inst.LD_H_N, inst.LD_L_N:
+		// Small immediates (loop counters, small deltas) dominate real LD
+		// r,n uses far more than the full 0-255 range does.
+		if m.rng.IntN(100) < 70 {
+			return uint16(m.rng.IntN(16))
+		}
+		return uint16(m.rng.IntN(256))
+	default:
+		return uint16(m.rng.IntN(256))
+	}
+}
+
+// randomBranchTarget picks a target address that lands on an instruction
+// boundary within seq, or on the address just past its end (a clean exit) —
+// either one keeps inst.IsWellFormedCFG happy for this instruction on its own.
+func (m *Mutator) randomBranchTarget(seq []inst.Instruction) uint16 {
+	addrs := inst.SeqAddresses(seq)
+	end := uint16(inst.SeqByteSize(seq))
+	targets := append(addrs, end)
+	return targets[m.rng.IntN(len(targets))]
+}
+
+// MutationProfile weights how often the Mutator draws an opcode from each
+// inst.Class (chunk4-5), so e.g. an ALU-only target stops wasting MCMC
+// budget proposing shifts and rotates it'll never need.
+type MutationProfile struct {
+	weights map[inst.Class]float64
+}
+
+// UniformProfile weights every class equally — Mutator's default, and
+// identical to sampling allOps directly.
+func UniformProfile() *MutationProfile {
+	p := &MutationProfile{weights: make(map[inst.Class]float64)}
+	for _, c := range inst.AllClasses() {
+		p.weights[c] = 1
+	}
+	return p
+}
+
+// ProfileFromTarget derives a profile from target's own class histogram:
+// a class's weight is its instruction count in target, plus a smoothing
+// floor so classes target doesn't use are still proposed occasionally
+// rather than never — STOKE still needs to reach instructions the target
+// itself doesn't contain.
+func ProfileFromTarget(target []inst.Instruction) *MutationProfile {
+	const floor = 0.1
+
+	counts := make(map[inst.Class]int)
+	for _, instr := range target {
+		counts[inst.ClassOf(instr.Op)]++
+	}
+
+	p := &MutationProfile{weights: make(map[inst.Class]float64)}
+	for _, c := range inst.AllClasses() {
+		p.weights[c] = floor + float64(counts[c])
+	}
+	return p
+}
+
+// sample draws one opcode: first a Class weighted by p.weights (restricted
+// to classes opsByClass actually has opcodes for), then an opcode uniformly
+// within that class. allOps is the fallback if every weight collapses to 0.
+func (p *MutationProfile) sample(rng *rand.Rand, opsByClass map[inst.Class][]inst.OpCode, allOps []inst.OpCode) inst.OpCode {
+	var total float64
+	for c, ops := range opsByClass {
+		if len(ops) == 0 {
+			continue
+		}
+		total += p.weights[c]
+	}
+	if total <= 0 {
+		return allOps[rng.IntN(len(allOps))]
+	}
+
+	r := rng.Float64() * total
+	for _, c := range inst.AllClasses() {
+		ops := opsByClass[c]
+		if len(ops) == 0 {
+			continue
+		}
+		r -= p.weights[c]
+		if r < 0 {
+			return ops[rng.IntN(len(ops))]
+		}
+	}
+	// Floating-point rounding left r just above 0 after the last class;
+	// fall back to it rather than panicking on an out-of-range index.
+	for _, c := range inst.AllClasses() {
+		if ops := opsByClass[c]; len(ops) > 0 {
+			return ops[rng.IntN(len(ops))]
+		}
+	}
+	return allOps[rng.IntN(len(allOps))]
+}
+
 func copySeq(seq []inst.Instruction) []inst.Instruction {
 	out := make([]inst.Instruction, len(seq))
 	copy(out, seq)
@@ -1,24 +1,66 @@
 package stoke
 
 import (
+	"context"
 	"fmt"
+	"math"
 	"math/rand/v2"
 	"sync"
 	"time"
 
 	"github.com/oisee/z80-optimizer/pkg/inst"
 	"github.com/oisee/z80-optimizer/pkg/result"
-	"github.com/oisee/z80-optimizer/pkg/search"
 )
 
 // Config holds STOKE search configuration.
 type Config struct {
 	Target     []inst.Instruction
-	Chains     int     // Number of independent MCMC chains (goroutines)
+	Chains     int     // Number of MCMC chains, one per rung of the temperature ladder
 	Iterations int     // Iterations per chain
-	Decay      float64 // Temperature decay factor per step
+	Decay      float64 // Per-chain temperature decay factor per step, layered on top of the ladder
 	Verbose    bool
-	DeadFlags  uint8 // If nonzero, ignore these flag bits during equivalence checks
+	DeadFlags  uint8   // If nonzero, ignore these flag bits during equivalence checks
+	DeadRegs   RegMask // chunk4-1: if nonzero, also ignore these registers during equivalence checks
+
+	// chunk4-6: which machine's cycle timing the search optimizes for. Nil
+	// keeps Chain's PlainZ80 default (uncontended, flat T-states).
+	CostModel CostModel
+
+	// chunk5-5: which Z80-family variant candidates must be legal on. Nil
+	// keeps Chain's unrestricted opcode pool (plain Z80, including
+	// undocumented opcodes).
+	Variant inst.Target
+
+	// chunk4-2: parallel tempering. Chains start on a geometric ladder of
+	// temperatures from TempMin (the exploitation rung) to TempMax (the
+	// exploration rung) instead of all starting at 1.0, and every
+	// SwapInterval iterations adjacent rungs attempt a replica exchange.
+	TempMin      float64
+	TempMax      float64
+	SwapInterval int
+
+	// chunk12-3: two-tier verification. Chains hand anything clearing the
+	// cheap local acceptance test to a pool of VerifierWorkers goroutines
+	// instead of proving it inline, so a slow exhaustive-verification call
+	// never stalls the chain that found it; VerifyQueueSize bounds how many
+	// candidates can be queued ahead of that pool. Zero picks sensible
+	// defaults for both.
+	VerifierWorkers int
+	VerifyQueueSize int
+
+	// Stats, if non-nil, is populated with the two-tier verification
+	// pipeline's counters (chunk12-3) as the search runs — see VerifyStats.
+	Stats *VerifyStats
+
+	// chunk12-4: checkpoint/resume. If CheckpointPath is set, the search
+	// periodically (every CheckpointEvery, default 30s if unset) serializes
+	// every chain's current/best candidates, temperature, RNG state, and
+	// counters, plus every verified Result found so far, to CheckpointPath
+	// via an atomic write-then-rename. Resume/ResumeStream load that file
+	// and continue the search from exactly where it left off instead of
+	// starting over.
+	CheckpointPath  string
+	CheckpointEvery time.Duration
 }
 
 // Result holds a verified optimization found by STOKE.
@@ -28,8 +70,91 @@ type Result struct {
 	Iter    int
 }
 
-// Run launches N independent MCMC chains in parallel and collects verified results.
+// temperatureLadder builds a geometric sequence of n temperatures from tMin
+// to tMax (ascending): chains[0] runs coldest (exploitation), chains[n-1]
+// runs hottest (exploration).
+func temperatureLadder(n int, tMin, tMax float64) []float64 {
+	ladder := make([]float64, n)
+	if n == 1 {
+		ladder[0] = tMin
+		return ladder
+	}
+	ratio := math.Pow(tMax/tMin, 1/float64(n-1))
+	t := tMin
+	for i := range ladder {
+		ladder[i] = t
+		t *= ratio
+	}
+	return ladder
+}
+
+// Run launches a parallel-tempering ladder of MCMC chains and blocks until
+// the search completes, returning every verified result. It's a thin
+// wrapper over RunStream for callers who don't need results as they arrive
+// or an early-cancellation path; see RunStream's doc comment for both.
 func Run(cfg Config) []Result {
+	resultsCh, _ := RunStream(context.Background(), cfg)
+	var results []Result
+	for r := range resultsCh {
+		results = append(results, r)
+	}
+	return results
+}
+
+// RunStream is Run's streaming form (chunk12-1): instead of collecting
+// every verified Result into a slice the caller only sees once the whole
+// search finishes, it launches the same parallel-tempering ladder of MCMC
+// chains in the background and sends each Result on the returned channel
+// as soon as that chain verifies it — so a caller can persist or display
+// results incrementally, or stop consuming once it has enough.
+//
+// ctx cancellation is honored both between swap-interval batches and
+// inside each chain's inner per-iteration loop, so a caller can cut a long
+// search short (SIGINT, a wall-clock budget independent of cfg.Iterations)
+// and still keep whatever was already found. Both returned channels close
+// once every chain has stopped stepping; the error channel receives
+// ctx.Err() if the search ended via cancellation rather than exhausting
+// cfg.Iterations.
+func RunStream(ctx context.Context, cfg Config) (<-chan Result, <-chan error) {
+	resultsCh := make(chan Result, 16)
+	errCh := make(chan error, 1)
+
+	go func() {
+		defer close(resultsCh)
+		defer close(errCh)
+		runStream(ctx, cfg, resultsCh)
+		if err := ctx.Err(); err != nil {
+			errCh <- err
+		}
+	}()
+
+	return resultsCh, errCh
+}
+
+// runStream does the actual work behind RunStream, sending verified results
+// on resultsCh as they're found. It's a thin entry point over runStreamFrom,
+// which also backs Resume/ResumeStream.
+func runStream(ctx context.Context, cfg Config, resultsCh chan<- Result) {
+	runStreamFrom(ctx, cfg, resultsCh, nil)
+}
+
+// defaultCheckpointEvery is how often a checkpointing search serializes its
+// state when Config.CheckpointEvery is left unset.
+const defaultCheckpointEvery = 30 * time.Second
+
+// runStreamFrom does the actual work behind runStream and Resume/ResumeStream,
+// sending verified results on resultsCh as they're found. Chains occupy
+// fixed rungs of a geometric temperature ladder (cfg.TempMin..cfg.TempMax);
+// every cfg.SwapInterval iterations, adjacent rungs attempt a replica
+// exchange so a good candidate found by a hot (exploring) chain can migrate
+// down to a cold (exploiting) one instead of the whole run getting stuck
+// wherever its one chain happened to anneal.
+//
+// resume is nil for a fresh search (runStream's case). When non-nil
+// (chunk12-4, ResumeStream's case), chains are reconstructed from its
+// checkpointed ChainStates via NewChainFromState instead of freshly seeded,
+// and iteration starts from resume.Iter instead of 0.
+func runStreamFrom(ctx context.Context, cfg Config, resultsCh chan<- Result, resume *checkpointFile) {
 	if cfg.Chains <= 0 {
 		cfg.Chains = 1
 	}
@@ -39,13 +164,25 @@ func Run(cfg Config) []Result {
 	if cfg.Decay <= 0 || cfg.Decay >= 1 {
 		cfg.Decay = 0.9999
 	}
+	if cfg.TempMin <= 0 {
+		cfg.TempMin = 0.1
+	}
+	if cfg.TempMax <= 0 || cfg.TempMax < cfg.TempMin {
+		cfg.TempMax = 1.0
+	}
+	if cfg.SwapInterval <= 0 {
+		cfg.SwapInterval = 1000
+	}
+	if cfg.CheckpointPath != "" && cfg.CheckpointEvery <= 0 {
+		cfg.CheckpointEvery = defaultCheckpointEvery
+	}
 
 	targetBytes := inst.SeqByteSize(cfg.Target)
 	targetCycles := inst.SeqTStates(cfg.Target)
 
 	if cfg.Verbose {
-		fmt.Printf("STOKE search: %d chains Ã— %d iterations (decay=%.6f)\n",
-			cfg.Chains, cfg.Iterations, cfg.Decay)
+		fmt.Printf("STOKE search: %d chains Ã— %d iterations (decay=%.6f, ladder=%.4g..%.4g, swap every %d)\n",
+			cfg.Chains, cfg.Iterations, cfg.Decay, cfg.TempMin, cfg.TempMax, cfg.SwapInterval)
 		fmt.Printf("Target: ")
 		for i, instr := range cfg.Target {
 			if i > 0 {
@@ -56,12 +193,89 @@ func Run(cfg Config) []Result {
 		fmt.Printf(" (%d bytes, %d T-states)\n\n", targetBytes, targetCycles)
 	}
 
-	var mu sync.Mutex
-	var results []Result
-	var wg sync.WaitGroup
+	var baseSeed uint64
+	var pool *ChainPool
+	startIter := 0
+
+	if resume != nil {
+		// chunk12-4: pick up exactly where the checkpoint left off instead
+		// of seeding a fresh ladder.
+		baseSeed = resume.BaseSeed
+		startIter = resume.Iter
+		ladder := temperatureLadder(len(resume.Chains), cfg.TempMin, cfg.TempMax)
+		maxLen := len(cfg.Target) + 2
+		if maxLen < 10 {
+			maxLen = 10
+		}
+		chains := make([]*Chain, len(resume.Chains))
+		for i, st := range resume.Chains {
+			c, err := NewChainFromState(cfg.Target, maxLen, st)
+			if err != nil {
+				// Can't restore this rung's exact RNG state — start it
+				// fresh on the same ladder rung rather than aborting the
+				// whole resume over one corrupt chain.
+				if cfg.Verbose {
+					fmt.Printf("  resume: rung %d: %v (starting fresh)\n", i, err)
+				}
+				c = NewChain(cfg.Target, ladder[i], baseSeed+uint64(i)*0x9E3779B97F4A7C15)
+			}
+			chains[i] = c
+		}
+		pairs := 0
+		if len(chains) > 1 {
+			pairs = len(chains) - 1
+		}
+		pool = &ChainPool{
+			Chains:       chains,
+			Ladder:       ladder,
+			SwapInterval: cfg.SwapInterval,
+			swapAttempts: make([]int64, pairs),
+			swapAccepts:  make([]int64, pairs),
+		}
+	} else {
+		baseSeed = rand.Uint64()
+		// chunk5-2: the ladder and its chains live in a ChainPool so replica
+		// exchange, swap-rate tracking, and (for callers who want them)
+		// AutoTune/BurnIn/AnnealColdest all share one implementation.
+		pool = NewChainPool(cfg.Target, cfg.Chains, cfg.TempMin, cfg.TempMax, cfg.SwapInterval, baseSeed)
+	}
+	chains := pool.Chains
+	ladder := pool.Ladder
+
+	seeds := make([]uint64, len(chains))
+	for i := range seeds {
+		seeds[i] = baseSeed + uint64(i)*0x9E3779B97F4A7C15
+	}
+
+	for i := range chains {
+		if cfg.DeadFlags != 0 {
+			chains[i].deadFlags = cfg.DeadFlags
+		}
+		if cfg.DeadRegs != DeadRegNone {
+			chains[i].deadRegs = cfg.DeadRegs
+		}
+		if cfg.CostModel != nil {
+			chains[i].SetCostModel(cfg.CostModel)
+		}
+		if cfg.Variant != nil {
+			chains[i].SetVariant(cfg.Variant)
+		}
+	}
+	swapRNG := rand.New(rand.NewPCG(baseSeed^0x5A17, baseSeed))
 
-	// Seed from random source
-	baseSeed := rand.Uint64()
+	// chunk12-3: candidates that clear the cheap local acceptance test below
+	// are handed off here instead of being proven inline, so a slow
+	// ExhaustiveCheck never stalls the chain that found the candidate.
+	pipeline := newVerifyPipeline(cfg, resultsCh, targetBytes, targetCycles)
+	if resume != nil {
+		// chunk12-4: seed the dedup set with what the checkpoint already
+		// verified, so a rung that rediscovers one of them after resuming
+		// doesn't pay for re-verifying it.
+		for _, r := range resume.Results {
+			pipeline.seen.Store(seqKey(r.Rule.Replacement), struct{}{})
+		}
+	}
+	stopPipeline := pipeline.start(ctx)
 
 	// Progress tracking
 	startTime := time.Now()
@@ -77,108 +291,153 @@ func Run(cfg Config) []Result {
 					return
 				case <-ticker.C:
 					elapsed := time.Since(startTime)
-					mu.Lock()
-					found := len(results)
-					mu.Unlock()
 					fmt.Printf("  [%s] %d verified results found\n",
-						elapsed.Round(time.Second), found)
+						elapsed.Round(time.Second), pipeline.stats.Verified.Load())
 				}
 			}
 		}()
 	}
 
-	for i := 0; i < cfg.Chains; i++ {
-		wg.Add(1)
-		go func(chainID int) {
-			defer wg.Done()
+	// verifyAndRecord checks chains[ci]'s current best against the cheap
+	// local acceptance test and, if it clears it, hands it to the verifier
+	// pipeline and resets the rung to keep exploring. The expensive exhaustive
+	// proof — and the decision whether this candidate becomes a Result — now
+	// happens asynchronously in pipeline, off this (the chain's own) goroutine.
+	verifyAndRecord := func(ci, globalIter int) {
+		chain := chains[ci]
+		best, bestCost := chain.Best()
+		// Zero mismatches means cost < 1000 (since 1000*0 + size + cycles/100 < 1000 for any reasonable seq)
+		if bestCost >= 1000 || !chain.IsShorter() {
+			return
+		}
+
+		pipeline.enqueue(ctx, ci, globalIter, best)
+
+		// Reset the rung to explore more, keeping its ladder temperature.
+		chains[ci] = NewChain(cfg.Target, ladder[ci], seeds[ci]+uint64(globalIter))
+		if cfg.DeadFlags != 0 {
+			chains[ci].deadFlags = cfg.DeadFlags
+		}
+		if cfg.DeadRegs != DeadRegNone {
+			chains[ci].deadRegs = cfg.DeadRegs
+		}
+		if cfg.CostModel != nil {
+			chains[ci].SetCostModel(cfg.CostModel)
+		}
+		if cfg.Variant != nil {
+			chains[ci].SetVariant(cfg.Variant)
+		}
+	}
 
-			seed := baseSeed + uint64(chainID)*0x9E3779B97F4A7C15
-			chain := NewChain(cfg.Target, 1.0, seed)
-			if cfg.DeadFlags != 0 {
-				chain.deadFlags = cfg.DeadFlags
+	// chunk12-4: writeCheckpointIfDue serializes the current search state to
+	// cfg.CheckpointPath if checkpointing is on and CheckpointEvery has
+	// elapsed since the last write. Only called between batches, when every
+	// chain goroutine has joined and chains[] is safe to read without a race.
+	var lastCheckpointAt time.Time
+	writeCheckpointIfDue := func(iter int) {
+		if cfg.CheckpointPath == "" {
+			return
+		}
+		if !lastCheckpointAt.IsZero() && time.Since(lastCheckpointAt) < cfg.CheckpointEvery {
+			return
+		}
+		states := make([]ChainState, 0, len(chains))
+		for i, c := range chains {
+			st, err := c.state()
+			if err != nil {
+				if cfg.Verbose {
+					fmt.Printf("  checkpoint: skipping chain %d: %v\n", i, err)
+				}
+				continue
 			}
+			states = append(states, st)
+		}
+		f := checkpointFile{
+			Version:      checkpointVersion,
+			Target:       cfg.Target,
+			DeadFlags:    cfg.DeadFlags,
+			DeadRegs:     cfg.DeadRegs,
+			TempMin:      cfg.TempMin,
+			TempMax:      cfg.TempMax,
+			SwapInterval: cfg.SwapInterval,
+			BaseSeed:     baseSeed,
+			Iter:         iter,
+			Chains:       states,
+			Results:      pipeline.snapshotResults(),
+		}
+		if err := writeCheckpoint(cfg.CheckpointPath, f); err != nil && cfg.Verbose {
+			fmt.Printf("  checkpoint: write failed: %v\n", err)
+		}
+		lastCheckpointAt = time.Now()
+	}
 
-			for iter := 0; iter < cfg.Iterations; iter++ {
-				chain.Step(cfg.Decay)
-
-				// Check if best has zero mismatches and is shorter
-				best, bestCost := chain.Best()
-				// Zero mismatches means cost < 1000 (since 1000*0 + size + cycles/100 < 1000 for any reasonable seq)
-				if bestCost < 1000 && chain.IsShorter() {
-					// Verify with ExhaustiveCheck (masked or full)
-					verified := false
-					var deadFlags uint8
-					if cfg.DeadFlags != 0 {
-						verified = search.ExhaustiveCheckMasked(cfg.Target, best, cfg.DeadFlags)
-						if verified {
-							deadFlags = search.FlagDiff(cfg.Target, best)
-						}
-					} else {
-						verified = search.ExhaustiveCheck(cfg.Target, best)
-					}
+	iter := startIter
+	for iter < cfg.Iterations {
+		if ctx.Err() != nil {
+			break
+		}
+
+		batch := cfg.SwapInterval
+		if iter+batch > cfg.Iterations {
+			batch = cfg.Iterations - iter
+		}
 
-					if verified {
-						candBytes := inst.SeqByteSize(best)
-						candCycles := inst.SeqTStates(best)
-						r := Result{
-							Rule: result.Rule{
-								Source:      copySeq(cfg.Target),
-								Replacement: copySeq(best),
-								BytesSaved:  targetBytes - candBytes,
-								CyclesSaved: targetCycles - candCycles,
-								DeadFlags:   deadFlags,
-							},
-							ChainID: chainID,
-							Iter:    iter,
-						}
-
-						mu.Lock()
-						results = append(results, r)
-						mu.Unlock()
-
-						if cfg.Verbose {
-							fmt.Printf("  Chain %d @ iter %d: ", chainID, iter)
-							for j, instr := range best {
-								if j > 0 {
-									fmt.Print(" : ")
-								}
-								fmt.Print(inst.Disassemble(instr))
-							}
-							if deadFlags != 0 {
-								fmt.Printf(" (-%d bytes, -%d cycles, dead flags 0x%02X) VERIFIED\n",
-									r.Rule.BytesSaved, r.Rule.CyclesSaved, deadFlags)
-							} else {
-								fmt.Printf(" (-%d bytes, -%d cycles) VERIFIED\n",
-									r.Rule.BytesSaved, r.Rule.CyclesSaved)
-							}
-						}
-
-						// Reset chain to explore more
-						chain = NewChain(cfg.Target, 1.0, seed+uint64(iter))
-						if cfg.DeadFlags != 0 {
-							chain.deadFlags = cfg.DeadFlags
-						}
+		var wg sync.WaitGroup
+		for ci := 0; ci < len(chains); ci++ {
+			wg.Add(1)
+			go func(ci int) {
+				defer wg.Done()
+				for b := 0; b < batch; b++ {
+					if ctx.Err() != nil {
+						return
 					}
+					chains[ci].Step(cfg.Decay)
+					verifyAndRecord(ci, iter+b)
 				}
-			}
+			}(ci)
+		}
+		wg.Wait()
+		iter += batch
 
-			if cfg.Verbose {
-				fmt.Printf("  Chain %d done: %d accepted, %d rejected\n",
-					chainID, chain.Accepted, chain.Rejected)
-			}
-		}(i)
+		// Replica exchange between adjacent rungs — sequential, since all
+		// chain goroutines above have joined.
+		pool.TryExchanges(swapRNG)
+
+		writeCheckpointIfDue(iter)
+	}
+
+	// Every chain goroutine has stopped enqueuing; drain whatever the
+	// verifier pool still has in flight before anything downstream reads
+	// its final counters or resultsCh is closed.
+	stopPipeline()
+
+	if cfg.CheckpointPath != "" {
+		// Final checkpoint reflects the fully-drained verifier pool's
+		// results, so a caller resuming a run that actually finished picks
+		// up with nothing left to do instead of missing its tail end.
+		lastCheckpointAt = time.Time{}
+		writeCheckpointIfDue(iter)
+	}
+
+	if cfg.Verbose {
+		for ci, chain := range chains {
+			fmt.Printf("  Chain %d (T=%.4g) done: %d accepted, %d rejected, %d/%d swaps accepted\n",
+				ci, ladder[ci], chain.Accepted, chain.Rejected, chain.SwapsAccepted, chain.SwapsProposed)
+		}
+		swapsAttempted, swapsAccepted := pool.TotalSwaps()
+		fmt.Printf("  Replica exchanges: %d/%d accepted\n", swapsAccepted, swapsAttempted)
+		fmt.Printf("  Verifier: %d enqueued, %d deduped, %d verified, %d rejected\n",
+			pipeline.stats.Enqueued.Load(), pipeline.stats.DedupedDropped.Load(),
+			pipeline.stats.Verified.Load(), pipeline.stats.Rejected.Load())
 	}
 
-	wg.Wait()
 	close(done)
 
 	if cfg.Verbose {
 		elapsed := time.Since(startTime)
 		fmt.Printf("\nSTOKE complete: %d verified results in %s\n",
-			len(results), elapsed.Round(time.Millisecond))
+			pipeline.stats.Verified.Load(), elapsed.Round(time.Millisecond))
 	}
-
-	return results
 }
 
 // Deduplicate removes duplicate results (same replacement sequence).
@@ -1,6 +1,7 @@
 package stoke
 
 import (
+	"context"
 	"math/rand/v2"
 	"testing"
 
@@ -153,6 +154,36 @@ func TestMutatePreservesValidSequences(t *testing.T) {
 	}
 }
 
+func TestMutateBiasesTowardTargetClass(t *testing.T) {
+	target := []inst.Instruction{
+		{Op: inst.ADD_A_B},
+		{Op: inst.SUB_C},
+		{Op: inst.AND_N, Imm: 0x0F},
+	}
+
+	countALU := func(profile *MutationProfile) int {
+		rng := rand.New(rand.NewPCG(7, 7))
+		m := NewMutator(rng, 10)
+		if profile != nil {
+			m.SetProfile(profile)
+		}
+		alu := 0
+		for i := 0; i < 2000; i++ {
+			op := m.randomOp()
+			if c := inst.ClassOf(op); c == inst.ClassALU8 || c == inst.ClassALU16 {
+				alu++
+			}
+		}
+		return alu
+	}
+
+	uniform := countALU(nil)
+	biased := countALU(ProfileFromTarget(target))
+	if biased <= uniform {
+		t.Fatalf("biased ALU draw rate %d did not exceed uniform rate %d", biased, uniform)
+	}
+}
+
 func TestCostIdentical(t *testing.T) {
 	seq := []inst.Instruction{{Op: inst.AND_A}}
 	cost := Cost(seq, seq)
@@ -267,6 +298,73 @@ func TestEndToEndAND0xFF(t *testing.T) {
 	}
 }
 
+// TestRunStreamMatchesRun verifies RunStream (chunk12-1) finds the same
+// kind of result as Run against an identical seed of work — Run is just
+// RunStream drained into a slice, so this is really checking that draining
+// doesn't lose or corrupt anything.
+func TestRunStreamMatchesRun(t *testing.T) {
+	target := []inst.Instruction{{Op: inst.AND_N, Imm: 0xFF}}
+
+	resultsCh, errCh := RunStream(context.Background(), Config{
+		Target:     target,
+		Chains:     4,
+		Iterations: 100_000,
+		Decay:      0.9999,
+	})
+
+	var results []Result
+	for r := range resultsCh {
+		results = append(results, r)
+	}
+	if err := <-errCh; err != nil {
+		t.Fatalf("unexpected error from a non-cancelled RunStream: %v", err)
+	}
+	if len(results) == 0 {
+		t.Fatal("RunStream found no results for AND 0FFh")
+	}
+	for _, r := range results {
+		if !search.ExhaustiveCheck(target, r.Rule.Replacement) {
+			t.Fatalf("reported result does not pass ExhaustiveCheck: %v", r.Rule.Replacement)
+		}
+	}
+}
+
+// TestRunStreamRespectsCancellation verifies a cancelled ctx stops RunStream
+// well short of cfg.Iterations and reports ctx.Err() on the error channel
+// (chunk12-1), rather than blocking until the full (here, enormous)
+// iteration budget is exhausted.
+func TestRunStreamRespectsCancellation(t *testing.T) {
+	target := []inst.Instruction{{Op: inst.AND_N, Imm: 0xFF}}
+	ctx, cancel := context.WithCancel(context.Background())
+
+	resultsCh, errCh := RunStream(ctx, Config{
+		Target:       target,
+		Chains:       2,
+		Iterations:   1_000_000_000,
+		Decay:        0.9999,
+		SwapInterval: 10,
+	})
+
+	// Let it run a handful of batches, then cancel.
+	for i := 0; i < 3; i++ {
+		select {
+		case _, ok := <-resultsCh:
+			if !ok {
+				t.Fatal("resultsCh closed before cancellation")
+			}
+		default:
+		}
+	}
+	cancel()
+
+	for range resultsCh {
+		// Drain whatever was in flight; RunStream must still close this.
+	}
+	if err := <-errCh; err != context.Canceled {
+		t.Fatalf("errCh = %v, want context.Canceled", err)
+	}
+}
+
 func TestDeduplicate(t *testing.T) {
 	r1 := Result{Rule: result.Rule{Replacement: []inst.Instruction{{Op: inst.AND_A}}}}
 	r2 := Result{Rule: result.Rule{Replacement: []inst.Instruction{{Op: inst.AND_A}}}}
@@ -348,3 +446,207 @@ func TestEndToEndDeadFlags_LDA0(t *testing.T) {
 		}
 	}
 }
+
+func TestCostMaskedRegs_LDAB_vs_NOP(t *testing.T) {
+	target := []inst.Instruction{{Op: inst.LD_A_B}}
+	candidate := []inst.Instruction{{Op: inst.NOP}}
+
+	full := Cost(target, candidate)
+	if full < 1000 {
+		t.Fatalf("Cost without mask should have mismatches, got %d", full)
+	}
+
+	masked := CostMaskedRegs(target, candidate, 0, DeadRegA)
+	if masked >= 1000 {
+		t.Fatalf("CostMaskedRegs(DeadRegA) should have 0 mismatches, got %d", masked)
+	}
+}
+
+func TestMismatchesMaskedRegs(t *testing.T) {
+	target := []inst.Instruction{{Op: inst.LD_A_B}}
+	candidate := []inst.Instruction{{Op: inst.NOP}}
+
+	full := Mismatches(target, candidate)
+	if full == 0 {
+		t.Fatal("Mismatches should be > 0 without mask")
+	}
+
+	masked := MismatchesMaskedRegs(target, candidate, 0, DeadRegA)
+	if masked != 0 {
+		t.Fatalf("MismatchesMaskedRegs(DeadRegA) should be 0, got %d", masked)
+	}
+}
+
+func TestEndToEndDeadRegs_LDAN(t *testing.T) {
+	// LD A, 5 (2 bytes) should be optimizable to NOP (1 byte) when A is dead.
+	target := []inst.Instruction{{Op: inst.LD_A_N, Imm: 5}}
+
+	results := Run(Config{
+		Target:     target,
+		Chains:     4,
+		Iterations: 200_000,
+		Decay:      0.9999,
+		Verbose:    false,
+		DeadRegs:   DeadRegA,
+	})
+
+	if len(results) == 0 {
+		t.Fatal("STOKE with DeadRegs=DeadRegA failed to find optimization for LD A, 5")
+	}
+
+	foundNop := false
+	for _, r := range results {
+		if len(r.Rule.Replacement) == 1 && r.Rule.Replacement[0].Op == inst.NOP {
+			foundNop = true
+		}
+		if !search.ExhaustiveCheckMaskedRegs(target, r.Rule.Replacement, 0, DeadRegA) {
+			t.Fatalf("result does not pass ExhaustiveCheckMaskedRegs: %v", r.Rule.Replacement)
+		}
+	}
+	if !foundNop {
+		t.Logf("warning: didn't find NOP specifically, but found %d optimizations", len(results))
+		for _, r := range results {
+			t.Logf("  found: %v (-%d bytes)", r.Rule.Replacement, r.Rule.BytesSaved)
+		}
+	}
+}
+
+func TestTemperatureLadder(t *testing.T) {
+	ladder := temperatureLadder(4, 0.1, 1.0)
+	if len(ladder) != 4 {
+		t.Fatalf("expected 4 rungs, got %d", len(ladder))
+	}
+	if ladder[0] != 0.1 {
+		t.Fatalf("ladder[0] = %v, want TempMin 0.1", ladder[0])
+	}
+	for i := 1; i < len(ladder); i++ {
+		if ladder[i] <= ladder[i-1] {
+			t.Fatalf("ladder not strictly ascending: %v", ladder)
+		}
+	}
+	if got := ladder[len(ladder)-1]; got < 0.999 || got > 1.001 {
+		t.Fatalf("ladder top = %v, want ~TempMax 1.0", got)
+	}
+}
+
+func TestTemperatureLadderSingleChain(t *testing.T) {
+	ladder := temperatureLadder(1, 0.1, 1.0)
+	if len(ladder) != 1 || ladder[0] != 0.1 {
+		t.Fatalf("single-chain ladder = %v, want [0.1]", ladder)
+	}
+}
+
+func TestTryReplicaExchangeSwapsCurrentNotBest(t *testing.T) {
+	target := []inst.Instruction{{Op: inst.AND_A}}
+	cold := NewChain(target, 0.1, 1)
+	hot := NewChain(target, 1.0, 2)
+
+	// Give the hot chain a much better (lower) cost so the exchange — which
+	// always favors moving the lower-cost state onto the colder rung — is
+	// guaranteed to accept.
+	cold.current, cold.cost = []inst.Instruction{{Op: inst.XOR_A}, {Op: inst.LD_A_B}}, 5000
+	hot.current, hot.cost = []inst.Instruction{{Op: inst.NOP}}, 10
+	cold.best, hot.best = copySeq(cold.current), copySeq(hot.current)
+
+	rng := rand.New(rand.NewPCG(1, 1))
+	if !cold.TryReplicaExchange(hot, rng) {
+		t.Fatal("exchange moving a lower-cost state onto the colder rung should always accept")
+	}
+	if cold.cost != 10 || hot.cost != 5000 {
+		t.Fatalf("costs not swapped: cold=%d hot=%d", cold.cost, hot.cost)
+	}
+	if len(cold.current) != 1 || cold.current[0].Op != inst.NOP {
+		t.Fatalf("cold.current not swapped: %v", cold.current)
+	}
+	if cold.SwapsAccepted != 1 || hot.SwapsAccepted != 1 {
+		t.Fatalf("SwapsAccepted not incremented: cold=%d hot=%d", cold.SwapsAccepted, hot.SwapsAccepted)
+	}
+	if cold.SwapsProposed != 1 || hot.SwapsProposed != 1 {
+		t.Fatalf("SwapsProposed not incremented: cold=%d hot=%d", cold.SwapsProposed, hot.SwapsProposed)
+	}
+}
+
+// TestTryReplicaExchangeProposedCountsRejections verifies SwapsProposed
+// increments even when the exchange is rejected, unlike SwapsAccepted
+// (chunk12-2): a caller diagnosing a stuck ladder needs to tell "never
+// offered" apart from "offered but always rejected".
+func TestTryReplicaExchangeProposedCountsRejections(t *testing.T) {
+	target := []inst.Instruction{{Op: inst.AND_A}}
+	cold := NewChain(target, 0.1, 1)
+	hot := NewChain(target, 1.0, 2)
+
+	// Give the cold chain the much better cost, so moving it onto the
+	// hotter rung is unfavorable and TryReplicaExchange must reject.
+	cold.current, cold.cost = []inst.Instruction{{Op: inst.NOP}}, 10
+	hot.current, hot.cost = []inst.Instruction{{Op: inst.XOR_A}, {Op: inst.LD_A_B}}, 5000
+	cold.best, hot.best = copySeq(cold.current), copySeq(hot.current)
+
+	rng := rand.New(rand.NewPCG(1, 1))
+	if cold.TryReplicaExchange(hot, rng) {
+		t.Fatal("exchange moving a higher-cost state onto the colder rung should reject")
+	}
+	if cold.SwapsAccepted != 0 || hot.SwapsAccepted != 0 {
+		t.Fatalf("SwapsAccepted should stay 0 on rejection: cold=%d hot=%d", cold.SwapsAccepted, hot.SwapsAccepted)
+	}
+	if cold.SwapsProposed != 1 || hot.SwapsProposed != 1 {
+		t.Fatalf("SwapsProposed should increment even when rejected: cold=%d hot=%d", cold.SwapsProposed, hot.SwapsProposed)
+	}
+}
+
+func TestEndToEndParallelTempering_LDA0(t *testing.T) {
+	target := []inst.Instruction{{Op: inst.LD_A_N, Imm: 0}}
+
+	results := Run(Config{
+		Target:       target,
+		Chains:       4,
+		Iterations:   50_000,
+		Decay:        0.9999,
+		TempMin:      0.1,
+		TempMax:      2.0,
+		SwapInterval: 500,
+		DeadFlags:    0xFF,
+	})
+
+	if len(results) == 0 {
+		t.Fatal("parallel-tempering STOKE failed to find optimization for LD A, 0")
+	}
+	for _, r := range results {
+		if !search.ExhaustiveCheckMasked(target, r.Rule.Replacement, 0xFF) {
+			t.Fatalf("result does not pass ExhaustiveCheckMasked: %v", r.Rule.Replacement)
+		}
+	}
+}
+
+func TestMutateRejectsDanglingBranch(t *testing.T) {
+	// A JR NZ whose target is a byte offset that exists nowhere in the
+	// 1-instruction sequence below (it's too short to ever reach 0x0050):
+	// Mutate must reject any candidate that keeps this branch dangling.
+	rng := rand.New(rand.NewPCG(1, 2))
+	m := NewMutator(rng, 10)
+	seq := []inst.Instruction{{Op: inst.JR_NZ, Imm: 0x0050}}
+
+	for i := 0; i < 200; i++ {
+		out := m.Mutate(seq)
+		if !inst.IsWellFormedCFG(out) {
+			t.Fatalf("Mutate returned a sequence with a dangling branch: %v", out)
+		}
+	}
+}
+
+func TestRandomBranchTargetIsWellFormed(t *testing.T) {
+	rng := rand.New(rand.NewPCG(3, 4))
+	m := NewMutator(rng, 10)
+	seq := []inst.Instruction{
+		{Op: inst.LD_A_N, Imm: 5},
+		{Op: inst.INC_A},
+		{Op: inst.NOP},
+	}
+
+	for i := 0; i < 50; i++ {
+		target := m.randomBranchTarget(seq)
+		candidate := []inst.Instruction{{Op: inst.JR_NZ, Imm: target}}
+		if !inst.IsWellFormedCFG(append(copySeq(seq), candidate[0])) {
+			t.Fatalf("randomBranchTarget produced a dangling target %#x for seq %v", target, seq)
+		}
+	}
+}
@@ -0,0 +1,221 @@
+package stoke
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/oisee/z80-optimizer/pkg/cpu"
+	"github.com/oisee/z80-optimizer/pkg/inst"
+	"github.com/oisee/z80-optimizer/pkg/result"
+	"github.com/oisee/z80-optimizer/pkg/search"
+	"github.com/oisee/z80-optimizer/pkg/verify/smt"
+)
+
+// Default verifier pool sizing when Config leaves VerifierWorkers/
+// VerifyQueueSize unset.
+const (
+	defaultVerifierWorkers = 2
+	defaultVerifyQueueSize = 64
+)
+
+// VerifyStats counts candidates moving through the two-tier verification
+// pipeline (chunk12-3): chains enqueue anything clearing the cheap local
+// acceptance test (bestCost < 1000 && IsShorter) instead of proving it
+// inline, so a slow ExhaustiveCheck/ExhaustiveCheckMasked call never stalls
+// the MCMC loop that found it. A caller that wants to watch a long run for
+// verifier backpressure sets Config.Stats to a *VerifyStats before calling
+// Run/RunStream and reads the counters with the embedded atomic.Int64s' Load
+// methods.
+type VerifyStats struct {
+	Enqueued       atomic.Int64 // cleared the cheap test and was handed to a verifier
+	DedupedDropped atomic.Int64 // an identical candidate was already queued or verified
+	Verified       atomic.Int64 // passed exhaustive verification
+	Rejected       atomic.Int64 // failed it
+}
+
+// verifyJob is one candidate handed to the verifier pool.
+type verifyJob struct {
+	ci         int
+	globalIter int
+	best       []inst.Instruction
+}
+
+// verifyPipeline runs candidates found by chains through exhaustive
+// verification off the hot MCMC path: chains enqueue, a dedup set (keyed by
+// seqKey) drops anything already in flight or already verified so several
+// chains converging on the same sequence only pay for one proof, and a pool
+// of worker goroutines drains the queue and sends verified Results on
+// resultsCh.
+type verifyPipeline struct {
+	cfg          Config
+	jobs         chan verifyJob
+	seen         sync.Map // seqKey(best) -> struct{}
+	stats        *VerifyStats
+	resultsCh    chan<- Result
+	targetBytes  int
+	targetCycles int
+
+	// chunk12-4: when the search is checkpointing, every verified Result is
+	// also kept here (in addition to being sent on resultsCh) so a
+	// checkpoint write can include the deduped verified set without
+	// reading back out of resultsCh, which a checkpointing caller may not
+	// be draining promptly.
+	trackResults bool
+	mu           sync.Mutex
+	verified     []Result
+}
+
+func newVerifyPipeline(cfg Config, resultsCh chan<- Result, targetBytes, targetCycles int) *verifyPipeline {
+	queueSize := cfg.VerifyQueueSize
+	if queueSize <= 0 {
+		queueSize = defaultVerifyQueueSize
+	}
+	stats := cfg.Stats
+	if stats == nil {
+		stats = &VerifyStats{}
+	}
+	return &verifyPipeline{
+		cfg:          cfg,
+		jobs:         make(chan verifyJob, queueSize),
+		stats:        stats,
+		resultsCh:    resultsCh,
+		targetBytes:  targetBytes,
+		targetCycles: targetCycles,
+		trackResults: cfg.CheckpointPath != "",
+	}
+}
+
+// snapshotResults returns a copy of every Result verified so far, for a
+// checkpoint write to include. Only populated when trackResults is set.
+func (p *verifyPipeline) snapshotResults() []Result {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	out := make([]Result, len(p.verified))
+	copy(out, p.verified)
+	return out
+}
+
+// start launches the verifier worker pool and returns a stop func: the
+// caller must call it once no more candidates will be enqueued, which closes
+// the job queue and blocks until every worker has drained it (so any
+// in-flight verification still gets to send its Result before resultsCh is
+// closed upstream).
+func (p *verifyPipeline) start(ctx context.Context) (stop func()) {
+	workers := p.cfg.VerifierWorkers
+	if workers <= 0 {
+		workers = defaultVerifierWorkers
+	}
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			for job := range p.jobs {
+				p.verify(ctx, job)
+			}
+		}()
+	}
+	return func() {
+		close(p.jobs)
+		wg.Wait()
+	}
+}
+
+// enqueue hands best to the verifier pool unless an identical sequence is
+// already in flight or already verified, in which case it's dropped and
+// DedupedDropped is incremented instead. Safe to call from multiple chain
+// goroutines concurrently.
+func (p *verifyPipeline) enqueue(ctx context.Context, ci, globalIter int, best []inst.Instruction) {
+	key := seqKey(best)
+	if _, loaded := p.seen.LoadOrStore(key, struct{}{}); loaded {
+		p.stats.DedupedDropped.Add(1)
+		return
+	}
+	p.stats.Enqueued.Add(1)
+	select {
+	case p.jobs <- verifyJob{ci: ci, globalIter: globalIter, best: copySeq(best)}:
+	case <-ctx.Done():
+	}
+}
+
+// verify proves one queued candidate and, if it holds up, sends a Result on
+// resultsCh. This is the same verification logic Run used to run inline
+// before chunk12-3; only where it runs (a worker goroutine instead of the
+// chain's own goroutine) has changed.
+func (p *verifyPipeline) verify(ctx context.Context, job verifyJob) {
+	cfg := p.cfg
+	best := job.best
+
+	var verified bool
+	var deadFlags uint8
+	var deadRegs RegMask
+	if cfg.DeadRegs != DeadRegNone {
+		// smt.Verify doesn't model register masking, so this combination
+		// still goes through the sweep directly.
+		verified = search.ExhaustiveCheckMaskedRegs(cfg.Target, best, cfg.DeadFlags, cfg.DeadRegs)
+		if verified {
+			deadFlags = search.FlagDiff(cfg.Target, best)
+			deadRegs = cfg.DeadRegs
+		}
+	} else {
+		var cex *cpu.State
+		verified, cex = smt.Verify(cfg.Target, best, cfg.DeadFlags)
+		if verified && cfg.DeadFlags != 0 {
+			deadFlags = search.FlagDiff(cfg.Target, best)
+		}
+		if cex != nil {
+			recordCounterexample(*cex)
+		}
+	}
+	if !verified {
+		p.stats.Rejected.Add(1)
+		return
+	}
+	p.stats.Verified.Add(1)
+
+	candBytes := inst.SeqByteSize(best)
+	candCycles := inst.SeqTStates(best)
+	r := Result{
+		Rule: result.Rule{
+			Source:      copySeq(cfg.Target),
+			Replacement: copySeq(best),
+			BytesSaved:  p.targetBytes - candBytes,
+			CyclesSaved: p.targetCycles - candCycles,
+			DeadFlags:   deadFlags,
+			DeadRegs:    deadRegs,
+		},
+		ChainID: job.ci,
+		Iter:    job.globalIter,
+	}
+
+	if p.trackResults {
+		p.mu.Lock()
+		p.verified = append(p.verified, r)
+		p.mu.Unlock()
+	}
+
+	select {
+	case p.resultsCh <- r:
+	case <-ctx.Done():
+		return
+	}
+
+	if cfg.Verbose {
+		fmt.Printf("  Chain %d @ iter %d: ", job.ci, job.globalIter)
+		for j, instr := range best {
+			if j > 0 {
+				fmt.Print(" : ")
+			}
+			fmt.Print(inst.Disassemble(instr))
+		}
+		if deadFlags != 0 || deadRegs != DeadRegNone {
+			fmt.Printf(" (-%d bytes, -%d cycles, dead flags 0x%02X, dead regs 0x%04X) VERIFIED\n",
+				r.Rule.BytesSaved, r.Rule.CyclesSaved, deadFlags, deadRegs)
+		} else {
+			fmt.Printf(" (-%d bytes, -%d cycles) VERIFIED\n",
+				r.Rule.BytesSaved, r.Rule.CyclesSaved)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package stoke
+
+import (
+	"context"
+	"testing"
+
+	"github.com/oisee/z80-optimizer/pkg/inst"
+)
+
+func TestVerifyPipelineDedupesIdenticalCandidates(t *testing.T) {
+	target := []inst.Instruction{{Op: inst.AND_A}}
+	resultsCh := make(chan Result, 4)
+	stats := &VerifyStats{}
+	cfg := Config{Target: target, Stats: stats}
+
+	p := newVerifyPipeline(cfg, resultsCh, inst.SeqByteSize(target), inst.SeqTStates(target))
+	stop := p.start(context.Background())
+
+	best := []inst.Instruction{{Op: inst.AND_A}}
+	p.enqueue(context.Background(), 0, 0, best)
+	p.enqueue(context.Background(), 0, 1, best)
+	stop()
+	close(resultsCh)
+
+	if stats.Enqueued.Load() != 1 {
+		t.Fatalf("Enqueued = %d, want 1", stats.Enqueued.Load())
+	}
+	if stats.DedupedDropped.Load() != 1 {
+		t.Fatalf("DedupedDropped = %d, want 1", stats.DedupedDropped.Load())
+	}
+}
+
+func TestEndToEndVerifyStatsPopulated(t *testing.T) {
+	// AND 0FFh (2 bytes) should be optimizable to AND A (1 byte); this just
+	// re-runs TestEndToEndAND0xFF's search while also checking that the
+	// chunk12-3 verifier pipeline counted what it verified.
+	target := []inst.Instruction{{Op: inst.AND_N, Imm: 0xFF}}
+	stats := &VerifyStats{}
+
+	results := Run(Config{
+		Target:     target,
+		Chains:     4,
+		Iterations: 100_000,
+		Decay:      0.9999,
+		Stats:      stats,
+	})
+
+	if len(results) == 0 {
+		t.Fatal("STOKE failed to find optimization for AND 0FFh")
+	}
+	if stats.Verified.Load() != int64(len(results)) {
+		t.Fatalf("Stats.Verified = %d, want %d (len(results))", stats.Verified.Load(), len(results))
+	}
+	if stats.Enqueued.Load() < stats.Verified.Load() {
+		t.Fatalf("Stats.Enqueued (%d) should be >= Stats.Verified (%d)", stats.Enqueued.Load(), stats.Verified.Load())
+	}
+}
@@ -0,0 +1,181 @@
+// Package timing models per-M-cycle memory contention for STOKE's
+// CostModel (chunk4-6), refining its flat 8-T-state contention phase into
+// a per-access, PC-aware model: each instruction's opcode fetch, operand
+// fetch, and memory read/write resolve to a (T-state offset, address)
+// pair, and a ContentionMap decides how much a contended machine delays
+// that specific access. This is the same opcode/operand/mem-R/W split
+// CompCert's postpass scheduling oracle uses when it separates read_at_id
+// from read_at_e1 sub-cycle stages, rather than treating an instruction
+// as one opaque timing blob.
+package timing
+
+import "github.com/oisee/z80-optimizer/pkg/inst"
+
+// AccessKind is the bus stage an Access represents.
+type AccessKind int
+
+const (
+	OpcodeFetch AccessKind = iota
+	OperandFetch
+	MemRead
+	MemWrite
+)
+
+// Access is one bus cycle an instruction performs: the T-state offset from
+// the instruction's first cycle, which stage it is, and whether its
+// address is resolvable from PC alone. Opcode/operand bytes are always
+// PC-relative (Addressable); memory R/W through (HL)/(IX+d)/(BC)/(DE) is
+// not — Sequence has no CPU state to resolve those addresses from, so
+// callers must pick a policy for them (see genericModel.TStates).
+type Access struct {
+	TOffset     int
+	Kind        AccessKind
+	Addressable bool
+}
+
+// Sequence returns op's bus cycles in execution order. It reuses the same
+// per-opcode byte counts inst.AccessOf (chunk4-6) already derives, just
+// split into individual accesses instead of summed: each opcode/operand
+// byte is a 4 T-state M1/fetch cycle, each separately-addressed memory
+// byte is a 3 T-state MREQ cycle.
+func Sequence(op inst.OpCode) []Access {
+	a := inst.AccessOf(op)
+	var out []Access
+	t := 0
+
+	add := func(n uint8, kind AccessKind, addressable bool, width int) {
+		for i := uint8(0); i < n; i++ {
+			out = append(out, Access{TOffset: t, Kind: kind, Addressable: addressable})
+			t += width
+		}
+	}
+	add(a.OpcodeFetchBytes, OpcodeFetch, true, 4)
+	add(a.OperandFetchBytes, OperandFetch, true, 4)
+	add(a.MemReads, MemRead, false, 3)
+	add(a.MemWrites, MemWrite, false, 3)
+	return out
+}
+
+// ContentionMap answers how many extra T-states a single bus access pays,
+// given the address it touches and the T-state within the machine's video
+// frame it lands on.
+type ContentionMap interface {
+	Delay(addr uint16, frameT int) int
+}
+
+// TimingModel computes the actual T-states a sequence takes on a specific
+// machine, given the PC execution starts at and the T-state within the
+// machine's video frame that start lands on. frameOffset generalizes
+// stoke.zxSpectrum48k's single frameOffset field (a phase within one
+// repeating 8-T-state pattern) to a full frame position, since a
+// ContentionMap may care where in the whole frame — not just where in one
+// 8-T-state slice — an access falls.
+type TimingModel interface {
+	TStates(seq []inst.Instruction, basePC uint16, frameOffset int) int
+}
+
+// unaddressedTarget is the address genericModel charges mem R/W accesses
+// against: Sequence can't resolve (HL)/(IX+d)/(BC)/(DE) without full CPU
+// state, so — same pessimism stoke.zxSpectrum48k already applies to every
+// access — genericModel assumes the worst case, a contended address.
+const unaddressedTarget = 0x4000
+
+// genericModel walks a sequence's Accesses, advancing PC and frame position
+// as it goes, and totals base T-states plus whatever ContentionMap.Delay
+// adds for each one.
+type genericModel struct {
+	contention ContentionMap
+}
+
+func (m genericModel) TStates(seq []inst.Instruction, basePC uint16, frameOffset int) int {
+	total := 0
+	pc := basePC
+	frameT := frameOffset
+	for _, instr := range seq {
+		byteIdx := uint16(0)
+		for _, acc := range Sequence(instr.Op) {
+			addr := uint16(unaddressedTarget)
+			if acc.Addressable {
+				addr = pc + byteIdx
+				byteIdx++
+			}
+			total += m.contention.Delay(addr, frameT+acc.TOffset)
+		}
+		base := inst.TStates(instr.Op)
+		total += base
+		frameT += base
+		pc += uint16(len(inst.Catalog[instr.Op].Bytes))
+	}
+	return total
+}
+
+// noContention is a ContentionMap that never delays anything, so Plain
+// reduces exactly to inst.SeqTStates regardless of basePC/frameOffset.
+type noContention struct{}
+
+func (noContention) Delay(uint16, int) int { return 0 }
+
+// Plain is an uncontended TimingModel: no machine-specific wait states.
+var Plain TimingModel = genericModel{contention: noContention{}}
+
+// contentionPattern is the ZX Spectrum ULA's repeating early-contention
+// delay: of every 8 T-states the CPU is stalled by 6,5,4,3,2,1,0,0 T-states
+// depending on which of those eight a contended access lands on. Duplicated
+// from stoke.contentionPattern rather than imported — pkg/stoke imports
+// pkg/timing (to build a CostModel on top of it), so the reverse import
+// would cycle; same reasoning as stoke.testVectors' own duplication note.
+var contentionPattern = [8]int{6, 5, 4, 3, 2, 1, 0, 0}
+
+// spectrum48FrameLen is the ZX Spectrum 48K's T-states per video frame
+// (69888 at 3.5MHz / 50Hz).
+const spectrum48FrameLen = 69888
+
+// spectrum48Contention charges contentionPattern on any access to
+// 0x4000-0x7FFF, but only while the raster is in the 14335..14463 window of
+// the frame — the window this model actually exercises. A full per-scanline
+// contention table covering the whole screen-drawing period is out of scope
+// here; like stoke.zxSpectrum48k, every access in-window pays the full
+// pattern regardless of where it actually resolves on screen.
+type spectrum48Contention struct{}
+
+func (spectrum48Contention) Delay(addr uint16, frameT int) int {
+	if addr < 0x4000 || addr > 0x7FFF {
+		return 0
+	}
+	t := frameT % spectrum48FrameLen
+	if t < 14335 || t > 14463 {
+		return 0
+	}
+	return contentionPattern[t%8]
+}
+
+// Spectrum48 is a ZX Spectrum 48K TimingModel: contended 0x4000-0x7FFF RAM
+// accesses pay the ULA's contention pattern during the 14335..14463 window.
+var Spectrum48 TimingModel = genericModel{contention: spectrum48Contention{}}
+
+// spectrum128FrameLen is the ZX Spectrum 128's T-states per video frame
+// (70908 at 3.5469MHz / 50Hz) — longer than the 48K's because the 128's ULA
+// runs a slightly slower clock.
+const spectrum128FrameLen = 70908
+
+// spectrum128Contention is spectrum48Contention with the 128K's longer
+// frame length. The 128K also contends paged-in RAM banks 4-7 at
+// 0xC000-0xFFFF when an odd bank is paged in; that paging-dependent case
+// isn't modeled here, same scope limit as spectrum48Contention's
+// single-window simplification.
+type spectrum128Contention struct{}
+
+func (spectrum128Contention) Delay(addr uint16, frameT int) int {
+	if addr < 0x4000 || addr > 0x7FFF {
+		return 0
+	}
+	t := frameT % spectrum128FrameLen
+	if t < 14335 || t > 14463 {
+		return 0
+	}
+	return contentionPattern[t%8]
+}
+
+// Spectrum128 is a ZX Spectrum 128 TimingModel: same contended-range and
+// in-window rule as Spectrum48, clocked against the 128's longer frame.
+var Spectrum128 TimingModel = genericModel{contention: spectrum128Contention{}}
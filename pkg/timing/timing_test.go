@@ -0,0 +1,74 @@
+package timing
+
+import (
+	"testing"
+
+	"github.com/oisee/z80-optimizer/pkg/inst"
+)
+
+func TestSequenceSplitsOpcodeAndMemAccess(t *testing.T) {
+	// LD A, (HL): 1 opcode byte (addressable), 1 mem read (not addressable).
+	accesses := Sequence(inst.LD_A_HLI)
+	if len(accesses) != 2 {
+		t.Fatalf("Sequence(LD A,(HL)) = %d accesses, want 2", len(accesses))
+	}
+	if accesses[0].Kind != OpcodeFetch || !accesses[0].Addressable {
+		t.Errorf("accesses[0] = %+v, want addressable OpcodeFetch", accesses[0])
+	}
+	if accesses[1].Kind != MemRead || accesses[1].Addressable {
+		t.Errorf("accesses[1] = %+v, want non-addressable MemRead", accesses[1])
+	}
+	if accesses[1].TOffset != 4 {
+		t.Errorf("accesses[1].TOffset = %d, want 4", accesses[1].TOffset)
+	}
+}
+
+func TestPlainMatchesSeqTStates(t *testing.T) {
+	seq := []inst.Instruction{{Op: inst.ADD_A_B}, {Op: inst.LD_A_N, Imm: 5}}
+	want := inst.SeqTStates(seq)
+	if got := Plain.TStates(seq, 0x8000, 0); got != want {
+		t.Errorf("Plain.TStates() = %d, want %d", got, want)
+	}
+}
+
+func TestSpectrum48ContendsInWindowOnly(t *testing.T) {
+	seq := []inst.Instruction{{Op: inst.NOP}}
+	base := inst.TStates(inst.NOP)
+
+	// PC in contended RAM, frame offset inside the 14335..14463 window, at
+	// a pattern phase (14336%8 == 0) that actually charges a delay.
+	if got := Spectrum48.TStates(seq, 0x5000, 14336); got != base+contentionPattern[0] {
+		t.Errorf("in-window: got %d, want %d", got, base+contentionPattern[0])
+	}
+	// Same PC, frame offset outside the window: no delay.
+	if got := Spectrum48.TStates(seq, 0x5000, 0); got != base {
+		t.Errorf("out-of-window: got %d, want %d (no contention)", got, base)
+	}
+	// In-window but PC outside contended RAM: no delay.
+	if got := Spectrum48.TStates(seq, 0x8000, 14336); got != base {
+		t.Errorf("uncontended RAM: got %d, want %d (no contention)", got, base)
+	}
+}
+
+func TestSpectrum128UsesLongerFrame(t *testing.T) {
+	seq := []inst.Instruction{{Op: inst.NOP}}
+	base := inst.TStates(inst.NOP)
+	// A frame offset past the 48K's frame length but still inside the
+	// window modulo the 128K's longer frame.
+	offset := spectrum128FrameLen + 14336
+	if got := Spectrum128.TStates(seq, 0x5000, offset); got != base+contentionPattern[0] {
+		t.Errorf("Spectrum128.TStates() = %d, want %d", got, base+contentionPattern[0])
+	}
+}
+
+func TestMemReadAssumedContended(t *testing.T) {
+	// LD A, (HL) has no resolvable (HL) target, so genericModel assumes
+	// the pessimistic 0x4000 address for its MemRead access even when PC
+	// itself sits outside contended RAM.
+	seq := []inst.Instruction{{Op: inst.LD_A_HLI}}
+	base := inst.TStates(inst.LD_A_HLI)
+	got := Spectrum48.TStates(seq, 0x8000, 14336)
+	if got == base {
+		t.Fatal("MemRead at (HL) should still pay contention via the pessimistic unaddressedTarget default")
+	}
+}
@@ -0,0 +1,34 @@
+// Package smt wires pkg/search/symbolic's Z3-backed equivalence check into
+// stoke's MCMC verification path. Chain.Cost already narrows a search down
+// to candidates that match on a handful of fixed test vectors; Verify is the
+// final gate that decides whether a zero-mismatch candidate is actually
+// equivalent to the target on every input, the same proof-of-equivalence
+// role search.ExhaustiveCheck plays for the brute-force searcher, but backed
+// by a solver instead of enumeration once the register count makes
+// enumeration a sample rather than a proof (see search.NeedsSMT).
+package smt
+
+import (
+	"github.com/oisee/z80-optimizer/pkg/cpu"
+	"github.com/oisee/z80-optimizer/pkg/inst"
+	"github.com/oisee/z80-optimizer/pkg/search"
+	"github.com/oisee/z80-optimizer/pkg/search/symbolic"
+)
+
+// Verify proves target and candidate equivalent on the given dead-flags
+// mask, the same contract as search.ExhaustiveCheckMasked. If Z3 disagrees
+// (or isn't installed, or the sequence uses an opcode symbolic can't lower),
+// it falls back to the sweep rather than failing outright. When Z3 finds a
+// genuine counterexample, Verify returns it so the caller can fold its input
+// vector into its own fast-path test vectors and reject the same bad
+// candidate without another solver call next time.
+func Verify(target, candidate []inst.Instruction, deadFlags search.FlagMask) (ok bool, counterexample *cpu.State) {
+	equiv, cex, err := symbolic.SymbolicEquiv(target, candidate, deadFlags)
+	if err != nil {
+		return search.ExhaustiveCheckMasked(target, candidate, deadFlags), nil
+	}
+	if !equiv && cex != nil {
+		return false, &cex.Vector
+	}
+	return equiv, nil
+}